@@ -0,0 +1,50 @@
+package header
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// CallOption configures a single call of a Getter, overriding its defaults for it.
+// Getters ignore the CallOptions they do not apply to, e.g. the ones reading
+// the local store ignore the peer to request.
+type CallOption func(*CallParams)
+
+// CallParams is the set of parameters of a single call of a Getter.
+type CallParams struct {
+	// Peer, if set, is the only peer the requests of the call are sent to.
+	Peer peer.ID
+	// Timeout, if set, bounds the duration of the call.
+	Timeout time.Duration
+	// NoRetry makes the call fail with the error of the first failed request.
+	NoRetry bool
+	// SkipProofs disables verification of the proofs attached to the received headers.
+	SkipProofs bool
+	// Priority, if set, overrides the default priority of the requests of the call.
+	Priority Priority
+}
+
+// Priority is the priority the requests of a call are served with by the remote peers.
+type Priority int
+
+const (
+	// PriorityDefault leaves the priority to the Getter, e.g. high for heads and single headers
+	// and low for ranges.
+	PriorityDefault Priority = iota
+	// PriorityLow makes the requests of the call served after the ones of high priority,
+	// e.g. for background syncing.
+	PriorityLow
+	// PriorityHigh makes the requests of the call served ahead of the ones of low priority,
+	// e.g. for interactive calls.
+	PriorityHigh
+)
+
+// NewCallParams returns the CallParams configured with the given CallOptions.
+func NewCallParams(opts ...CallOption) CallParams {
+	var params CallParams
+	for _, opt := range opts {
+		opt(&params)
+	}
+	return params
+}
@@ -0,0 +1,219 @@
+package header
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
+	"go.opentelemetry.io/otel/metric/unit"
+)
+
+// WithGetterCache wraps the given Getter with an LRU cache of the given size.
+// Headers returned by any of the Getter methods are cached and served from the cache
+// on subsequent Get and GetByHeight calls. Head requests always hit the wrapped Getter.
+func WithGetterCache[H Header](getter Getter[H], size int) (Getter[H], error) {
+	byHash, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	byHeight, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedGetter[H]{
+		Getter:   getter,
+		byHash:   byHash,
+		byHeight: byHeight,
+	}, nil
+}
+
+type cachedGetter[H Header] struct {
+	Getter[H]
+
+	byHash   *lru.Cache
+	byHeight *lru.Cache
+}
+
+func (c *cachedGetter[H]) Get(ctx context.Context, hash Hash, opts ...CallOption) (H, error) {
+	if h, ok := c.byHash.Get(hash.String()); ok {
+		return h.(H), nil
+	}
+	h, err := c.Getter.Get(ctx, hash, opts...)
+	if err != nil {
+		return h, err
+	}
+	c.add(h)
+	return h, nil
+}
+
+func (c *cachedGetter[H]) GetByHeight(ctx context.Context, height uint64, opts ...CallOption) (H, error) {
+	if h, ok := c.byHeight.Get(height); ok {
+		return h.(H), nil
+	}
+	h, err := c.Getter.GetByHeight(ctx, height, opts...)
+	if err != nil {
+		return h, err
+	}
+	c.add(h)
+	return h, nil
+}
+
+func (c *cachedGetter[H]) GetRangeByHeight(ctx context.Context, from, amount uint64, opts ...CallOption) ([]H, error) {
+	hs, err := c.Getter.GetRangeByHeight(ctx, from, amount, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.add(hs...)
+	return hs, nil
+}
+
+func (c *cachedGetter[H]) GetVerifiedRange(ctx context.Context, from H, amount uint64, opts ...CallOption) ([]H, error) {
+	hs, err := c.Getter.GetVerifiedRange(ctx, from, amount, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.add(hs...)
+	return hs, nil
+}
+
+func (c *cachedGetter[H]) add(hs ...H) {
+	for _, h := range hs {
+		c.byHash.Add(h.Hash().String(), h)
+		c.byHeight.Add(uint64(h.Height()), h)
+	}
+}
+
+// WithGetterMetrics wraps the given Getter with Otel metrics
+// measuring the duration of every request and whether it failed.
+func WithGetterMetrics[H Header](getter Getter[H]) (Getter[H], error) {
+	duration, err := meter.
+		SyncFloat64().
+		Histogram(
+			"header_getter_request_duration",
+			instrument.WithUnit(unit.Milliseconds),
+			instrument.WithDescription("Duration of Getter requests in milliseconds"),
+		)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsGetter[H]{
+		Getter:   getter,
+		duration: duration,
+	}, nil
+}
+
+type metricsGetter[H Header] struct {
+	Getter[H]
+
+	duration syncfloat64.Histogram
+}
+
+func (m *metricsGetter[H]) Head(ctx context.Context, opts ...CallOption) (h H, err error) {
+	defer m.observe(ctx, "head", time.Now(), &err)
+	return m.Getter.Head(ctx, opts...)
+}
+
+func (m *metricsGetter[H]) Get(ctx context.Context, hash Hash, opts ...CallOption) (h H, err error) {
+	defer m.observe(ctx, "get", time.Now(), &err)
+	return m.Getter.Get(ctx, hash, opts...)
+}
+
+func (m *metricsGetter[H]) GetByHeight(ctx context.Context, height uint64, opts ...CallOption) (h H, err error) {
+	defer m.observe(ctx, "get_by_height", time.Now(), &err)
+	return m.Getter.GetByHeight(ctx, height, opts...)
+}
+
+func (m *metricsGetter[H]) GetRangeByHeight(ctx context.Context, from, amount uint64, opts ...CallOption) (hs []H, err error) {
+	defer m.observe(ctx, "get_range_by_height", time.Now(), &err)
+	return m.Getter.GetRangeByHeight(ctx, from, amount, opts...)
+}
+
+func (m *metricsGetter[H]) GetVerifiedRange(ctx context.Context, from H, amount uint64, opts ...CallOption) (hs []H, err error) {
+	defer m.observe(ctx, "get_verified_range", time.Now(), &err)
+	return m.Getter.GetVerifiedRange(ctx, from, amount, opts...)
+}
+
+func (m *metricsGetter[H]) observe(ctx context.Context, method string, start time.Time, err *error) {
+	m.duration.Record(
+		ctx,
+		float64(time.Since(start).Milliseconds()),
+		attribute.String("method", method),
+		attribute.Bool("failed", *err != nil),
+	)
+}
+
+// WithGetterRetry wraps the given Getter so that failed requests are retried
+// up to the given amount of attempts, doubling the backoff between attempts.
+// Requests are not retried if the header is not found or the context is done.
+func WithGetterRetry[H Header](getter Getter[H], attempts int, backoff time.Duration) Getter[H] {
+	return &retryGetter[H]{
+		Getter:   getter,
+		attempts: attempts,
+		backoff:  backoff,
+	}
+}
+
+type retryGetter[H Header] struct {
+	Getter[H]
+
+	attempts int
+	backoff  time.Duration
+}
+
+func (r *retryGetter[H]) Head(ctx context.Context, opts ...CallOption) (H, error) {
+	return retry(ctx, r.attempts, r.backoff, func() (H, error) {
+		return r.Getter.Head(ctx, opts...)
+	})
+}
+
+func (r *retryGetter[H]) Get(ctx context.Context, hash Hash, opts ...CallOption) (H, error) {
+	return retry(ctx, r.attempts, r.backoff, func() (H, error) {
+		return r.Getter.Get(ctx, hash, opts...)
+	})
+}
+
+func (r *retryGetter[H]) GetByHeight(ctx context.Context, height uint64, opts ...CallOption) (H, error) {
+	return retry(ctx, r.attempts, r.backoff, func() (H, error) {
+		return r.Getter.GetByHeight(ctx, height, opts...)
+	})
+}
+
+func (r *retryGetter[H]) GetRangeByHeight(ctx context.Context, from, amount uint64, opts ...CallOption) ([]H, error) {
+	return retry(ctx, r.attempts, r.backoff, func() ([]H, error) {
+		return r.Getter.GetRangeByHeight(ctx, from, amount, opts...)
+	})
+}
+
+func (r *retryGetter[H]) GetVerifiedRange(ctx context.Context, from H, amount uint64, opts ...CallOption) ([]H, error) {
+	return retry(ctx, r.attempts, r.backoff, func() ([]H, error) {
+		return r.Getter.GetVerifiedRange(ctx, from, amount, opts...)
+	})
+}
+
+func retry[T any](ctx context.Context, attempts int, backoff time.Duration, f func() (T, error)) (T, error) {
+	for attempt := 1; ; attempt++ {
+		out, err := f()
+		if err == nil || attempt >= attempts || !retriable(ctx, err) {
+			return out, err
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return out, err
+		}
+	}
+}
+
+func retriable(ctx context.Context, err error) bool {
+	return ctx.Err() == nil &&
+		!errors.Is(err, ErrNotFound) &&
+		!errors.Is(err, ErrNoHead) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
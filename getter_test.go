@@ -0,0 +1,103 @@
+package header_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header"
+	"github.com/celestiaorg/go-header/headertest"
+)
+
+func TestWithGetterCache(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	store := headertest.NewDummyStore(t)
+	counter := &countingGetter{Getter: store}
+	getter, err := header.WithGetterCache[*headertest.DummyHeader](counter, 16)
+	require.NoError(t, err)
+
+	_, err = getter.GetRangeByHeight(ctx, 1, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 1, counter.calls)
+
+	h, err := getter.GetByHeight(ctx, 3)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, h.Height())
+	_, err = getter.Get(ctx, h.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, 1, counter.calls)
+
+	_, err = getter.GetByHeight(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, 2, counter.calls)
+}
+
+func TestWithGetterRetry(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	store := headertest.NewDummyStore(t)
+	counter := &countingGetter{Getter: store, failures: 2}
+	getter := header.WithGetterRetry[*headertest.DummyHeader](counter, 3, time.Millisecond)
+
+	_, err := getter.Head(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, counter.calls)
+
+	// not found errors are not retried
+	counter.calls, counter.failures, counter.err = 0, 2, header.ErrNotFound
+	_, err = getter.Head(ctx)
+	require.ErrorIs(t, err, header.ErrNotFound)
+	assert.Equal(t, 1, counter.calls)
+}
+
+type countingGetter struct {
+	header.Getter[*headertest.DummyHeader]
+
+	calls    int
+	failures int
+	err      error
+}
+
+func (c *countingGetter) fail() error {
+	c.calls++
+	if c.failures > 0 {
+		c.failures--
+		if c.err != nil {
+			return c.err
+		}
+		return errors.New("transient")
+	}
+	return nil
+}
+
+func (c *countingGetter) Head(ctx context.Context, _ ...header.CallOption) (*headertest.DummyHeader, error) {
+	if err := c.fail(); err != nil {
+		return nil, err
+	}
+	return c.Getter.Head(ctx)
+}
+
+func (c *countingGetter) GetByHeight(ctx context.Context, height uint64, _ ...header.CallOption) (*headertest.DummyHeader, error) {
+	if err := c.fail(); err != nil {
+		return nil, err
+	}
+	return c.Getter.GetByHeight(ctx, height)
+}
+
+func (c *countingGetter) GetRangeByHeight(
+	ctx context.Context,
+	from, amount uint64,
+	_ ...header.CallOption,
+) ([]*headertest.DummyHeader, error) {
+	if err := c.fail(); err != nil {
+		return nil, err
+	}
+	return c.Getter.GetRangeByHeight(ctx, from, amount)
+}
@@ -29,7 +29,7 @@ type DummyHeader struct {
 	hash header.Hash
 }
 
-func RandDummyHeader(t *testing.T) *DummyHeader {
+func RandDummyHeader(t testing.TB) *DummyHeader {
 	t.Helper()
 
 	dh := &DummyHeader{
@@ -60,24 +60,36 @@ func (d *DummyHeader) ChainID() string {
 }
 
 func (d *DummyHeader) Hash() header.Hash {
-	if len(d.hash) == 0 {
-		if err := d.rehash(); err != nil {
-			panic(err)
-		}
+	if len(d.hash) != 0 {
+		return d.hash
 	}
-	return d.hash
+	// headers decoded without UnmarshalBinary, e.g. from JSON, are hashed on every call,
+	// as caching the hash here would race with concurrent readers
+	hash, err := d.computeHash()
+	if err != nil {
+		panic(err)
+	}
+	return hash
 }
 
 func (d *DummyHeader) rehash() error {
-	b, err := d.MarshalBinary()
+	hash, err := d.computeHash()
 	if err != nil {
 		return err
 	}
-	hash := sha3.Sum512(b)
-	d.hash = hash[:]
+	d.hash = hash
 	return nil
 }
 
+func (d *DummyHeader) computeHash() (header.Hash, error) {
+	b, err := d.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	hash := sha3.Sum512(b)
+	return hash[:], nil
+}
+
 func (d *DummyHeader) Height() int64 {
 	return d.Raw.Height
 }
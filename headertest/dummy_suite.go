@@ -8,13 +8,13 @@ import (
 // DummySuite provides everything you need to test chain of DummyHeaders.
 // If not, please don't hesitate to extend it for your case.
 type DummySuite struct {
-	t *testing.T
+	t testing.TB
 
 	head *DummyHeader
 }
 
 // NewTestSuite setups a new test suite.
-func NewTestSuite(t *testing.T) *DummySuite {
+func NewTestSuite(t testing.TB) *DummySuite {
 	return &DummySuite{
 		t: t,
 	}
@@ -27,6 +27,15 @@ func (s *DummySuite) Head() *DummyHeader {
 	return s.head
 }
 
+// Fork creates a new suite sharing the current head, so the headers it generates
+// form a branch competing with the ones generated by the original suite.
+func (s *DummySuite) Fork() *DummySuite {
+	return &DummySuite{
+		t:    s.t,
+		head: s.Head(),
+	}
+}
+
 func (s *DummySuite) GenDummyHeaders(num int) []*DummyHeader {
 	headers := make([]*DummyHeader, num)
 	for i := range headers {
@@ -50,7 +59,7 @@ func (s *DummySuite) NextHeader() *DummyHeader {
 }
 
 func (s *DummySuite) genesis() *DummyHeader {
-	return &DummyHeader{
+	gen := &DummyHeader{
 		hash: nil,
 		Raw: Raw{
 			PreviousHash: nil,
@@ -58,4 +67,8 @@ func (s *DummySuite) genesis() *DummyHeader {
 			Time:         time.Now().Add(-10 * time.Second).UTC(),
 		},
 	}
+	if err := gen.rehash(); err != nil {
+		s.t.Fatal(err)
+	}
+	return gen
 }
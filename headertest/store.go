@@ -48,11 +48,11 @@ func (m *Store[H]) Height() uint64 {
 	return uint64(m.HeadHeight)
 }
 
-func (m *Store[H]) Head(context.Context) (H, error) {
+func (m *Store[H]) Head(context.Context, ...header.CallOption) (H, error) {
 	return m.Headers[m.HeadHeight], nil
 }
 
-func (m *Store[H]) Get(ctx context.Context, hash header.Hash) (H, error) {
+func (m *Store[H]) Get(ctx context.Context, hash header.Hash, _ ...header.CallOption) (H, error) {
 	for _, header := range m.Headers {
 		if bytes.Equal(header.Hash(), hash) {
 			return header, nil
@@ -62,11 +62,11 @@ func (m *Store[H]) Get(ctx context.Context, hash header.Hash) (H, error) {
 	return zero, header.ErrNotFound
 }
 
-func (m *Store[H]) GetByHeight(ctx context.Context, height uint64) (H, error) {
+func (m *Store[H]) GetByHeight(ctx context.Context, height uint64, _ ...header.CallOption) (H, error) {
 	return m.Headers[int64(height)], nil
 }
 
-func (m *Store[H]) GetRangeByHeight(ctx context.Context, from, to uint64) ([]H, error) {
+func (m *Store[H]) GetRangeByHeight(ctx context.Context, from, to uint64, _ ...header.CallOption) ([]H, error) {
 	headers := make([]H, to-from)
 	// As the requested range is [from; to),
 	// check that (to-1) height in request is less than
@@ -85,6 +85,7 @@ func (m *Store[H]) GetVerifiedRange(
 	ctx context.Context,
 	h H,
 	to uint64,
+	_ ...header.CallOption,
 ) ([]H, error) {
 	return m.GetRangeByHeight(ctx, uint64(h.Height())+1, to)
 }
@@ -105,21 +105,22 @@ type Store[H Header] interface {
 
 // Getter contains the behavior necessary for a component to retrieve
 // headers that have been processed during header sync.
+// Every call can be configured with CallOptions, which Getters ignore if they do not apply to them.
 type Getter[H Header] interface {
 	Head[H]
 
 	// Get returns the Header corresponding to the given hash.
-	Get(context.Context, Hash) (H, error)
+	Get(context.Context, Hash, ...CallOption) (H, error)
 
 	// GetByHeight returns the Header corresponding to the given block height.
-	GetByHeight(context.Context, uint64) (H, error)
+	GetByHeight(context.Context, uint64, ...CallOption) (H, error)
 
 	// GetRangeByHeight returns the given range of Headers.
-	GetRangeByHeight(ctx context.Context, from, amount uint64) ([]H, error)
+	GetRangeByHeight(ctx context.Context, from, amount uint64, opts ...CallOption) ([]H, error)
 
 	// GetVerifiedRange requests the header range from the provided Header and
 	// verifies that the returned headers are adjacent to each other.
-	GetVerifiedRange(ctx context.Context, from H, amount uint64) ([]H, error)
+	GetVerifiedRange(ctx context.Context, from H, amount uint64, opts ...CallOption) ([]H, error)
 }
 
 // Head contains the behavior necessary for a component to retrieve
@@ -127,5 +128,5 @@ type Getter[H Header] interface {
 // reporting it.
 type Head[H Header] interface {
 	// Head returns the latest known header.
-	Head(context.Context) (H, error)
+	Head(context.Context, ...CallOption) (H, error)
 }
@@ -2,19 +2,69 @@ package local
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/celestiaorg/go-header"
 )
 
+// ErrHeadStale is returned by Exchange.Head along with the head, if the head lags behind
+// the expected chain tip by more than the configured amount of blocks.
+type ErrHeadStale struct {
+	Height int64
+	Lag    time.Duration
+	Missed uint64
+}
+
+func (ehs *ErrHeadStale) Error() string {
+	return fmt.Sprintf("header/local: head %d is stale: %d blocks missed over %s", ehs.Height, ehs.Missed, ehs.Lag)
+}
+
+// Parameters is the set of parameters that can be configured for the local Exchange.
+type Parameters struct {
+	// BlockTime is the expected time between blocks, used to estimate how stale the head is.
+	BlockTime time.Duration
+	// MaxHeadLag is the max amount of blocks the head can lag behind before Head returns ErrHeadStale.
+	// Zero disables the check.
+	MaxHeadLag uint64
+}
+
+// Option is the functional option that is applied to the local Exchange to configure its parameters.
+type Option func(*Parameters)
+
+// WithBlockTime is a functional option that configures the
+// `BlockTime` parameter.
+func WithBlockTime(blockTime time.Duration) Option {
+	return func(p *Parameters) {
+		p.BlockTime = blockTime
+	}
+}
+
+// WithMaxHeadLag is a functional option that configures the
+// `MaxHeadLag` parameter.
+func WithMaxHeadLag(blocks uint64) Option {
+	return func(p *Parameters) {
+		p.MaxHeadLag = blocks
+	}
+}
+
 // Exchange is a simple Exchange that reads Headers from Store without any networking.
 type Exchange[H header.Header] struct {
 	store header.Store[H]
+
+	Params Parameters
 }
 
 // NewExchange creates a new local Exchange.
-func NewExchange[H header.Header](store header.Store[H]) header.Exchange[H] {
+func NewExchange[H header.Header](store header.Store[H], opts ...Option) *Exchange[H] {
+	var params Parameters
+	for _, opt := range opts {
+		opt(&params)
+	}
+
 	return &Exchange[H]{
-		store: store,
+		store:  store,
+		Params: params,
 	}
 }
 
@@ -26,26 +76,63 @@ func (l *Exchange[H]) Stop(context.Context) error {
 	return nil
 }
 
-func (l *Exchange[H]) Head(ctx context.Context) (H, error) {
-	return l.store.Head(ctx)
+// Head returns the head of the Store. If MaxHeadLag and BlockTime are configured
+// and the head is stale, the head is returned along with ErrHeadStale.
+func (l *Exchange[H]) Head(ctx context.Context, opts ...header.CallOption) (H, error) {
+	head, err := l.store.Head(ctx, opts...)
+	if err != nil || l.Params.MaxHeadLag == 0 || l.Params.BlockTime == 0 {
+		return head, err
+	}
+
+	lag, missed := l.lag(head)
+	if missed > l.Params.MaxHeadLag {
+		return head, &ErrHeadStale{
+			Height: head.Height(),
+			Lag:    lag,
+			Missed: missed,
+		}
+	}
+	return head, nil
+}
+
+// HeadLag reports how long ago the head was produced and
+// how many blocks were expected to be produced since then.
+// The amount of missed blocks is only reported if BlockTime is configured.
+func (l *Exchange[H]) HeadLag(ctx context.Context) (time.Duration, uint64, error) {
+	head, err := l.store.Head(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	lag, missed := l.lag(head)
+	return lag, missed, nil
+}
+
+func (l *Exchange[H]) lag(head H) (time.Duration, uint64) {
+	lag := time.Since(head.Time())
+	if lag < 0 || l.Params.BlockTime == 0 {
+		return lag, 0
+	}
+	return lag, uint64(lag / l.Params.BlockTime)
 }
 
-func (l *Exchange[H]) GetByHeight(ctx context.Context, height uint64) (H, error) {
-	return l.store.GetByHeight(ctx, height)
+func (l *Exchange[H]) GetByHeight(ctx context.Context, height uint64, opts ...header.CallOption) (H, error) {
+	return l.store.GetByHeight(ctx, height, opts...)
 }
 
-func (l *Exchange[H]) GetRangeByHeight(ctx context.Context, origin, amount uint64) ([]H, error) {
+func (l *Exchange[H]) GetRangeByHeight(ctx context.Context, origin, amount uint64, opts ...header.CallOption) ([]H, error) {
 	if amount == 0 {
 		return nil, nil
 	}
-	return l.store.GetRangeByHeight(ctx, origin, origin+amount)
+	return l.store.GetRangeByHeight(ctx, origin, origin+amount, opts...)
 }
 
 func (l *Exchange[H]) GetVerifiedRange(ctx context.Context, from H, amount uint64,
+	opts ...header.CallOption,
 ) ([]H, error) {
-	return l.store.GetVerifiedRange(ctx, from, uint64(from.Height())+amount+1)
+	return l.store.GetVerifiedRange(ctx, from, uint64(from.Height())+amount+1, opts...)
 }
 
-func (l *Exchange[H]) Get(ctx context.Context, hash header.Hash) (H, error) {
-	return l.store.Get(ctx, hash)
+func (l *Exchange[H]) Get(ctx context.Context, hash header.Hash, opts ...header.CallOption) (H, error) {
+	return l.store.Get(ctx, hash, opts...)
 }
@@ -0,0 +1,36 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header/headertest"
+)
+
+func TestExchange_HeadStale(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	store := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 1)
+	// head of the test suite is produced 10 seconds ago
+	ex := NewExchange[*headertest.DummyHeader](store, WithBlockTime(time.Second))
+
+	lag, missed, err := ex.HeadLag(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, lag, time.Second*10)
+	assert.GreaterOrEqual(t, missed, uint64(10))
+
+	_, err = ex.Head(ctx)
+	require.NoError(t, err)
+
+	ex.Params.MaxHeadLag = 5
+	head, err := ex.Head(ctx)
+	var staleErr *ErrHeadStale
+	require.True(t, errors.As(err, &staleErr))
+	assert.Equal(t, head.Height(), staleErr.Height)
+}
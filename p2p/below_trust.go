@@ -0,0 +1,60 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// ErrBelowTrustDisabled is returned when Headers below the trusted root are requested,
+// while the client is not configured to allow it with WithBelowTrustRequests.
+var ErrBelowTrustDisabled = errors.New("header/p2p: requests below the trusted root are disabled")
+
+// BelowTrust is a Header older than the trusted root of the client, e.g. the Header the store
+// was initialized with. Such Headers are not verified by the regular verification rules,
+// but only by being linked to the root by the hash chain. They are as trustworthy as the root,
+// as long as the hash function is collision resistant, and are meant for historical analytics
+// rather than for extending the synced chain.
+type BelowTrust[H header.Header] struct {
+	Header H
+	// Root is the hash of the trusted Header the Header is linked to.
+	Root header.Hash
+	// Distance is the amount of Headers between the Header and the root, the root excluded.
+	Distance uint64
+}
+
+// GetBelowTrust requests the given amount of Headers preceding the given trusted root
+// and verifies they are linked to it by the hash chain. The Headers are returned
+// in ascending order of height, marked as BelowTrust.
+// It must be enabled with WithBelowTrustRequests, as such Headers are not verified against
+// the trusted root the same way the synced ones are.
+func (ex *Exchange[H]) GetBelowTrust(ctx context.Context, root H, amount uint64) ([]BelowTrust[H], error) {
+	if !ex.Params.belowTrust {
+		return nil, ErrBelowTrustDisabled
+	}
+	if root.Height() <= 1 {
+		return make([]BelowTrust[H], 0), nil
+	}
+
+	headers, err := ex.GetRangeDescending(ctx, uint64(root.Height())-1, amount)
+	if err != nil {
+		return nil, err
+	}
+	// the headers are linked with each other, so the chain is anchored by the first one only
+	if len(headers) > 0 && !linked(headers[0], root) {
+		return nil, fmt.Errorf("%w: header %d is not the parent of the trusted root %d",
+			errBrokenChain, headers[0].Height(), root.Height())
+	}
+
+	belowTrust := make([]BelowTrust[H], len(headers))
+	for i, h := range headers {
+		belowTrust[len(headers)-1-i] = BelowTrust[H]{
+			Header:   h,
+			Root:     root.Hash(),
+			Distance: uint64(i),
+		}
+	}
+	return belowTrust, nil
+}
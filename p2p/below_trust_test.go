@@ -0,0 +1,38 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExchange_GetBelowTrust(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	hosts := createMocknet(t, 2)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+	root := store.Headers[4]
+
+	_, err := exchg.GetBelowTrust(ctx, root, 2)
+	require.ErrorIs(t, err, ErrBelowTrustDisabled)
+
+	exchg.Params.belowTrust = true
+	headers, err := exchg.GetBelowTrust(ctx, root, 10)
+	require.NoError(t, err)
+	require.Len(t, headers, 3)
+	for i, h := range headers {
+		assert.Equal(t, store.Headers[int64(i+1)].Hash(), h.Header.Hash())
+		assert.Equal(t, root.Hash(), h.Root)
+		assert.EqualValues(t, 2-i, h.Distance)
+	}
+
+	// headers not linked to the root are rejected
+	forked := *root
+	forked.Raw.PreviousHash = store.Headers[1].Hash()
+	_, err = exchg.GetBelowTrust(ctx, &forked, 1)
+	require.ErrorIs(t, err, errBrokenChain)
+}
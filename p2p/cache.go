@@ -0,0 +1,234 @@
+package p2p
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// headerCache keeps recently fetched headers by hash and height,
+// so repeated requests within a short window are served without hitting the network.
+// A nil headerCache caches nothing.
+type headerCache[H header.Header] struct {
+	// ttl is the time a cached header stays valid. Zero means no expiration.
+	ttl time.Duration
+
+	byHash   *lru.Cache
+	byHeight *lru.Cache
+}
+
+type cacheEntry[H header.Header] struct {
+	header H
+	added  time.Time
+}
+
+// newHeaderCache creates a new headerCache of the given size.
+// Zero size returns nil, meaning no caching.
+func newHeaderCache[H header.Header](size int, ttl time.Duration) (*headerCache[H], error) {
+	if size == 0 {
+		return nil, nil
+	}
+	byHash, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	byHeight, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &headerCache[H]{
+		ttl:      ttl,
+		byHash:   byHash,
+		byHeight: byHeight,
+	}, nil
+}
+
+// get returns the cached Header by the given hash.
+func (c *headerCache[H]) get(hash header.Hash) (H, bool) {
+	if c == nil {
+		var zero H
+		return zero, false
+	}
+	return c.lookup(c.byHash, hash.String())
+}
+
+// getByHeight returns the cached Header of the given height.
+func (c *headerCache[H]) getByHeight(height uint64) (H, bool) {
+	if c == nil {
+		var zero H
+		return zero, false
+	}
+	return c.lookup(c.byHeight, height)
+}
+
+// add caches the given Headers.
+func (c *headerCache[H]) add(headers ...H) {
+	if c == nil {
+		return
+	}
+	now := time.Now()
+	for _, h := range headers {
+		entry := &cacheEntry[H]{header: h, added: now}
+		c.byHash.Add(h.Hash().String(), entry)
+		c.byHeight.Add(uint64(h.Height()), entry)
+	}
+}
+
+func (c *headerCache[H]) lookup(cache *lru.Cache, key any) (H, bool) {
+	var zero H
+	v, ok := cache.Get(key)
+	if !ok {
+		return zero, false
+	}
+	entry := v.(*cacheEntry[H])
+	if c.ttl > 0 && time.Since(entry.added) > c.ttl {
+		cache.Remove(key)
+		return zero, false
+	}
+	return entry.header, true
+}
+
+// minNotFoundReports is the amount of peers that must report not having a height,
+// before it is cached as missing. It does not depend on the amount of trusted peers,
+// so a single peer can never make a height missing on its own.
+const minNotFoundReports = 2
+
+// notFoundCache remembers heights above the network head that multiple peers reported not to have,
+// so repeated premature requests for them, e.g. by polling applications, are answered
+// without hitting the network until the ttl passes or the network head reaches them.
+// Nothing is remembered until the network head is known, as any height is above an unknown head.
+// A nil notFoundCache remembers nothing.
+type notFoundCache struct {
+	ttl time.Duration
+
+	lk      sync.Mutex
+	reports map[uint64]*notFoundEntry
+}
+
+type notFoundEntry struct {
+	peers   map[peer.ID]struct{}
+	expires time.Time
+}
+
+// newNotFoundCache creates a new notFoundCache remembering missing heights for the given ttl.
+// Zero ttl returns nil, meaning no caching.
+func newNotFoundCache(ttl time.Duration) *notFoundCache {
+	if ttl == 0 {
+		return nil
+	}
+	return &notFoundCache{
+		ttl:     ttl,
+		reports: make(map[uint64]*notFoundEntry),
+	}
+}
+
+// report records that the given peer does not have the given height above the network head.
+func (c *notFoundCache) report(height, networkHead uint64, from peer.ID) {
+	if c == nil || networkHead == 0 || height <= networkHead {
+		return
+	}
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	now := time.Now()
+	for h, entry := range c.reports {
+		if now.After(entry.expires) || h <= networkHead {
+			delete(c.reports, h)
+		}
+	}
+	entry, ok := c.reports[height]
+	if !ok {
+		entry = &notFoundEntry{peers: make(map[peer.ID]struct{})}
+		c.reports[height] = entry
+	}
+	entry.peers[from] = struct{}{}
+	entry.expires = now.Add(c.ttl)
+}
+
+// missing reports whether the given height is known to be missing on at least minNotFoundReports peers.
+func (c *notFoundCache) missing(height, networkHead uint64) bool {
+	if c == nil || networkHead == 0 {
+		return false
+	}
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	entry, ok := c.reports[height]
+	if !ok {
+		return false
+	}
+	if time.Now().After(entry.expires) || height <= networkHead {
+		delete(c.reports, height)
+		return false
+	}
+	return len(entry.peers) >= minNotFoundReports
+}
+
+// responseCache keeps recently served ranges of Headers marshaled, so popular ranges, e.g. the ones
+// near the head requested by many syncing peers, are served without hitting the store and
+// marshaling them again. Ranges served partially, as the store did not have all the requested
+// Headers yet, are invalidated once new Headers are appended to the store, while the ones
+// overlapping Headers the store removes or replaces are invalidated by the store, if it reports them.
+// See remover. A nil responseCache caches nothing.
+type responseCache[H header.Header] struct {
+	ranges *lru.Cache
+}
+
+type rangeKey struct {
+	from, to uint64
+}
+
+type rangeEntry[H header.Header] struct {
+	headers []servedHeader[H]
+	// height is the height of the store the range was served at.
+	height uint64
+}
+
+// newResponseCache creates a new responseCache of the given amount of ranges.
+// Zero size returns nil, meaning no caching.
+func newResponseCache[H header.Header](size int) (*responseCache[H], error) {
+	if size == 0 {
+		return nil, nil
+	}
+	ranges, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &responseCache[H]{ranges: ranges}, nil
+}
+
+// get returns the cached range [from; to) if it is still valid at the given height of the store.
+func (c *responseCache[H]) get(from, to, height uint64) ([]servedHeader[H], bool) {
+	key := rangeKey{from: from, to: to}
+	v, ok := c.ranges.Get(key)
+	if !ok {
+		return nil, false
+	}
+	entry := v.(*rangeEntry[H])
+	if uint64(len(entry.headers)) < to-from && entry.height != height {
+		// the store got new Headers since, so the range can be served in full now
+		c.ranges.Remove(key)
+		return nil, false
+	}
+	return entry.headers, true
+}
+
+// invalidate drops the cached ranges overlapping [from; to).
+func (c *responseCache[H]) invalidate(_ context.Context, from, to uint64) {
+	for _, k := range c.ranges.Keys() {
+		key := k.(rangeKey)
+		if key.from < to && from < key.to {
+			c.ranges.Remove(key)
+		}
+	}
+}
+
+// add caches the range [from; to) served at the given height of the store.
+func (c *responseCache[H]) add(from, to, height uint64, headers []servedHeader[H]) {
+	c.ranges.Add(rangeKey{from: from, to: to}, &rangeEntry[H]{headers: headers, height: height})
+}
@@ -0,0 +1,89 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header/headertest"
+)
+
+func TestHeaderCache(t *testing.T) {
+	suite := headertest.NewTestSuite(t)
+	headers := suite.GenDummyHeaders(3)
+
+	cache, err := newHeaderCache[*headertest.DummyHeader](2, 0)
+	require.NoError(t, err)
+	cache.add(headers...)
+
+	// the oldest header is evicted
+	_, ok := cache.get(headers[0].Hash())
+	assert.False(t, ok)
+	h, ok := cache.getByHeight(uint64(headers[2].Height()))
+	require.True(t, ok)
+	assert.Equal(t, headers[2].Hash(), h.Hash())
+	h, ok = cache.get(headers[1].Hash())
+	require.True(t, ok)
+	assert.Equal(t, headers[1].Hash(), h.Hash())
+}
+
+func TestHeaderCache_TTL(t *testing.T) {
+	suite := headertest.NewTestSuite(t)
+	h := suite.NextHeader()
+
+	cache, err := newHeaderCache[*headertest.DummyHeader](8, time.Millisecond*10)
+	require.NoError(t, err)
+	cache.add(h)
+
+	_, ok := cache.get(h.Hash())
+	assert.True(t, ok)
+	time.Sleep(time.Millisecond * 20)
+	_, ok = cache.get(h.Hash())
+	assert.False(t, ok)
+	_, ok = cache.getByHeight(uint64(h.Height()))
+	assert.False(t, ok)
+}
+
+func TestHeaderCache_Disabled(t *testing.T) {
+	cache, err := newHeaderCache[*headertest.DummyHeader](0, 0)
+	require.NoError(t, err)
+	require.Nil(t, cache)
+
+	cache.add(headertest.RandDummyHeader(t))
+	_, ok := cache.getByHeight(1)
+	assert.False(t, ok)
+}
+
+func TestNotFoundCache(t *testing.T) {
+	cache := newNotFoundCache(time.Minute)
+	cache.report(10, 5, "peer1")
+	// a single report is never enough
+	assert.False(t, cache.missing(10, 5))
+
+	cache.report(10, 5, "peer1")
+	assert.False(t, cache.missing(10, 5))
+	cache.report(10, 5, "peer2")
+	assert.True(t, cache.missing(10, 5))
+
+	// heights at or below the network head are never missing
+	cache.report(4, 5, "peer1")
+	cache.report(4, 5, "peer2")
+	assert.False(t, cache.missing(4, 5))
+	assert.False(t, cache.missing(10, 10))
+	assert.False(t, cache.missing(10, 5))
+
+	// nothing is missing until the network head is known
+	cache.report(10, 0, "peer1")
+	cache.report(10, 0, "peer2")
+	assert.False(t, cache.missing(10, 0))
+	assert.False(t, cache.missing(10, 5))
+
+	// entries expire after the ttl
+	cache = newNotFoundCache(time.Millisecond * 10)
+	cache.report(10, 5, "peer1")
+	cache.report(10, 5, "peer2")
+	time.Sleep(time.Millisecond * 20)
+	assert.False(t, cache.missing(10, 5))
+}
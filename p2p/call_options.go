@@ -0,0 +1,105 @@
+package p2p
+
+import (
+	"context"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/celestiaorg/go-header"
+	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
+)
+
+// callParams is the set of parameters of a single call of the Exchange, like Head, Get, GetByHeight,
+// GetRangeByHeight or GetVerifiedRange, configured with the CallOptions passed to the call
+// and overriding the client parameters for it.
+type callParams header.CallParams
+
+// newCallParams returns the parameters of the call configured with the given CallOptions.
+func newCallParams(opts []header.CallOption) callParams {
+	return callParams(header.NewCallParams(opts...))
+}
+
+// WithPeer is a CallOption that sends the requests of the call to the given peer only,
+// bypassing the selection of trusted and tracked peers as well as the cache.
+func WithPeer(pid peer.ID) header.CallOption {
+	return func(p *header.CallParams) {
+		p.Peer = pid
+	}
+}
+
+// WithTimeout is a CallOption that bounds the duration of the call
+// regardless of the client request timeouts.
+func WithTimeout(timeout time.Duration) header.CallOption {
+	return func(p *header.CallParams) {
+		p.Timeout = timeout
+	}
+}
+
+// WithNoRetry is a CallOption that makes the call fail with the error of the first failed request,
+// instead of retrying it with other peers.
+func WithNoRetry() header.CallOption {
+	return func(p *header.CallParams) {
+		p.NoRetry = true
+	}
+}
+
+// WithProofs is a CallOption that configures whether the received headers are verified
+// with the proofs attached to them by the configured ProofVerifier. Enabled by default.
+func WithProofs(enabled bool) header.CallOption {
+	return func(p *header.CallParams) {
+		p.SkipProofs = !enabled
+	}
+}
+
+// WithPriority is a CallOption that configures the priority the requests of the call are served
+// with by the remote peers. Heads and single headers are requested with high priority by default,
+// while ranges and background requests are requested with low priority.
+func WithPriority(priority header.Priority) header.CallOption {
+	return func(p *header.CallParams) {
+		p.Priority = priority
+	}
+}
+
+// isDefault reports whether the call is not configured with any CallOption.
+func (p callParams) isDefault() bool {
+	return p == callParams{}
+}
+
+// withTimeout bounds the given context with the timeout of the call, if set.
+func (p callParams) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.Timeout == 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, p.Timeout)
+}
+
+// priority returns the priority of the requests of the call, or the given default one if unset.
+func (p callParams) priority(def p2p_pb.Priority) p2p_pb.Priority {
+	switch p.Priority {
+	case header.PriorityLow:
+		return p2p_pb.Priority_LOW
+	case header.PriorityHigh:
+		return p2p_pb.Priority_HIGH
+	default:
+		return def
+	}
+}
+
+// sessionOptions returns the options of the session serving the call.
+func sessionOptions[H header.Header](p callParams) []option[H] {
+	var opts []option[H]
+	if p.Peer != "" {
+		opts = append(opts, withPeer[H](p.Peer))
+	}
+	if p.NoRetry {
+		opts = append(opts, withNoRetry[H]())
+	}
+	if p.SkipProofs {
+		opts = append(opts, withProofVerifier[H](nil))
+	}
+	if p.Priority != header.PriorityDefault {
+		opts = append(opts, withPriority[H](p.priority(p2p_pb.Priority_LOW)))
+	}
+	return opts
+}
@@ -0,0 +1,106 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header"
+	"github.com/celestiaorg/go-header/headertest"
+	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
+)
+
+func TestExchange_CallOptions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	hosts := createMocknet(t, 3)
+	// the trusted peer has 5 headers, while the other one has 10
+	exchg, _ := createP2PExAndServer(t, hosts[0], hosts[1])
+	store := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10)
+	// priority is the priority of the last request served by the other peer
+	var priority atomic.Int32
+	serv, err := NewExchangeServer[*headertest.DummyHeader](hosts[2], store,
+		WithNetworkID[ServerParameters](networkID),
+		WithRequestHook[ServerParameters](
+			func(_ peer.ID, req *p2p_pb.HeaderRequest, _ *p2p_pb.HeaderResponse, _ error, _ time.Duration) {
+				priority.Store(int32(req.Priority))
+			}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, serv.Start(ctx))
+	t.Cleanup(func() {
+		serv.Stop(ctx) //nolint:errcheck
+	})
+	exchg.proofs = func(_ context.Context, h *headertest.DummyHeader, _ []byte) error {
+		if h.Height() == 7 {
+			return errors.New("invalid proof")
+		}
+		return nil
+	}
+
+	t.Run("WithPeer", func(t *testing.T) {
+		head, err := exchg.Head(ctx, WithPeer(hosts[2].ID()))
+		require.NoError(t, err)
+		assert.EqualValues(t, 10, head.Height())
+
+		h, err := exchg.GetByHeight(ctx, 8, WithPeer(hosts[2].ID()))
+		require.NoError(t, err)
+		assert.Equal(t, store.Headers[8].Hash(), h.Hash())
+
+		headers, err := exchg.GetRangeByHeight(ctx, 8, 3, WithPeer(hosts[2].ID()))
+		require.NoError(t, err)
+		require.Len(t, headers, 3)
+		assert.Equal(t, store.Headers[10].Hash(), headers[2].Hash())
+	})
+
+	t.Run("WithProofs", func(t *testing.T) {
+		_, err := exchg.GetByHeight(ctx, 7, WithPeer(hosts[2].ID()))
+		require.Error(t, err)
+
+		h, err := exchg.GetByHeight(ctx, 7, WithPeer(hosts[2].ID()), WithProofs(false))
+		require.NoError(t, err)
+		assert.Equal(t, store.Headers[7].Hash(), h.Hash())
+	})
+
+	t.Run("WithNoRetry", func(t *testing.T) {
+		// the range is missing on the trusted peer, so the session fails right away
+		// instead of retrying it until the deadline
+		_, err := exchg.GetRangeByHeight(ctx, 8, 3, WithPeer(hosts[1].ID()), WithNoRetry())
+		require.Error(t, err)
+		assert.NoError(t, ctx.Err())
+	})
+
+	t.Run("WithPriority", func(t *testing.T) {
+		requested := func(want p2p_pb.Priority) func() bool {
+			return func() bool { return priority.Load() == int32(want) }
+		}
+
+		// single headers are requested with high priority and ranges with low priority by default
+		_, err := exchg.GetByHeight(ctx, 9, WithPeer(hosts[2].ID()))
+		require.NoError(t, err)
+		require.Eventually(t, requested(p2p_pb.Priority_HIGH), time.Second, time.Millisecond)
+		_, err = exchg.GetRangeByHeight(ctx, 8, 2, WithPeer(hosts[2].ID()))
+		require.NoError(t, err)
+		require.Eventually(t, requested(p2p_pb.Priority_LOW), time.Second, time.Millisecond)
+
+		_, err = exchg.GetRangeByHeight(ctx, 8, 2, WithPeer(hosts[2].ID()), WithPriority(header.PriorityHigh))
+		require.NoError(t, err)
+		require.Eventually(t, requested(p2p_pb.Priority_HIGH), time.Second, time.Millisecond)
+		_, err = exchg.GetByHeight(ctx, 9, WithPeer(hosts[2].ID()), WithPriority(header.PriorityLow))
+		require.NoError(t, err)
+		require.Eventually(t, requested(p2p_pb.Priority_LOW), time.Second, time.Millisecond)
+	})
+
+	t.Run("WithTimeout", func(t *testing.T) {
+		_, err := exchg.GetRangeByHeight(ctx, 8, 3, WithPeer(hosts[1].ID()), WithTimeout(time.Millisecond*100))
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.NoError(t, ctx.Err())
+	})
+}
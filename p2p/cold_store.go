@@ -0,0 +1,63 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// WithColdStore is a functional option that configures the
+// `coldStore` parameter: a secondary Getter, e.g. an archive backed by object storage,
+// the server consults for the historical headers its store lacks, e.g. pruned ones.
+// Like the store, it is asked for ranges of [from; to), so a store.Store, e.g. of an archival node,
+// can be used as is. Requests below RecentHeaders are refused regardless of it.
+func WithColdStore[T ServerParameters, H header.Header](cold header.Getter[H]) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ServerParameters:
+			t.coldStore = cold
+		}
+	}
+}
+
+// get returns the Header at the given hash from the store,
+// falling back to the cold store, if set, if the store lacks it.
+func (serv *ExchangeServer[H]) get(ctx context.Context, hash header.Hash) (H, error) {
+	h, err := serv.store.Get(ctx, hash)
+	if serv.cold == nil || !errors.Is(err, header.ErrNotFound) {
+		return h, err
+	}
+	log.Debugw("server: getting header from cold store", "hash", hash.String())
+	return serv.cold.Get(ctx, hash)
+}
+
+// historyEnd returns the height the store has the headers of range [from; to) from,
+// so the historical headers below it are served by the cold store. It returns from
+// if the cold store is not set or the store has the header at from.
+func (serv *ExchangeServer[H]) historyEnd(ctx context.Context, from, to uint64) uint64 {
+	if serv.cold == nil {
+		return from
+	}
+	if head := serv.store.Height() + 1; to > head {
+		to = head
+	}
+	end := from
+	for end < to && !serv.store.HasAt(ctx, end) {
+		end++
+	}
+	return end
+}
+
+// getColdRange returns the Headers in range [from; to) from the cold store.
+func (serv *ExchangeServer[H]) getColdRange(ctx context.Context, from, to uint64) ([]H, error) {
+	log.Debugw("server: getting headers from cold store", "from", from, "to", to)
+	headers, err := serv.cold.GetRangeByHeight(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(headers) == 0 {
+		return nil, header.ErrNotFound
+	}
+	return headers, nil
+}
@@ -0,0 +1,122 @@
+package p2p
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+
+	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
+	"github.com/celestiaorg/go-libp2p-messenger/serde"
+)
+
+// Compression is a codec responses are compressed with on the wire.
+// The client advertises the codec it accepts in every request and the server
+// compresses the responses to it with the codec, if it supports the codec as well.
+// The responses to a request are compressed as a whole into the body of a single response,
+// so the redundancy across the headers is exploited.
+type Compression = p2p_pb.Compression
+
+const (
+	// NoCompression disables compression of responses.
+	NoCompression = p2p_pb.Compression_NONE
+	// ZstdCompression compresses responses with zstd.
+	ZstdCompression = p2p_pb.Compression_ZSTD
+	// SnappyCompression compresses responses with snappy.
+	SnappyCompression = p2p_pb.Compression_SNAPPY
+)
+
+// maxDecompressedSize limits the size of decompressed responses to a request
+// to protect against decompression bombs.
+const maxDecompressedSize = 16 << 20
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxDecompressedSize))
+)
+
+func validateCompression(codec Compression) error {
+	if _, ok := p2p_pb.Compression_name[int32(codec)]; !ok {
+		return fmt.Errorf("invalid compression: unknown codec. %s: %v", providedSuffix, codec)
+	}
+	return nil
+}
+
+// compress compresses the given payload with the given codec.
+func compress(codec Compression, data []byte) ([]byte, error) {
+	switch codec {
+	case NoCompression:
+		return data, nil
+	case ZstdCompression:
+		return zstdEncoder.EncodeAll(data, nil), nil
+	case SnappyCompression:
+		return s2.EncodeSnappy(nil, data), nil
+	default:
+		return nil, fmt.Errorf("header/p2p: unknown compression codec %v", codec)
+	}
+}
+
+// decompress decompresses the given payload compressed with the given codec.
+func decompress(codec Compression, data []byte) ([]byte, error) {
+	switch codec {
+	case NoCompression:
+		return data, nil
+	case ZstdCompression:
+		return zstdDecoder.DecodeAll(data, nil)
+	case SnappyCompression:
+		size, err := s2.DecodedLen(data)
+		if err != nil {
+			return nil, err
+		}
+		if size > maxDecompressedSize {
+			return nil, fmt.Errorf("header/p2p: decompressed payload is too large: %d", size)
+		}
+		return s2.Decode(nil, data)
+	default:
+		return nil, fmt.Errorf("header/p2p: unknown compression codec %v", codec)
+	}
+}
+
+// readResponses reads the next message of the responses to a request from the given reader,
+// unpacking the responses compressed as a whole. It returns the uncompressed responses
+// and the size of the message read. Messages or responses above the given max message size
+// result in ErrResponseLimitExceeded. Zero max message size disables the size check.
+func readResponses(r io.Reader, maxMsgSize uint64) ([]*p2p_pb.HeaderResponse, int, error) {
+	resp := new(p2p_pb.HeaderResponse)
+	n, err := serde.Read(r, resp)
+	if err != nil {
+		return nil, n, err
+	}
+	if maxMsgSize > 0 && uint64(n) > maxMsgSize {
+		return nil, n, fmt.Errorf("%w: message of %d bytes above %d", ErrResponseLimitExceeded, n, maxMsgSize)
+	}
+	if resp.Compression == NoCompression {
+		return []*p2p_pb.HeaderResponse{resp}, n, nil
+	}
+
+	raw, err := decompress(resp.Compression, resp.Body)
+	if err != nil {
+		return nil, n, err
+	}
+	var resps []*p2p_pb.HeaderResponse
+	for body := bytes.NewReader(raw); body.Len() > 0; {
+		resp := new(p2p_pb.HeaderResponse)
+		respLn, err := serde.Read(body, resp)
+		if err != nil {
+			return nil, n, fmt.Errorf("header/p2p: reading compressed response: %w", err)
+		}
+		if maxMsgSize > 0 && uint64(respLn) > maxMsgSize {
+			return nil, n, fmt.Errorf("%w: response of %d bytes above %d", ErrResponseLimitExceeded, respLn, maxMsgSize)
+		}
+		if resp.Compression != NoCompression {
+			return nil, n, fmt.Errorf("header/p2p: compressed response within compressed response")
+		}
+		resps = append(resps, resp)
+	}
+	if len(resps) == 0 {
+		return nil, n, fmt.Errorf("header/p2p: empty compressed response")
+	}
+	return resps, n, nil
+}
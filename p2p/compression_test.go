@@ -0,0 +1,146 @@
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/net/conngater"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header/headertest"
+	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
+	"github.com/celestiaorg/go-libp2p-messenger/serde"
+)
+
+func TestCompression(t *testing.T) {
+	data := bytes.Repeat([]byte("header"), 1000)
+	for _, codec := range []Compression{NoCompression, ZstdCompression, SnappyCompression} {
+		compressed, err := compress(codec, data)
+		require.NoError(t, err)
+		if codec != NoCompression {
+			assert.Less(t, len(compressed), len(data))
+		}
+
+		decompressed, err := decompress(codec, compressed)
+		require.NoError(t, err)
+		assert.Equal(t, data, decompressed)
+	}
+
+	_, err := compress(Compression(100), data)
+	require.Error(t, err)
+}
+
+func TestExchange_Compression(t *testing.T) {
+	tests := []struct {
+		name         string
+		client, serv Compression
+	}{
+		{"matching codecs", ZstdCompression, ZstdCompression},
+		{"different codecs", SnappyCompression, ZstdCompression},
+		{"uncompressed client", NoCompression, SnappyCompression},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			hosts := createMocknet(t, 2)
+			store := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10)
+			serv, err := NewExchangeServer[*headertest.DummyHeader](hosts[1], store,
+				WithNetworkID[ServerParameters](networkID),
+				WithCompression[ServerParameters](tt.serv),
+			)
+			require.NoError(t, err)
+			require.NoError(t, serv.Start(ctx))
+			t.Cleanup(func() {
+				serv.Stop(ctx) //nolint:errcheck
+			})
+
+			connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+			require.NoError(t, err)
+			ex, err := NewExchange[*headertest.DummyHeader](hosts[0], []peer.ID{hosts[1].ID()}, connGater,
+				WithNetworkID[ClientParameters](networkID),
+				WithCompression[ClientParameters](tt.client),
+			)
+			require.NoError(t, err)
+			require.NoError(t, ex.Start(ctx))
+			t.Cleanup(func() {
+				ex.Stop(ctx) //nolint:errcheck
+			})
+
+			h, err := ex.GetByHeight(ctx, 5)
+			require.NoError(t, err)
+			assert.Equal(t, store.Headers[5].Hash(), h.Hash())
+
+			head, err := ex.Head(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, store.Headers[10].Hash(), head.Hash())
+		})
+	}
+}
+
+func TestExchangeServer_CompressionThreshold(t *testing.T) {
+	hosts := createMocknet(t, 3)
+	store := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10)
+
+	// send returns the messages the server with the given threshold writes for a range of 10 headers
+	send := func(server int, threshold int) []*p2p_pb.HeaderResponse {
+		serv, err := NewExchangeServer[*headertest.DummyHeader](hosts[server], store,
+			WithNetworkID[ServerParameters](networkID),
+			WithCompression[ServerParameters](ZstdCompression),
+			WithCompressionThreshold[ServerParameters](threshold),
+		)
+		require.NoError(t, err)
+		require.NoError(t, serv.Start(context.Background()))
+		t.Cleanup(func() {
+			serv.Stop(context.Background()) //nolint:errcheck
+		})
+
+		stream, err := hosts[0].NewStream(context.Background(), hosts[server].ID(), protocolIDs(networkID)...)
+		require.NoError(t, err)
+		_, err = serde.Write(stream, &p2p_pb.HeaderRequest{
+			Data:        &p2p_pb.HeaderRequest_Origin{Origin: 1},
+			Amount:      10,
+			Compression: ZstdCompression,
+		})
+		require.NoError(t, err)
+		require.NoError(t, stream.CloseWrite())
+		var msgs []*p2p_pb.HeaderResponse
+		for {
+			msg := new(p2p_pb.HeaderResponse)
+			if _, err := serde.Read(stream, msg); err != nil {
+				require.ErrorIs(t, err, io.EOF)
+				return msgs
+			}
+			msgs = append(msgs, msg)
+		}
+	}
+
+	// responses below the threshold are sent as they are
+	msgs := send(1, 1<<20)
+	require.Len(t, msgs, 10)
+	for _, msg := range msgs {
+		assert.Equal(t, NoCompression, msg.Compression)
+	}
+	// responses above it are compressed as a whole into a single message
+	msgs = send(2, 0)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, ZstdCompression, msgs[0].Compression)
+
+	var raw bytes.Buffer
+	_, err := serde.Write(&raw, msgs[0])
+	require.NoError(t, err)
+	resps, _, err := readResponses(&raw, 0)
+	require.NoError(t, err)
+	require.Len(t, resps, 10)
+	for i, resp := range resps {
+		body, err := store.Headers[int64(i+1)].MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, body, resp.Body)
+		assert.Equal(t, NoCompression, resp.Compression)
+	}
+}
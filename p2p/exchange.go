@@ -5,38 +5,82 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math/rand"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
 	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/libp2p/go-libp2p/p2p/net/conngater"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/celestiaorg/go-header"
 	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
 )
 
-var log = logging.Logger("header/p2p")
+var (
+	log = logging.Logger("header/p2p")
+	// clientTracer traces client requests along with their attempts per peer.
+	clientTracer = otel.Tracer("header/client")
+)
 
 // the minimum number of headers of the same height received from trusted peers
 // to determine the network head. If all trusted header will return headers with
 // non-equal height, then the highest header will be chosen.
 const minTrustedHeadResponses = 2
 
+// maxRangeAmount bounds the amount of Headers a single range call fetches, as the whole range
+// is held in memory. Longer ranges are fetched with several calls, e.g. by GetRangeStream.
+const maxRangeAmount = header.MaxRangeRequestSize * 1024
+
 // Exchange enables sending outbound HeaderRequests to the network as well as
 // handling inbound HeaderRequests from the network.
 type Exchange[H header.Header] struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
-	protocolID protocol.ID
-	host       host.Host
+	protocolIDs []protocol.ID
+	host        host.Host
+	// transport opens the streams requests are sent over.
+	transport Transport
+
+	trustedLk sync.RWMutex
+	// trusted are the peers Head and single header requests are sent to.
+	trusted peer.IDSlice
 
-	trustedPeers func() peer.IDSlice
-	peerTracker  *peerTracker
+	headLk sync.RWMutex
+	// trustedHead is the latest head agreed on by the trusted peers.
+	// The heads received from tracked peers are verified against it.
+	trustedHead H
+
+	peerTracker *PeerTracker
+	// sharedTracker reports whether the peerTracker is provided externally,
+	// so its lifecycle is not managed by the Exchange.
+	sharedTracker bool
+	// rand drives all randomized choices of the Exchange.
+	rand *lockedRand
+	// backfill paces range requests, so they do not compete with head requests.
+	backfill *pacer
+	// parallelism adapts the amount of concurrent range requests to the measured throughput.
+	parallelism *parallelism
+	// cache serves recently fetched headers without network requests.
+	cache *headerCache[H]
+	// notFound answers requests for heights known to be missing without network requests.
+	notFound *notFoundCache
+	// inflight deduplicates concurrent identical requests.
+	inflight singleflight.Group
+	// proofs verifies the received headers with the proofs attached to them, if set.
+	proofs ProofVerifier[H]
+	// attestations handles the attestations attached to the received heads, if set.
+	attestations HeadAttestationHandler[H]
 
 	Params ClientParameters
 
@@ -64,24 +108,53 @@ func NewExchange[H header.Header](
 	}
 
 	ex := &Exchange[H]{
-		host:       host,
-		protocolID: protocolID(params.networkID),
-		peerTracker: newPeerTracker(
-			host,
-			connGater,
-		),
-		Params: params,
+		host:          host,
+		transport:     newTransport(host, params.transport),
+		protocolIDs:   protocolIDs(params.networkID),
+		peerTracker:   params.peerTracker,
+		sharedTracker: params.peerTracker != nil,
+		Params:        params,
+		rand:          newRand(params.seed),
+		backfill:      newPacer(params.BackfillBandwidth),
+		parallelism:   newParallelism(params.MaxParallelRequests),
+		notFound:      newNotFoundCache(params.NotFoundCacheTTL),
 	}
-
-	ex.trustedPeers = func() peer.IDSlice {
-		return shufflePeers(peers)
+	ex.cache, err = newHeaderCache[H](params.CacheSize, params.CacheTTL)
+	if err != nil {
+		return nil, err
 	}
+	ex.proofs, err = typedOption[ProofVerifier[H]]("proof verifier", params.proofVerifier)
+	if err != nil {
+		return nil, err
+	}
+	ex.attestations, err = typedOption[HeadAttestationHandler[H]]("head attestation handler", params.headAttestationHandler)
+	if err != nil {
+		return nil, err
+	}
+	if params.metrics {
+		if err = ex.InitMetrics(); err != nil {
+			return nil, err
+		}
+	}
+	if !ex.sharedTracker {
+		ex.peerTracker, err = NewPeerTracker(host, connGater, opts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// trusted peers are always allowed to be tracked
+	ex.peerTracker.allow(peers...)
+
+	ex.trusted = append(make(peer.IDSlice, 0, len(peers)), peers...)
 	return ex, nil
 }
 
-func (ex *Exchange[H]) Start(context.Context) error {
+func (ex *Exchange[H]) Start(ctx context.Context) error {
+	// the context is guarded by trustedLk, as AddTrustedPeer may be called concurrently
+	ex.trustedLk.Lock()
 	ex.ctx, ex.cancel = context.WithCancel(context.Background())
-	log.Infow("client: starting client", "protocol ID", ex.protocolID)
+	ex.trustedLk.Unlock()
+	log.Infow("client: starting client", "protocol IDs", ex.protocolIDs)
 
 	trustedPeers := ex.trustedPeers()
 
@@ -89,32 +162,96 @@ func (ex *Exchange[H]) Start(context.Context) error {
 		// Try to pre-connect to trusted peers.
 		// We don't really care if we succeed at this point
 		// and just need any peers in the peerTracker asap
-		go func(p peer.ID) {
-			err := ex.host.Connect(ex.ctx, peer.AddrInfo{ID: p})
-			if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
-				log.Debugw("err connecting to a bootstrap peer", "err", err, "peer", p)
-			}
-		}(p)
+		go ex.connect(ex.ctx, peer.AddrInfo{ID: p})
 	}
-	go ex.peerTracker.gc()
-	go ex.peerTracker.track()
-	return nil
+	if ex.sharedTracker {
+		return nil
+	}
+	return ex.peerTracker.Start(ctx)
 }
 
 func (ex *Exchange[H]) Stop(ctx context.Context) error {
 	// cancel the session if it exists
 	ex.cancel()
+	if ex.sharedTracker {
+		return nil
+	}
 	// stop the peerTracker
-	return ex.peerTracker.stop(ctx)
+	return ex.peerTracker.Stop(ctx)
+}
+
+// AddTrustedPeer adds the given peer to the trusted peers Head and single header
+// requests are sent to. It is safe to call concurrently with requests, allowing
+// operators to rotate trusted peers without restarting the node.
+func (ex *Exchange[H]) AddTrustedPeer(info peer.AddrInfo) {
+	ex.trustedLk.Lock()
+	defer ex.trustedLk.Unlock()
+	for _, p := range ex.trusted {
+		if p == info.ID {
+			return
+		}
+	}
+	ex.trusted = append(ex.trusted, info.ID)
+	ex.host.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.PermanentAddrTTL)
+	ex.peerTracker.allow(info.ID)
+	log.Infow("added trusted peer", "peer", info.ID)
+
+	if ex.ctx != nil {
+		go ex.connect(ex.ctx, info)
+	}
+}
+
+// RemoveTrustedPeer removes the given peer from the trusted peers. In the allowlist mode,
+// the peer is no longer tracked, unless it is allowlisted with WithPeerAllowlist.
+// The last trusted peer can't be removed, as the Exchange can't request Head without one.
+func (ex *Exchange[H]) RemoveTrustedPeer(pID peer.ID) error {
+	ex.trustedLk.Lock()
+	defer ex.trustedLk.Unlock()
+	for i, p := range ex.trusted {
+		if p != pID {
+			continue
+		}
+		if len(ex.trusted) == 1 {
+			return fmt.Errorf("header/p2p: can't remove the last trusted peer %s", pID)
+		}
+		ex.trusted = append(ex.trusted[:i:i], ex.trusted[i+1:]...)
+		ex.peerTracker.disallow(pID)
+		log.Infow("removed trusted peer", "peer", pID)
+		return nil
+	}
+	return nil
+}
+
+// trustedPeers returns the trusted peers in a random order.
+func (ex *Exchange[H]) trustedPeers() peer.IDSlice {
+	ex.trustedLk.RLock()
+	defer ex.trustedLk.RUnlock()
+	return shufflePeers(ex.trusted, ex.rand)
+}
+
+// connect tries to connect to the given peer in the background of the Exchange.
+func (ex *Exchange[H]) connect(ctx context.Context, info peer.AddrInfo) {
+	err := ex.host.Connect(ctx, info)
+	if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		log.Debugw("err connecting to a bootstrap peer", "err", err, "peer", info.ID)
+	}
 }
 
 // Head requests the latest Header from trusted peers.
 //
 // The Head must be verified thereafter where possible.
 // We request in parallel all the trusted peers, compare their response
-// and return the highest one.
-func (ex *Exchange[H]) Head(ctx context.Context) (H, error) {
+// and return the highest one. See WithHeadFallback for the behaviour when all of them fail,
+// in which case the head is verified against the latest head of the trusted peers.
+// The request can be sent to a single peer with the WithPeer CallOption, in which case
+// the head quorum and the fallback do not apply.
+func (ex *Exchange[H]) Head(ctx context.Context, opts ...header.CallOption) (H, error) {
 	log.Debug("requesting head")
+	call := newCallParams(opts)
+	ctx, cancel := call.withTimeout(ctx)
+	defer cancel()
+	ctx, span := clientTracer.Start(ctx, "head")
+	defer span.End()
 
 	reqCtx := ctx
 	if deadline, ok := ctx.Deadline(); ok {
@@ -128,20 +265,137 @@ func (ex *Exchange[H]) Head(ctx context.Context) (H, error) {
 		defer cancel()
 	}
 
+	var zero H
+	peers, quorum := ex.trustedPeers(), ex.Params.HeadQuorum
+	if call.Peer != "" {
+		peers, quorum = peer.IDSlice{call.Peer}, 0
+	}
+	headers, err := ex.requestHeads(ctx, reqCtx, peers, quorum > 0, call)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return zero, err
+	}
+
+	var head H
+	fromTrusted := call.Peer == "" && len(headers) > 0
+	switch {
+	case len(headers) == 0 && ex.Params.headFallback > 0 && call.Peer == "" && !call.NoRetry:
+		// all the trusted peers failed, so the head is cross-checked between the top tracked peers
+		peers := ex.untrustedPeers(ex.peerTracker.headPeers(), ex.Params.headFallback)
+		log.Warnw("all trusted peers failed head request, falling back to tracked peers", "amount", len(peers))
+		headers, err = ex.requestHeads(ctx, reqCtx, peers, true, call)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			return zero, err
+		}
+		head, err = quorumHead[H](headers, minTrustedHeadResponses)
+		if err == nil {
+			head, err = ex.verifyFallbackHead(head)
+		}
+	case quorum > 0:
+		head, err = quorumHead[H](headers, quorum)
+	default:
+		head, err = bestHead[H](headers)
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return zero, err
+	}
+	if fromTrusted {
+		ex.updateTrustedHead(head)
+	}
+	ex.peerTracker.updateNetworkHead(uint64(head.Height()))
+	span.SetAttributes(attribute.Int64("height", head.Height()))
+	span.SetStatus(codes.Ok, "")
+	return head, nil
+}
+
+// updateTrustedHead sets the trusted head if the given one is higher than the known one.
+func (ex *Exchange[H]) updateTrustedHead(head H) {
+	ex.headLk.Lock()
+	defer ex.headLk.Unlock()
+	if ex.trustedHead.IsZero() || head.Height() > ex.trustedHead.Height() {
+		ex.trustedHead = head
+	}
+}
+
+// verifyFallbackHead verifies the head tracked peers agreed on against the trusted head,
+// as tracked peers are not trusted on their own. Heads not above the trusted head
+// can not be verified, so the trusted head is returned for them instead, even though it is stale.
+// On a cold start, there is no trusted head yet, so the head agreed on by the tracked peers
+// is returned as it is and must be verified by the caller against its subjective head.
+func (ex *Exchange[H]) verifyFallbackHead(head H) (H, error) {
+	trusted := ex.latestTrustedHead()
+
+	var zero H
+	switch {
+	case trusted.IsZero():
+		log.Warnw("no trusted head to verify the fallback head against, returning it unverified",
+			"height", head.Height())
+		return head, nil
+	case head.Height() <= trusted.Height():
+		log.Warnw("fallback head is not above the trusted head, returning the stale trusted head",
+			"fallbackHeight", head.Height(),
+			"trustedHeight", trusted.Height(),
+			"trustedAge", time.Since(trusted.Time()),
+		)
+		return trusted, nil
+	}
+	if err := header.Verify(trusted, head); err != nil {
+		return zero, fmt.Errorf("header/p2p: verifying fallback head %d against trusted head %d: %w",
+			head.Height(), trusted.Height(), err)
+	}
+	return head, nil
+}
+
+// latestTrustedHead returns the latest head agreed on by the trusted peers, if any.
+func (ex *Exchange[H]) latestTrustedHead() H {
+	ex.headLk.RLock()
+	defer ex.headLk.RUnlock()
+	return ex.trustedHead
+}
+
+// verifyCandidate verifies the head candidate against the trusted head, if any,
+// so heads not following it can not win the quorum. Candidates at the height of the trusted head
+// must match it, while lower ones can not be verified and are left to the quorum.
+func (ex *Exchange[H]) verifyCandidate(h H) error {
+	trusted := ex.latestTrustedHead()
+	switch {
+	case trusted.IsZero() || h.Height() < trusted.Height():
+		return nil
+	case h.Height() == trusted.Height():
+		if !bytes.Equal(h.Hash(), trusted.Hash()) {
+			return fmt.Errorf("header/p2p: head %d differs from the trusted head", h.Height())
+		}
+		return nil
+	}
+	return header.Verify(trusted, h)
+}
+
+// requestHeads requests the head from the given peers in parallel within reqCtx and
+// returns the received heads. If validate is set, invalid heads and the ones failing verification
+// against the trusted head are discarded.
+func (ex *Exchange[H]) requestHeads(
+	ctx, reqCtx context.Context,
+	peers peer.IDSlice,
+	validate bool,
+	call callParams,
+) ([]H, error) {
 	var (
-		zero         H
-		trustedPeers = ex.trustedPeers()
-		headerRespCh = make(chan H, len(trustedPeers))
+		headerRespCh = make(chan H, len(peers))
 		headerReq    = &p2p_pb.HeaderRequest{
-			Data:   &p2p_pb.HeaderRequest_Origin{Origin: uint64(0)},
-			Amount: 1,
+			Data:        &p2p_pb.HeaderRequest_Origin{Origin: uint64(0)},
+			Amount:      1,
+			Compression: ex.Params.compression,
+			Priority:    call.priority(p2p_pb.Priority_HIGH),
 		}
 	)
-	for _, from := range trustedPeers {
+	for _, from := range peers {
 		go func(from peer.ID) {
-			headers, err := ex.request(reqCtx, from, headerReq)
+			headers, err := ex.request(reqCtx, from, headerReq, call)
 			if err != nil {
-				log.Errorw("head request to trusted peer failed", "trustedPeer", from, "err", err)
+				log.Errorw("head request to peer failed", "peer", from, "err", err)
+				var zero H
 				headerRespCh <- zero
 				return
 			}
@@ -150,111 +404,546 @@ func (ex *Exchange[H]) Head(ctx context.Context) (H, error) {
 		}(from)
 	}
 
-	headers := make([]H, 0, len(trustedPeers))
-	for range trustedPeers {
+	headers := make([]H, 0, len(peers))
+	for range peers {
 		select {
 		case h := <-headerRespCh:
-			if !h.IsZero() {
-				headers = append(headers, h)
+			if h.IsZero() {
+				continue
+			}
+			if validate {
+				// each candidate is validated and verified before it can vote
+				if err := h.Validate(); err != nil {
+					log.Errorw("invalid head from peer", "height", h.Height(), "err", err)
+					continue
+				}
+				if err := ex.verifyCandidate(h); err != nil {
+					log.Errorw("unverified head from peer", "height", h.Height(), "err", err)
+					continue
+				}
 			}
+			headers = append(headers, h)
 		case <-ctx.Done():
-			return zero, ctx.Err()
+			return nil, ctx.Err()
 		case <-ex.ctx.Done():
-			return zero, ex.ctx.Err()
+			return nil, ex.ctx.Err()
 		}
 	}
-	return bestHead[H](headers)
+	return headers, nil
+}
+
+// untrustedPeers returns up to the given amount of the given tracked peers, which are not trusted,
+// preserving their order.
+func (ex *Exchange[H]) untrustedPeers(stats []*peerStat, limit int) peer.IDSlice {
+	trusted := make(map[peer.ID]struct{})
+	for _, p := range ex.trustedPeers() {
+		trusted[p] = struct{}{}
+	}
+
+	peers := make(peer.IDSlice, 0, limit)
+	for _, stat := range stats {
+		if len(peers) == limit {
+			break
+		}
+		if _, ok := trusted[stat.peerID]; !ok {
+			peers = append(peers, stat.peerID)
+		}
+	}
+	return peers
 }
 
 // GetByHeight performs a request for the Header at the given
 // height to the network. Note that the Header must be verified
 // thereafter.
-func (ex *Exchange[H]) GetByHeight(ctx context.Context, height uint64) (H, error) {
+func (ex *Exchange[H]) GetByHeight(ctx context.Context, height uint64, opts ...header.CallOption) (H, error) {
 	log.Debugw("requesting header", "height", height)
+	call := newCallParams(opts)
+	ctx, cancel := call.withTimeout(ctx)
+	defer cancel()
+	ctx, span := clientTracer.Start(ctx, "get-by-height", trace.WithAttributes(
+		attribute.Int64("height", int64(height)),
+	))
+	defer span.End()
+
 	var zero H
 	// sanity check height
 	if height == 0 {
 		return zero, fmt.Errorf("specified request height must be greater than 0")
 	}
+	if h, ok := ex.cache.getByHeight(height); ok && call.Peer == "" {
+		span.AddEvent("served-from-cache")
+		return h, nil
+	}
+	if call.Peer == "" && ex.notFound.missing(height, ex.peerTracker.networkHead.Load()) {
+		span.AddEvent("missing-in-cache")
+		return zero, header.ErrNotFound
+	}
 	// create request
 	req := &p2p_pb.HeaderRequest{
-		Data:   &p2p_pb.HeaderRequest_Origin{Origin: height},
-		Amount: 1,
+		Data:        &p2p_pb.HeaderRequest_Origin{Origin: height},
+		Amount:      1,
+		Compression: ex.Params.compression,
+		Priority:    call.priority(p2p_pb.Priority_HIGH),
 	}
-	headers, err := ex.performRequest(ctx, req)
+	headers, err := ex.shared(ctx, call, fmt.Sprintf("height/%d", height), func(ctx context.Context) ([]H, error) {
+		return ex.performRequest(ctx, req, call)
+	})
 	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
 		return zero, err
 	}
+	ex.cache.add(headers[0])
+	span.SetStatus(codes.Ok, "")
 	return headers[0], nil
 }
 
 // GetRangeByHeight performs a request for the given range of Headers
 // to the network. Note that the Headers must be verified thereafter.
-func (ex *Exchange[H]) GetRangeByHeight(ctx context.Context, from, amount uint64) ([]H, error) {
+// Ranges of any size are split into chunks of MaxHeadersPerRangeRequest, which are
+// fetched from multiple tracked peers in parallel and reassembled in order.
+// The chunks above the max range advertised by a peer are split further for it.
+// If the context is done after a part of the range was fetched, its contiguous prefix
+// is returned along with ErrPartialResponse.
+// Ranges above maxRangeAmount are rejected with header.ErrHeadersLimitExceeded.
+func (ex *Exchange[H]) GetRangeByHeight(ctx context.Context, from, amount uint64, opts ...header.CallOption) ([]H, error) {
+	if amount == 0 {
+		return make([]H, 0), nil
+	}
+	if err := checkRange(from, amount); err != nil {
+		return nil, err
+	}
+	call := newCallParams(opts)
+	ctx, cancel := call.withTimeout(ctx)
+	defer cancel()
+	ctx, span := clientTracer.Start(ctx, "get-range-by-height", trace.WithAttributes(
+		attribute.Int64("from", int64(from)),
+		attribute.Int64("amount", int64(amount)),
+	))
+	defer span.End()
+
+	headers, err := ex.shared(ctx, call, fmt.Sprintf("range/%d/%d", from, amount), func(ctx context.Context) ([]H, error) {
+		session := ex.newSession(ex.ctx, sessionOptions[H](call)...)
+		defer session.close()
+		return session.getRangeByHeight(ctx, from, amount, ex.Params.MaxHeadersPerRangeRequest)
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return headers, err
+	}
+	span.SetStatus(codes.Ok, "")
+	return headers, nil
+}
+
+// checkRange ensures the range of the given amount of Headers from the given height
+// is within maxRangeAmount and does not overflow the heights.
+func checkRange(from, amount uint64) error {
+	if amount > maxRangeAmount {
+		return fmt.Errorf("%w: %d headers above %d", header.ErrHeadersLimitExceeded, amount, maxRangeAmount)
+	}
+	if from+amount < from {
+		return fmt.Errorf("header/p2p: range of %d headers from %d overflows", amount, from)
+	}
+	return nil
+}
+
+// GetRangeDescending performs a request for the given amount of Headers ending at the given
+// height and returns them in descending order, e.g. to walk the chain backwards to the
+// ancestor of a fork. Ranges above the MaxRangeRequestSize are requested sequentially.
+// The returned Headers are hash-linked with each other, so only the first one
+// must be verified thereafter.
+func (ex *Exchange[H]) GetRangeDescending(
+	ctx context.Context,
+	from, amount uint64,
+	opts ...header.CallOption,
+) ([]H, error) {
+	call := newCallParams(opts)
+	if from == 0 {
+		return nil, fmt.Errorf("header/p2p: invalid descending range from height 0")
+	}
+	if amount > from {
+		amount = from
+	}
 	if amount == 0 {
 		return make([]H, 0), nil
 	}
-	if amount > header.MaxRangeRequestSize {
-		return nil, header.ErrHeadersLimitExceeded
+	return ex.shared(ctx, call, fmt.Sprintf("descending/%d/%d", from, amount), func(ctx context.Context) ([]H, error) {
+		headers := make([]H, 0, amount)
+		for to := from; uint64(len(headers)) < amount; {
+			size := amount - uint64(len(headers))
+			if size > header.MaxRangeRequestSize {
+				size = header.MaxRangeRequestSize
+			}
+			req := &p2p_pb.HeaderRequest{
+				Data:        &p2p_pb.HeaderRequest_Origin{Origin: to},
+				Amount:      size,
+				Compression: ex.Params.compression,
+				Descending:  true,
+				Priority:    call.priority(p2p_pb.Priority_LOW),
+			}
+			resp, err := ex.performRequest(ctx, req, call)
+			if err != nil {
+				return nil, err
+			}
+			if uint64(len(resp)) != size {
+				return nil, fmt.Errorf("header/p2p: partial descending range: requested %d, received %d",
+					size, len(resp))
+			}
+			headers = append(headers, resp...)
+			to -= size
+		}
+		return headers, verifyDescending(headers, from)
+	})
+}
+
+// verifyDescending ensures the headers descend from the given height one by one
+// and each of them is the parent of the previous one.
+func verifyDescending[H header.Header](headers []H, from uint64) error {
+	for i, h := range headers {
+		if uint64(h.Height()) != from-uint64(i) {
+			return fmt.Errorf("header/p2p: unexpected header in descending range: expected height %d, received %d",
+				from-uint64(i), h.Height())
+		}
+		if i > 0 && !linked(h, headers[i-1]) {
+			return fmt.Errorf("header/p2p: header %d is not the parent of header %d",
+				h.Height(), headers[i-1].Height())
+		}
 	}
-	session := newSession[H](ex.ctx, ex.host, ex.peerTracker, ex.protocolID, ex.Params.RangeRequestTimeout)
-	defer session.close()
-	return session.getRangeByHeight(ctx, from, amount, ex.Params.MaxHeadersPerRangeRequest)
+	return nil
+}
+
+// GetRangeStream requests the range of Headers (from:to) above the given trusted Header from the
+// network and streams them in ascending order as responses arrive, instead of buffering the whole
+// range in memory. The range is fetched in chunks and every Header is verified against the trusted
+// one or the previously streamed Header before it is streamed, so no verification is needed thereafter.
+// The Header channel is closed once the range is streamed or the first error occurs,
+// which is then sent on the error channel.
+func (ex *Exchange[H]) GetRangeStream(
+	ctx context.Context,
+	from H,
+	to uint64,
+	opts ...header.CallOption,
+) (<-chan H, <-chan error) {
+	out, errCh := make(chan H), make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		defer close(out)
+
+		call := newCallParams(opts)
+		stream := func(h H) error {
+			select {
+			case out <- h:
+				from = h
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		for height := uint64(from.Height()) + 1; height < to; height = uint64(from.Height()) + 1 {
+			amount := to - height
+			if amount > header.MaxRangeRequestSize {
+				amount = header.MaxRangeRequestSize
+			}
+
+			chunkCtx, cancel := call.withTimeout(ctx)
+			session := ex.newSession(ex.ctx, append(sessionOptions[H](call), withValidation(from), withStream(stream))...)
+			_, err := session.getRangeByHeight(chunkCtx, height, amount, ex.Params.MaxHeadersPerRangeRequest)
+			session.close()
+			cancel()
+			if err != nil {
+				errCh <- err
+				return
+			}
+		}
+	}()
+	return out, errCh
 }
 
 // GetVerifiedRange performs a request for the given range of Headers to the network and
 // ensures that returned headers are correct against the passed one.
+// If the context is done after a part of the range was fetched and verified, its contiguous prefix
+// is returned along with ErrPartialResponse.
 func (ex *Exchange[H]) GetVerifiedRange(
 	ctx context.Context,
 	from H,
 	amount uint64,
+	opts ...header.CallOption,
 ) ([]H, error) {
 	if amount == 0 {
 		return make([]H, 0), nil
 	}
-	session := newSession[H](
-		ex.ctx, ex.host, ex.peerTracker, ex.protocolID, ex.Params.RangeRequestTimeout, withValidation(from),
-	)
-	defer session.close()
-	// we request the next header height that we don't have: `fromHead`+1
-	return session.getRangeByHeight(ctx, uint64(from.Height())+1, amount, ex.Params.MaxHeadersPerRangeRequest)
+	if err := checkRange(uint64(from.Height())+1, amount); err != nil {
+		return nil, err
+	}
+	call := newCallParams(opts)
+	ctx, cancel := call.withTimeout(ctx)
+	defer cancel()
+	key := fmt.Sprintf("verified/%s/%d", from.Hash(), amount)
+	return ex.shared(ctx, call, key, func(ctx context.Context) ([]H, error) {
+		session := ex.newSession(ex.ctx, append(sessionOptions[H](call), withValidation(from))...)
+		defer session.close()
+		// we request the next header height that we don't have: `fromHead`+1
+		return session.getRangeByHeight(ctx, uint64(from.Height())+1, amount, ex.Params.MaxHeadersPerRangeRequest)
+	})
 }
 
 // Get performs a request for the Header by the given hash corresponding
 // to the RawHeader. Note that the Header must be verified thereafter.
-func (ex *Exchange[H]) Get(ctx context.Context, hash header.Hash) (H, error) {
+func (ex *Exchange[H]) Get(ctx context.Context, hash header.Hash, opts ...header.CallOption) (H, error) {
 	log.Debugw("requesting header", "hash", hash.String())
+	call := newCallParams(opts)
+	ctx, cancel := call.withTimeout(ctx)
+	defer cancel()
+	ctx, span := clientTracer.Start(ctx, "get", trace.WithAttributes(
+		attribute.String("hash", hash.String()),
+	))
+	defer span.End()
+
 	var zero H
+	if h, ok := ex.cache.get(hash); ok && call.Peer == "" {
+		span.AddEvent("served-from-cache")
+		return h, nil
+	}
 	// create request
 	req := &p2p_pb.HeaderRequest{
-		Data:   &p2p_pb.HeaderRequest_Hash{Hash: hash},
-		Amount: 1,
+		Data:        &p2p_pb.HeaderRequest_Hash{Hash: hash},
+		Amount:      1,
+		Compression: ex.Params.compression,
+		Priority:    call.priority(p2p_pb.Priority_HIGH),
 	}
-	headers, err := ex.performRequest(ctx, req)
+	headers, err := ex.shared(ctx, call, "hash/"+hash.String(), func(ctx context.Context) ([]H, error) {
+		return ex.performRequest(ctx, req, call)
+	})
 	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
 		return zero, err
 	}
+	ex.cache.add(headers[0])
+	span.SetAttributes(attribute.Int64("height", headers[0].Height()))
+	span.SetStatus(codes.Ok, "")
+	return headers[0], nil
+}
 
-	if !bytes.Equal(headers[0].Hash(), hash) {
-		return zero, fmt.Errorf("incorrect hash in header: expected %x, got %x", hash, headers[0].Hash())
+// GetByHashes performs requests for the Headers by the given hashes in a single round trip
+// per MaxRangeRequestSize hashes, instead of a Get call for each hash. Headers are returned
+// in the order of the given hashes. Note that the Headers must be verified thereafter.
+func (ex *Exchange[H]) GetByHashes(ctx context.Context, hashes []header.Hash, opts ...header.CallOption) ([]H, error) {
+	call := newCallParams(opts)
+	log.Debugw("requesting headers by hashes", "amount", len(hashes))
+	headers := make([]H, len(hashes))
+	// indexes of the headers missing in the cache
+	missing := make([]int, 0, len(hashes))
+	for i, hash := range hashes {
+		if h, ok := ex.cache.get(hash); ok {
+			headers[i] = h
+			continue
+		}
+		missing = append(missing, i)
+	}
+
+	for len(missing) > 0 {
+		batch := missing
+		if uint64(len(batch)) > header.MaxRangeRequestSize {
+			batch = batch[:header.MaxRangeRequestSize]
+		}
+		missing = missing[len(batch):]
+
+		list := &p2p_pb.HashList{Hashes: make([][]byte, len(batch))}
+		for i, idx := range batch {
+			list.Hashes[i] = hashes[idx]
+		}
+		req := &p2p_pb.HeaderRequest{
+			Data:        &p2p_pb.HeaderRequest_Hashes{Hashes: list},
+			Amount:      uint64(len(batch)),
+			Compression: ex.Params.compression,
+			Priority:    call.priority(p2p_pb.Priority_LOW),
+		}
+		// the amount and order of the received headers are validated against the requested hashes
+		resp, err := ex.performRequest(ctx, req, call)
+		if err != nil {
+			return nil, err
+		}
+		for i, idx := range batch {
+			headers[idx] = resp[i]
+		}
+		ex.cache.add(resp...)
+	}
+	return headers, nil
+}
+
+// GetFromPeer requests the Header by the given hash from the given peer,
+// bypassing the selection of trusted and tracked peers.
+// Note that the Header must be verified thereafter.
+func (ex *Exchange[H]) GetFromPeer(ctx context.Context, hash header.Hash, pid peer.ID) (H, error) {
+	log.Debugw("requesting header from peer", "hash", hash.String(), "peer", pid)
+	var zero H
+	req := &p2p_pb.HeaderRequest{
+		Data:        &p2p_pb.HeaderRequest_Hash{Hash: hash},
+		Amount:      1,
+		Compression: ex.Params.compression,
+		Priority:    p2p_pb.Priority_HIGH,
+	}
+	headers, err := ex.request(ctx, pid, req, callParams{})
+	if err != nil {
+		return zero, err
 	}
 	return headers[0], nil
 }
 
-const requestRetry = 3
+// GetRangeFromPeer requests the range of Headers [from:to) from the given peer,
+// bypassing the selection of trusted and tracked peers. Ranges above the MaxRangeRequestSize
+// are requested from the peer sequentially. Unlike other range requests, a partial response
+// is not re-requested from other peers and results in an error.
+// Note that the Headers must be verified thereafter.
+func (ex *Exchange[H]) GetRangeFromPeer(ctx context.Context, from, to uint64, pid peer.ID) ([]H, error) {
+	if from == 0 || from >= to {
+		return nil, fmt.Errorf("header/p2p: invalid range(%d,%d)", from, to)
+	}
+	log.Debugw("requesting headers from peer", "from", from, "to", to, "peer", pid)
+
+	headers := make([]H, 0, to-from)
+	for from < to {
+		amount := to - from
+		if amount > header.MaxRangeRequestSize {
+			amount = header.MaxRangeRequestSize
+		}
+		req := &p2p_pb.HeaderRequest{
+			Data:        &p2p_pb.HeaderRequest_Origin{Origin: from},
+			Amount:      amount,
+			Compression: ex.Params.compression,
+		}
+		resp, err := ex.request(ctx, pid, req, callParams{})
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(resp)) != amount {
+			return nil, fmt.Errorf("header/p2p: peer %s sent partial range: requested %d, received %d",
+				pid, amount, len(resp))
+		}
+		headers = append(headers, resp...)
+		from += amount
+	}
+	return headers, nil
+}
+
+// shared collapses concurrent identical requests, identified by the given key, into a single
+// request and shares its result between the callers, e.g. when the syncer and an RPC handler
+// request the same range at the same time.
+// Partial results returned along with ErrPartialResponse are passed to the caller the request
+// was run within, while the rest retry it within their own context.
+func (ex *Exchange[H]) shared(
+	ctx context.Context,
+	call callParams,
+	key string,
+	request func(context.Context) ([]H, error),
+) ([]H, error) {
+	if !call.isDefault() {
+		// calls configured with CallOptions may get different results, so they are not shared
+		return request(ctx)
+	}
+	// owner is set if the request runs within the context of this caller
+	var owner atomic.Bool
+	resCh := ex.inflight.DoChan(key, func() (any, error) {
+		owner.Store(true)
+		return request(ctx)
+	})
+
+	var res singleflight.Result
+	select {
+	case res = <-resCh:
+	case <-ctx.Done():
+		if !owner.Load() {
+			return nil, ctx.Err()
+		}
+		// the request is bound to the same context, so it returns the partial result, if any, right away
+		res = <-resCh
+	}
+
+	if res.Err != nil {
+		if res.Shared && ctx.Err() == nil &&
+			(errors.Is(res.Err, context.Canceled) || errors.Is(res.Err, context.DeadlineExceeded)) {
+			// the request was canceled by another caller, so it is retried within the own context
+			return request(ctx)
+		}
+		if errors.Is(res.Err, ErrPartialResponse) {
+			headers, _ := res.Val.([]H)
+			return append(make([]H, 0, len(headers)), headers...), res.Err
+		}
+		return nil, res.Err
+	}
+	headers := res.Val.([]H)
+	if res.Shared {
+		// every caller gets its own slice, so they can't interfere
+		headers = append(make([]H, 0, len(headers)), headers...)
+	}
+	return headers, nil
+}
+
+// newSession creates a session for ranged requests to the tracked peers
+// configured with the client parameters.
+func (ex *Exchange[H]) newSession(ctx context.Context, opts ...option[H]) *session[H] {
+	opts = append([]option[H]{
+		withRand[H](ex.rand),
+		withPacer[H](ex.backfill),
+		withParallelism[H](ex.parallelism),
+		withMetrics[H](ex.metrics),
+		withCompression[H](ex.Params.compression),
+		withProofVerifier[H](ex.proofs),
+		withMaxMessageSize[H](ex.Params.MaxMessageSize),
+		withScheduler[H](ex.Params.scheduler),
+	}, opts...)
+	return newSession[H](ctx, ex.transport, ex.peerTracker, ex.protocolIDs, ex.Params.RangeRequestTimeout, opts...)
+}
 
 func (ex *Exchange[H]) performRequest(
 	ctx context.Context,
 	req *p2p_pb.HeaderRequest,
+	call callParams,
 ) ([]H, error) {
 	if req.Amount == 0 {
 		return make([]H, 0), nil
 	}
 
-	trustedPeers := ex.trustedPeers()
-	var reqErr error
+	// single headers are requested from the quickest trusted peers first,
+	// while ranges are requested from the ones with the highest throughput
+	trustedPeers := ex.peerTracker.routable(ex.trustedPeers())
+	if req.Amount > 1 {
+		ex.peerTracker.sortByThroughput(trustedPeers)
+	} else {
+		ex.peerTracker.sortByLatency(trustedPeers)
+	}
+	retries := ex.Params.MaxRetries
+	if call.Peer != "" {
+		trustedPeers = peer.IDSlice{call.Peer}
+	}
+	if call.NoRetry && len(trustedPeers) > 0 {
+		trustedPeers, retries = trustedPeers[:1], 1
+	}
+	var (
+		reqErr error
+		// failed is the peer the last attempt failed on, which is recorded as retried
+		// once the request is sent to another peer
+		failed peer.ID
+	)
 
-	for i := 0; i < requestRetry; i++ {
+	for i := 0; i < retries; i++ {
+		if call.Peer == "" && len(trustedPeers) > 0 {
+			// peers responding they pruned the header are not retried
+			if trustedPeers = ex.retaining(trustedPeers, req); len(trustedPeers) == 0 {
+				if reqErr == nil {
+					reqErr = &PrunedError{}
+				}
+				break
+			}
+		}
+		if i > 0 && ex.Params.backoff != nil {
+			select {
+			case <-time.After(ex.Params.backoff(i)):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-ex.ctx.Done():
+				return nil, ex.ctx.Err()
+			}
+		}
 		for _, peer := range trustedPeers {
 			select {
 			case <-ctx.Done():
@@ -264,9 +953,15 @@ func (ex *Exchange[H]) performRequest(
 			default:
 			}
 
-			h, err := ex.request(ctx, peer, req)
+			if failed != "" && failed != peer {
+				ex.metrics.observeRetry(ctx, failed)
+			}
+			h, err := ex.request(ctx, peer, req, call)
 			if err != nil {
-				reqErr = err
+				if errors.Is(err, header.ErrNotFound) {
+					ex.recordNotFound(peer, req)
+				}
+				reqErr, failed = err, peer
 				log.Debugw("requesting header from trustedPeer failed",
 					"trustedPeer", peer, "err", err, "try", i)
 				continue
@@ -274,53 +969,290 @@ func (ex *Exchange[H]) performRequest(
 			return h, err
 		}
 	}
+
+	notFound := errors.Is(reqErr, header.ErrNotFound) || errors.As(reqErr, new(*PrunedError))
+	if notFound && call.Peer == "" && !call.NoRetry && ex.expectedFound(req) {
+		// the trusted peers may be lagging or pruned, so the header is looked for on other tracked peers
+		return ex.requestUntrusted(ctx, req, reqErr, failed, call)
+	}
 	return nil, reqErr
 }
 
-// request sends the HeaderRequest to a remote peer.
+// requestUntrusted sends the HeaderRequest to up to NotFoundRetries tracked peers, which are not trusted,
+// in the order of their score, until one of them responds. If none of them does, the given error
+// is returned. The given peer the request failed on last is recorded as retried once the request
+// is sent to a tracked peer.
+func (ex *Exchange[H]) requestUntrusted(
+	ctx context.Context,
+	req *p2p_pb.HeaderRequest,
+	reqErr error,
+	failed peer.ID,
+	call callParams,
+) ([]H, error) {
+	stats := ex.peerTracker.peers()
+	sortByScore(stats)
+	for _, peer := range ex.retaining(ex.untrustedPeers(stats, ex.Params.NotFoundRetries), req) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ex.ctx.Done():
+			return nil, ex.ctx.Err()
+		default:
+		}
+
+		if failed != "" {
+			ex.metrics.observeRetry(ctx, failed)
+		}
+		h, err := ex.request(ctx, peer, req, call)
+		if err != nil {
+			if errors.Is(err, header.ErrNotFound) {
+				ex.recordNotFound(peer, req)
+			}
+			failed = peer
+			log.Debugw("requesting header from tracked peer failed", "peer", peer, "err", err)
+			continue
+		}
+		return h, nil
+	}
+	return nil, reqErr
+}
+
+// retaining filters out the given peers which advertised they do not serve the header
+// requested by height anymore, so the request is routed to other peers right away.
+func (ex *Exchange[H]) retaining(peers peer.IDSlice, req *p2p_pb.HeaderRequest) peer.IDSlice {
+	origin := req.GetOrigin()
+	if origin == 0 {
+		return peers
+	}
+	retaining := make(peer.IDSlice, 0, len(peers))
+	for _, pID := range peers {
+		if ex.peerTracker.retains(pID, origin) {
+			retaining = append(retaining, pID)
+		}
+	}
+	return retaining
+}
+
+// expectedFound reports whether the requested header is expected to be found on the network,
+// i.e. it is requested by hash or its height is not above the network head.
+func (ex *Exchange[H]) expectedFound(req *p2p_pb.HeaderRequest) bool {
+	_, byHeight := req.Data.(*p2p_pb.HeaderRequest_Origin)
+	return !byHeight || req.GetOrigin() <= ex.peerTracker.networkHead.Load()
+}
+
+// recordNotFound records that the given peer does not have the requested header.
+// Heights above the network head are cached as missing, while peers missing the history
+// below it get their score lowered.
+func (ex *Exchange[H]) recordNotFound(from peer.ID, req *p2p_pb.HeaderRequest) {
+	if _, byHeight := req.Data.(*p2p_pb.HeaderRequest_Origin); !byHeight || req.Amount != 1 {
+		return
+	}
+	if ex.expectedFound(req) {
+		ex.peerTracker.decreaseScore(from)
+		return
+	}
+	ex.notFound.report(req.GetOrigin(), ex.peerTracker.networkHead.Load(), from)
+}
+
+// request sends the HeaderRequest to a remote peer and records the result for its circuit breaker.
 func (ex *Exchange[H]) request(
 	ctx context.Context,
 	to peer.ID,
 	req *p2p_pb.HeaderRequest,
+	call callParams,
+) ([]H, error) {
+	ctx, span := startRequestSpan(ctx, to, req)
+	defer span.End()
+
+	if req.Priority == p2p_pb.Priority_HIGH {
+		// high priority requests share the slots of the peer with range requests of sessions,
+		// jumping ahead of them if the peer is at the limit
+		release, err := ex.peerTracker.acquirePrioritized(ctx, to)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+		defer release()
+	}
+
+	ex.peerTracker.reserveProbe(to)
+	headers, err := ex.sendRequest(ctx, to, req, call)
+	ex.peerTracker.recordResult(to, err)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		if errors.Is(err, errBrokenChain) {
+			ex.metrics.observeBlocked(ctx, to)
+			ex.peerTracker.blockPeer(to, &InvalidResponseError{Request: req, Err: err})
+		}
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "")
+	return headers, nil
+}
+
+// sendRequest sends the HeaderRequest to a remote peer.
+func (ex *Exchange[H]) sendRequest(
+	ctx context.Context,
+	to peer.ID,
+	req *p2p_pb.HeaderRequest,
+	call callParams,
 ) ([]H, error) {
 	log.Debugw("requesting peer", "peer", to)
-	responses, size, duration, err := sendMessage(ctx, ex.host, to, ex.protocolID, req)
-	ex.metrics.observeResponse(ctx, size, duration, err)
+	if timeout := ex.requestTimeout(req); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	responses, size, duration, err := sendMessage(ctx, ex.transport, to, ex.protocolIDs, req, ex.Params.MaxMessageSize)
+	ex.metrics.observeResponse(ctx, to, size, duration, err)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("bytes", int64(size)))
 	if err != nil {
 		log.Debugw("err sending request", "peer", to, "err", err)
 		return nil, err
 	}
+	ex.peerTracker.updateLatency(to, time.Duration(duration)*time.Millisecond)
+	if isHeadRequest(req) && len(responses) > 0 {
+		ex.peerTracker.updateAdvertised(to, responses[0])
+	}
 
 	headers := make([]H, 0, len(responses))
 	for _, response := range responses {
-		if err = convertStatusCodeToError(response.StatusCode); err != nil {
-			return nil, err
-		}
-		var empty H
-		header := empty.New()
-		err := header.UnmarshalBinary(response.Body)
+		h, err := ex.processResponse(ctx, to, req, response, call)
 		if err != nil {
 			return nil, err
 		}
-		err = validateChainID(ex.Params.chainID, header.(H).ChainID())
-		if err != nil {
-			return nil, err
-		}
-		headers = append(headers, header.(H))
+		headers = append(headers, h)
 	}
 
 	if len(headers) == 0 {
 		return nil, header.ErrNotFound
 	}
+	if err = validateResponse(req, headers); err != nil {
+		return nil, err
+	}
+	// only valid responses count towards the throughput, so invalid ones don't raise the score
+	ex.peerTracker.updateThroughput(to, size, duration)
 	return headers, nil
 }
 
+// validateResponse ensures the received Headers are the ones requested:
+// Headers requested by hashes must match them, while ranges must consist of adjacent Headers
+// of the expected heights, linked by their hashes.
+func validateResponse[H header.Header](req *p2p_pb.HeaderRequest, headers []H) error {
+	switch req.Data.(type) {
+	case *p2p_pb.HeaderRequest_Hash:
+		if !bytes.Equal(headers[0].Hash(), req.GetHash()) {
+			return fmt.Errorf("%w: incorrect hash in header: expected %x, got %x",
+				errInvalidResponse, req.GetHash(), headers[0].Hash())
+		}
+	case *p2p_pb.HeaderRequest_Hashes:
+		hashes := req.GetHashes().GetHashes()
+		if len(headers) != len(hashes) {
+			return fmt.Errorf("%w: unexpected amount of headers: expected %d, got %d",
+				errInvalidResponse, len(hashes), len(headers))
+		}
+		for i, h := range headers {
+			if !bytes.Equal(h.Hash(), hashes[i]) {
+				return fmt.Errorf("%w: incorrect hash in header: expected %x, got %x",
+					errInvalidResponse, hashes[i], h.Hash())
+			}
+		}
+	case *p2p_pb.HeaderRequest_Origin:
+		if isHeadRequest(req) {
+			return nil
+		}
+		for i, h := range headers {
+			height, parent, child := req.GetOrigin()+uint64(i), i-1, i
+			if req.Descending {
+				height, parent, child = req.GetOrigin()-uint64(i), i, i-1
+			}
+			if uint64(h.Height()) != height {
+				return fmt.Errorf("%w: unexpected header: expected height %d, received %d",
+					errInvalidResponse, height, h.Height())
+			}
+			if i > 0 && !linked(headers[parent], headers[child]) {
+				return fmt.Errorf("%w: header %d is not the parent of header %d",
+					errBrokenChain, headers[parent].Height(), headers[child].Height())
+			}
+		}
+	}
+	return nil
+}
+
+// linked reports whether the given parent Header is referenced as the previous one by the child.
+func linked[H header.Header](parent, child H) bool {
+	return bytes.Equal(child.LastHeader(), parent.Hash())
+}
+
+// processResponse converts the HeaderResponse to the request from the given peer into Header.
+func (ex *Exchange[H]) processResponse(
+	ctx context.Context,
+	from peer.ID,
+	req *p2p_pb.HeaderRequest,
+	response *p2p_pb.HeaderResponse,
+	call callParams,
+) (H, error) {
+	var zero H
+	if err := convertStatusCodeToError(response); err != nil {
+		return zero, err
+	}
+	if ex.Params.verifyHeadSignature && isHeadRequest(req) {
+		signer, err := verifyHeadSignature(response)
+		if err != nil {
+			return zero, err
+		}
+		// a head relayed from another peer is not vouched for by the responding one
+		if signer != from {
+			return zero, fmt.Errorf("%w: signed by %s", errForeignHeadSignature, signer)
+		}
+	}
+	h, err := header.Unmarshal[H](response.Body)
+	if err != nil {
+		return zero, err
+	}
+	err = validateChainID(ex.Params.chainID, h.ChainID())
+	if err != nil {
+		return zero, err
+	}
+	if isHeadRequest(req) {
+		if err = ex.attestations.handle(ctx, from, h, response.Attestation); err != nil {
+			return zero, err
+		}
+	}
+	if call.SkipProofs {
+		return h, nil
+	}
+	if err = ex.proofs.verify(ctx, h, response.Proof); err != nil {
+		return zero, err
+	}
+	return h, nil
+}
+
+// startRequestSpan starts a span of a single request attempt to the given peer.
+func startRequestSpan(ctx context.Context, to peer.ID, req *p2p_pb.HeaderRequest) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("peer", to.String()),
+		attribute.Int64("amount", int64(req.Amount)),
+	}
+	if _, ok := req.Data.(*p2p_pb.HeaderRequest_Origin); ok {
+		attrs = append(attrs, attribute.Int64("from", int64(req.GetOrigin())))
+	}
+	return clientTracer.Start(ctx, "request-peer", trace.WithAttributes(attrs...))
+}
+
+// requestTimeout returns the timeout for the given request to a single peer.
+func (ex *Exchange[H]) requestTimeout(req *p2p_pb.HeaderRequest) time.Duration {
+	if ex.Params.HeadRequestTimeout > 0 && isHeadRequest(req) {
+		return ex.Params.HeadRequestTimeout
+	}
+	return ex.Params.RequestTimeout
+}
+
 // shufflePeers changes the order of trusted peers.
-func shufflePeers(peers peer.IDSlice) peer.IDSlice {
+func shufflePeers(peers peer.IDSlice, rand *lockedRand) peer.IDSlice {
 	tpeers := make(peer.IDSlice, len(peers))
 	copy(tpeers, peers)
-	//nolint:gosec // G404: Use of weak random number generator
-	rand.New(rand.NewSource(time.Now().UnixNano())).Shuffle(
+	rand.Shuffle(
 		len(tpeers),
 		func(i, j int) { tpeers[i], tpeers[j] = tpeers[j], tpeers[i] },
 	)
@@ -357,3 +1289,41 @@ func bestHead[H header.Header](result []H) (H, error) {
 	// otherwise return header with the max height
 	return result[0], nil
 }
+
+// ErrHeadDisagreement is returned by Exchange.Head when trusted peers
+// do not agree on any head header with the configured quorum.
+type ErrHeadDisagreement struct {
+	// Quorum is the required amount of agreeing trusted peers.
+	Quorum int
+	// Votes maps the height of each received head to the amount of peers that responded with it.
+	Votes map[int64]int
+}
+
+func (e *ErrHeadDisagreement) Error() string {
+	return fmt.Sprintf("header/p2p: trusted peers disagree on the head: quorum %d, votes %v", e.Quorum, e.Votes)
+}
+
+// quorumHead returns the Header with the maximum height that was received
+// from at least the given amount of peers.
+func quorumHead[H header.Header](result []H, quorum int) (H, error) {
+	var zero H
+	if len(result) == 0 {
+		return zero, header.ErrNotFound
+	}
+	counter := make(map[string]int)
+	for _, res := range result {
+		counter[res.Hash().String()]++
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Height() > result[j].Height()
+	})
+
+	votes := make(map[int64]int)
+	for _, res := range result {
+		if counter[res.Hash().String()] >= quorum {
+			return res, nil
+		}
+		votes[res.Height()]++
+	}
+	return zero, &ErrHeadDisagreement{Quorum: quorum, Votes: votes}
+}
@@ -0,0 +1,96 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/celestiaorg/go-header"
+	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
+)
+
+// Session requests Headers from the tracked peers of the Exchange, rotating between them
+// by their score and retrying failed requests on other peers. Unlike Exchange methods,
+// Session allows custom fetch patterns, such as fetching sparse heights,
+// and can be reused for multiple requests until it is closed.
+// The peers are rotated across all the calls of the Session, so they share its queue of peers.
+type Session[H header.Header] struct {
+	ex *Exchange[H]
+	// session is the internal session the calls of the Session are derived from.
+	session *session[H]
+}
+
+// NewSession creates a new Session over the tracked peers of the Exchange.
+// The Exchange must be started and the Session must be closed once it is no longer needed.
+func (ex *Exchange[H]) NewSession() *Session[H] {
+	return &Session[H]{
+		ex:      ex,
+		session: ex.newSession(ex.ctx),
+	}
+}
+
+// GetRangeByHeight requests the range of Headers of the given amount starting from the given height.
+// Note that the Headers must be verified thereafter.
+func (s *Session[H]) GetRangeByHeight(ctx context.Context, from, amount uint64, opts ...header.CallOption) ([]H, error) {
+	if amount == 0 {
+		return make([]H, 0), nil
+	}
+	call := newCallParams(opts)
+	ctx, cancel := call.withTimeout(ctx)
+	defer cancel()
+	session := s.session.derive(sessionOptions[H](call)...)
+	defer session.close()
+	return session.getRangeByHeight(ctx, from, amount, s.ex.Params.MaxHeadersPerRangeRequest)
+}
+
+// GetVerifiedRange requests the range of Headers of the given amount following the given one
+// and ensures they are correct against it.
+func (s *Session[H]) GetVerifiedRange(ctx context.Context, from H, amount uint64, opts ...header.CallOption) ([]H, error) {
+	if amount == 0 {
+		return make([]H, 0), nil
+	}
+	call := newCallParams(opts)
+	ctx, cancel := call.withTimeout(ctx)
+	defer cancel()
+	session := s.session.derive(append(sessionOptions[H](call), withValidation(from))...)
+	defer session.close()
+	return session.getRangeByHeight(ctx, uint64(from.Height())+1, amount, s.ex.Params.MaxHeadersPerRangeRequest)
+}
+
+// GetByHeights requests Headers at the given, not necessarily adjacent, heights in parallel
+// and returns them in ascending order of height. Duplicate heights are requested once.
+// Note that the Headers must be verified thereafter.
+func (s *Session[H]) GetByHeights(ctx context.Context, heights ...uint64) ([]H, error) {
+	heights = append(make([]uint64, 0, len(heights)), heights...)
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+
+	requests := make([]*p2p_pb.HeaderRequest, 0, len(heights))
+	for i, height := range heights {
+		if height == 0 {
+			return nil, fmt.Errorf("specified request height must be greater than 0")
+		}
+		if i > 0 && heights[i-1] == height {
+			continue
+		}
+		requests = append(requests, &p2p_pb.HeaderRequest{
+			Data:   &p2p_pb.HeaderRequest_Origin{Origin: height},
+			Amount: 1,
+		})
+	}
+	if len(requests) == 0 {
+		return make([]H, 0), nil
+	}
+
+	session := s.session.derive()
+	defer session.close()
+	headers, err := session.fetch(ctx, requests, uint64(len(requests)))
+	if err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// Close cancels all the ongoing requests of the Session.
+func (s *Session[H]) Close() {
+	s.session.close()
+}
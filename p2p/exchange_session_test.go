@@ -0,0 +1,62 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSession_GetByHeights(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+
+	session := exchg.NewSession()
+	t.Cleanup(session.Close)
+
+	headers, err := session.GetByHeights(context.Background(), 5, 2, 2, 4)
+	require.NoError(t, err)
+	require.Len(t, headers, 3)
+	for i, height := range []int64{2, 4, 5} {
+		assert.Equal(t, store.Headers[height].Hash(), headers[i].Hash())
+	}
+
+	// the session can be reused
+	headers, err = session.GetVerifiedRange(context.Background(), store.Headers[1], 3)
+	require.NoError(t, err)
+	require.Len(t, headers, 3)
+	assert.Equal(t, store.Headers[4].Hash(), headers[2].Hash())
+}
+
+func TestSession_Close(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	exchg, _ := createP2PExAndServer(t, hosts[0], hosts[1])
+
+	session := exchg.NewSession()
+	session.Close()
+
+	_, err := session.GetRangeByHeight(context.Background(), 1, 3)
+	require.Error(t, err)
+}
+
+func TestSession_SharedPeers(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+
+	session := exchg.NewSession()
+	t.Cleanup(session.Close)
+
+	// the only peer fails the call, which times out as no other peer is left to retry with
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+	defer cancel()
+	_, err := session.GetByHeights(ctx, 100)
+	require.Error(t, err)
+
+	// while the peer is still available to the following calls
+	headers, err := session.GetByHeights(context.Background(), 2)
+	require.NoError(t, err)
+	require.Len(t, headers, 1)
+	assert.Equal(t, store.Headers[2].Hash(), headers[0].Hash())
+}
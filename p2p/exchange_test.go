@@ -1,7 +1,12 @@
 package p2p
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"math"
+	stdsync "sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -17,6 +22,8 @@ import (
 	swarm "github.com/libp2p/go-libp2p/p2p/net/swarm/testing"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/instrument"
 
 	"github.com/celestiaorg/go-header"
 	"github.com/celestiaorg/go-header/headertest"
@@ -62,6 +69,206 @@ func TestExchange_RequestHead_UnresponsivePeer(t *testing.T) {
 	assert.NotNil(t, head)
 }
 
+func TestExchange_RequestTimeouts(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	exchg, err := NewExchange[*headertest.DummyHeader](hosts[0], []peer.ID{hosts[1].ID()}, nil,
+		WithRequestTimeout(time.Second*5),
+		WithHeadRequestTimeout(time.Millisecond*500),
+	)
+	require.NoError(t, err)
+
+	head := &p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 0}, Amount: 1}
+	single := &p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 10}, Amount: 1}
+	assert.Equal(t, time.Millisecond*500, exchg.requestTimeout(head))
+	assert.Equal(t, time.Second*5, exchg.requestTimeout(single))
+
+	// head requests fall back to the general timeout
+	exchg.Params.HeadRequestTimeout = 0
+	assert.Equal(t, time.Second*5, exchg.requestTimeout(head))
+}
+
+func TestExchange_AddRemoveTrustedPeer(t *testing.T) {
+	hosts := createMocknet(t, 3)
+	exchg, _ := createP2PExAndServer(t, hosts[0], hosts[1])
+
+	exchg.AddTrustedPeer(peer.AddrInfo{ID: hosts[2].ID()})
+	exchg.AddTrustedPeer(peer.AddrInfo{ID: hosts[2].ID()})
+	assert.ElementsMatch(t, peer.IDSlice{hosts[1].ID(), hosts[2].ID()}, exchg.trustedPeers())
+
+	require.NoError(t, exchg.RemoveTrustedPeer(hosts[1].ID()))
+	assert.Equal(t, peer.IDSlice{hosts[2].ID()}, exchg.trustedPeers())
+	// the last trusted peer can't be removed
+	require.Error(t, exchg.RemoveTrustedPeer(hosts[2].ID()))
+
+	// trusted peers can be added while the Exchange starts
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	exchg, err = NewExchange[*headertest.DummyHeader](hosts[0], []peer.ID{hosts[1].ID()}, connGater,
+		WithNetworkID[ClientParameters](networkID),
+		WithChainID(networkID),
+	)
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		exchg.AddTrustedPeer(peer.AddrInfo{ID: hosts[2].ID()})
+	}()
+	require.NoError(t, exchg.Start(context.Background()))
+	<-done
+	require.NoError(t, exchg.Stop(context.Background()))
+}
+
+func TestExchange_HeadQuorumVerified(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	hosts := createMocknet(t, 3)
+	suite := headertest.NewTestSuite(t)
+	store := headertest.NewStore[*headertest.DummyHeader](t, suite, 5)
+	for _, host := range hosts[1:] {
+		server(ctx, t, host, store)
+	}
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	exchg, err := NewExchange[*headertest.DummyHeader](hosts[0], []peer.ID{hosts[1].ID(), hosts[2].ID()}, connGater,
+		WithHeadQuorum(2),
+	)
+	require.NoError(t, err)
+	require.NoError(t, exchg.Start(ctx))
+	t.Cleanup(func() {
+		exchg.Stop(ctx) //nolint:errcheck
+	})
+
+	trusted, err := exchg.Head(ctx)
+	require.NoError(t, err)
+
+	// heads failing verification against the trusted head can not win the quorum
+	require.NoError(t, store.Append(ctx, suite.GenDummyHeaders(1)...))
+	store.Headers[store.HeadHeight].Raw.Time = time.Now().Add(time.Hour)
+	_, err = exchg.Head(ctx)
+	require.Error(t, err)
+
+	// nor the ones differing from the trusted head at its height
+	forked := headertest.NewTestSuite(t).GenDummyHeaders(int(trusted.Height()))
+	require.Equal(t, trusted.Height(), forked[len(forked)-1].Height())
+	require.Error(t, exchg.verifyCandidate(forked[len(forked)-1]))
+	require.NoError(t, exchg.verifyCandidate(trusted))
+}
+
+func TestExchange_HeadFallback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	hosts := createMocknet(t, 4)
+	suite := headertest.NewTestSuite(t)
+	store := headertest.NewStore[*headertest.DummyHeader](t, suite, 5)
+	// the trusted peer serves headers for a while only, while tracked peers keep serving
+	trustedServ, err := NewExchangeServer[*headertest.DummyHeader](hosts[1], store)
+	require.NoError(t, err)
+	for _, host := range hosts[2:] {
+		server(ctx, t, host, store)
+	}
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	exchg, err := NewExchange[*headertest.DummyHeader](hosts[0], []peer.ID{hosts[1].ID()}, connGater,
+		WithHeadFallback(2),
+	)
+	require.NoError(t, err)
+	require.NoError(t, exchg.Start(ctx))
+	t.Cleanup(func() {
+		exchg.Stop(ctx) //nolint:errcheck
+	})
+	exchg.peerTracker.peerLk.Lock()
+	for _, host := range hosts[2:] {
+		exchg.peerTracker.trackedPeers[host.ID()] = &peerStat{peerID: host.ID(), peerScore: defaultScore}
+	}
+	exchg.peerTracker.peerLk.Unlock()
+
+	// on a cold start, the head agreed on by the tracked peers is returned unverified
+	head, err := exchg.Head(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, store.Headers[store.HeadHeight].Hash(), head.Hash())
+
+	require.NoError(t, trustedServ.Start(ctx))
+	trusted, err := exchg.Head(ctx)
+	require.NoError(t, err)
+	require.NoError(t, trustedServ.Stop(ctx))
+
+	// fallback heads not above the trusted head are replaced with it
+	head, err = exchg.verifyFallbackHead(store.Headers[2])
+	require.NoError(t, err)
+	assert.Equal(t, trusted.Hash(), head.Hash())
+
+	require.NoError(t, store.Append(ctx, suite.GenDummyHeaders(3)...))
+	head, err = exchg.Head(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, store.Headers[store.HeadHeight].Hash(), head.Hash())
+
+	// heads failing verification against the trusted head are rejected
+	require.NoError(t, store.Append(ctx, suite.GenDummyHeaders(1)...))
+	store.Headers[store.HeadHeight].Raw.Time = time.Now().Add(time.Hour)
+	_, err = exchg.Head(ctx)
+	require.Error(t, err)
+
+	// without the fallback, Head fails
+	exchg.Params.headFallback = 0
+	_, err = exchg.Head(ctx)
+	require.Error(t, err)
+}
+
+func TestExchange_RequestFromPeer(t *testing.T) {
+	hosts := createMocknet(t, 3)
+	exchg, _ := createP2PExAndServer(t, hosts[0], hosts[1])
+	// the peer is neither trusted nor tracked
+	store2 := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10)
+	serv, err := NewExchangeServer[*headertest.DummyHeader](hosts[2], store2, WithNetworkID[ServerParameters](networkID))
+	require.NoError(t, err)
+	require.NoError(t, serv.Start(context.Background()))
+	t.Cleanup(func() {
+		serv.Stop(context.Background()) //nolint:errcheck
+	})
+
+	headers, err := exchg.GetRangeFromPeer(context.Background(), 2, 8, hosts[2].ID())
+	require.NoError(t, err)
+	require.Len(t, headers, 6)
+	for _, h := range headers {
+		assert.Equal(t, store2.Headers[h.Height()].Hash(), h.Hash())
+	}
+
+	h, err := exchg.GetFromPeer(context.Background(), store2.Headers[3].Hash(), hosts[2].ID())
+	require.NoError(t, err)
+	assert.Equal(t, store2.Headers[3].Hash(), h.Hash())
+
+	// the trusted peer does not have the header of another chain
+	_, err = exchg.GetFromPeer(context.Background(), store2.Headers[3].Hash(), hosts[1].ID())
+	require.ErrorIs(t, err, header.ErrNotFound)
+	// the trusted peer has only a part of the range
+	_, err = exchg.GetRangeFromPeer(context.Background(), 2, 8, hosts[1].ID())
+	require.Error(t, err)
+	_, err = exchg.GetRangeFromPeer(context.Background(), 2, 4, hosts[1].ID())
+	require.NoError(t, err)
+}
+
+func TestExchange_DeduplicatesConcurrentRequests(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+	slowStore := &slowStore{Store: store, delay: time.Millisecond * 100}
+	replaceServer(t, hosts[1], slowStore)
+
+	var wg stdsync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h, err := exchg.GetByHeight(context.Background(), 3)
+			require.NoError(t, err)
+			assert.Equal(t, store.Headers[3].Hash(), h.Hash())
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, slowStore.calls.Load())
+}
+
 func TestExchange_RequestHeader(t *testing.T) {
 	hosts := createMocknet(t, 2)
 	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
@@ -72,6 +279,73 @@ func TestExchange_RequestHeader(t *testing.T) {
 	assert.Equal(t, store.Headers[5].Hash(), header.Hash())
 }
 
+func TestExchange_RequestHeaderWithMetrics(t *testing.T) {
+	hosts := createMocknet(t, 3)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+	require.NoError(t, exchg.InitMetrics())
+	require.NotNil(t, exchg.metrics)
+	size, bytes, retries, failures := newRecorder(), newRecorder(), newRecorder(), newRecorder()
+	exchg.metrics.responseSize = size
+	exchg.metrics.bytesReceived = bytes
+	exchg.metrics.retries = retries
+	exchg.metrics.failures = failures
+
+	h, err := exchg.GetByHeight(context.Background(), 5)
+	require.NoError(t, err)
+	assert.Equal(t, store.Headers[5].Hash(), h.Hash())
+	headers, err := exchg.GetRangeByHeight(context.Background(), 1, 5)
+	require.NoError(t, err)
+	assert.Len(t, headers, 5)
+
+	// every response is recorded along with its size
+	assert.Equal(t, 2, size.calls)
+	assert.Positive(t, size.value(hosts[1].ID()))
+	assert.Equal(t, size.value(hosts[1].ID()), bytes.value(hosts[1].ID()))
+	assert.Zero(t, retries.calls)
+	assert.Zero(t, failures.calls)
+
+	// the request is not retried without another peer to retry it with
+	_, err = exchg.GetByHeight(context.Background(), 100)
+	require.ErrorIs(t, err, header.ErrNotFound)
+	assert.Zero(t, retries.calls)
+	assert.Zero(t, failures.calls)
+
+	hosts[1].SetStreamHandler(protocolID(networkID), func(stream network.Stream) {
+		stream.Reset() //nolint:errcheck
+	})
+	_, err = exchg.Head(context.Background())
+	require.Error(t, err)
+	assert.EqualValues(t, 1, failures.value(hosts[1].ID()))
+
+	// the request failing on a trusted peer is retried with another one
+	replaceServer(t, hosts[2], store)
+	exchg.AddTrustedPeer(peer.AddrInfo{ID: hosts[2].ID()})
+	require.Eventually(t, func() bool {
+		h, err := exchg.GetByHeight(context.Background(), 3)
+		require.NoError(t, err)
+		assert.Equal(t, store.Headers[3].Hash(), h.Hash())
+		return retries.value(hosts[1].ID()) > 0
+	}, time.Second*5, time.Millisecond)
+	assert.Zero(t, retries.value(hosts[2].ID()))
+}
+
+func TestExchange_ProtocolFallback(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+	// the server only speaks the previous version of the protocol
+	ids := protocolIDs(networkID)
+	hosts[1].RemoveStreamHandler(ids[0])
+
+	header, err := exchg.GetByHeight(context.Background(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, store.Headers[3].Hash(), header.Hash())
+
+	stream, err := hosts[0].NewStream(context.Background(), hosts[1].ID(), ids...)
+	require.NoError(t, err)
+	assert.Equal(t, ids[1], stream.Protocol())
+	stream.Reset() //nolint:errcheck
+}
+
 func TestExchange_RequestHeaders(t *testing.T) {
 	hosts := createMocknet(t, 2)
 	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
@@ -149,14 +423,97 @@ func TestExchange_RequestFullRangeHeaders(t *testing.T) {
 	require.Len(t, headers, int(header.MaxRangeRequestSize))
 }
 
-// TestExchange_RequestHeadersLimitExceeded tests that the Exchange instance will return
-// header.ErrHeadersLimitExceeded if the requested range will be move than MaxRangeRequestSize.
+// TestExchange_GetRangeStream tests that the Exchange instance streams the range above
+// MaxRangeRequestSize in order and verifies it against the trusted header.
+func TestExchange_GetRangeStream(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	amount := int(header.MaxRangeRequestSize) + 100
+	store := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), amount)
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	exchange, err := NewExchange[*headertest.DummyHeader](hosts[0], []peer.ID{hosts[1].ID()}, connGater,
+		WithNetworkID[ClientParameters](networkID),
+		WithChainID(networkID),
+	)
+	require.NoError(t, err)
+	exchange.ctx, exchange.cancel = context.WithCancel(context.Background())
+	t.Cleanup(exchange.cancel)
+
+	server, err := NewExchangeServer[*headertest.DummyHeader](hosts[1], store, WithNetworkID[ServerParameters](networkID))
+	require.NoError(t, err)
+	require.NoError(t, server.Start(context.Background()))
+	t.Cleanup(func() {
+		server.Stop(context.Background()) //nolint:errcheck
+	})
+	exchange.peerTracker.trackedPeers[hosts[1].ID()] = &peerStat{peerID: hosts[1].ID()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+	headers, errCh := exchange.GetRangeStream(ctx, store.Headers[1], uint64(amount)+1)
+	height := int64(2)
+	for h := range headers {
+		require.Equal(t, height, h.Height())
+		require.Equal(t, store.Headers[height].Hash(), h.Hash())
+		height++
+	}
+	require.NoError(t, <-errCh)
+	require.EqualValues(t, amount+1, height)
+
+	// the first chunk is verified against the trusted header as well
+	untrusted := headertest.NewTestSuite(t).GenDummyHeaders(1)[0]
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+	headers, errCh = exchange.GetRangeStream(ctx, untrusted, uint64(amount)+1)
+	for h := range headers {
+		t.Fatalf("unexpected header %d", h.Height())
+	}
+	require.Error(t, <-errCh)
+}
+
+// TestExchange_RequestHeadersBeyondLimit tests that the Exchange instance splits the range
+// above MaxRangeRequestSize among the peers.
 func TestExchange_RequestHeadersLimitExceeded(t *testing.T) {
 	hosts := createMocknet(t, 2)
 	exchg, _ := createP2PExAndServer(t, hosts[0], hosts[1])
-	_, err := exchg.GetRangeByHeight(context.Background(), 1, 600)
+	_, err := exchg.GetRangeByHeight(context.Background(), 1, maxRangeAmount+1)
+	require.ErrorIs(t, err, header.ErrHeadersLimitExceeded)
+	// ranges overflowing the heights are rejected as well
+	_, err = exchg.GetRangeByHeight(context.Background(), math.MaxUint64-10, 20)
 	require.Error(t, err)
-	require.ErrorAs(t, err, &header.ErrHeadersLimitExceeded)
+}
+
+func TestExchange_RequestHeadersBeyondLimit(t *testing.T) {
+	hosts := createMocknet(t, 4)
+	amount := header.MaxRangeRequestSize*2 + 10
+	store := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), int(amount))
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	exchange, err := NewExchange[*headertest.DummyHeader](hosts[0], []peer.ID{hosts[1].ID()}, connGater,
+		WithNetworkID[ClientParameters](networkID),
+		WithChainID(networkID),
+	)
+	require.NoError(t, err)
+	exchange.ctx, exchange.cancel = context.WithCancel(context.Background())
+	t.Cleanup(exchange.cancel)
+	for _, host := range hosts[1:] {
+		server, err := NewExchangeServer[*headertest.DummyHeader](host, store, WithNetworkID[ServerParameters](networkID))
+		require.NoError(t, err)
+		require.NoError(t, server.Start(context.Background()))
+		t.Cleanup(func() {
+			server.Stop(context.Background()) //nolint:errcheck
+		})
+		exchange.peerTracker.trackedPeers[host.ID()] = &peerStat{peerID: host.ID()}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+	// the range beyond the limit is split among the peers and reassembled in order
+	headers, err := exchange.GetRangeByHeight(ctx, 1, amount)
+	require.NoError(t, err)
+	require.Len(t, headers, int(amount))
+	for i, h := range headers {
+		require.EqualValues(t, i+1, h.Height())
+	}
 }
 
 // TestExchange_RequestHeadersFromAnotherPeer tests that the Exchange instance will request range
@@ -164,10 +521,12 @@ func TestExchange_RequestHeadersLimitExceeded(t *testing.T) {
 func TestExchange_RequestHeadersFromAnotherPeer(t *testing.T) {
 	hosts := createMocknet(t, 3)
 	// create client + server(it does not have needed headers)
-	exchg, _ := createP2PExAndServer(t, hosts[0], hosts[1])
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
 	// create one more server(with more headers in the store)
+	other := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10)
+	shareChain(store, other)
 	serverSideEx, err := NewExchangeServer[*headertest.DummyHeader](
-		hosts[2], headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10),
+		hosts[2], other,
 		WithNetworkID[ServerParameters](networkID),
 	)
 	require.NoError(t, err)
@@ -299,6 +658,368 @@ func Test_bestHead(t *testing.T) {
 	}
 }
 
+// Test_quorumHead ensures that the highest head agreed on by the quorum of trusted peers is chosen.
+func Test_quorumHead(t *testing.T) {
+	suite := headertest.NewTestSuite(t)
+	res := make([]*headertest.DummyHeader, 0)
+	for i := 0; i < 3; i++ {
+		res = append(res, suite.NextHeader())
+	}
+	// heights 1 and 2 are agreed on by 3 and 2 peers respectively
+	res = append(res, res[0], res[0], res[1])
+
+	head, err := quorumHead(res, 2)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, head.Height())
+
+	head, err = quorumHead(res, 3)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, head.Height())
+
+	_, err = quorumHead(res, 4)
+	var disagreement *ErrHeadDisagreement
+	require.ErrorAs(t, err, &disagreement)
+	require.Equal(t, map[int64]int{1: 3, 2: 2, 3: 1}, disagreement.Votes)
+}
+
+// Test_shufflePeersSeeded ensures that trusted peers are shuffled reproducibly
+// when the Exchange is configured with a seed.
+func Test_shufflePeersSeeded(t *testing.T) {
+	peers := peer.IDSlice{"peer1", "peer2", "peer3", "peer4", "peer5", "peer6"}
+	const seed = 42
+
+	first, second := newRand(seed), newRand(seed)
+	for i := 0; i < 5; i++ {
+		require.Equal(t, shufflePeers(peers, first), shufflePeers(peers, second))
+	}
+}
+
+// TestExchange_RequestByHashes tests that the Exchange instance can
+// request many headers by their hashes at once, keeping their order.
+func TestExchange_RequestByHashes(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+
+	hashes := []header.Hash{store.Headers[4].Hash(), store.Headers[2].Hash(), store.Headers[5].Hash()}
+	headers, err := exchg.GetByHashes(context.Background(), hashes)
+	require.NoError(t, err)
+	require.Len(t, headers, len(hashes))
+	for i, h := range headers {
+		assert.Equal(t, hashes[i], h.Hash())
+	}
+
+	// a single unknown hash fails the whole batch
+	_, err = exchg.GetByHashes(context.Background(), []header.Hash{hashes[0], headertest.RandBytes(32)})
+	require.Error(t, err)
+}
+
+func TestExchange_CachesMissingHeights(t *testing.T) {
+	hosts := createMocknet(t, 3)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+	replaceServer(t, hosts[2], store)
+	exchg.notFound = newNotFoundCache(time.Minute)
+	transport := &countingTransport{Transport: exchg.transport}
+	exchg.transport = transport
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	// heights are not cached as missing until the network head is known
+	_, err := exchg.GetByHeight(ctx, 100)
+	require.ErrorIs(t, err, header.ErrNotFound)
+	requested := transport.opened.Load()
+	require.NotZero(t, requested)
+	_, err = exchg.GetByHeight(ctx, 100)
+	require.ErrorIs(t, err, header.ErrNotFound)
+	require.Greater(t, transport.opened.Load(), requested)
+
+	// nor by a single trusted peer
+	exchg.peerTracker.updateNetworkHead(5)
+	_, err = exchg.GetByHeight(ctx, 100)
+	require.ErrorIs(t, err, header.ErrNotFound)
+	requested = transport.opened.Load()
+	_, err = exchg.GetByHeight(ctx, 100)
+	require.ErrorIs(t, err, header.ErrNotFound)
+	require.Greater(t, transport.opened.Load(), requested)
+
+	// the premature request reported by multiple peers is answered without hitting the network
+	exchg.AddTrustedPeer(peer.AddrInfo{ID: hosts[2].ID()})
+	_, err = exchg.GetByHeight(ctx, 100)
+	require.ErrorIs(t, err, header.ErrNotFound)
+	requested = transport.opened.Load()
+	_, err = exchg.GetByHeight(ctx, 100)
+	require.ErrorIs(t, err, header.ErrNotFound)
+	assert.Equal(t, requested, transport.opened.Load())
+
+	// heights within the store are requested as usual
+	h, err := exchg.GetByHeight(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, store.Headers[3].Hash(), h.Hash())
+}
+
+func TestExchange_RetriesNotFoundWithTrackedPeers(t *testing.T) {
+	hosts := createMocknet(t, 3)
+	// the trusted peer has 5 headers, while the tracked one has 10
+	exchg, _ := createP2PExAndServer(t, hosts[0], hosts[1])
+	store := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10)
+	serv, err := NewExchangeServer[*headertest.DummyHeader](hosts[2], store, WithNetworkID[ServerParameters](networkID))
+	require.NoError(t, err)
+	require.NoError(t, serv.Start(context.Background()))
+	t.Cleanup(func() {
+		serv.Stop(context.Background()) //nolint:errcheck
+	})
+	exchg.peerTracker.peerLk.Lock()
+	exchg.peerTracker.trackedPeers[hosts[2].ID()] = &peerStat{peerID: hosts[2].ID(), peerScore: 50}
+	exchg.peerTracker.peerLk.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	// heights above the network head are not looked for on other peers
+	_, err = exchg.GetByHeight(ctx, 8)
+	require.ErrorIs(t, err, header.ErrNotFound)
+
+	exchg.peerTracker.updateNetworkHead(10)
+	h, err := exchg.GetByHeight(ctx, 8)
+	require.NoError(t, err)
+	assert.Equal(t, store.Headers[8].Hash(), h.Hash())
+	// the trusted peer missing the history gets its score lowered
+	assert.Less(t, exchg.peerTracker.trackedPeers[hosts[1].ID()].score(), float32(100))
+}
+
+func TestExchange_ReportsBlockedPeers(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+	blocked := make(chan error, 1)
+	exchg.peerTracker.onBlocked = func(_ peer.ID, reason error) {
+		blocked <- reason
+	}
+
+	// the peer serves headers which are not linked to each other
+	other := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 5)
+	hosts[1].SetStreamHandler(protocolID(networkID), func(stream network.Stream) {
+		req := new(p2p_pb.HeaderRequest)
+		if _, err := serde.Read(stream, req); err != nil {
+			stream.Reset() //nolint:errcheck
+			return
+		}
+		for i := uint64(0); i < req.Amount; i++ {
+			headers := store.Headers
+			if i%2 == 1 {
+				headers = other.Headers
+			}
+			bin, _ := headers[int64(req.GetOrigin()+i)].MarshalBinary()
+			serde.Write(stream, &p2p_pb.HeaderResponse{Body: bin, StatusCode: p2p_pb.StatusCode_OK}) //nolint:errcheck
+		}
+		stream.Close() //nolint:errcheck
+	})
+
+	// the only peer gets blocked, so the range is never received
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+	t.Cleanup(cancel)
+	_, err := exchg.GetRangeByHeight(ctx, 1, 4)
+	require.Error(t, err)
+
+	select {
+	case reason := <-blocked:
+		var invalid *InvalidResponseError
+		require.ErrorAs(t, reason, &invalid)
+		assert.EqualValues(t, 1, invalid.Request.GetOrigin())
+		assert.ErrorIs(t, invalid, errBrokenChain)
+	case <-time.After(time.Second):
+		t.Fatal("peer was not blocked")
+	}
+}
+
+func TestExchange_RequestWithProofs(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+	// the proof of a header is its hash
+	provider := func(_ context.Context, h *headertest.DummyHeader) ([]byte, error) {
+		return h.Hash(), nil
+	}
+	replaceServer(t, hosts[1], store,
+		WithProofProvider[ServerParameters](ProofProvider[*headertest.DummyHeader](provider)),
+	)
+
+	var verified atomic.Int32
+	exchg.proofs = func(_ context.Context, h *headertest.DummyHeader, proof []byte) error {
+		verified.Add(1)
+		if !bytes.Equal(h.Hash(), proof) {
+			return errors.New("invalid proof")
+		}
+		return nil
+	}
+	h, err := exchg.GetByHeight(context.Background(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, store.Headers[3].Hash(), h.Hash())
+	headers, err := exchg.GetRangeByHeight(context.Background(), 1, 4)
+	require.NoError(t, err)
+	assert.Len(t, headers, 4)
+	assert.EqualValues(t, 5, verified.Load())
+
+	exchg.proofs = func(context.Context, *headertest.DummyHeader, []byte) error {
+		return errors.New("invalid proof")
+	}
+	_, err = exchg.GetByHeight(context.Background(), 4)
+	require.Error(t, err)
+
+	// the verifier must match the header type of the Exchange
+	_, err = NewExchange[*headertest.DummyHeader](hosts[0], []peer.ID{hosts[1].ID()}, nil,
+		WithProofVerifier[ClientParameters](ProofVerifier[header.Header](
+			func(context.Context, header.Header, []byte) error { return nil },
+		)),
+	)
+	require.Error(t, err)
+}
+
+func TestExchange_RequestHeadersDescending(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+
+	headers, err := exchg.GetRangeDescending(context.Background(), 5, 3)
+	require.NoError(t, err)
+	require.Len(t, headers, 3)
+	for i, h := range headers {
+		assert.Equal(t, store.Headers[int64(5-i)].Hash(), h.Hash())
+	}
+
+	// the range is capped at the genesis
+	headers, err = exchg.GetRangeDescending(context.Background(), 3, 10)
+	require.NoError(t, err)
+	require.Len(t, headers, 3)
+	assert.EqualValues(t, 1, headers[2].Height())
+
+	// the top header must exist
+	_, err = exchg.GetRangeDescending(context.Background(), 10, 3)
+	require.Error(t, err)
+}
+
+func TestExchange_ResponseLimits(t *testing.T) {
+	hosts := createMocknet(t, 3)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+
+	// responses above the max message size lower the score of the peer
+	exchg.Params.MaxMessageSize = 10
+	_, err := exchg.GetByHeight(context.Background(), 3)
+	require.ErrorIs(t, err, ErrResponseLimitExceeded)
+	assert.Less(t, exchg.peerTracker.trackedPeers[hosts[1].ID()].score(), float32(100))
+
+	// the peer responds with more headers than requested
+	ids := protocolIDs(networkID)
+	hosts[2].SetStreamHandler(ids[0], func(stream network.Stream) {
+		req := new(p2p_pb.HeaderRequest)
+		if _, err := serde.Read(stream, req); err != nil {
+			stream.Reset() //nolint:errcheck
+			return
+		}
+		for i := uint64(0); i <= req.Amount; i++ {
+			bin, err := store.Headers[int64(req.GetOrigin()+i)].MarshalBinary()
+			if err != nil {
+				stream.Reset() //nolint:errcheck
+				return
+			}
+			resp := &p2p_pb.HeaderResponse{Body: bin, StatusCode: p2p_pb.StatusCode_OK}
+			if _, err = serde.Write(stream, resp); err != nil {
+				stream.Reset() //nolint:errcheck
+				return
+			}
+		}
+		stream.Close() //nolint:errcheck
+	})
+	req := &p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 1}, Amount: 2}
+	_, _, _, err = sendMessage(context.Background(), hostTransport{host: hosts[0]}, hosts[2].ID(), ids, req, 0)
+	require.ErrorIs(t, err, ErrResponseLimitExceeded)
+}
+
+func TestExchange_AdvertisesTail(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+	replaceServer(t, hosts[1], &tailStore{Store: store, tail: 3})
+
+	_, err := exchg.Head(context.Background())
+	require.NoError(t, err)
+	stat := exchg.peerTracker.trackedPeers[hosts[1].ID()]
+	assert.False(t, stat.retains(2))
+	assert.True(t, stat.retains(3))
+}
+
+func TestExchange_AdvertisesLimits(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+	replaceServer(t, hosts[1], store,
+		WithMaxHeadersPerResponse[ServerParameters](16),
+		WithHeadSubscriptions[ServerParameters](time.Second),
+	)
+
+	_, err := exchg.Head(context.Background())
+	require.NoError(t, err)
+	info := exchg.peerTracker.trackedPeers[hosts[1].ID()].info()
+	assert.EqualValues(t, 16, info.MaxRange)
+	assert.Contains(t, info.Features, p2p_pb.Feature_FEATURE_HEAD_SUBSCRIPTION)
+	assert.NotContains(t, info.Features, p2p_pb.Feature_FEATURE_PROOFS)
+}
+
+func TestExchange_ReroutesPrunedRequests(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	hosts := createMocknet(t, 3)
+	store := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10)
+	recent, err := NewExchangeServer[*headertest.DummyHeader](hosts[1], store, WithRecentHeaders[ServerParameters](3))
+	require.NoError(t, err)
+	require.NoError(t, recent.Start(ctx))
+	t.Cleanup(func() {
+		recent.Stop(ctx) //nolint:errcheck
+	})
+	server(ctx, t, hosts[2], store)
+
+	exchg := client(ctx, t, hosts[0], []peer.ID{hosts[1].ID(), hosts[2].ID()})
+	_, err = exchg.GetByHeight(ctx, 2, WithPeer(hosts[1].ID()))
+	var prunedErr *PrunedError
+	require.ErrorAs(t, err, &prunedErr)
+	assert.EqualValues(t, 8, prunedErr.Tail)
+	assert.False(t, exchg.peerTracker.retains(hosts[1].ID(), 7))
+
+	// the peer which pruned the header is not requested anymore
+	h, err := exchg.GetByHeight(ctx, 2)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, h.Height())
+}
+
+func TestExchange_RetriesInvalidResponses(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	hosts := createMocknet(t, 3)
+	store := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 5)
+	server(ctx, t, hosts[2], store)
+	// the quickest trusted peer responds with headers of wrong heights
+	hosts[1].SetStreamHandler(protocolID(""), func(stream network.Stream) {
+		req := new(p2p_pb.HeaderRequest)
+		if _, err := serde.Read(stream, req); err != nil {
+			stream.Reset() //nolint:errcheck
+			return
+		}
+		bin, _ := store.Headers[int64(req.GetOrigin())+1].MarshalBinary()
+		resp := &p2p_pb.HeaderResponse{Body: bin, StatusCode: p2p_pb.StatusCode_OK}
+		serde.Write(stream, resp) //nolint:errcheck
+		stream.Close()            //nolint:errcheck
+	})
+
+	exchg := client(ctx, t, hosts[0], []peer.ID{hosts[1].ID(), hosts[2].ID()})
+	bad := &peerStat{peerID: hosts[1].ID(), peerScore: 100, latency: time.Millisecond}
+	exchg.peerTracker.peerLk.Lock()
+	exchg.peerTracker.trackedPeers[hosts[1].ID()] = bad
+	exchg.peerTracker.trackedPeers[hosts[2].ID()] = &peerStat{peerID: hosts[2].ID(), latency: time.Second}
+	exchg.peerTracker.peerLk.Unlock()
+
+	h, err := exchg.GetByHeight(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, store.Headers[3].Hash(), h.Hash())
+	// the peer is penalized instead of failing the request
+	assert.Less(t, bad.score(), float32(100))
+	assert.Equal(t, 1, bad.failures)
+}
+
 // TestExchange_RequestByHashFails tests that the Exchange instance can
 // respond with a StatusCode_NOT_FOUND if it will not have requested header.
 func TestExchange_RequestByHashFails(t *testing.T) {
@@ -401,15 +1122,95 @@ func TestExchange_RequestHeadersFromAnotherPeerWhenTimeout(t *testing.T) {
 	assert.NotEqual(t, newPeerScore, prevScore)
 }
 
+// TestExchange_ResumesPartialRange ensures the verified prefix of a response broken midway is kept,
+// while only the remainder is requested from another peer.
+func TestExchange_ResumesPartialRange(t *testing.T) {
+	hosts := createMocknet(t, 3)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+	serv, err := NewExchangeServer[*headertest.DummyHeader](hosts[2], store, WithNetworkID[ServerParameters](networkID))
+	require.NoError(t, err)
+	require.NoError(t, serv.Start(context.Background()))
+	t.Cleanup(func() {
+		serv.Stop(context.Background()) //nolint:errcheck
+	})
+	exchg.peerTracker.peerLk.Lock()
+	exchg.peerTracker.trackedPeers[hosts[2].ID()] = &peerStat{peerID: hosts[2].ID(), peerScore: 50}
+	exchg.peerTracker.peerLk.Unlock()
+
+	// the best peer drops the stream after the first two headers
+	var requested []uint64
+	hosts[1].SetStreamHandler(protocolID(networkID), func(stream network.Stream) {
+		req := new(p2p_pb.HeaderRequest)
+		if _, err := serde.Read(stream, req); err != nil {
+			stream.Reset() //nolint:errcheck
+			return
+		}
+		requested = append(requested, req.GetOrigin())
+		for i := uint64(0); i < 2; i++ {
+			bin, _ := store.Headers[int64(req.GetOrigin()+i)].MarshalBinary()
+			serde.Write(stream, &p2p_pb.HeaderResponse{Body: bin, StatusCode: p2p_pb.StatusCode_OK}) //nolint:errcheck
+		}
+		stream.Reset() //nolint:errcheck
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	headers, err := exchg.GetRangeByHeight(ctx, 1, 5)
+	require.NoError(t, err)
+	require.Len(t, headers, 5)
+	for i, h := range headers {
+		assert.Equal(t, store.Headers[int64(i+1)].Hash(), h.Hash())
+	}
+	// only the remainder is requested from the other peer
+	assert.Equal(t, []uint64{1}, requested)
+}
+
+// TestExchange_ReturnsPrefixOnDeadline ensures the contiguous prefix of the range fetched
+// before the deadline is returned along with ErrPartialResponse.
+func TestExchange_ReturnsPrefixOnDeadline(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+	exchg.Params.MaxHeadersPerRangeRequest = 2
+
+	// the peer serves the first chunk only and hangs on the rest
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	hosts[1].SetStreamHandler(protocolID(networkID), func(stream network.Stream) {
+		req := new(p2p_pb.HeaderRequest)
+		if _, err := serde.Read(stream, req); err != nil || req.GetOrigin() != 1 {
+			<-release
+			stream.Reset() //nolint:errcheck
+			return
+		}
+		for i := uint64(0); i < req.Amount; i++ {
+			bin, _ := store.Headers[int64(req.GetOrigin()+i)].MarshalBinary()
+			serde.Write(stream, &p2p_pb.HeaderResponse{Body: bin, StatusCode: p2p_pb.StatusCode_OK}) //nolint:errcheck
+		}
+		stream.Close() //nolint:errcheck
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	t.Cleanup(cancel)
+	headers, err := exchg.GetRangeByHeight(ctx, 1, 5)
+	require.ErrorIs(t, err, ErrPartialResponse)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Len(t, headers, 2)
+	for i, h := range headers {
+		assert.Equal(t, store.Headers[int64(i+1)].Hash(), h.Hash())
+	}
+}
+
 // TestExchange_RequestPartialRange enusres in case of receiving a partial response
 // from server, Exchange will re-request remaining headers from another peer
 func TestExchange_RequestPartialRange(t *testing.T) {
 	hosts := createMocknet(t, 3)
-	exchg, _ := createP2PExAndServer(t, hosts[0], hosts[1])
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
 
 	// create one more server(with more headers in the store)
+	other := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10)
+	shareChain(store, other)
 	serverSideEx, err := NewExchangeServer[*headertest.DummyHeader](
-		hosts[2], headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10),
+		hosts[2], other,
 		WithNetworkID[ServerParameters](networkID),
 	)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -436,6 +1237,14 @@ func TestExchange_RequestPartialRange(t *testing.T) {
 	assert.NotEqual(t, prevScoreBefore2, prevScoreAfter2)
 }
 
+// shareChain replaces the headers of the dst store with the ones of the src store of the same height,
+// so the stores serve the same chain.
+func shareChain(dst, src *headertest.Store[*headertest.DummyHeader]) {
+	for height := range dst.Headers {
+		dst.Headers[height] = src.Headers[height]
+	}
+}
+
 func createMocknet(t *testing.T, amount int) []libhost.Host {
 	net, err := mocknet.FullMeshConnected(amount)
 	require.NoError(t, err)
@@ -474,6 +1283,24 @@ func createP2PExAndServer(
 	return ex, store
 }
 
+// replaceServer starts a server over the given store on the host of the server started by
+// createP2PExAndServer, replacing its handlers.
+func replaceServer(
+	t *testing.T,
+	host libhost.Host,
+	store header.Store[*headertest.DummyHeader],
+	opts ...Option[ServerParameters],
+) *ExchangeServer[*headertest.DummyHeader] {
+	opts = append([]Option[ServerParameters]{WithNetworkID[ServerParameters](networkID)}, opts...)
+	serv, err := NewExchangeServer[*headertest.DummyHeader](host, store, opts...)
+	require.NoError(t, err)
+	require.NoError(t, serv.Start(context.Background()))
+	t.Cleanup(func() {
+		serv.Stop(context.Background()) //nolint:errcheck
+	})
+	return serv
+}
+
 func quicHosts(t *testing.T, n int) []libhost.Host {
 	hosts := make([]libhost.Host, n)
 	for i := range hosts {
@@ -512,6 +1339,65 @@ func server(ctx context.Context, t *testing.T, host libhost.Host, store header.S
 	return server
 }
 
+// recorder implements the metric instruments of the client, summing the recorded values per peer.
+type recorder struct {
+	instrument.Synchronous
+
+	lk     stdsync.Mutex
+	values map[string]float64
+	calls  int
+}
+
+func newRecorder() *recorder {
+	return &recorder{values: make(map[string]float64)}
+}
+
+func (r *recorder) Add(_ context.Context, incr int64, attrs ...attribute.KeyValue) {
+	r.Record(context.Background(), float64(incr), attrs...)
+}
+
+func (r *recorder) Record(_ context.Context, value float64, attrs ...attribute.KeyValue) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	r.calls++
+	for _, attr := range attrs {
+		if attr.Key == "peer" {
+			r.values[attr.Value.AsString()] += value
+		}
+	}
+}
+
+func (r *recorder) value(p peer.ID) float64 {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	return r.values[p.String()]
+}
+
+type slowStore struct {
+	*headertest.Store[*headertest.DummyHeader]
+	delay time.Duration
+	calls atomic.Int32
+}
+
+func (s *slowStore) GetRangeByHeight(ctx context.Context, from, to uint64, _ ...header.CallOption) ([]*headertest.DummyHeader, error) {
+	s.calls.Add(1)
+	time.Sleep(s.delay)
+	return s.Store.GetRangeByHeight(ctx, from, to)
+}
+
+type tailStore struct {
+	*headertest.Store[*headertest.DummyHeader]
+	tail int64
+}
+
+func (s *tailStore) Tail(context.Context) (*headertest.DummyHeader, error) {
+	return s.Headers[s.tail], nil
+}
+
+func (s *tailStore) HasAt(ctx context.Context, height uint64) bool {
+	return int64(height) >= s.tail && s.Store.HasAt(ctx, height)
+}
+
 type timedOutStore struct {
 	headertest.Store[*headertest.DummyHeader]
 	timeout time.Duration
@@ -522,7 +1408,7 @@ func (t *timedOutStore) HasAt(_ context.Context, _ uint64) bool {
 	return true
 }
 
-func (t *timedOutStore) Head(_ context.Context) (*headertest.DummyHeader, error) {
+func (t *timedOutStore) Head(_ context.Context, _ ...header.CallOption) (*headertest.DummyHeader, error) {
 	time.Sleep(t.timeout)
 	return nil, header.ErrNoHead
 }
@@ -0,0 +1,53 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// HeadAttestor returns an opaque attestation of the given head the server attaches to head responses,
+// e.g. a signature of the head with the key of the node, so the head-serving infrastructure
+// can be held accountable for the heads it serves. Nil attestation attaches nothing.
+type HeadAttestor[H header.Header] func(ctx context.Context, head H) ([]byte, error)
+
+// HeadAttestationHandler handles the opaque attestation of the given head attached by the serving peer,
+// e.g. by verifying and recording it. The attestation is nil if the peer attached none.
+// Heads the handler returns an error for are rejected.
+type HeadAttestationHandler[H header.Header] func(ctx context.Context, from peer.ID, head H, attestation []byte) error
+
+// WithHeadAttestor is a functional option that configures the
+// `headAttestor` parameter.
+func WithHeadAttestor[T ServerParameters, H header.Header](attestor HeadAttestor[H]) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ServerParameters:
+			t.headAttestor = attestor
+		}
+	}
+}
+
+// WithHeadAttestationHandler is a functional option that configures the
+// `headAttestationHandler` parameter.
+func WithHeadAttestationHandler[T ClientParameters, H header.Header](handler HeadAttestationHandler[H]) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.headAttestationHandler = handler
+		}
+	}
+}
+
+// handle handles the attestation of the head. Nil handler accepts any head.
+func (h HeadAttestationHandler[H]) handle(ctx context.Context, from peer.ID, head H, attestation []byte) error {
+	if h == nil {
+		return nil
+	}
+	if err := h(ctx, from, head, attestation); err != nil {
+		return fmt.Errorf("header/p2p: handling attestation of head %d: %w", head.Height(), err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header/headertest"
+)
+
+func TestExchange_HeadAttestation(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+	// the attestation of a head is its hash
+	attestor := func(_ context.Context, h *headertest.DummyHeader) ([]byte, error) {
+		return h.Hash(), nil
+	}
+	replaceServer(t, hosts[1], store,
+		WithHeadAttestor[ServerParameters](HeadAttestor[*headertest.DummyHeader](attestor)),
+	)
+
+	var attested []peer.ID
+	exchg.attestations = func(_ context.Context, from peer.ID, h *headertest.DummyHeader, attestation []byte) error {
+		if !bytes.Equal(h.Hash(), attestation) {
+			return errors.New("invalid attestation")
+		}
+		attested = append(attested, from)
+		return nil
+	}
+	head, err := exchg.Head(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, store.Headers[store.HeadHeight].Hash(), head.Hash())
+	assert.Equal(t, []peer.ID{hosts[1].ID()}, attested)
+
+	// only heads are attested
+	_, err = exchg.GetByHeight(context.Background(), 3)
+	require.NoError(t, err)
+	assert.Len(t, attested, 1)
+
+	exchg.attestations = func(context.Context, peer.ID, *headertest.DummyHeader, []byte) error {
+		return errors.New("invalid attestation")
+	}
+	_, err = exchg.Head(context.Background())
+	require.Error(t, err)
+}
@@ -0,0 +1,75 @@
+package p2p
+
+import (
+	"errors"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
+)
+
+// headSignatureDomain separates head signatures from any other signatures
+// made with the same libp2p key.
+var headSignatureDomain = []byte("/header-ex/head-signature/")
+
+var (
+	// errUnsignedHead is returned when the head response does not carry a signature.
+	errUnsignedHead = errors.New("header/p2p: unsigned head response")
+	// errInvalidHeadSignature is returned when the signature of the head response does not match.
+	errInvalidHeadSignature = errors.New("header/p2p: invalid head response signature")
+	// errForeignHeadSignature is returned when the head response is signed by another peer
+	// than the one responding.
+	errForeignHeadSignature = errors.New("header/p2p: head response signed by another peer")
+)
+
+// signHead signs the body of the head response with the given key and attaches
+// the signature along with the public key to the response.
+func signHead(key crypto.PrivKey, resp *p2p_pb.HeaderResponse) error {
+	sig, err := key.Sign(headSignaturePayload(resp.Body))
+	if err != nil {
+		return err
+	}
+
+	pubKey, err := crypto.MarshalPublicKey(key.GetPublic())
+	if err != nil {
+		return err
+	}
+
+	resp.Signature, resp.PublicKey = sig, pubKey
+	return nil
+}
+
+// verifyHeadSignature verifies the signature of the head response and
+// returns the identity of the peer that signed it.
+func verifyHeadSignature(resp *p2p_pb.HeaderResponse) (peer.ID, error) {
+	if len(resp.Signature) == 0 {
+		return "", errUnsignedHead
+	}
+
+	pubKey, err := crypto.UnmarshalPublicKey(resp.PublicKey)
+	if err != nil {
+		return "", err
+	}
+
+	ok, err := pubKey.Verify(headSignaturePayload(resp.Body), resp.Signature)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", errInvalidHeadSignature
+	}
+	return peer.IDFromPublicKey(pubKey)
+}
+
+func headSignaturePayload(body []byte) []byte {
+	payload := make([]byte, 0, len(headSignatureDomain)+len(body))
+	payload = append(payload, headSignatureDomain...)
+	return append(payload, body...)
+}
+
+// isHeadRequest reports whether the request asks for the head of the remote peer.
+func isHeadRequest(req *p2p_pb.HeaderRequest) bool {
+	_, ok := req.Data.(*p2p_pb.HeaderRequest_Origin)
+	return ok && req.GetOrigin() == 0
+}
@@ -0,0 +1,80 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header/headertest"
+	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
+)
+
+func Test_HeadSignature(t *testing.T) {
+	key, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	id, err := peer.IDFromPrivateKey(key)
+	require.NoError(t, err)
+
+	resp := &p2p_pb.HeaderResponse{Body: []byte("head"), StatusCode: p2p_pb.StatusCode_OK}
+	_, err = verifyHeadSignature(resp)
+	require.ErrorIs(t, err, errUnsignedHead)
+
+	err = signHead(key, resp)
+	require.NoError(t, err)
+
+	signer, err := verifyHeadSignature(resp)
+	require.NoError(t, err)
+	require.Equal(t, id, signer)
+
+	resp.Body = []byte("another head")
+	_, err = verifyHeadSignature(resp)
+	require.ErrorIs(t, err, errInvalidHeadSignature)
+}
+
+func TestExchange_RequestSignedHead(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+	exchg.Params.verifyHeadSignature = true
+
+	// the server does not sign heads
+	_, err := exchg.Head(context.Background())
+	require.Error(t, err)
+
+	signingServer, err := NewExchangeServer[*headertest.DummyHeader](hosts[1], store,
+		WithNetworkID[ServerParameters](networkID),
+		WithHeadSigning(true),
+	)
+	require.NoError(t, err)
+	require.NoError(t, signingServer.Start(context.Background()))
+	t.Cleanup(func() {
+		signingServer.Stop(context.Background()) //nolint:errcheck
+	})
+
+	head, err := exchg.Head(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, store.HeadHeight, head.Height())
+}
+
+func TestExchange_RequestHeadSignedByAnotherPeer(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	exchg, store := createP2PExAndServer(t, hosts[0], hosts[1])
+	exchg.Params.verifyHeadSignature = true
+
+	bin, err := store.Headers[store.HeadHeight].MarshalBinary()
+	require.NoError(t, err)
+	req := &p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 0}, Amount: 1}
+	resp := &p2p_pb.HeaderResponse{Body: bin, StatusCode: p2p_pb.StatusCode_OK}
+	require.NoError(t, signHead(hosts[1].Peerstore().PrivKey(hosts[1].ID()), resp))
+	_, err = exchg.processResponse(context.Background(), hosts[1].ID(), req, resp, callParams{})
+	require.NoError(t, err)
+
+	// the head relayed by another peer is rejected
+	key, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	require.NoError(t, signHead(key, resp))
+	_, err = exchg.processResponse(context.Background(), hosts[1].ID(), req, resp, callParams{})
+	require.ErrorIs(t, err, errForeignHeadSignature)
+}
@@ -0,0 +1,185 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
+	"github.com/celestiaorg/go-libp2p-messenger/serde"
+)
+
+// resubscribeDelay specifies how long the client waits before resubscribing
+// to the trusted peers once all of them ended their head subscriptions.
+var resubscribeDelay = time.Second
+
+// SubscribeHead subscribes to new heads pushed by the trusted peers over the exchange protocol,
+// which is useful on networks where gossipsub is unavailable or blocked.
+// The client holds a stream to a single trusted peer open at a time and moves on to the next one
+// if the stream ends. Peers not supporting subscriptions respond with their current head only,
+// so with them the subscription degrades to polling the head every resubscribeDelay.
+// Only heads higher than the previously received ones are sent to the returned channel,
+// which is closed once the given context is done. The Heads must be verified thereafter.
+func (ex *Exchange[H]) SubscribeHead(ctx context.Context) <-chan H {
+	out := make(chan H)
+	go func() {
+		defer close(out)
+
+		var last uint64
+		for {
+			for _, p := range ex.peerTracker.routable(ex.trustedPeers()) {
+				err := ex.subscribeHead(ctx, p, &last, out)
+				if ctx.Err() != nil || ex.ctx.Err() != nil {
+					return
+				}
+				log.Debugw("head subscription ended", "peer", p, "err", err)
+			}
+
+			select {
+			case <-time.After(resubscribeDelay):
+			case <-ctx.Done():
+				return
+			case <-ex.ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// subscribeHead subscribes to new heads of the given peer and sends the ones above the last height
+// to the given channel until the stream ends.
+func (ex *Exchange[H]) subscribeHead(ctx context.Context, to peer.ID, last *uint64, out chan<- H) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := ex.transport.OpenStream(ctx, to, ex.protocolIDs...)
+	if err != nil {
+		return fmt.Errorf("header/p2p: failed to open a new stream: %w", err)
+	}
+	// the stream has no deadline, so it is reset once the subscription is over
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-ex.ctx.Done():
+		}
+		stream.Reset() //nolint:errcheck
+	}()
+
+	req := &p2p_pb.HeaderRequest{
+		Data:        &p2p_pb.HeaderRequest_Origin{Origin: 0},
+		Amount:      1,
+		Compression: ex.Params.compression,
+		Subscribe:   true,
+	}
+	if _, err = serde.Write(stream, req); err != nil {
+		return fmt.Errorf("header/p2p: failed to write a request: %w", err)
+	}
+	if err = stream.CloseWrite(); err != nil {
+		return err
+	}
+
+	for {
+		resps, _, err := readResponses(stream, ex.Params.MaxMessageSize)
+		if err != nil {
+			return err
+		}
+		if len(resps) != 1 {
+			return fmt.Errorf("%w: %d heads in a single push", ErrResponseLimitExceeded, len(resps))
+		}
+		resp := resps[0]
+
+		head, err := ex.processResponse(ctx, to, req, resp, callParams{})
+		if err != nil {
+			ex.peerTracker.recordResult(to, err)
+			return err
+		}
+		if uint64(head.Height()) <= *last {
+			continue
+		}
+		*last = uint64(head.Height())
+		ex.peerTracker.updateNetworkHead(*last)
+		ex.peerTracker.updateAdvertised(to, resp)
+
+		select {
+		case out <- head:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// acquireSubscription reserves a head subscription unless MaxHeadSubscriptions are open already.
+func (serv *ExchangeServer[H]) acquireSubscription() bool {
+	if serv.subscriptions.Add(1) > int64(serv.Params.MaxHeadSubscriptions) {
+		serv.subscriptions.Add(-1)
+		return false
+	}
+	return true
+}
+
+// headNotifier is implemented by stores reporting the updates of their head, e.g. store.Store.
+type headNotifier interface {
+	HeadUpdates() <-chan struct{}
+}
+
+// handleHeadSubscription pushes new heads to the subscribed client as they are
+// appended to the store, until the client or the server closes the stream.
+// Stores not reporting their head updates are checked for new heads every headSubscriptionInterval.
+func (serv *ExchangeServer[H]) handleHeadSubscription(stream network.Stream, req *p2p_pb.HeaderRequest) {
+	log.Debugw("server: handling head subscription", "peer", stream.Conn().RemotePeer())
+	notifier, notifies := serv.store.(headNotifier)
+	var ticks <-chan time.Time
+	if !notifies {
+		ticker := time.NewTicker(serv.Params.headSubscriptionInterval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	var last int64
+	for {
+		// updates are subscribed to before the head is read, so none of them is missed
+		var updated <-chan struct{}
+		if notifies {
+			updated = notifier.HeadUpdates()
+		}
+		head, err := serv.store.Head(serv.ctx)
+		switch {
+		case err != nil:
+			log.Debugw("server: could not get current head", "err", err)
+		case head.Height() > last:
+			if err = stream.SetWriteDeadline(time.Now().Add(serv.Params.WriteDeadline)); err != nil {
+				log.Debugf("error setting deadline: %s", err)
+			}
+			var resp *p2p_pb.HeaderResponse
+			served, err := serv.marshal([]H{head}, nil)
+			if err == nil {
+				resp, err = serv.response(req, served[0], p2p_pb.StatusCode_OK)
+			}
+			if err == nil {
+				_, err = serv.writeResponses(stream, req, []*p2p_pb.HeaderResponse{resp})
+			}
+			if err != nil {
+				log.Debugw("server: head subscription ended", "peer", stream.Conn().RemotePeer(), "err", err)
+				stream.Reset() //nolint:errcheck
+				return
+			}
+			last = head.Height()
+		}
+
+		select {
+		case <-serv.draining:
+			// the subscription ends gracefully once the server stops
+			stream.Close() //nolint:errcheck
+			return
+		case <-serv.ctx.Done():
+			stream.Reset() //nolint:errcheck
+			return
+		case <-ticks:
+		case <-updated:
+		}
+	}
+}
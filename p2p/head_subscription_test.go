@@ -0,0 +1,156 @@
+package p2p
+
+import (
+	"context"
+	"io"
+	stdsync "sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header"
+	"github.com/celestiaorg/go-header/headertest"
+	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
+	"github.com/celestiaorg/go-header/store"
+	"github.com/celestiaorg/go-libp2p-messenger/serde"
+)
+
+func TestExchange_SubscribeHead(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	hosts := createMocknet(t, 2)
+	exchg, _ := createP2PExAndServer(t, hosts[0], hosts[1])
+
+	suite := headertest.NewTestSuite(t)
+	store := &lockedStore{Store: headertest.NewStore[*headertest.DummyHeader](t, suite, 5)}
+	replaceServer(t, hosts[1], store, WithHeadSubscriptions(time.Millisecond*10))
+
+	heads := exchg.SubscribeHead(ctx)
+	head := <-heads
+	assert.EqualValues(t, 5, head.Height())
+
+	for i := 0; i < 3; i++ {
+		next := suite.NextHeader()
+		require.NoError(t, store.Append(ctx, next))
+		select {
+		case head = <-heads:
+			assert.Equal(t, next.Hash(), head.Hash())
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+
+	// the channel is closed once the subscription is canceled
+	cancel()
+	for range heads {
+	}
+}
+
+// TestExchange_SubscribeHeadUpdates ensures that new heads are pushed as soon as the store
+// reports them instead of being polled for.
+func TestExchange_SubscribeHeadUpdates(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	hosts := createMocknet(t, 2)
+	exchg, _ := createP2PExAndServer(t, hosts[0], hosts[1])
+
+	suite := headertest.NewTestSuite(t)
+	s, err := store.NewStoreWithHead(ctx, sync.MutexWrap(datastore.NewMapDatastore()), suite.Head())
+	require.NoError(t, err)
+	require.NoError(t, s.Start(ctx))
+	t.Cleanup(func() {
+		s.Stop(ctx) //nolint:errcheck
+	})
+	// the store would never be polled for new heads within the test
+	replaceServer(t, hosts[1], s, WithHeadSubscriptions(time.Hour))
+
+	heads := exchg.SubscribeHead(ctx)
+	head := <-heads
+	assert.Equal(t, suite.Head().Hash(), head.Hash())
+
+	for i := 0; i < 3; i++ {
+		next := suite.NextHeader()
+		require.NoError(t, s.Append(ctx, next))
+		select {
+		case head = <-heads:
+			assert.Equal(t, next.Hash(), head.Hash())
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+}
+
+// TestExchangeServer_CapsHeadSubscriptions ensures that subscribers above MaxHeadSubscriptions
+// are served the current head only.
+func TestExchangeServer_CapsHeadSubscriptions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	hosts := createMocknet(t, 2)
+	_, store := createP2PExAndServer(t, hosts[0], hosts[1])
+	serv := replaceServer(t, hosts[1], store,
+		WithHeadSubscriptions(time.Millisecond*10),
+		WithMaxHeadSubscriptions[ServerParameters](2),
+	)
+	serv.subscriptions.Store(2)
+
+	stream, err := hosts[0].NewStream(ctx, hosts[1].ID(), protocolID(networkID))
+	require.NoError(t, err)
+	req := &p2p_pb.HeaderRequest{
+		Data:      &p2p_pb.HeaderRequest_Origin{Origin: 0},
+		Amount:    1,
+		Subscribe: true,
+	}
+	_, err = serde.Write(stream, req)
+	require.NoError(t, err)
+	require.NoError(t, stream.CloseWrite())
+
+	resp := new(p2p_pb.HeaderResponse)
+	_, err = serde.Read(stream, resp)
+	require.NoError(t, err)
+	assert.Equal(t, p2p_pb.StatusCode_OK, resp.StatusCode)
+	// the stream is closed right after the head instead of being held open
+	closed := make(chan error, 1)
+	go func() {
+		_, err := serde.Read(stream, new(p2p_pb.HeaderResponse))
+		closed <- err
+	}()
+	select {
+	case err = <-closed:
+		require.ErrorIs(t, err, io.EOF)
+	case <-time.After(time.Second):
+		t.Fatal("head subscription held open above the limit")
+	}
+	assert.EqualValues(t, 2, serv.subscriptions.Load())
+
+	// subscriptions can not be enabled without any of them allowed
+	_, err = NewExchangeServer[*headertest.DummyHeader](hosts[1], store,
+		WithHeadSubscriptions(time.Millisecond*10),
+		WithMaxHeadSubscriptions[ServerParameters](0),
+	)
+	require.Error(t, err)
+}
+
+// lockedStore guards the headertest.Store for concurrent appends and reads.
+type lockedStore struct {
+	lk stdsync.Mutex
+	*headertest.Store[*headertest.DummyHeader]
+}
+
+func (s *lockedStore) Head(ctx context.Context, _ ...header.CallOption) (*headertest.DummyHeader, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	return s.Store.Head(ctx)
+}
+
+func (s *lockedStore) Append(ctx context.Context, headers ...*headertest.DummyHeader) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	return s.Store.Append(ctx, headers...)
+}
@@ -2,12 +2,16 @@ package p2p
 
 import (
 	"context"
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
 
@@ -16,10 +20,99 @@ import (
 	"github.com/celestiaorg/go-libp2p-messenger/serde"
 )
 
+// protocolVersions lists the supported versions of the header exchange protocol, newest first.
+// The client negotiates the newest one supported by the remote peer, while the server handles
+// all of them, responding to the peers of older versions in the way they understand:
+//   - v0.0.4 adds optional fields to the wire format, which older peers ignore;
+//   - v0.0.5 adds the status codes beyond OK and NOT_FOUND, which older peers fail on,
+//     so they are downgraded with legacyStatusCode.
+//
+// This allows rolling out wire format upgrades without splitting the network.
+var protocolVersions = []string{"v0.0.5", "v0.0.4", "v0.0.3"}
+
+// statusCodesVersion is the version of the protocol introducing the status codes
+// beyond OK and NOT_FOUND.
+const statusCodesVersion = "v0.0.5"
+
+// protocolID returns the newest version of the header exchange protocol ID.
 func protocolID(networkID string) protocol.ID {
-	return protocol.ID(fmt.Sprintf("/%s/header-ex/v0.0.3", networkID))
+	return protocolIDs(networkID)[0]
+}
+
+// protocolIDs returns all the supported versions of the header exchange protocol ID, newest first.
+func protocolIDs(networkID string) []protocol.ID {
+	ids := make([]protocol.ID, len(protocolVersions))
+	for i, version := range protocolVersions {
+		ids[i] = protocol.ID(fmt.Sprintf("/%s/header-ex/%s", networkID, version))
+	}
+	return ids
+}
+
+// ErrResponseLimitExceeded is returned when a peer responds with more headers than requested
+// or with a message above the max message size.
+var ErrResponseLimitExceeded = errors.New("header/p2p: response limit exceeded")
+
+// ErrPartialResponse is returned along with the contiguous prefix of the requested range
+// that was fetched and verified before the request was interrupted, e.g. by the context deadline.
+// The prefix can be used and the rest of the range requested again.
+var ErrPartialResponse = errors.New("header/p2p: partial response")
+
+// errBrokenChain is returned when a peer responds with a range of headers that are not
+// linked by their hashes. Peers returning broken chains are blocked.
+var errBrokenChain = errors.New("header/p2p: broken hash chain")
+
+// InvalidResponseError describes the invalid response a peer got blocked for.
+// It is passed as the reason to the callback set with WithOnBlockedPeer, so operators
+// can tell peers serving invalid headers apart from the ones with flaky connections.
+type InvalidResponseError struct {
+	// Request is the request the peer responded to.
+	Request *p2p_pb.HeaderRequest
+	// Err is the validation error of the response.
+	Err error
+}
+
+func (e *InvalidResponseError) Error() string {
+	return fmt.Sprintf("header/p2p: invalid response to request %s: %s", e.Request.String(), e.Err)
+}
+
+func (e *InvalidResponseError) Unwrap() error {
+	return e.Err
+}
+
+// RateLimitedError is returned when a peer refuses to serve the request, as the client exceeded
+// the rate limits of the peer. Requests are not routed to the peer until RetryAfter passes.
+type RateLimitedError struct {
+	// RetryAfter is how long the peer asked to wait before sending the next request.
+	RetryAfter time.Duration
 }
 
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("header/p2p: rate limited, retry after %s", e.RetryAfter)
+}
+
+// ErrInvalidRequest is returned when a peer refuses to serve the request, as it is malformed
+// or exceeds the limits of the peer.
+var ErrInvalidRequest = errors.New("header/p2p: invalid request")
+
+// ErrPeerInternal is returned when a peer fails to serve the request because of its internal error,
+// e.g. of its store, as opposed to missing the requested headers.
+var ErrPeerInternal = errors.New("header/p2p: peer internal error")
+
+// PrunedError is returned when a peer refuses to serve the request, as the requested headers
+// are below the window of recent headers the peer serves. Requests for headers below Tail
+// are not routed to the peer.
+type PrunedError struct {
+	// Tail is the lowest height the peer serves.
+	Tail uint64
+}
+
+func (e *PrunedError) Error() string {
+	return fmt.Sprintf("header/p2p: headers pruned, peer serves from %d", e.Tail)
+}
+
+// errInvalidResponse is returned when a peer responds with headers other than requested.
+var errInvalidResponse = errors.New("header/p2p: invalid response")
+
 func PubsubTopicID(networkID string) string {
 	return fmt.Sprintf("/%s/header-sub/v0.0.1", networkID)
 }
@@ -33,18 +126,63 @@ func validateChainID(want, have string) error {
 	return nil
 }
 
-// sendMessage opens the stream to the given peers and sends HeaderRequest to fetch
+// sendMessage opens the stream to the given peers over the transport and sends HeaderRequest to fetch
 // Headers. As a result sendMessage returns HeaderResponse, the size of fetched
 // data, the duration of the request and an error.
+// If the peer serves the requested range in pages, as it exceeds the limits of the peer,
+// the continuation of every page is followed until the whole range is received.
+// Responses above the given max message size or exceeding the requested amount
+// result in ErrResponseLimitExceeded. Zero max message size disables the size check.
 func sendMessage(
 	ctx context.Context,
-	host host.Host,
+	transport Transport,
 	to peer.ID,
-	protocol protocol.ID,
+	protocols []protocol.ID,
 	req *p2p_pb.HeaderRequest,
+	maxMsgSize uint64,
+) ([]*p2p_pb.HeaderResponse, uint64, uint64, error) {
+	responses, size, duration, err := sendPage(ctx, transport, to, protocols, req, maxMsgSize)
+	for err == nil && len(responses) > 0 && uint64(len(responses)) < req.Amount {
+		// the page must continue right after the received headers, so the peer can't loop the client
+		next := responses[len(responses)-1].Continuation
+		if next == 0 || next != req.GetOrigin()+uint64(len(responses)) {
+			break
+		}
+		page := &p2p_pb.HeaderRequest{
+			Data:        &p2p_pb.HeaderRequest_Origin{Origin: next},
+			Amount:      req.Amount - uint64(len(responses)),
+			Compression: req.Compression,
+			Priority:    req.Priority,
+		}
+		var (
+			pageResps              []*p2p_pb.HeaderResponse
+			pageSize, pageDuration uint64
+		)
+		pageResps, pageSize, pageDuration, err = sendPage(ctx, transport, to, protocols, page, maxMsgSize)
+		responses = append(responses, pageResps...)
+		size += pageSize
+		duration += pageDuration
+		if len(pageResps) == 0 {
+			// the peer has nothing more to serve, so the collected pages are returned as they are
+			break
+		}
+	}
+	return responses, size, duration, err
+}
+
+// sendPage sends HeaderRequest to the given peer and reads the responses to it
+// within a single stream.
+func sendPage(
+	ctx context.Context,
+	transport Transport,
+	to peer.ID,
+	protocols []protocol.ID,
+	req *p2p_pb.HeaderRequest,
+	maxMsgSize uint64,
 ) ([]*p2p_pb.HeaderResponse, uint64, uint64, error) {
 	startTime := time.Now()
-	stream, err := host.NewStream(ctx, to, protocol)
+	// the newest protocol supported by the peer is negotiated
+	stream, err := transport.OpenStream(ctx, to, protocols...)
 	if err != nil {
 		return nil, 0, 0, fmt.Errorf("header/p2p: failed to open a new stream: %w", err)
 	}
@@ -73,16 +211,25 @@ func sendMessage(
 	headers := make([]*p2p_pb.HeaderResponse, 0)
 
 	var totalRespLn uint64
-	for i := 0; i < int(req.Amount); i++ {
-		resp := new(p2p_pb.HeaderResponse)
-		respLn, readErr := serde.Read(stream, resp)
+	for uint64(len(headers)) < req.Amount {
+		resps, respLn, readErr := readResponses(stream, maxMsgSize)
 		if readErr != nil {
 			err = readErr
 			break
 		}
-
 		totalRespLn += uint64(respLn)
-		headers = append(headers, resp)
+		headers = append(headers, resps...)
+	}
+	if err == nil && uint64(len(headers)) > req.Amount {
+		err = fmt.Errorf("%w: more than %d headers", ErrResponseLimitExceeded, req.Amount)
+	}
+
+	// the server closes the stream after the requested amount of headers,
+	// so anything else is an over-count response
+	if err == nil && uint64(len(headers)) == req.Amount {
+		if _, readErr := serde.Read(stream, new(p2p_pb.HeaderResponse)); readErr == nil {
+			err = fmt.Errorf("%w: more than %d headers", ErrResponseLimitExceeded, req.Amount)
+		}
 	}
 
 	duration := time.Since(startTime).Milliseconds()
@@ -108,14 +255,81 @@ func sendMessage(
 	return headers, totalRespLn, uint64(duration), err
 }
 
-// convertStatusCodeToError converts passed status code into an error.
-func convertStatusCodeToError(code p2p_pb.StatusCode) error {
-	switch code {
+// convertStatusCodeToError converts the status code of the passed response into an error.
+func convertStatusCodeToError(resp *p2p_pb.HeaderResponse) error {
+	switch resp.StatusCode {
 	case p2p_pb.StatusCode_OK:
 		return nil
 	case p2p_pb.StatusCode_NOT_FOUND:
 		return header.ErrNotFound
+	case p2p_pb.StatusCode_RATE_LIMITED:
+		return &RateLimitedError{RetryAfter: time.Duration(resp.RetryAfter) * time.Millisecond}
+	case p2p_pb.StatusCode_PRUNED:
+		return &PrunedError{Tail: resp.Tail}
+	case p2p_pb.StatusCode_INVALID_REQUEST:
+		return ErrInvalidRequest
+	case p2p_pb.StatusCode_INTERNAL_ERROR:
+		return ErrPeerInternal
 	default:
-		return fmt.Errorf("unknown status code %d", code)
+		return fmt.Errorf("unknown status code %d", resp.StatusCode)
 	}
 }
+
+// supportsStatusCodes reports whether the given protocol ID is of statusCodesVersion or newer.
+func supportsStatusCodes(id protocol.ID) bool {
+	for _, version := range protocolVersions {
+		if strings.HasSuffix(string(id), "/"+version) {
+			return true
+		}
+		if version == statusCodesVersion {
+			return false
+		}
+	}
+	return false
+}
+
+// legacyStatusCode returns the status code to respond with over the given protocol in place of
+// the given one. The peers of the versions before statusCodesVersion handle OK and NOT_FOUND only,
+// so the status codes asking them to look for the headers elsewhere are replaced with NOT_FOUND,
+// while the others are not representable and false is returned, so the stream is reset instead.
+func legacyStatusCode(id protocol.ID, code p2p_pb.StatusCode) (p2p_pb.StatusCode, bool) {
+	if supportsStatusCodes(id) {
+		return code, true
+	}
+	switch code {
+	case p2p_pb.StatusCode_OK, p2p_pb.StatusCode_NOT_FOUND:
+		return code, true
+	case p2p_pb.StatusCode_PRUNED, p2p_pb.StatusCode_INVALID_REQUEST:
+		return p2p_pb.StatusCode_NOT_FOUND, true
+	default:
+		return code, false
+	}
+}
+
+// lockedRand is a pseudo-random source safe for concurrent use.
+type lockedRand struct {
+	lk   sync.Mutex
+	rand *rand.Rand
+}
+
+// newRand creates a lockedRand from the given seed.
+// If seed is zero, the source is seeded with secure randomness.
+func newRand(seed int64) *lockedRand {
+	if seed == 0 {
+		var buf [8]byte
+		if _, err := crand.Read(buf[:]); err != nil {
+			seed = time.Now().UnixNano()
+		} else {
+			seed = int64(binary.BigEndian.Uint64(buf[:]))
+		}
+	}
+	//nolint:gosec // G404: Use of weak random number generator
+	return &lockedRand{rand: rand.New(rand.NewSource(seed))}
+}
+
+// Shuffle pseudo-randomizes the order of n elements.
+func (r *lockedRand) Shuffle(n int, swap func(i, j int)) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	r.rand.Shuffle(n, swap)
+}
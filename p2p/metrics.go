@@ -3,21 +3,31 @@ package p2p
 import (
 	"context"
 
+	"github.com/libp2p/go-libp2p/core/peer"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric/global"
 	"go.opentelemetry.io/otel/metric/instrument"
 	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+
+	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
 )
 
 type metrics struct {
 	responseSize     syncfloat64.Histogram
 	responseDuration syncfloat64.Histogram
+	bytesReceived    syncint64.Counter
+	retries          syncint64.Counter
+	failures         syncint64.Counter
+	blocked          syncint64.Counter
 }
 
 var (
 	meter = global.MeterProvider().Meter("header/p2p")
 )
 
+// InitMetrics enables Otel metrics to monitor requests of the Exchange per peer.
+// See also WithMetrics.
 func (ex *Exchange[H]) InitMetrics() error {
 	responseSize, err := meter.
 		SyncFloat64().
@@ -39,25 +49,198 @@ func (ex *Exchange[H]) InitMetrics() error {
 		return err
 	}
 
+	bytesReceived, err := meter.
+		SyncInt64().
+		Counter(
+			"header_p2p_headers_received_bytes",
+			instrument.WithDescription("Amount of bytes received in get headers responses"),
+		)
+	if err != nil {
+		return err
+	}
+
+	retries, err := meter.
+		SyncInt64().
+		Counter(
+			"header_p2p_headers_request_retries",
+			instrument.WithDescription("Amount of get headers requests retried after a failed attempt"),
+		)
+	if err != nil {
+		return err
+	}
+
+	failures, err := meter.
+		SyncInt64().
+		Counter(
+			"header_p2p_headers_request_failures",
+			instrument.WithDescription("Amount of failed get headers requests"),
+		)
+	if err != nil {
+		return err
+	}
+
+	blocked, err := meter.
+		SyncInt64().
+		Counter(
+			"header_p2p_peers_blocked",
+			instrument.WithDescription("Amount of peers blocked for invalid responses"),
+		)
+	if err != nil {
+		return err
+	}
+
 	ex.metrics = &metrics{
 		responseSize:     responseSize,
 		responseDuration: responseDuration,
+		bytesReceived:    bytesReceived,
+		retries:          retries,
+		failures:         failures,
+		blocked:          blocked,
 	}
 	return nil
 }
 
-func (m *metrics) observeResponse(ctx context.Context, size uint64, duration uint64, err error) {
+// observeResponse records the size and the duration of the response from the given peer.
+func (m *metrics) observeResponse(ctx context.Context, from peer.ID, size uint64, duration uint64, err error) {
 	if m == nil {
 		return
 	}
-	m.responseSize.Record(
-		ctx,
-		float64(size),
-		attribute.Bool("failed", err != nil),
-	)
-	m.responseDuration.Record(
-		ctx,
-		float64(duration),
+	attrs := []attribute.KeyValue{
+		attribute.String("peer", from.String()),
 		attribute.Bool("failed", err != nil),
+	}
+	m.responseSize.Record(ctx, float64(size), attrs...)
+	m.responseDuration.Record(ctx, float64(duration), attrs...)
+	m.bytesReceived.Add(ctx, int64(size), attrs[0])
+	if err != nil {
+		m.failures.Add(ctx, 1, attrs[0])
+	}
+}
+
+// observeRetry records a request retried after a failed attempt to the given peer.
+func (m *metrics) observeRetry(ctx context.Context, failed peer.ID) {
+	if m == nil {
+		return
+	}
+	m.retries.Add(ctx, 1, attribute.String("peer", failed.String()))
+}
+
+// observeBlocked records the given peer blocked for an invalid response.
+func (m *metrics) observeBlocked(ctx context.Context, blocked peer.ID) {
+	if m == nil {
+		return
+	}
+	m.blocked.Add(ctx, 1, attribute.String("peer", blocked.String()))
+}
+
+// statuses of the requests served by the ExchangeServer.
+const (
+	requestOK          = "ok"
+	requestNotFound    = "not_found"
+	requestRateLimited = "rate_limited"
+	requestPruned      = "pruned"
+	requestInvalid     = "invalid"
+	requestBusy        = "busy"
+	requestError       = "error"
+)
+
+type serverMetrics struct {
+	requests         syncint64.Counter
+	bytesSent        syncint64.Counter
+	compressionRatio syncfloat64.Histogram
+}
+
+// InitMetrics enables Otel metrics to monitor requests served by the ExchangeServer per type,
+// status and peer. See also WithMetrics.
+func (serv *ExchangeServer[H]) InitMetrics() error {
+	requests, err := meter.
+		SyncInt64().
+		Counter(
+			"header_p2p_server_requests",
+			instrument.WithDescription("Amount of requests served by type, status and peer"),
+		)
+	if err != nil {
+		return err
+	}
+
+	bytesSent, err := meter.
+		SyncInt64().
+		Counter(
+			"header_p2p_server_sent_bytes",
+			instrument.WithDescription("Amount of bytes sent in responses to requests"),
+		)
+	if err != nil {
+		return err
+	}
+
+	compressionRatio, err := meter.
+		SyncFloat64().
+		Histogram(
+			"header_p2p_server_compression_ratio",
+			instrument.WithDescription("Ratio of the compressed to the raw size of header payloads by codec"),
+		)
+	if err != nil {
+		return err
+	}
+
+	serv.metrics = &serverMetrics{
+		requests:         requests,
+		bytesSent:        bytesSent,
+		compressionRatio: compressionRatio,
+	}
+	return nil
+}
+
+// observeRequest records the given request from the given peer served with the given status.
+func (m *serverMetrics) observeRequest(ctx context.Context, from peer.ID, req *p2p_pb.HeaderRequest, status string) {
+	if m == nil {
+		return
+	}
+	m.requests.Add(ctx, 1,
+		attribute.String("peer", from.String()),
+		attribute.String("type", requestType(req)),
+		attribute.String("status", status),
 	)
 }
+
+// observeSent records the amount of bytes sent to the given peer.
+func (m *serverMetrics) observeSent(ctx context.Context, to peer.ID, size int) {
+	if m == nil {
+		return
+	}
+	m.bytesSent.Add(ctx, int64(size), attribute.String("peer", to.String()))
+}
+
+// observeCompression records the ratio of the compressed to the raw size of a payload
+// compressed with the given codec.
+func (m *serverMetrics) observeCompression(ctx context.Context, codec Compression, raw, compressed int) {
+	if m == nil {
+		return
+	}
+	m.compressionRatio.Record(ctx, float64(compressed)/float64(raw), attribute.String("codec", codec.String()))
+}
+
+// requestType describes the type of the given request for metrics.
+func requestType(req *p2p_pb.HeaderRequest) string {
+	switch req.Data.(type) {
+	case *p2p_pb.HeaderRequest_Hash:
+		return "hash"
+	case *p2p_pb.HeaderRequest_Hashes:
+		return "hashes"
+	case *p2p_pb.HeaderRequest_Origin:
+		switch {
+		case isHeadRequest(req) && req.Subscribe:
+			return "head_subscription"
+		case isHeadRequest(req):
+			return "head"
+		case req.Descending:
+			return "range_descending"
+		case req.Amount == 1:
+			return "height"
+		default:
+			return "range"
+		}
+	default:
+		return "unknown"
+	}
+}
@@ -3,6 +3,13 @@ package p2p
 import (
 	"fmt"
 	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/net/conngater"
+
+	"github.com/celestiaorg/go-header"
+	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
+	"github.com/celestiaorg/go-libp2p-messenger/serde"
 )
 
 // parameters is an interface that encompasses all params needed for
@@ -12,6 +19,21 @@ type parameters interface {
 	ServerParameters | ClientParameters
 }
 
+// typedOption returns the value of the option generic over the header type, which is stored
+// untyped as the parameters are not generic, typed for H by the given type V.
+// It returns an error if the option was configured for another header type.
+func typedOption[V any](name string, value any) (V, error) {
+	var zero V
+	if value == nil {
+		return zero, nil
+	}
+	typed, ok := value.(V)
+	if !ok {
+		return zero, fmt.Errorf("header/p2p: %s of %T does not match the header type", name, value)
+	}
+	return typed, nil
+}
+
 // Option is the functional option that is applied to the exchange instance
 // to configure parameters.
 type Option[T parameters] func(*T)
@@ -25,17 +47,79 @@ type ServerParameters struct {
 	// RangeRequestTimeout defines a timeout after which the session will try to re-request headers
 	// from another peer.
 	RangeRequestTimeout time.Duration
+	// MaxHeadersPerResponse defines the max amount of headers served per 1 request.
+	// Larger ranges are served in pages of it, which the client follows up on.
+	MaxHeadersPerResponse uint64
+	// MaxMessageSize defines the max size of a single response message in bytes.
+	// Headers marshaled above it are not served.
+	MaxMessageSize uint64
+	// MaxConcurrentRequests defines the max amount of inbound requests served concurrently.
+	// Requests above it wait for RequestQueueSize, with high priority ones served first,
+	// and are rejected, asking the client to retry later, once the queue is full.
+	// Head subscriptions are not limited by it. Zero disables the limit.
+	MaxConcurrentRequests int
+	// RequestQueueSize defines the max amount of inbound requests waiting to be served
+	// once MaxConcurrentRequests is reached.
+	RequestQueueSize int
+	// ResponseCacheSize defines the amount of recently served ranges kept marshaled in memory,
+	// so popular ranges are served without hitting the store. Zero disables the cache.
+	ResponseCacheSize int
+	// PeerRequestsPerSecond defines the amount of requests per second served to a single peer.
+	// Requests above it are responded with the rate limited status and the time the peer
+	// should wait before retrying. Zero disables the limit.
+	PeerRequestsPerSecond uint64
+	// PeerHeadersPerSecond defines the amount of headers per second served to a single peer,
+	// while a single request for up to MaxHeadersPerResponse headers is always allowed to a peer
+	// that did not request anything recently. Zero disables the limit.
+	PeerHeadersPerSecond uint64
+	// CompressionThreshold defines the size in bytes of the responses to a request above which
+	// they are compressed for clients accepting compression. Smaller responses are not worth
+	// the CPU time. Zero compresses responses of any size.
+	CompressionThreshold int
+	// RecentHeaders defines the amount of the most recent headers served, e.g. matching
+	// the pruning window of the local store. Requests for older headers are responded with
+	// the pruned status, so clients reroute them to other peers right away.
+	// Zero serves all the stored headers.
+	RecentHeaders uint64
+	// MaxHeadSubscriptions defines the max amount of head subscriptions held open at once,
+	// as each of them occupies a goroutine for as long as the client stays subscribed.
+	// Clients subscribing above it are served the current head only and fall back to polling.
+	MaxHeadSubscriptions int
 	// networkID is a network that will be used to create a protocol.ID
 	// Is empty by default
 	networkID string
+	// signHead enables signing of head responses with the libp2p key of the host.
+	signHead bool
+	// compression is the codec responses are compressed with for clients accepting it.
+	compression Compression
+	// proofProvider is the ProofProvider attaching proofs to the served headers.
+	proofProvider any
+	// headAttestor is the HeadAttestor attaching attestations to the served heads.
+	headAttestor any
+	// coldStore is the header.Getter serving the historical headers missing from the store.
+	coldStore any
+	// metrics enables Otel metrics of the served requests.
+	metrics bool
+	// requestHook is called with every handled request.
+	requestHook RequestHook
+	// connGater is the blocklist of peers the server refuses to serve.
+	connGater *conngater.BasicConnectionGater
+	// headSubscriptionInterval is how often new heads are checked for and pushed to
+	// the subscribed clients, unless the store reports its head updates.
+	// Zero disables head subscriptions.
+	headSubscriptionInterval time.Duration
 }
 
 // DefaultServerParameters returns the default params to configure the store.
 func DefaultServerParameters() ServerParameters {
 	return ServerParameters{
-		WriteDeadline:       time.Second * 8,
-		ReadDeadline:        time.Minute,
-		RangeRequestTimeout: time.Second * 10,
+		WriteDeadline:         time.Second * 8,
+		ReadDeadline:          time.Minute,
+		RangeRequestTimeout:   time.Second * 10,
+		MaxHeadersPerResponse: header.MaxRangeRequestSize,
+		MaxMessageSize:        serde.MaxMessageSize,
+		CompressionThreshold:  1 << 10,
+		MaxHeadSubscriptions:  128,
 	}
 }
 
@@ -50,6 +134,42 @@ func (p *ServerParameters) Validate() error {
 		return fmt.Errorf("invalid request timeout for session: "+
 			"%s. %s: %v", greaterThenZero, providedSuffix, p.RangeRequestTimeout)
 	}
+	if p.MaxHeadersPerResponse == 0 {
+		return fmt.Errorf("invalid MaxHeadersPerResponse: %s. %s: %v",
+			greaterThenZero, providedSuffix, p.MaxHeadersPerResponse)
+	}
+	if p.MaxConcurrentRequests < 0 {
+		return fmt.Errorf("invalid MaxConcurrentRequests: should not be negative. %s: %v",
+			providedSuffix, p.MaxConcurrentRequests)
+	}
+	if p.RequestQueueSize < 0 {
+		return fmt.Errorf("invalid RequestQueueSize: should not be negative. %s: %v",
+			providedSuffix, p.RequestQueueSize)
+	}
+	if p.ResponseCacheSize < 0 {
+		return fmt.Errorf("invalid ResponseCacheSize: should not be negative. %s: %v",
+			providedSuffix, p.ResponseCacheSize)
+	}
+	if p.CompressionThreshold < 0 {
+		return fmt.Errorf("invalid CompressionThreshold: should not be negative. %s: %v",
+			providedSuffix, p.CompressionThreshold)
+	}
+	if p.headSubscriptionInterval > 0 && p.MaxHeadSubscriptions <= 0 {
+		return fmt.Errorf("invalid MaxHeadSubscriptions: %s. %s: %v",
+			greaterThenZero, providedSuffix, p.MaxHeadSubscriptions)
+	}
+	if err := validateMaxMessageSize(p.MaxMessageSize); err != nil {
+		return err
+	}
+	return validateCompression(p.compression)
+}
+
+// validateMaxMessageSize ensures the max message size fits the limit of the underlying serde.
+func validateMaxMessageSize(size uint64) error {
+	if size == 0 || size > serde.MaxMessageSize {
+		return fmt.Errorf("invalid MaxMessageSize: should be in range (0;%d]. %s: %v",
+			serde.MaxMessageSize, providedSuffix, size)
+	}
 	return nil
 }
 
@@ -75,6 +195,17 @@ func WithReadDeadline[T ServerParameters](deadline time.Duration) Option[T] {
 	}
 }
 
+// WithHeadSigning is a functional option that configures the
+// `signHead` parameter.
+func WithHeadSigning[T ServerParameters](enabled bool) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ServerParameters:
+			t.signHead = enabled
+		}
+	}
+}
+
 // WithRangeRequestTimeout is a functional option that configures the
 // `RangeRequestTimeout` parameter.
 func WithRangeRequestTimeout[T parameters](duration time.Duration) Option[T] {
@@ -101,6 +232,177 @@ func WithNetworkID[T parameters](networkID string) Option[T] {
 	}
 }
 
+// WithCompression is a functional option that configures the
+// `compression` parameter. The client advertises the codec in its requests
+// and the server compresses responses only for clients advertising the same codec,
+// so peers with different or no codecs keep exchanging uncompressed headers.
+func WithCompression[T parameters](codec Compression) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) {
+		case *ClientParameters:
+			t.compression = codec
+		case *ServerParameters:
+			t.compression = codec
+		}
+	}
+}
+
+// WithHeadSubscriptions is a functional option that configures the
+// `headSubscriptionInterval` parameter, enabling clients to subscribe to new heads.
+// New heads are pushed as soon as stores reporting their head updates, e.g. store.Store,
+// append them, while other stores are checked for new heads every interval.
+func WithHeadSubscriptions[T ServerParameters](interval time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ServerParameters:
+			t.headSubscriptionInterval = interval
+		}
+	}
+}
+
+// WithMaxMessageSize is a functional option that configures the
+// `MaxMessageSize` parameter. The server does not serve headers above it,
+// while the client rejects such responses with ErrResponseLimitExceeded.
+func WithMaxMessageSize[T parameters](size uint64) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) {
+		case *ClientParameters:
+			t.MaxMessageSize = size
+		case *ServerParameters:
+			t.MaxMessageSize = size
+		}
+	}
+}
+
+// WithMaxHeadersPerResponse is a functional option that configures the
+// `MaxHeadersPerResponse` parameter.
+func WithMaxHeadersPerResponse[T ServerParameters](amount uint64) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ServerParameters:
+			t.MaxHeadersPerResponse = amount
+		}
+	}
+}
+
+// WithMaxConcurrentRequests is a functional option that configures the
+// `MaxConcurrentRequests` parameter.
+func WithMaxConcurrentRequests[T ServerParameters](limit int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ServerParameters:
+			t.MaxConcurrentRequests = limit
+		}
+	}
+}
+
+// WithRequestQueueSize is a functional option that configures the
+// `RequestQueueSize` parameter.
+func WithRequestQueueSize[T ServerParameters](size int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ServerParameters:
+			t.RequestQueueSize = size
+		}
+	}
+}
+
+// WithMaxHeadSubscriptions is a functional option that configures the
+// `MaxHeadSubscriptions` parameter.
+func WithMaxHeadSubscriptions[T ServerParameters](amount int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ServerParameters:
+			t.MaxHeadSubscriptions = amount
+		}
+	}
+}
+
+// WithResponseCacheSize is a functional option that configures the
+// `ResponseCacheSize` parameter.
+func WithResponseCacheSize[T ServerParameters](size int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ServerParameters:
+			t.ResponseCacheSize = size
+		}
+	}
+}
+
+// WithCompressionThreshold is a functional option that configures the
+// `CompressionThreshold` parameter.
+func WithCompressionThreshold[T ServerParameters](size int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ServerParameters:
+			t.CompressionThreshold = size
+		}
+	}
+}
+
+// WithRecentHeaders is a functional option that configures the
+// `RecentHeaders` parameter.
+func WithRecentHeaders[T ServerParameters](amount uint64) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ServerParameters:
+			t.RecentHeaders = amount
+		}
+	}
+}
+
+// RequestHook is called by the ExchangeServer once a request is handled, with the peer that sent it,
+// the request, the last response written, the error failing the request and the time it took to handle,
+// e.g. for audit logging, abuse detection or custom accounting.
+// The response is nil if none was written, e.g. for head subscriptions or failed requests.
+// The hook is called synchronously by the handler, so it must not block.
+type RequestHook func(peer.ID, *p2p_pb.HeaderRequest, *p2p_pb.HeaderResponse, error, time.Duration)
+
+// WithRequestHook is a functional option that configures the
+// `requestHook` parameter.
+func WithRequestHook[T ServerParameters](hook RequestHook) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ServerParameters:
+			t.requestHook = hook
+		}
+	}
+}
+
+// WithConnectionGater is a functional option that configures the
+// `connGater` parameter. The server refuses to serve the peers blocked by it,
+// e.g. the ones blocked by the PeerTracker of the Exchange sharing the gater, resetting their streams right away.
+func WithConnectionGater[T ServerParameters](gater *conngater.BasicConnectionGater) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ServerParameters:
+			t.connGater = gater
+		}
+	}
+}
+
+// WithPeerRequestsPerSecond is a functional option that configures the
+// `PeerRequestsPerSecond` parameter.
+func WithPeerRequestsPerSecond[T ServerParameters](limit uint64) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ServerParameters:
+			t.PeerRequestsPerSecond = limit
+		}
+	}
+}
+
+// WithPeerHeadersPerSecond is a functional option that configures the
+// `PeerHeadersPerSecond` parameter.
+func WithPeerHeadersPerSecond[T ServerParameters](limit uint64) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ServerParameters:
+			t.PeerHeadersPerSecond = limit
+		}
+	}
+}
+
 // WithParams is a functional option that overrides Client/ServerParameters
 func WithParams[T parameters](params T) Option[T] {
 	return func(p *T) {
@@ -115,10 +417,115 @@ type ClientParameters struct {
 	// RangeRequestTimeout defines a timeout after which the session will try to re-request headers
 	// from another peer.
 	RangeRequestTimeout time.Duration
+	// RequestTimeout defines a timeout for a single request to a trusted peer,
+	// such as Head or single header requests. Zero disables the timeout,
+	// leaving it to the caller's context.
+	RequestTimeout time.Duration
+	// HeadRequestTimeout defines a timeout for a single head request to a peer, overriding
+	// the RequestTimeout for head requests. Head requests are small, so a short timeout allows
+	// to quickly move on from unresponsive peers, while range requests are bound by
+	// the RangeRequestTimeout. Zero applies the RequestTimeout.
+	HeadRequestTimeout time.Duration
+	// MaxRetries defines how many times requests for single headers go through
+	// all the trusted peers before failing.
+	MaxRetries int
+	// NotFoundRetries defines the max amount of other tracked peers single headers are requested from,
+	// in the order of their score, if none of the trusted peers has them. Heights above the network head
+	// are not retried. Zero disables the retries.
+	NotFoundRetries int
+	// PeerProbeInterval defines how often tracked peers that were not requested within the interval
+	// are probed for liveness with a head request. Zero disables probing.
+	PeerProbeInterval time.Duration
+	// PeerGCBatchSize defines the max amount of peers processed during garbage collection
+	// of the peer tracker before yielding to other peer tracker operations.
+	PeerGCBatchSize int
+	// BackfillBandwidth defines the bandwidth budget in bytes per second for range requests,
+	// which are mostly used to backfill the history. Head requests are not limited by it.
+	// Zero disables the limit.
+	BackfillBandwidth uint64
+	// MaxParallelRequests enables adaptive parallelism of range requests and defines the max amount
+	// of them sent concurrently across all the ranges requested in parallel. Starting with a single
+	// request, the limit grows while the throughput of the requests keeps improving and is halved
+	// on timeouts, similarly to TCP congestion control. The amount of available peers bounds
+	// the parallelism regardless. Zero disables the adaptive limit.
+	MaxParallelRequests int
+	// HeadQuorum defines the amount of trusted peers that must agree on the head.
+	// If set, Head returns the highest header agreed on by the quorum or ErrHeadDisagreement.
+	// Zero keeps the best effort behaviour, preferring heads received from at least two peers.
+	HeadQuorum int
+	// MaxInflightPerPeer defines the max amount of concurrent range requests to a single peer
+	// across all the ranges requested in parallel. Requests exceeding it are routed to other peers
+	// or wait for the busy peer. Interactive requests for single headers and heads wait for
+	// the busy peer ahead of range requests, so they are not starved by the catch-up sync.
+	// Zero disables the limit.
+	MaxInflightPerPeer int
+	// CircuitBreakerThreshold defines the amount of requests a peer can fail in a row before
+	// the client stops routing requests to it for the CircuitBreakerCooldown. Unlike blocking,
+	// the peer stays tracked and gets a probe request once the cooldown passes.
+	// Zero disables the circuit breaker.
+	CircuitBreakerThreshold int
+	// CircuitBreakerCooldown defines for how long requests are not routed to a peer
+	// with the open circuit breaker.
+	CircuitBreakerCooldown time.Duration
+	// CacheSize defines the amount of recently fetched headers cached by the client, so
+	// repeated requests for them from different subsystems do not hit the network.
+	// Zero disables the cache.
+	CacheSize int
+	// CacheTTL defines how long cached headers are served. Zero means they are served
+	// until evicted by newer ones.
+	CacheTTL time.Duration
+	// NotFoundCacheTTL defines how long heights above the network head, which multiple peers
+	// reported not to have, are answered with ErrNotFound without hitting the network.
+	// A single peer reporting a height missing is not enough, whatever the amount of trusted peers.
+	// Zero disables caching of missing heights.
+	NotFoundCacheTTL time.Duration
+	// MaxMessageSize defines the max size of a single response message in bytes.
+	// Peers responding with larger messages or more headers than requested get their score lowered.
+	MaxMessageSize uint64
 	// networkID is a network that will be used to create a protocol.ID
 	networkID string
 	// chainID is an identifier of the chain.
 	chainID string
+	// seed initializes the pseudo-random source used for all randomized choices,
+	// such as the order of trusted peers and peer selection within a session.
+	// Zero keeps the default, securely seeded source.
+	seed int64
+	// verifyHeadSignature makes the client accept only head responses
+	// signed by the serving peer.
+	verifyHeadSignature bool
+	// metrics enables Otel metrics of the client requests per peer.
+	metrics bool
+	// compression is the codec the client accepts response bodies to be compressed with.
+	compression Compression
+	// onBlockedPeer is called every time the client blocks a peer
+	// along with the reason the peer was blocked for.
+	onBlockedPeer func(peer.ID, error)
+	// peerAllowlist, if set, restricts the peers the client tracks and sends requests to
+	// to the given ones plus the trusted peers.
+	peerAllowlist []peer.ID
+	// peerIDStore, if set, persists tracked peers for peerRecordTTL,
+	// so they are reused after restarts.
+	peerIDStore   PeerIDStore
+	peerRecordTTL time.Duration
+	// backoff returns the delay before the given retry attempt over the trusted peers.
+	backoff func(attempt int) time.Duration
+	// proofVerifier is the ProofVerifier verifying the received headers with their proofs.
+	proofVerifier any
+	// headAttestationHandler is the HeadAttestationHandler handling the attestations
+	// of the received heads.
+	headAttestationHandler any
+	// headFallback is the amount of top tracked peers Head falls back to
+	// if all the trusted peers fail. Zero disables the fallback.
+	headFallback int
+	// peerTracker is an externally managed PeerTracker shared with other protocols.
+	peerTracker *PeerTracker
+	// transport, if set, opens the streams requests are sent over instead of the host.
+	transport Transport
+	// belowTrust allows requesting Headers below the trusted root with GetBelowTrust.
+	belowTrust bool
+	// scheduler, if set, splits the requested ranges and assigns them to peers
+	// instead of the default sequential strategy.
+	scheduler Scheduler
 }
 
 // DefaultClientParameters returns the default params to configure the store.
@@ -126,6 +533,10 @@ func DefaultClientParameters() ClientParameters {
 	return ClientParameters{
 		MaxHeadersPerRangeRequest: 64,
 		RangeRequestTimeout:       time.Second * 8,
+		PeerGCBatchSize:           defaultGCBatchSize,
+		MaxRetries:                3,
+		NotFoundRetries:           3,
+		MaxMessageSize:            serde.MaxMessageSize,
 	}
 }
 
@@ -143,7 +554,54 @@ func (p *ClientParameters) Validate() error {
 		return fmt.Errorf("invalid request timeout for session: "+
 			"%s. %s: %v", greaterThenZero, providedSuffix, p.RangeRequestTimeout)
 	}
-	return nil
+	if p.MaxRetries <= 0 {
+		return fmt.Errorf("invalid MaxRetries: %s. %s: %v",
+			greaterThenZero, providedSuffix, p.MaxRetries)
+	}
+	if p.NotFoundRetries < 0 {
+		return fmt.Errorf("invalid NotFoundRetries: should not be negative. %s: %v",
+			providedSuffix, p.NotFoundRetries)
+	}
+	if p.MaxParallelRequests < 0 {
+		return fmt.Errorf("invalid MaxParallelRequests: should not be negative. %s: %v",
+			providedSuffix, p.MaxParallelRequests)
+	}
+	if p.HeadQuorum < 0 {
+		return fmt.Errorf("invalid HeadQuorum: should not be negative. %s: %v",
+			providedSuffix, p.HeadQuorum)
+	}
+	if p.MaxInflightPerPeer < 0 {
+		return fmt.Errorf("invalid MaxInflightPerPeer: should not be negative. %s: %v",
+			providedSuffix, p.MaxInflightPerPeer)
+	}
+	if p.CircuitBreakerThreshold < 0 {
+		return fmt.Errorf("invalid CircuitBreakerThreshold: should not be negative. %s: %v",
+			providedSuffix, p.CircuitBreakerThreshold)
+	}
+	if p.CircuitBreakerThreshold > 0 && p.CircuitBreakerCooldown <= 0 {
+		return fmt.Errorf("invalid CircuitBreakerCooldown: %s. %s: %v",
+			greaterThenZero, providedSuffix, p.CircuitBreakerCooldown)
+	}
+	if p.CacheSize < 0 {
+		return fmt.Errorf("invalid CacheSize: should not be negative. %s: %v",
+			providedSuffix, p.CacheSize)
+	}
+	if p.NotFoundCacheTTL < 0 {
+		return fmt.Errorf("invalid NotFoundCacheTTL: should not be negative. %s: %v",
+			providedSuffix, p.NotFoundCacheTTL)
+	}
+	if p.PeerGCBatchSize <= 0 {
+		return fmt.Errorf("invalid PeerGCBatchSize: %s. %s: %v",
+			greaterThenZero, providedSuffix, p.PeerGCBatchSize)
+	}
+	if p.peerIDStore != nil && p.peerRecordTTL <= 0 {
+		return fmt.Errorf("invalid peer record ttl: %s. %s: %v",
+			greaterThenZero, providedSuffix, p.peerRecordTTL)
+	}
+	if err := validateMaxMessageSize(p.MaxMessageSize); err != nil {
+		return err
+	}
+	return validateCompression(p.compression)
 }
 
 // WithMaxHeadersPerRangeRequest is a functional option that configures the
@@ -158,6 +616,178 @@ func WithMaxHeadersPerRangeRequest[T ClientParameters](amount uint64) Option[T]
 	}
 }
 
+// WithRequestTimeout is a functional option that configures the
+// `RequestTimeout` parameter.
+func WithRequestTimeout[T ClientParameters](timeout time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.RequestTimeout = timeout
+		}
+	}
+}
+
+// WithHeadRequestTimeout is a functional option that configures the
+// `HeadRequestTimeout` parameter.
+func WithHeadRequestTimeout[T ClientParameters](timeout time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.HeadRequestTimeout = timeout
+		}
+	}
+}
+
+// WithMaxRetries is a functional option that configures the
+// `MaxRetries` parameter.
+func WithMaxRetries[T ClientParameters](retries int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.MaxRetries = retries
+		}
+	}
+}
+
+// WithNotFoundRetries is a functional option that configures the
+// `NotFoundRetries` parameter.
+func WithNotFoundRetries[T ClientParameters](retries int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.NotFoundRetries = retries
+		}
+	}
+}
+
+// WithBackoff is a functional option that configures the
+// `backoff` policy applied between retries over the trusted peers.
+// See ExponentialBackoff for the default implementation.
+func WithBackoff[T ClientParameters](backoff func(attempt int) time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.backoff = backoff
+		}
+	}
+}
+
+// ExponentialBackoff returns a backoff policy doubling the given base delay
+// with each attempt, up to the given max delay.
+func ExponentialBackoff(base, maxDelay time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		delay := base
+		for i := 1; i < attempt && delay < maxDelay; i++ {
+			delay *= 2
+		}
+		if delay > maxDelay {
+			return maxDelay
+		}
+		return delay
+	}
+}
+
+// WithPeerProbeInterval is a functional option that configures the
+// `PeerProbeInterval` parameter.
+func WithPeerProbeInterval[T ClientParameters](interval time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.PeerProbeInterval = interval
+		}
+	}
+}
+
+// WithPeerGCBatchSize is a functional option that configures the
+// `PeerGCBatchSize` parameter.
+func WithPeerGCBatchSize[T ClientParameters](size int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.PeerGCBatchSize = size
+		}
+	}
+}
+
+// WithBackfillBandwidth is a functional option that configures the
+// `BackfillBandwidth` parameter.
+func WithBackfillBandwidth[T ClientParameters](bytesPerSecond uint64) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.BackfillBandwidth = bytesPerSecond
+		}
+	}
+}
+
+// WithMaxParallelRequests is a functional option that configures the
+// `MaxParallelRequests` parameter.
+func WithMaxParallelRequests[T ClientParameters](limit int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.MaxParallelRequests = limit
+		}
+	}
+}
+
+// WithHeadQuorum is a functional option that configures the
+// `HeadQuorum` parameter.
+func WithHeadQuorum[T ClientParameters](quorum int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.HeadQuorum = quorum
+		}
+	}
+}
+
+// WithMaxInflightPerPeer is a functional option that configures the
+// `MaxInflightPerPeer` parameter.
+func WithMaxInflightPerPeer[T ClientParameters](limit int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.MaxInflightPerPeer = limit
+		}
+	}
+}
+
+// WithCircuitBreaker is a functional option that configures the
+// `CircuitBreakerThreshold` and `CircuitBreakerCooldown` parameters.
+func WithCircuitBreaker[T ClientParameters](threshold int, cooldown time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.CircuitBreakerThreshold = threshold
+			t.CircuitBreakerCooldown = cooldown
+		}
+	}
+}
+
+// WithCache is a functional option that configures the
+// `CacheSize` and `CacheTTL` parameters.
+func WithCache[T ClientParameters](size int, ttl time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.CacheSize = size
+			t.CacheTTL = ttl
+		}
+	}
+}
+
+// WithNotFoundCache is a functional option that configures the
+// `NotFoundCacheTTL` parameter.
+func WithNotFoundCache[T ClientParameters](ttl time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.NotFoundCacheTTL = ttl
+		}
+	}
+}
+
 // WithChainID is a functional option that configures the
 // `chainID` parameter.
 func WithChainID[T ClientParameters](chainID string) Option[T] {
@@ -168,3 +798,145 @@ func WithChainID[T ClientParameters](chainID string) Option[T] {
 		}
 	}
 }
+
+// WithSeed is a functional option that configures the
+// `seed` parameter. It makes peer selection reproducible and
+// is intended for integration tests and simulations only.
+func WithSeed[T ClientParameters](seed int64) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.seed = seed
+		}
+	}
+}
+
+// WithHeadSignatureVerification is a functional option that configures the
+// `verifyHeadSignature` parameter.
+func WithHeadSignatureVerification[T ClientParameters](enabled bool) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.verifyHeadSignature = enabled
+		}
+	}
+}
+
+// WithMetrics is a functional option that enables
+// Otel metrics of the client requests, such as latency, received bytes,
+// retries and failures per peer, or of the requests served by the server,
+// such as requests per type, status and peer and sent bytes.
+func WithMetrics[T parameters]() Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) {
+		case *ClientParameters:
+			t.metrics = true
+		case *ServerParameters:
+			t.metrics = true
+		}
+	}
+}
+
+// WithOnBlockedPeer is a functional option that configures the
+// `onBlockedPeer` callback. It allows applications to propagate peer bans
+// into their own reputation systems. Peers blocked for invalid responses are reported
+// with the InvalidResponseError describing the request and the validation error.
+// The callback must not block.
+func WithOnBlockedPeer[T ClientParameters](onBlocked func(peer.ID, error)) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.onBlockedPeer = onBlocked
+		}
+	}
+}
+
+// WithPeerAllowlist is a functional option that configures the
+// `peerAllowlist` parameter. It enables the allowlist-only mode, where
+// only the given peers and the trusted peers are tracked and requested,
+// making request targets deterministic in private networks.
+func WithPeerAllowlist[T ClientParameters](peers ...peer.ID) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.peerAllowlist = append(make([]peer.ID, 0, len(peers)), peers...)
+		}
+	}
+}
+
+// WithPeerIDStore is a functional option that configures the
+// `peerIDStore` and `peerRecordTTL` parameters. Tracked peers are persisted
+// in the given store and stay valid for the given ttl after they were last seen.
+func WithPeerIDStore[T ClientParameters](store PeerIDStore, ttl time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.peerIDStore = store
+			t.peerRecordTTL = ttl
+		}
+	}
+}
+
+// WithHeadFallback is a functional option that configures the
+// `headFallback` parameter. If all the trusted peers fail to respond with a valid head,
+// Head requests it from the given amount of tracked peers with the best head score instead
+// and returns the highest head at least two of them agree on, once it is verified against
+// the latest head received from the trusted peers. Before any head is received from them,
+// the head is returned unverified, so it must be verified against the subjective head,
+// while heads not above the latest trusted one are replaced with it, even though it is stale.
+func WithHeadFallback[T ClientParameters](peers int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.headFallback = peers
+		}
+	}
+}
+
+// WithPeerTracker is a functional option that makes the client use the given PeerTracker,
+// e.g. to share it with other protocols. The client does not start or stop it, so
+// its lifecycle must be managed by the caller. Tracker related options are ignored in this case.
+func WithPeerTracker[T ClientParameters](tracker *PeerTracker) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.peerTracker = tracker
+		}
+	}
+}
+
+// WithTransport is a functional option that configures the
+// `transport` parameter. The client and its peer tracker send requests over
+// the streams opened by the given Transport instead of the libp2p host.
+func WithTransport[T ClientParameters](transport Transport) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.transport = transport
+		}
+	}
+}
+
+// WithScheduler is a functional option that configures the
+// `scheduler` parameter, controlling how the requested ranges are split
+// into requests to single peers, ordered and assigned to peers.
+func WithScheduler[T ClientParameters](scheduler Scheduler) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.scheduler = scheduler
+		}
+	}
+}
+
+// WithBelowTrustRequests is a functional option that configures the
+// `belowTrust` parameter, allowing to request Headers older than the trusted root
+// with GetBelowTrust.
+func WithBelowTrustRequests[T ClientParameters](enabled bool) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.belowTrust = enabled
+		}
+	}
+}
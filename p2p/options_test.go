@@ -1,12 +1,21 @@
 package p2p
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header/headertest"
 )
 
+// otherHeader is a header type the options are not configured for.
+type otherHeader struct {
+	headertest.DummyHeader
+}
+
 func TestOptionsClientWithParams(t *testing.T) {
 	params := DefaultClientParameters()
 
@@ -30,3 +39,28 @@ func TestOptionsServerWithParams(t *testing.T) {
 	opt(&params)
 	assert.Equal(t, timeout, params.RangeRequestTimeout)
 }
+
+func TestTypedOption(t *testing.T) {
+	params := DefaultServerParameters()
+	WithProofProvider[ServerParameters](ProofProvider[*headertest.DummyHeader](
+		func(context.Context, *headertest.DummyHeader) ([]byte, error) { return nil, nil },
+	))(&params)
+
+	provider, err := typedOption[ProofProvider[*headertest.DummyHeader]]("proof provider", params.proofProvider)
+	require.NoError(t, err)
+	assert.NotNil(t, provider)
+	_, err = typedOption[ProofProvider[*otherHeader]]("proof provider", params.proofProvider)
+	assert.Error(t, err)
+	unset, err := typedOption[HeadAttestor[*headertest.DummyHeader]]("head attestor", params.headAttestor)
+	require.NoError(t, err)
+	assert.Nil(t, unset)
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(time.Millisecond*100, time.Second)
+	assert.Equal(t, time.Millisecond*100, backoff(1))
+	assert.Equal(t, time.Millisecond*200, backoff(2))
+	assert.Equal(t, time.Millisecond*800, backoff(4))
+	assert.Equal(t, time.Second, backoff(5))
+	assert.Equal(t, time.Second, backoff(100))
+}
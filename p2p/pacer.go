@@ -0,0 +1,89 @@
+package p2p
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultHeaderSize is the size of headers the pacer assumes until it receives any.
+const defaultHeaderSize = 1 << 10
+
+// pacer paces requests, so the amount of received bytes does not exceed
+// the given bandwidth on average.
+// The bandwidth is reserved for the expected responses before the requests are sent,
+// so concurrent requests do not burst above it.
+// A nil pacer does not limit anything.
+type pacer struct {
+	lk sync.Mutex
+	// bandwidth is the amount of bytes allowed per second.
+	bandwidth uint64
+	// next is the time after which the next request can be sent,
+	// accounting the bandwidth reserved by the requests in flight.
+	next time.Time
+	// headerSize is the average size of the received headers,
+	// by which the size of the expected responses is estimated.
+	headerSize uint64
+}
+
+// newPacer creates a new pacer for the given bandwidth in bytes per second.
+// Zero bandwidth returns nil, meaning no limits.
+func newPacer(bandwidth uint64) *pacer {
+	if bandwidth == 0 {
+		return nil
+	}
+	return &pacer{bandwidth: bandwidth, headerSize: defaultHeaderSize}
+}
+
+// wait reserves the bandwidth for a response with the given amount of headers and blocks
+// until the bandwidth reserved before is used up. It returns the amount of reserved bytes,
+// which must be settled with consume once the response is received or the request is dropped.
+// The reservation is released if the context is done before.
+func (p *pacer) wait(ctx context.Context, headers uint64) (uint64, error) {
+	if p == nil {
+		return 0, nil
+	}
+
+	p.lk.Lock()
+	reserved := headers * p.headerSize
+	now := time.Now()
+	if p.next.Before(now) {
+		p.next = now
+	}
+	delay := p.next.Sub(now)
+	p.next = p.next.Add(p.duration(reserved))
+	p.lk.Unlock()
+	if delay <= 0 {
+		return reserved, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return reserved, nil
+	case <-ctx.Done():
+		p.consume(reserved, 0, 0)
+		return 0, ctx.Err()
+	}
+}
+
+// consume settles the bandwidth reserved by wait with the received bytes and headers,
+// delaying or advancing the next request accordingly.
+func (p *pacer) consume(reserved, size, headers uint64) {
+	if p == nil {
+		return
+	}
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	if headers > 0 {
+		p.headerSize = (p.headerSize + size/headers) / 2
+	}
+	p.next = p.next.Add(p.duration(size) - p.duration(reserved))
+}
+
+// duration returns the time it takes to receive the given amount of bytes within the bandwidth.
+func (p *pacer) duration(size uint64) time.Duration {
+	return time.Duration(size) * time.Second / time.Duration(p.bandwidth)
+}
@@ -0,0 +1,62 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	// nil pacer does not limit anything
+	require.Nil(t, newPacer(0))
+	_, err := (*pacer)(nil).wait(ctx, 1)
+	require.NoError(t, err)
+
+	p := newPacer(1000)
+	reserved, err := p.wait(ctx, 1)
+	require.NoError(t, err)
+	require.EqualValues(t, defaultHeaderSize, reserved)
+
+	// 100 bytes at 1000 bytes/sec delay the next request by 100ms
+	p.consume(reserved, 100, 1)
+	start := time.Now()
+	_, err = p.wait(ctx, 0)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), time.Millisecond*90)
+
+	// the pacer respects the context
+	p.consume(0, 10000, 0)
+	waitCtx, waitCancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer waitCancel()
+	_, err = p.wait(waitCtx, 0)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestPacer_BoundsBursts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	// the first request reserves a second of the bandwidth
+	p := newPacer(defaultHeaderSize * 10)
+	reserved, err := p.wait(ctx, 10)
+	require.NoError(t, err)
+
+	// so the concurrent one waits for it, even though nothing is received yet
+	waitCtx, waitCancel := context.WithTimeout(ctx, time.Millisecond*50)
+	defer waitCancel()
+	_, err = p.wait(waitCtx, 10)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the request dropped before being sent releases its reservation,
+	// while the smaller response settles the reservation of the first one
+	p.consume(reserved, defaultHeaderSize, 10)
+	start := time.Now()
+	_, err = p.wait(ctx, 0)
+	require.NoError(t, err)
+	require.Less(t, time.Since(start), time.Millisecond*200)
+}
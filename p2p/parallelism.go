@@ -0,0 +1,158 @@
+package p2p
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// parallelism limits the amount of concurrent range requests, adapting the limit to the measured
+// throughput similarly to the congestion window of TCP. The limit grows while the throughput of
+// the requests keeps improving, doubling it every round until the first timeout and adding a single
+// request per round afterwards, and is halved on timeouts.
+// A nil parallelism does not limit anything.
+type parallelism struct {
+	lk sync.Mutex
+	// max is the upper bound of the limit.
+	max int
+	// limit is the current amount of concurrent requests allowed.
+	limit int
+	// threshold is the limit after which it grows additively instead of doubling.
+	threshold int
+	// inflight is the amount of requests currently in flight.
+	inflight int
+	// released is closed once a slot is released.
+	released chan struct{}
+
+	// roundStart is the start of the current measurement round, which lasts until
+	// as many requests as the limit allows are completed.
+	roundStart time.Time
+	// roundBytes and roundDone are the amount of bytes received and requests completed
+	// within the current round.
+	roundBytes uint64
+	roundDone  int
+	// throughput is the throughput of the previous round in bytes per millisecond.
+	throughput float64
+}
+
+// newParallelism creates a new parallelism limiting the amount of concurrent requests
+// to the given max. Zero max returns nil, meaning no limits.
+func newParallelism(max int) *parallelism {
+	if max == 0 {
+		return nil
+	}
+	return &parallelism{max: max, limit: 1, threshold: max}
+}
+
+// acquire blocks until a slot for a request is available and reserves it.
+func (p *parallelism) acquire(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	for p.inflight >= p.limit {
+		if p.released == nil {
+			p.released = make(chan struct{})
+		}
+		released := p.released
+		p.lk.Unlock()
+		select {
+		case <-released:
+		case <-ctx.Done():
+			p.lk.Lock()
+			return ctx.Err()
+		}
+		p.lk.Lock()
+	}
+	if p.roundStart.IsZero() {
+		p.roundStart = time.Now()
+	}
+	p.inflight++
+	return nil
+}
+
+// release frees the slot reserved by acquire and adapts the limit to the outcome of the request:
+// the amount of bytes received and whether the request timed out.
+func (p *parallelism) release(size uint64, timedOut bool) {
+	if p == nil {
+		return
+	}
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	p.free()
+
+	if timedOut {
+		// the peers or the link are overloaded, so back off right away
+		p.threshold = p.limit / 2
+		if p.threshold < 1 {
+			p.threshold = 1
+		}
+		p.limit = p.threshold
+		p.resetRound()
+		return
+	}
+
+	p.roundBytes += size
+	p.roundDone++
+	if p.roundDone < p.limit {
+		return
+	}
+
+	elapsed := time.Since(p.roundStart).Milliseconds()
+	if elapsed == 0 {
+		elapsed = 1
+	}
+	throughput := float64(p.roundBytes) / float64(elapsed)
+	if throughput > p.throughput {
+		// more requests in parallel still pay off
+		if p.limit < p.threshold {
+			p.limit *= 2
+		} else {
+			p.limit++
+		}
+		if p.limit > p.max {
+			p.limit = p.max
+		}
+	}
+	p.throughput = throughput
+	p.resetRound()
+}
+
+// cancel frees the slot reserved by acquire for a request that was not sent.
+func (p *parallelism) cancel() {
+	if p == nil {
+		return
+	}
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	p.free()
+}
+
+// free frees a reserved slot, signaling the waiting requests.
+func (p *parallelism) free() {
+	p.inflight--
+	if p.released != nil {
+		close(p.released)
+		p.released = nil
+	}
+}
+
+// current returns the current limit of concurrent requests.
+func (p *parallelism) current() int {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	return p.limit
+}
+
+// resetRound starts a new measurement round.
+func (p *parallelism) resetRound() {
+	p.roundBytes, p.roundDone = 0, 0
+	p.roundStart = time.Time{}
+	if p.inflight > 0 {
+		p.roundStart = time.Now()
+	}
+}
@@ -0,0 +1,58 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallelism(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	// nil parallelism does not limit anything
+	require.Nil(t, newParallelism(0))
+	require.NoError(t, (*parallelism)(nil).acquire(ctx))
+
+	p := newParallelism(5)
+	require.Equal(t, 1, p.current())
+	require.NoError(t, p.acquire(ctx))
+
+	// the limit is reached, so the next request waits
+	waitCtx, waitCancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer waitCancel()
+	require.ErrorIs(t, p.acquire(waitCtx), context.DeadlineExceeded)
+
+	// the limit doubles while the throughput improves
+	// a round completes as many requests as the limit allows, holding a single slot in between
+	round := func(size uint64) {
+		limit := p.current()
+		for i := 1; i < limit; i++ {
+			require.NoError(t, p.acquire(ctx))
+		}
+		for i := 0; i < limit; i++ {
+			p.release(size, false)
+		}
+		require.NoError(t, p.acquire(ctx))
+	}
+	round(1000)
+	require.Equal(t, 2, p.current())
+	round(1000000)
+	require.Equal(t, 4, p.current())
+	// but never exceeds the max
+	round(100000000)
+	require.Equal(t, 5, p.current())
+
+	// timeouts halve the limit, which then grows additively
+	p.release(0, true)
+	require.Equal(t, 2, p.current())
+	require.NoError(t, p.acquire(ctx))
+	round(1000000000)
+	require.Equal(t, 3, p.current())
+
+	// the limit does not grow if the throughput does not improve
+	round(1)
+	require.Equal(t, 3, p.current())
+}
@@ -22,24 +22,94 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
+type Priority int32
+
+const (
+	Priority_LOW  Priority = 0
+	Priority_HIGH Priority = 1
+)
+
+var Priority_name = map[int32]string{
+	0: "LOW",
+	1: "HIGH",
+}
+
+var Priority_value = map[string]int32{
+	"LOW":  0,
+	"HIGH": 1,
+}
+
+func (x Priority) String() string {
+	return proto.EnumName(Priority_name, int32(x))
+}
+
+func (Priority) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_43554822dc0b0806, []int{0}
+}
+
+type Compression int32
+
+const (
+	Compression_NONE   Compression = 0
+	Compression_ZSTD   Compression = 1
+	Compression_SNAPPY Compression = 2
+)
+
+var Compression_name = map[int32]string{
+	0: "NONE",
+	1: "ZSTD",
+	2: "SNAPPY",
+}
+
+var Compression_value = map[string]int32{
+	"NONE":   0,
+	"ZSTD":   1,
+	"SNAPPY": 2,
+}
+
+func (x Compression) String() string {
+	return proto.EnumName(Compression_name, int32(x))
+}
+
+func (Compression) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_43554822dc0b0806, []int{1}
+}
+
 type StatusCode int32
 
 const (
 	StatusCode_INVALID   StatusCode = 0
 	StatusCode_OK        StatusCode = 1
 	StatusCode_NOT_FOUND StatusCode = 2
+	// the peer exceeded the rate limits of the server and should retry after retryAfter
+	StatusCode_RATE_LIMITED StatusCode = 3
+	// the requested headers are below the window of recent headers served by the server,
+	// which advertises the lowest height it serves in the tail
+	StatusCode_PRUNED StatusCode = 4
+	// the request is malformed or exceeds the limits of the server
+	StatusCode_INVALID_REQUEST StatusCode = 5
+	// the server failed to serve the request because of an internal error, e.g. of its store
+	StatusCode_INTERNAL_ERROR StatusCode = 6
 )
 
 var StatusCode_name = map[int32]string{
 	0: "INVALID",
 	1: "OK",
 	2: "NOT_FOUND",
+	3: "RATE_LIMITED",
+	4: "PRUNED",
+	5: "INVALID_REQUEST",
+	6: "INTERNAL_ERROR",
 }
 
 var StatusCode_value = map[string]int32{
-	"INVALID":   0,
-	"OK":        1,
-	"NOT_FOUND": 2,
+	"INVALID":         0,
+	"OK":              1,
+	"NOT_FOUND":       2,
+	"RATE_LIMITED":    3,
+	"PRUNED":          4,
+	"INVALID_REQUEST": 5,
+	"INTERNAL_ERROR":  6,
 }
 
 func (x StatusCode) String() string {
@@ -47,15 +117,61 @@ func (x StatusCode) String() string {
 }
 
 func (StatusCode) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_43554822dc0b0806, []int{0}
+	return fileDescriptor_43554822dc0b0806, []int{2}
+}
+
+type Feature int32
+
+const (
+	Feature_FEATURE_NONE              Feature = 0
+	Feature_FEATURE_HEAD_SUBSCRIPTION Feature = 1
+	Feature_FEATURE_HEAD_SIGNATURE    Feature = 2
+	Feature_FEATURE_PROOFS            Feature = 3
+	Feature_FEATURE_HEAD_ATTESTATION  Feature = 4
+	Feature_FEATURE_COMPRESSION       Feature = 5
+)
+
+var Feature_name = map[int32]string{
+	0: "FEATURE_NONE",
+	1: "FEATURE_HEAD_SUBSCRIPTION",
+	2: "FEATURE_HEAD_SIGNATURE",
+	3: "FEATURE_PROOFS",
+	4: "FEATURE_HEAD_ATTESTATION",
+	5: "FEATURE_COMPRESSION",
+}
+
+var Feature_value = map[string]int32{
+	"FEATURE_NONE":              0,
+	"FEATURE_HEAD_SUBSCRIPTION": 1,
+	"FEATURE_HEAD_SIGNATURE":    2,
+	"FEATURE_PROOFS":            3,
+	"FEATURE_HEAD_ATTESTATION":  4,
+	"FEATURE_COMPRESSION":       5,
+}
+
+func (x Feature) String() string {
+	return proto.EnumName(Feature_name, int32(x))
+}
+
+func (Feature) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_43554822dc0b0806, []int{3}
 }
 
 type HeaderRequest struct {
 	// Types that are valid to be assigned to Data:
 	//	*HeaderRequest_Origin
 	//	*HeaderRequest_Hash
+	//	*HeaderRequest_Hashes
 	Data   isHeaderRequest_Data `protobuf_oneof:"data"`
 	Amount uint64               `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	// codec the client accepts the responses to be compressed with
+	Compression Compression `protobuf:"varint,5,opt,name=compression,proto3,enum=p2p.pb.Compression" json:"compression,omitempty"`
+	// requests the range of amount headers ending at the origin in descending order
+	Descending bool `protobuf:"varint,6,opt,name=descending,proto3" json:"descending,omitempty"`
+	// keeps the stream of a head request open to receive new heads as they arrive
+	Subscribe bool `protobuf:"varint,7,opt,name=subscribe,proto3" json:"subscribe,omitempty"`
+	// priority of the request, so interactive requests can be served ahead of bulk ones
+	Priority Priority `protobuf:"varint,8,opt,name=priority,proto3,enum=p2p.pb.Priority" json:"priority,omitempty"`
 }
 
 func (m *HeaderRequest) Reset()         { *m = HeaderRequest{} }
@@ -103,9 +219,13 @@ type HeaderRequest_Origin struct {
 type HeaderRequest_Hash struct {
 	Hash []byte `protobuf:"bytes,2,opt,name=hash,proto3,oneof" json:"hash,omitempty"`
 }
+type HeaderRequest_Hashes struct {
+	Hashes *HashList `protobuf:"bytes,4,opt,name=hashes,proto3,oneof" json:"hashes,omitempty"`
+}
 
 func (*HeaderRequest_Origin) isHeaderRequest_Data() {}
 func (*HeaderRequest_Hash) isHeaderRequest_Data()   {}
+func (*HeaderRequest_Hashes) isHeaderRequest_Data() {}
 
 func (m *HeaderRequest) GetData() isHeaderRequest_Data {
 	if m != nil {
@@ -128,6 +248,13 @@ func (m *HeaderRequest) GetHash() []byte {
 	return nil
 }
 
+func (m *HeaderRequest) GetHashes() *HashList {
+	if x, ok := m.GetData().(*HeaderRequest_Hashes); ok {
+		return x.Hashes
+	}
+	return nil
+}
+
 func (m *HeaderRequest) GetAmount() uint64 {
 	if m != nil {
 		return m.Amount
@@ -135,24 +262,122 @@ func (m *HeaderRequest) GetAmount() uint64 {
 	return 0
 }
 
+func (m *HeaderRequest) GetCompression() Compression {
+	if m != nil {
+		return m.Compression
+	}
+	return Compression_NONE
+}
+
+func (m *HeaderRequest) GetDescending() bool {
+	if m != nil {
+		return m.Descending
+	}
+	return false
+}
+
+func (m *HeaderRequest) GetSubscribe() bool {
+	if m != nil {
+		return m.Subscribe
+	}
+	return false
+}
+
+func (m *HeaderRequest) GetPriority() Priority {
+	if m != nil {
+		return m.Priority
+	}
+	return Priority_LOW
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*HeaderRequest) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
 		(*HeaderRequest_Origin)(nil),
 		(*HeaderRequest_Hash)(nil),
+		(*HeaderRequest_Hashes)(nil),
+	}
+}
+
+// list of hashes of the headers requested in a single round trip
+type HashList struct {
+	Hashes [][]byte `protobuf:"bytes,1,rep,name=hashes,proto3" json:"hashes,omitempty"`
+}
+
+func (m *HashList) Reset()         { *m = HashList{} }
+func (m *HashList) String() string { return proto.CompactTextString(m) }
+func (*HashList) ProtoMessage()    {}
+func (*HashList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_43554822dc0b0806, []int{1}
+}
+func (m *HashList) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *HashList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_HashList.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *HashList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HashList.Merge(m, src)
+}
+func (m *HashList) XXX_Size() int {
+	return m.Size()
+}
+func (m *HashList) XXX_DiscardUnknown() {
+	xxx_messageInfo_HashList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HashList proto.InternalMessageInfo
+
+func (m *HashList) GetHashes() [][]byte {
+	if m != nil {
+		return m.Hashes
 	}
+	return nil
 }
 
 type HeaderResponse struct {
 	Body       []byte     `protobuf:"bytes,1,opt,name=body,proto3" json:"body,omitempty"`
 	StatusCode StatusCode `protobuf:"varint,2,opt,name=statusCode,proto3,enum=p2p.pb.StatusCode" json:"statusCode,omitempty"`
+	// signature over the body made by the serving peer, set for head responses only
+	Signature []byte `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+	// marshaled public key of the serving peer to verify the signature with
+	PublicKey []byte `protobuf:"bytes,4,opt,name=publicKey,proto3" json:"publicKey,omitempty"`
+	// codec the body is compressed with, which then holds all the length-delimited
+	// responses to the request
+	Compression Compression `protobuf:"varint,5,opt,name=compression,proto3,enum=p2p.pb.Compression" json:"compression,omitempty"`
+	// opaque proof attached by the serving peer to verify the header with,
+	// e.g. commit signatures or an inclusion proof
+	Proof []byte `protobuf:"bytes,6,opt,name=proof,proto3" json:"proof,omitempty"`
+	// lowest height retained by the serving peer, set for head and pruned responses only.
+	// zero means the peer does not advertise it
+	Tail uint64 `protobuf:"varint,7,opt,name=tail,proto3" json:"tail,omitempty"`
+	// milliseconds the client should wait before sending the next request, set for
+	// rate limited responses only
+	RetryAfter uint64 `protobuf:"varint,8,opt,name=retryAfter,proto3" json:"retryAfter,omitempty"`
+	// origin of the next page of the requested range, set on the last response of a page
+	// if the server truncated the range to its limits. zero means the range is complete
+	Continuation uint64 `protobuf:"varint,9,opt,name=continuation,proto3" json:"continuation,omitempty"`
+	// opaque attestation of the head by the serving peer, set for head responses only
+	Attestation []byte `protobuf:"bytes,10,opt,name=attestation,proto3" json:"attestation,omitempty"`
+	// limits and features of the serving peer, set for head responses only,
+	// so clients can chunk requests appropriately
+	Limits *ServerLimits `protobuf:"bytes,11,opt,name=limits,proto3" json:"limits,omitempty"`
 }
 
 func (m *HeaderResponse) Reset()         { *m = HeaderResponse{} }
 func (m *HeaderResponse) String() string { return proto.CompactTextString(m) }
 func (*HeaderResponse) ProtoMessage()    {}
 func (*HeaderResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_43554822dc0b0806, []int{1}
+	return fileDescriptor_43554822dc0b0806, []int{2}
 }
 func (m *HeaderResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -195,10 +420,141 @@ func (m *HeaderResponse) GetStatusCode() StatusCode {
 	return StatusCode_INVALID
 }
 
+func (m *HeaderResponse) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *HeaderResponse) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+func (m *HeaderResponse) GetCompression() Compression {
+	if m != nil {
+		return m.Compression
+	}
+	return Compression_NONE
+}
+
+func (m *HeaderResponse) GetProof() []byte {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *HeaderResponse) GetTail() uint64 {
+	if m != nil {
+		return m.Tail
+	}
+	return 0
+}
+
+func (m *HeaderResponse) GetRetryAfter() uint64 {
+	if m != nil {
+		return m.RetryAfter
+	}
+	return 0
+}
+
+func (m *HeaderResponse) GetContinuation() uint64 {
+	if m != nil {
+		return m.Continuation
+	}
+	return 0
+}
+
+func (m *HeaderResponse) GetAttestation() []byte {
+	if m != nil {
+		return m.Attestation
+	}
+	return nil
+}
+
+func (m *HeaderResponse) GetLimits() *ServerLimits {
+	if m != nil {
+		return m.Limits
+	}
+	return nil
+}
+
+type ServerLimits struct {
+	// max amount of headers served per request; larger ranges are served in pages
+	MaxHeadersPerResponse uint64 `protobuf:"varint,1,opt,name=maxHeadersPerResponse,proto3" json:"maxHeadersPerResponse,omitempty"`
+	// max size of a single response message in bytes
+	MaxMessageSize uint64 `protobuf:"varint,2,opt,name=maxMessageSize,proto3" json:"maxMessageSize,omitempty"`
+	// optional features supported by the serving peer
+	Features []Feature `protobuf:"varint,3,rep,packed,name=features,proto3,enum=p2p.pb.Feature" json:"features,omitempty"`
+}
+
+func (m *ServerLimits) Reset()         { *m = ServerLimits{} }
+func (m *ServerLimits) String() string { return proto.CompactTextString(m) }
+func (*ServerLimits) ProtoMessage()    {}
+func (*ServerLimits) Descriptor() ([]byte, []int) {
+	return fileDescriptor_43554822dc0b0806, []int{3}
+}
+func (m *ServerLimits) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ServerLimits) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ServerLimits.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ServerLimits) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ServerLimits.Merge(m, src)
+}
+func (m *ServerLimits) XXX_Size() int {
+	return m.Size()
+}
+func (m *ServerLimits) XXX_DiscardUnknown() {
+	xxx_messageInfo_ServerLimits.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ServerLimits proto.InternalMessageInfo
+
+func (m *ServerLimits) GetMaxHeadersPerResponse() uint64 {
+	if m != nil {
+		return m.MaxHeadersPerResponse
+	}
+	return 0
+}
+
+func (m *ServerLimits) GetMaxMessageSize() uint64 {
+	if m != nil {
+		return m.MaxMessageSize
+	}
+	return 0
+}
+
+func (m *ServerLimits) GetFeatures() []Feature {
+	if m != nil {
+		return m.Features
+	}
+	return nil
+}
+
 func init() {
+	proto.RegisterEnum("p2p.pb.Priority", Priority_name, Priority_value)
+	proto.RegisterEnum("p2p.pb.Compression", Compression_name, Compression_value)
 	proto.RegisterEnum("p2p.pb.StatusCode", StatusCode_name, StatusCode_value)
+	proto.RegisterEnum("p2p.pb.Feature", Feature_name, Feature_value)
 	proto.RegisterType((*HeaderRequest)(nil), "p2p.pb.HeaderRequest")
+	proto.RegisterType((*HashList)(nil), "p2p.pb.HashList")
 	proto.RegisterType((*HeaderResponse)(nil), "p2p.pb.HeaderResponse")
+	proto.RegisterType((*ServerLimits)(nil), "p2p.pb.ServerLimits")
 }
 
 func init() {
@@ -206,24 +562,56 @@ func init() {
 }
 
 var fileDescriptor_43554822dc0b0806 = []byte{
-	// 271 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0x90, 0xcd, 0x4a, 0xf3, 0x40,
-	0x14, 0x86, 0x67, 0xfa, 0x85, 0xf9, 0xf0, 0xd8, 0x96, 0x32, 0x88, 0x64, 0x35, 0x94, 0x6e, 0x2c,
-	0x2e, 0x12, 0x89, 0x57, 0x60, 0x2d, 0x92, 0xa2, 0x24, 0x30, 0xfe, 0xe0, 0x2e, 0x4c, 0xc8, 0x60,
-	0x02, 0x9a, 0x19, 0x33, 0x93, 0x85, 0x77, 0xe1, 0x65, 0xb9, 0xec, 0xd2, 0xa5, 0x24, 0x37, 0x22,
-	0x4e, 0xe3, 0xcf, 0xee, 0x3c, 0x3c, 0x2f, 0xe7, 0x3d, 0x1c, 0x38, 0x7a, 0xac, 0x72, 0x13, 0x96,
-	0x52, 0x14, 0xb2, 0x09, 0x75, 0xa4, 0x43, 0x9d, 0x0f, 0x94, 0x35, 0xf2, 0xb9, 0x95, 0xc6, 0x06,
-	0xba, 0x51, 0x56, 0x51, 0xa2, 0x23, 0x1d, 0xe8, 0x7c, 0x91, 0xc1, 0x24, 0x76, 0x9e, 0xef, 0x34,
-	0xf5, 0x81, 0xa8, 0xa6, 0x7a, 0xa8, 0x6a, 0x1f, 0xcf, 0xf1, 0xd2, 0x8b, 0x11, 0x1f, 0x98, 0x1e,
-	0x80, 0x57, 0x0a, 0x53, 0xfa, 0xa3, 0x39, 0x5e, 0x8e, 0x63, 0xc4, 0x1d, 0xd1, 0x43, 0x20, 0xe2,
-	0x49, 0xb5, 0xb5, 0xf5, 0xff, 0x7d, 0xe5, 0xf9, 0x40, 0x2b, 0x02, 0x5e, 0x21, 0xac, 0x58, 0xdc,
-	0xc3, 0xf4, 0xbb, 0xc0, 0x68, 0x55, 0x1b, 0x49, 0x29, 0x78, 0xb9, 0x2a, 0x5e, 0xdc, 0xfe, 0x31,
-	0x77, 0x33, 0x8d, 0x00, 0x8c, 0x15, 0xb6, 0x35, 0xe7, 0xaa, 0x90, 0xae, 0x61, 0x1a, 0xd1, 0x60,
-	0x77, 0x63, 0x70, 0xfd, 0x63, 0xf8, 0x9f, 0xd4, 0xf1, 0x09, 0xc0, 0xaf, 0xa1, 0xfb, 0xf0, 0x7f,
-	0x93, 0xdc, 0x9d, 0x5d, 0x6d, 0xd6, 0x33, 0x44, 0x09, 0x8c, 0xd2, 0xcb, 0x19, 0xa6, 0x13, 0xd8,
-	0x4b, 0xd2, 0x9b, 0xec, 0x22, 0xbd, 0x4d, 0xd6, 0xb3, 0xd1, 0xca, 0x7f, 0xeb, 0x18, 0xde, 0x76,
-	0x0c, 0x7f, 0x74, 0x0c, 0xbf, 0xf6, 0x0c, 0x6d, 0x7b, 0x86, 0xde, 0x7b, 0x86, 0x72, 0xe2, 0xbe,
-	0x72, 0xfa, 0x19, 0x00, 0x00, 0xff, 0xff, 0x04, 0xdd, 0x70, 0x54, 0x40, 0x01, 0x00, 0x00,
+	// 776 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x54, 0xc1, 0x6e, 0xe3, 0x54,
+	0x14, 0x8d, 0x13, 0xd7, 0x4d, 0x6f, 0x3c, 0x99, 0xa7, 0xdb, 0x32, 0x18, 0x34, 0x13, 0x45, 0x59,
+	0x40, 0x14, 0x86, 0x56, 0x0a, 0xf0, 0x01, 0x6e, 0xe3, 0x4e, 0xac, 0x49, 0xed, 0xf0, 0xec, 0x80,
+	0x60, 0x13, 0x39, 0xc9, 0x6b, 0xfb, 0xa4, 0xc6, 0x36, 0x7e, 0x2f, 0x30, 0xe5, 0x0f, 0xd8, 0xb1,
+	0x65, 0xc9, 0x0f, 0xf0, 0x1d, 0x2c, 0x67, 0xc9, 0x12, 0xb5, 0x3f, 0x82, 0xfc, 0x6c, 0x27, 0x29,
+	0x62, 0xc5, 0x2a, 0xbe, 0xe7, 0x1c, 0x9d, 0x77, 0xef, 0xb9, 0x57, 0x81, 0x4f, 0xef, 0xf8, 0x42,
+	0x9c, 0xdd, 0xb2, 0x68, 0xc5, 0xb2, 0xb3, 0x74, 0x98, 0x9e, 0xa5, 0x8b, 0xb2, 0x9a, 0x67, 0xec,
+	0x87, 0x0d, 0x13, 0xf2, 0x34, 0xcd, 0x12, 0x99, 0xa0, 0x91, 0x0e, 0xd3, 0xd3, 0x74, 0xd1, 0xfb,
+	0xa3, 0x0e, 0xcf, 0xc6, 0x4a, 0x40, 0x0b, 0x1e, 0x2d, 0x30, 0x92, 0x8c, 0xdf, 0xf0, 0xd8, 0xd2,
+	0xba, 0x5a, 0x5f, 0x1f, 0xd7, 0x68, 0x59, 0xe3, 0x09, 0xe8, 0xb7, 0x91, 0xb8, 0xb5, 0xea, 0x5d,
+	0xad, 0x6f, 0x8e, 0x6b, 0x54, 0x55, 0x38, 0x00, 0x23, 0xff, 0x65, 0xc2, 0xd2, 0xbb, 0x5a, 0xbf,
+	0x35, 0x24, 0xa7, 0x85, 0xf5, 0xe9, 0x38, 0x12, 0xb7, 0x13, 0x2e, 0x64, 0xee, 0x50, 0x28, 0xf0,
+	0x05, 0x18, 0xd1, 0x3a, 0xd9, 0xc4, 0xd2, 0x6a, 0xe4, 0xde, 0xb4, 0xac, 0xf0, 0x2b, 0x68, 0x2d,
+	0x93, 0x75, 0x9a, 0x31, 0x21, 0x78, 0x12, 0x5b, 0x07, 0x5d, 0xad, 0xdf, 0x1e, 0x1e, 0x57, 0x46,
+	0x17, 0x3b, 0x8a, 0xee, 0xeb, 0xb0, 0x03, 0xb0, 0x62, 0x62, 0xc9, 0xe2, 0x15, 0x8f, 0x6f, 0x2c,
+	0xa3, 0xab, 0xf5, 0x9b, 0x74, 0x0f, 0xc1, 0x97, 0x70, 0x24, 0x36, 0x0b, 0xb1, 0xcc, 0xf8, 0x82,
+	0x59, 0x87, 0x8a, 0xde, 0x01, 0xf8, 0x1a, 0x9a, 0x69, 0xc6, 0x93, 0x8c, 0xcb, 0x7b, 0xab, 0xa9,
+	0x5e, 0xdc, 0xb6, 0x3e, 0x2d, 0x71, 0xba, 0x55, 0x9c, 0x1b, 0xa0, 0xaf, 0x22, 0x19, 0xf5, 0x7a,
+	0xd0, 0xac, 0x06, 0xcb, 0xc7, 0x29, 0x47, 0xd7, 0xba, 0x8d, 0xbe, 0x59, 0x8d, 0xd9, 0xfb, 0xa5,
+	0x01, 0xed, 0x2a, 0x54, 0x91, 0x26, 0xb1, 0x60, 0x88, 0xa0, 0x2f, 0x92, 0xd5, 0xbd, 0xca, 0xd4,
+	0xa4, 0xea, 0x1b, 0x87, 0x00, 0x42, 0x46, 0x72, 0x23, 0x2e, 0x92, 0x15, 0x53, 0xa9, 0xb6, 0x87,
+	0x58, 0xb5, 0x10, 0x6c, 0x19, 0xba, 0xa7, 0x52, 0x23, 0xf1, 0x9b, 0x38, 0x92, 0x9b, 0x8c, 0xa9,
+	0x10, 0x4d, 0xba, 0x03, 0x72, 0x36, 0xdd, 0x2c, 0xee, 0xf8, 0xf2, 0x2d, 0xbb, 0x57, 0xeb, 0x30,
+	0xe9, 0x0e, 0xf8, 0xbf, 0x29, 0x9f, 0xc0, 0x41, 0x9a, 0x25, 0xc9, 0xb5, 0x0a, 0xd8, 0xa4, 0x45,
+	0x91, 0x0f, 0x24, 0x23, 0x7e, 0xa7, 0x62, 0xd5, 0xa9, 0xfa, 0xce, 0xf7, 0x91, 0x31, 0x99, 0xdd,
+	0xdb, 0xd7, 0x92, 0x65, 0x2a, 0x53, 0x9d, 0xee, 0x21, 0xd8, 0x03, 0x73, 0x99, 0xc4, 0x92, 0xc7,
+	0x9b, 0x48, 0xe6, 0x1d, 0x1c, 0x29, 0xc5, 0x13, 0x0c, 0xbb, 0xd0, 0x8a, 0xa4, 0x64, 0x42, 0x16,
+	0x12, 0x50, 0x6f, 0xee, 0x43, 0xf8, 0x1a, 0x8c, 0x3b, 0xbe, 0xe6, 0x52, 0x58, 0x2d, 0x75, 0x70,
+	0x27, 0xdb, 0xc8, 0x58, 0xf6, 0x23, 0xcb, 0x26, 0x8a, 0xa3, 0xa5, 0xa6, 0xf7, 0x9b, 0x06, 0xe6,
+	0x3e, 0x81, 0x5f, 0xc2, 0x07, 0xeb, 0xe8, 0x5d, 0xb1, 0x1e, 0x31, 0xdd, 0xad, 0xa8, 0x38, 0x77,
+	0xfa, 0xdf, 0x24, 0x7e, 0x02, 0xed, 0x75, 0xf4, 0xee, 0x8a, 0x09, 0x11, 0xdd, 0xb0, 0x80, 0xff,
+	0x5c, 0xec, 0x4b, 0xa7, 0xff, 0x42, 0xf1, 0x33, 0x68, 0x5e, 0x33, 0xb5, 0x0c, 0x61, 0x35, 0xba,
+	0x8d, 0x7e, 0x7b, 0xf8, 0xbc, 0x6a, 0xef, 0xb2, 0xc0, 0xe9, 0x56, 0x30, 0x78, 0x05, 0xcd, 0xea,
+	0xd2, 0xf0, 0x10, 0x1a, 0x13, 0xff, 0x5b, 0x52, 0xc3, 0x26, 0xe8, 0x63, 0xf7, 0xcd, 0x98, 0x68,
+	0x83, 0xcf, 0xa1, 0xb5, 0xb7, 0x94, 0x9c, 0xf0, 0x7c, 0xcf, 0x29, 0x24, 0xdf, 0x07, 0xe1, 0x88,
+	0x68, 0x08, 0x60, 0x04, 0x9e, 0x3d, 0x9d, 0x7e, 0x47, 0xea, 0x83, 0x9f, 0x00, 0x76, 0x47, 0x83,
+	0x2d, 0x38, 0x74, 0xbd, 0x6f, 0xec, 0x89, 0x3b, 0x22, 0x35, 0x34, 0xa0, 0xee, 0xbf, 0x25, 0x1a,
+	0x3e, 0x83, 0x23, 0xcf, 0x0f, 0xe7, 0x97, 0xfe, 0xcc, 0x1b, 0x91, 0x3a, 0x12, 0x30, 0xa9, 0x1d,
+	0x3a, 0xf3, 0x89, 0x7b, 0xe5, 0x86, 0xce, 0x88, 0x34, 0x72, 0xbf, 0x29, 0x9d, 0x79, 0xce, 0x88,
+	0xe8, 0x78, 0x0c, 0xcf, 0x4b, 0x87, 0x39, 0x75, 0xbe, 0x9e, 0x39, 0x41, 0x48, 0x0e, 0x10, 0xa1,
+	0xed, 0x7a, 0xa1, 0x43, 0x3d, 0x7b, 0x32, 0x77, 0x28, 0xf5, 0x29, 0x31, 0x06, 0xbf, 0x6b, 0x70,
+	0x58, 0x0e, 0x97, 0x5b, 0x5e, 0x3a, 0x76, 0x38, 0xa3, 0xce, 0xbc, 0x6c, 0xf6, 0x15, 0x7c, 0x54,
+	0x21, 0x63, 0xc7, 0x1e, 0xcd, 0x83, 0xd9, 0x79, 0x70, 0x41, 0xdd, 0x69, 0xe8, 0xfa, 0x1e, 0xd1,
+	0xf0, 0x63, 0x78, 0xf1, 0x94, 0x76, 0xdf, 0x78, 0xaa, 0x24, 0xf5, 0xfc, 0xb1, 0x8a, 0x9b, 0x52,
+	0xdf, 0xbf, 0x0c, 0x48, 0x03, 0x5f, 0x82, 0xf5, 0x44, 0x6f, 0x87, 0xa1, 0x13, 0x84, 0xb6, 0x72,
+	0xd3, 0xf1, 0x43, 0x38, 0xae, 0xd8, 0x0b, 0xff, 0x6a, 0x4a, 0x9d, 0x20, 0xc8, 0x89, 0x83, 0x73,
+	0xeb, 0xcf, 0x87, 0x8e, 0xf6, 0xfe, 0xa1, 0xa3, 0xfd, 0xfd, 0xd0, 0xd1, 0x7e, 0x7d, 0xec, 0xd4,
+	0xde, 0x3f, 0x76, 0x6a, 0x7f, 0x3d, 0x76, 0x6a, 0x0b, 0x43, 0xfd, 0x21, 0x7e, 0xf1, 0xcf, 0x00,
+	0x9e, 0xb3, 0x92, 0xcd, 0x3b, 0x05, 0x00, 0x00,
 }
 
 func (m *HeaderRequest) Marshal() (dAtA []byte, err error) {
@@ -246,10 +634,35 @@ func (m *HeaderRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
-	if m.Amount != 0 {
-		i = encodeVarintHeaderRequest(dAtA, i, uint64(m.Amount))
+	if m.Priority != 0 {
+		i = encodeVarintHeaderRequest(dAtA, i, uint64(m.Priority))
 		i--
-		dAtA[i] = 0x18
+		dAtA[i] = 0x40
+	}
+	if m.Subscribe {
+		i--
+		if m.Subscribe {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.Descending {
+		i--
+		if m.Descending {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.Compression != 0 {
+		i = encodeVarintHeaderRequest(dAtA, i, uint64(m.Compression))
+		i--
+		dAtA[i] = 0x28
 	}
 	if m.Data != nil {
 		{
@@ -260,6 +673,11 @@ func (m *HeaderRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 			}
 		}
 	}
+	if m.Amount != 0 {
+		i = encodeVarintHeaderRequest(dAtA, i, uint64(m.Amount))
+		i--
+		dAtA[i] = 0x18
+	}
 	return len(dAtA) - i, nil
 }
 
@@ -291,6 +709,59 @@ func (m *HeaderRequest_Hash) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	}
 	return len(dAtA) - i, nil
 }
+func (m *HeaderRequest_Hashes) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *HeaderRequest_Hashes) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Hashes != nil {
+		{
+			size, err := m.Hashes.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintHeaderRequest(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	return len(dAtA) - i, nil
+}
+func (m *HashList) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *HashList) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *HashList) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Hashes) > 0 {
+		for iNdEx := len(m.Hashes) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Hashes[iNdEx])
+			copy(dAtA[i:], m.Hashes[iNdEx])
+			i = encodeVarintHeaderRequest(dAtA, i, uint64(len(m.Hashes[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *HeaderResponse) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -311,6 +782,66 @@ func (m *HeaderResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Limits != nil {
+		{
+			size, err := m.Limits.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintHeaderRequest(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x5a
+	}
+	if len(m.Attestation) > 0 {
+		i -= len(m.Attestation)
+		copy(dAtA[i:], m.Attestation)
+		i = encodeVarintHeaderRequest(dAtA, i, uint64(len(m.Attestation)))
+		i--
+		dAtA[i] = 0x52
+	}
+	if m.Continuation != 0 {
+		i = encodeVarintHeaderRequest(dAtA, i, uint64(m.Continuation))
+		i--
+		dAtA[i] = 0x48
+	}
+	if m.RetryAfter != 0 {
+		i = encodeVarintHeaderRequest(dAtA, i, uint64(m.RetryAfter))
+		i--
+		dAtA[i] = 0x40
+	}
+	if m.Tail != 0 {
+		i = encodeVarintHeaderRequest(dAtA, i, uint64(m.Tail))
+		i--
+		dAtA[i] = 0x38
+	}
+	if len(m.Proof) > 0 {
+		i -= len(m.Proof)
+		copy(dAtA[i:], m.Proof)
+		i = encodeVarintHeaderRequest(dAtA, i, uint64(len(m.Proof)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if m.Compression != 0 {
+		i = encodeVarintHeaderRequest(dAtA, i, uint64(m.Compression))
+		i--
+		dAtA[i] = 0x28
+	}
+	if len(m.PublicKey) > 0 {
+		i -= len(m.PublicKey)
+		copy(dAtA[i:], m.PublicKey)
+		i = encodeVarintHeaderRequest(dAtA, i, uint64(len(m.PublicKey)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.Signature) > 0 {
+		i -= len(m.Signature)
+		copy(dAtA[i:], m.Signature)
+		i = encodeVarintHeaderRequest(dAtA, i, uint64(len(m.Signature)))
+		i--
+		dAtA[i] = 0x1a
+	}
 	if m.StatusCode != 0 {
 		i = encodeVarintHeaderRequest(dAtA, i, uint64(m.StatusCode))
 		i--
@@ -326,15 +857,66 @@ func (m *HeaderResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintHeaderRequest(dAtA []byte, offset int, v uint64) int {
-	offset -= sovHeaderRequest(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
-	}
-	dAtA[offset] = uint8(v)
+func (m *ServerLimits) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ServerLimits) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ServerLimits) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Features) > 0 {
+		dAtA4 := make([]byte, len(m.Features)*10)
+		var j3 int
+		for _, num := range m.Features {
+			for num >= 1<<7 {
+				dAtA4[j3] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j3++
+			}
+			dAtA4[j3] = uint8(num)
+			j3++
+		}
+		i -= j3
+		copy(dAtA[i:], dAtA4[:j3])
+		i = encodeVarintHeaderRequest(dAtA, i, uint64(j3))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.MaxMessageSize != 0 {
+		i = encodeVarintHeaderRequest(dAtA, i, uint64(m.MaxMessageSize))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.MaxHeadersPerResponse != 0 {
+		i = encodeVarintHeaderRequest(dAtA, i, uint64(m.MaxHeadersPerResponse))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintHeaderRequest(dAtA []byte, offset int, v uint64) int {
+	offset -= sovHeaderRequest(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
 	return base
 }
 func (m *HeaderRequest) Size() (n int) {
@@ -349,6 +931,18 @@ func (m *HeaderRequest) Size() (n int) {
 	if m.Amount != 0 {
 		n += 1 + sovHeaderRequest(uint64(m.Amount))
 	}
+	if m.Compression != 0 {
+		n += 1 + sovHeaderRequest(uint64(m.Compression))
+	}
+	if m.Descending {
+		n += 2
+	}
+	if m.Subscribe {
+		n += 2
+	}
+	if m.Priority != 0 {
+		n += 1 + sovHeaderRequest(uint64(m.Priority))
+	}
 	return n
 }
 
@@ -373,6 +967,33 @@ func (m *HeaderRequest_Hash) Size() (n int) {
 	}
 	return n
 }
+func (m *HeaderRequest_Hashes) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Hashes != nil {
+		l = m.Hashes.Size()
+		n += 1 + l + sovHeaderRequest(uint64(l))
+	}
+	return n
+}
+func (m *HashList) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Hashes) > 0 {
+		for _, b := range m.Hashes {
+			l = len(b)
+			n += 1 + l + sovHeaderRequest(uint64(l))
+		}
+	}
+	return n
+}
+
 func (m *HeaderResponse) Size() (n int) {
 	if m == nil {
 		return 0
@@ -386,6 +1007,60 @@ func (m *HeaderResponse) Size() (n int) {
 	if m.StatusCode != 0 {
 		n += 1 + sovHeaderRequest(uint64(m.StatusCode))
 	}
+	l = len(m.Signature)
+	if l > 0 {
+		n += 1 + l + sovHeaderRequest(uint64(l))
+	}
+	l = len(m.PublicKey)
+	if l > 0 {
+		n += 1 + l + sovHeaderRequest(uint64(l))
+	}
+	if m.Compression != 0 {
+		n += 1 + sovHeaderRequest(uint64(m.Compression))
+	}
+	l = len(m.Proof)
+	if l > 0 {
+		n += 1 + l + sovHeaderRequest(uint64(l))
+	}
+	if m.Tail != 0 {
+		n += 1 + sovHeaderRequest(uint64(m.Tail))
+	}
+	if m.RetryAfter != 0 {
+		n += 1 + sovHeaderRequest(uint64(m.RetryAfter))
+	}
+	if m.Continuation != 0 {
+		n += 1 + sovHeaderRequest(uint64(m.Continuation))
+	}
+	l = len(m.Attestation)
+	if l > 0 {
+		n += 1 + l + sovHeaderRequest(uint64(l))
+	}
+	if m.Limits != nil {
+		l = m.Limits.Size()
+		n += 1 + l + sovHeaderRequest(uint64(l))
+	}
+	return n
+}
+
+func (m *ServerLimits) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.MaxHeadersPerResponse != 0 {
+		n += 1 + sovHeaderRequest(uint64(m.MaxHeadersPerResponse))
+	}
+	if m.MaxMessageSize != 0 {
+		n += 1 + sovHeaderRequest(uint64(m.MaxMessageSize))
+	}
+	if len(m.Features) > 0 {
+		l = 0
+		for _, e := range m.Features {
+			l += sovHeaderRequest(uint64(e))
+		}
+		n += 1 + sovHeaderRequest(uint64(l)) + l
+	}
 	return n
 }
 
@@ -496,6 +1171,201 @@ func (m *HeaderRequest) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hashes", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHeaderRequest
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHeaderRequest
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthHeaderRequest
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &HashList{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Data = &HeaderRequest_Hashes{v}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Compression", wireType)
+			}
+			m.Compression = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHeaderRequest
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Compression |= Compression(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Descending", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHeaderRequest
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Descending = bool(v != 0)
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Subscribe", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHeaderRequest
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Subscribe = bool(v != 0)
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Priority", wireType)
+			}
+			m.Priority = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHeaderRequest
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Priority |= Priority(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipHeaderRequest(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthHeaderRequest
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *HashList) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowHeaderRequest
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: HashList: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: HashList: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hashes", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHeaderRequest
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthHeaderRequest
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHeaderRequest
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Hashes = append(m.Hashes, make([]byte, postIndex-iNdEx))
+			copy(m.Hashes[len(m.Hashes)-1], dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipHeaderRequest(dAtA[iNdEx:])
@@ -599,6 +1469,411 @@ func (m *HeaderResponse) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Signature", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHeaderRequest
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthHeaderRequest
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHeaderRequest
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Signature = append(m.Signature[:0], dAtA[iNdEx:postIndex]...)
+			if m.Signature == nil {
+				m.Signature = []byte{}
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PublicKey", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHeaderRequest
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthHeaderRequest
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHeaderRequest
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.PublicKey = append(m.PublicKey[:0], dAtA[iNdEx:postIndex]...)
+			if m.PublicKey == nil {
+				m.PublicKey = []byte{}
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Compression", wireType)
+			}
+			m.Compression = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHeaderRequest
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Compression |= Compression(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Proof", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHeaderRequest
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthHeaderRequest
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHeaderRequest
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Proof = append(m.Proof[:0], dAtA[iNdEx:postIndex]...)
+			if m.Proof == nil {
+				m.Proof = []byte{}
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Tail", wireType)
+			}
+			m.Tail = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHeaderRequest
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Tail |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RetryAfter", wireType)
+			}
+			m.RetryAfter = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHeaderRequest
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RetryAfter |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Continuation", wireType)
+			}
+			m.Continuation = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHeaderRequest
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Continuation |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Attestation", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHeaderRequest
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthHeaderRequest
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHeaderRequest
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Attestation = append(m.Attestation[:0], dAtA[iNdEx:postIndex]...)
+			if m.Attestation == nil {
+				m.Attestation = []byte{}
+			}
+			iNdEx = postIndex
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limits", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHeaderRequest
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHeaderRequest
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthHeaderRequest
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Limits == nil {
+				m.Limits = &ServerLimits{}
+			}
+			if err := m.Limits.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipHeaderRequest(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthHeaderRequest
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ServerLimits) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowHeaderRequest
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ServerLimits: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ServerLimits: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxHeadersPerResponse", wireType)
+			}
+			m.MaxHeadersPerResponse = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHeaderRequest
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxHeadersPerResponse |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxMessageSize", wireType)
+			}
+			m.MaxMessageSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHeaderRequest
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxMessageSize |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType == 0 {
+				var v Feature
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowHeaderRequest
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= Feature(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.Features = append(m.Features, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowHeaderRequest
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthHeaderRequest
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthHeaderRequest
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				if elementCount != 0 && len(m.Features) == 0 {
+					m.Features = make([]Feature, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v Feature
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowHeaderRequest
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= Feature(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.Features = append(m.Features, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field Features", wireType)
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipHeaderRequest(dAtA[iNdEx:])
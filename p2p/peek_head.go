@@ -0,0 +1,119 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/celestiaorg/go-header"
+	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
+)
+
+// peekHeadPeers is the max amount of peers PeekHead asks for their head.
+var peekHeadPeers = 3
+
+// UntrustedHeight is an estimate of the network height reported by peers.
+// It is neither verified nor agreed on by the trusted peers, so it must only be used
+// for informational purposes, like displaying the sync progress, and never as a sync target.
+type UntrustedHeight uint64
+
+// PeekHead cheaply estimates the network height by asking a few peers, trusted ones first,
+// for their head. Unlike Head, the received heads are only decoded for their height and
+// not validated, so the estimate is returned as UntrustedHeight.
+// The median of the reported heights is returned, so a single peer cannot skew the estimate.
+func (ex *Exchange[H]) PeekHead(ctx context.Context) (UntrustedHeight, error) {
+	peers := ex.peekPeers()
+	if len(peers) == 0 {
+		return 0, errors.New("header/p2p: no peers to peek the head from")
+	}
+
+	req := &p2p_pb.HeaderRequest{
+		Data:        &p2p_pb.HeaderRequest_Origin{Origin: uint64(0)},
+		Amount:      1,
+		Compression: ex.Params.compression,
+	}
+	heightCh := make(chan uint64, len(peers))
+	for _, p := range peers {
+		go func(p peer.ID) {
+			height, err := ex.peekHead(ctx, p, req)
+			if err != nil {
+				log.Debugw("peeking head of peer failed", "peer", p, "err", err)
+			}
+			heightCh <- height
+		}(p)
+	}
+
+	heights := make([]uint64, 0, len(peers))
+collect:
+	for range peers {
+		select {
+		case height := <-heightCh:
+			if height > 0 {
+				heights = append(heights, height)
+			}
+		case <-ctx.Done():
+			break collect
+		case <-ex.ctx.Done():
+			return 0, ex.ctx.Err()
+		}
+	}
+	if len(heights) == 0 {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return 0, header.ErrNotFound
+	}
+
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	return UntrustedHeight(heights[len(heights)/2]), nil
+}
+
+// peekHead requests the head from the given peer and returns its height.
+func (ex *Exchange[H]) peekHead(ctx context.Context, to peer.ID, req *p2p_pb.HeaderRequest) (uint64, error) {
+	if timeout := ex.requestTimeout(req); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	responses, _, _, err := sendMessage(ctx, ex.transport, to, ex.protocolIDs, req, ex.Params.MaxMessageSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(responses) == 0 {
+		return 0, header.ErrNotFound
+	}
+	if err = convertStatusCodeToError(responses[0]); err != nil {
+		return 0, err
+	}
+	h, err := header.Unmarshal[H](responses[0].Body)
+	if err != nil {
+		return 0, err
+	}
+	if err = validateChainID(ex.Params.chainID, h.ChainID()); err != nil {
+		return 0, err
+	}
+	return uint64(h.Height()), nil
+}
+
+// peekPeers returns up to peekHeadPeers peers to peek the head from,
+// preferring the trusted peers over the tracked ones with the best head score.
+func (ex *Exchange[H]) peekPeers() peer.IDSlice {
+	peers := make(peer.IDSlice, 0, peekHeadPeers)
+	seen := make(map[peer.ID]struct{})
+	add := func(p peer.ID) {
+		if _, ok := seen[p]; ok || len(peers) == peekHeadPeers {
+			return
+		}
+		seen[p] = struct{}{}
+		peers = append(peers, p)
+	}
+	for _, p := range ex.peerTracker.routable(ex.trustedPeers()) {
+		add(p)
+	}
+	for _, stat := range ex.peerTracker.headPeers() {
+		add(stat.peerID)
+	}
+	return peers
+}
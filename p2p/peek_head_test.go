@@ -0,0 +1,54 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/net/conngater"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header/headertest"
+)
+
+// TestExchange_PeekHead ensures the median of the heights reported by peers is returned,
+// so a peer reporting an outlier does not skew the estimate.
+func TestExchange_PeekHead(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	hosts := createMocknet(t, 4)
+	trusted := make([]peer.ID, 0, len(hosts)-1)
+	for i, height := range []int{5, 7, 100} {
+		store := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), height)
+		serv, err := NewExchangeServer[*headertest.DummyHeader](hosts[i+1], store,
+			WithNetworkID[ServerParameters](networkID),
+		)
+		require.NoError(t, err)
+		require.NoError(t, serv.Start(ctx))
+		t.Cleanup(func() {
+			serv.Stop(ctx) //nolint:errcheck
+		})
+		trusted = append(trusted, hosts[i+1].ID())
+	}
+
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	exchg, err := NewExchange[*headertest.DummyHeader](hosts[0], trusted, connGater,
+		WithNetworkID[ClientParameters](networkID),
+		WithChainID(networkID),
+	)
+	require.NoError(t, err)
+	require.NoError(t, exchg.Start(ctx))
+	t.Cleanup(func() {
+		exchg.Stop(ctx) //nolint:errcheck
+	})
+
+	height, err := exchg.PeekHead(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 7, height)
+}
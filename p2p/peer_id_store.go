@@ -0,0 +1,112 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+var peerIDStorePrefix = datastore.NewKey("peers")
+
+// PeerRecord is a persisted record of a peer tracked by the PeerTracker.
+type PeerRecord struct {
+	// AddrInfo contains the ID of the peer along with its known addresses.
+	AddrInfo peer.AddrInfo
+	// Score is the latest score of the peer.
+	Score float32
+	// Expiry is the time after which the record is no longer valid.
+	Expiry time.Time
+}
+
+// PeerIDStore persists the peers tracked by the PeerTracker,
+// so they can be reused after restarts.
+// Records are updated and removed individually, avoiding rewrites of the whole peer list.
+type PeerIDStore interface {
+	// Load returns all the records that have not expired yet.
+	Load(context.Context) ([]PeerRecord, error)
+	// Put adds or updates the given records.
+	Put(context.Context, ...PeerRecord) error
+	// Delete removes the records of the given peers.
+	Delete(context.Context, ...peer.ID) error
+}
+
+// NewPeerIDStore creates a PeerIDStore backed by the given Datastore.
+func NewPeerIDStore(ds datastore.Batching) PeerIDStore {
+	return &peerIDStore{ds: namespace.Wrap(ds, peerIDStorePrefix)}
+}
+
+type peerIDStore struct {
+	ds datastore.Batching
+}
+
+func (s *peerIDStore) Load(ctx context.Context) ([]PeerRecord, error) {
+	res, err := s.ds.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		now     = time.Now()
+		records []PeerRecord
+		expired []peer.ID
+	)
+	for _, entry := range entries {
+		var record PeerRecord
+		if err = json.Unmarshal(entry.Value, &record); err != nil {
+			return nil, err
+		}
+		if record.Expiry.Before(now) {
+			expired = append(expired, record.AddrInfo.ID)
+			continue
+		}
+		records = append(records, record)
+	}
+
+	// clean up expired records lazily
+	return records, s.Delete(ctx, expired...)
+}
+
+func (s *peerIDStore) Put(ctx context.Context, records ...PeerRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		b, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if err = batch.Put(ctx, datastore.NewKey(record.AddrInfo.ID.String()), b); err != nil {
+			return err
+		}
+	}
+	return batch.Commit(ctx)
+}
+
+func (s *peerIDStore) Delete(ctx context.Context, peers ...peer.ID) error {
+	if len(peers) == 0 {
+		return nil
+	}
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	for _, pID := range peers {
+		if err = batch.Delete(ctx, datastore.NewKey(pID.String())); err != nil {
+			return err
+		}
+	}
+	return batch.Commit(ctx)
+}
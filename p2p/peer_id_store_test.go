@@ -0,0 +1,97 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/net/conngater"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header/headertest"
+)
+
+func TestPeerIDStore(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	store := NewPeerIDStore(ds)
+	h := createMocknet(t, 3)
+	peer1, peer2, expired := h[0].ID(), h[1].ID(), h[2].ID()
+
+	now := time.Now()
+	err := store.Put(ctx,
+		PeerRecord{AddrInfo: peer.AddrInfo{ID: peer1}, Score: 10, Expiry: now.Add(time.Hour)},
+		PeerRecord{AddrInfo: peer.AddrInfo{ID: peer2}, Score: 5, Expiry: now.Add(time.Hour)},
+		PeerRecord{AddrInfo: peer.AddrInfo{ID: expired}, Expiry: now.Add(-time.Hour)},
+	)
+	require.NoError(t, err)
+
+	records, err := store.Load(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	// partial update of a single record
+	err = store.Put(ctx, PeerRecord{AddrInfo: peer.AddrInfo{ID: peer1}, Score: 20, Expiry: now.Add(time.Hour)})
+	require.NoError(t, err)
+	require.NoError(t, store.Delete(ctx, peer2))
+
+	records, err = store.Load(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, peer1, records[0].AddrInfo.ID)
+	require.Equal(t, float32(20), records[0].Score)
+
+	// expired records are removed on load
+	has, err := ds.Has(ctx, peerIDStorePrefix.ChildString(expired.String()))
+	require.NoError(t, err)
+	require.False(t, has)
+}
+
+func TestPeerTracker_PersistsPeers(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	h := createMocknet(t, 3)
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	store := NewPeerIDStore(sync.MutexWrap(datastore.NewMapDatastore()))
+	// empty protocol ID disables the protocol check
+	p := newPeerTracker(h[0], connGater, nil, withPeerIDStore(store, time.Hour))
+
+	p.connected(h[1].ID())
+	p.connected(h[2].ID())
+	// peers with the default score are pruned by gc
+	for _, stat := range p.trackedPeers {
+		stat.peerScore = 10
+	}
+	p.collectGarbage()
+
+	records, err := store.Load(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	p.blockPeer(h[2].ID(), errors.New("test"))
+	records, err = store.Load(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, h[1].ID(), records[0].AddrInfo.ID)
+
+	// the persisted score is restored once the peer connects after a restart
+	p = newPeerTracker(h[0], connGater, nil, withPeerIDStore(store, time.Hour))
+	p.bootstrap(records)
+	p.connected(h[1].ID())
+	require.Contains(t, p.trackedPeers, h[1].ID())
+	require.Equal(t, float32(10), p.trackedPeers[h[1].ID()].score())
+
+	// persisted records must expire
+	_, err = NewExchange[*headertest.DummyHeader](h[0], []peer.ID{h[1].ID()}, connGater,
+		WithPeerIDStore[ClientParameters](store, 0),
+	)
+	require.Error(t, err)
+}
@@ -3,41 +3,130 @@ package p2p
 import (
 	"container/heap"
 	"context"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
 )
 
 // peerStat represents a peer's average statistics.
 type peerStat struct {
 	sync.RWMutex
 	peerID peer.ID
-	// score is the average speed per single request
+	// agentVersion is the version of the software the peer runs, as reported during identification.
+	agentVersion string
+	// protocols are the versions of the header exchange protocol the peer supports.
+	protocols []protocol.ID
+	// score is the throughput of the peer in bytes per millisecond,
+	// calculated from transferred and transferTime.
 	peerScore float32
+	// transferred is the amount of bytes received from the peer, decayed with every request.
+	transferred float64
+	// transferTime is the wall time in milliseconds spent receiving the transferred bytes,
+	// decayed the same way.
+	transferTime float64
+	// headScore is the average speed per single request weighted by how close
+	// the served headers are to the network head.
+	headScore float32
 	// pruneDeadline specifies when disconnected peer will be removed if
 	// it does not return online.
 	pruneDeadline time.Time
+	// lastUsed is the time of the latest request to the peer.
+	lastUsed time.Time
+	// persisted is the time the peer was last written to the PeerIDStore.
+	persisted time.Time
+	// latency is the average duration of requests to the peer.
+	// Zero means the peer was not requested yet.
+	latency time.Duration
+	// failures is the amount of requests to the peer failed in a row.
+	failures int
+	// breakerUntil is the time until which the circuit breaker of the peer is open,
+	// so requests are not routed to it. Zero means the breaker is closed.
+	breakerUntil time.Time
+	// throttledUntil is the time until which the peer asked not to be requested,
+	// as the client exceeded its rate limits.
+	throttledUntil time.Time
+	// inflight is the amount of requests currently sent to the peer by all the sessions.
+	inflight int
+	// prioritized is the amount of high priority requests waiting for a slot of the peer.
+	// Low priority requests yield to them.
+	prioritized int
+	// released is closed once a slot of the peer is released.
+	released chan struct{}
+	// tail is the lowest height retained by the peer, as advertised in its head responses.
+	// Zero means the peer did not advertise it.
+	tail uint64
+	// maxRange is the max amount of headers the peer serves per request,
+	// as advertised in its head responses. Zero means the peer did not advertise it.
+	maxRange uint64
+	// features are the optional features of the peer, as advertised in its head responses.
+	features []p2p_pb.Feature
 }
 
-// updateStats recalculates peer.score by averaging the last score
+// transferDecay is the weight of the previously transferred bytes and wall time relative
+// to the latest request when calculating the throughput of a peer.
+const transferDecay = 0.5
+
+// updateStats recalculates peer.score from the bandwidth of the peer.
 // updateStats takes the total amount of bytes that were requested from the peer
-// and the total request duration(in milliseconds). The final score is calculated
-// by dividing the amount by time, so the result score will represent how many bytes
-// were retrieved in 1 millisecond. This value will then be averaged relative to the
-// previous peerScore.
-func (p *peerStat) updateStats(amount uint64, time uint64) {
+// and the total request duration(in milliseconds) and accumulates them with the decayed
+// bytes and wall time of the previous requests. The score is then the accumulated bytes divided
+// by the accumulated time, so it represents how many bytes were retrieved in 1 millisecond.
+// Unlike averaging the speed of single requests, it weighs requests by their duration,
+// so the fixed round trip of small requests does not outweigh large responses of the peer.
+func (p *peerStat) updateStats(amount uint64, duration uint64) {
+	p.Lock()
+	defer p.Unlock()
+	p.lastUsed = time.Now()
+	if duration == 0 {
+		// ensures the time spent on a request is never zero, so dividing by it is handled properly
+		duration = 1
+	}
+	p.transferred = p.transferred*transferDecay + float64(amount)
+	p.transferTime = p.transferTime*transferDecay + float64(duration)
+	p.peerScore = float32(p.transferred / p.transferTime)
+}
+
+// updateHeadScore recalculates peer.headScore by averaging the speed of the latest request
+// with the previous headScore, weighting the speed by the proximity of the served headers
+// to the network head, so peers keeping up with the chain tip get a higher headScore than archival ones.
+func (p *peerStat) updateHeadScore(amount uint64, duration uint64, proximity float32) {
 	p.Lock()
 	defer p.Unlock()
 	averageSpeed := float32(amount)
-	if time != 0 {
-		averageSpeed /= float32(time)
+	if duration != 0 {
+		averageSpeed /= float32(duration)
 	}
-	if p.peerScore == 0.0 {
-		p.peerScore = averageSpeed
+	averageSpeed *= proximity
+	if p.headScore == 0.0 {
+		p.headScore = averageSpeed
+		return
+	}
+	p.headScore = (p.headScore + averageSpeed) / 2
+}
+
+// updateLatency averages the latency of the peer with the duration of the latest request.
+// Unlike peerScore, which prefers peers with a high throughput for bulk range requests,
+// latency prefers quickly responding peers for single header and head requests.
+func (p *peerStat) updateLatency(duration time.Duration) {
+	p.Lock()
+	defer p.Unlock()
+	if p.latency == 0 {
+		p.latency = duration
 		return
 	}
-	p.peerScore = (p.peerScore + averageSpeed) / 2
+	p.latency = (p.latency + duration) / 2
+}
+
+// avgLatency reads a peer's average latency.
+func (p *peerStat) avgLatency() time.Duration {
+	p.RLock()
+	defer p.RUnlock()
+	return p.latency
 }
 
 // decreaseScore decreases peerScore by 20% of the peer that failed the request by any reason.
@@ -48,7 +137,201 @@ func (p *peerStat) decreaseScore() {
 	p.Lock()
 	defer p.Unlock()
 
+	p.lastUsed = time.Now()
 	p.peerScore -= p.peerScore / 100 * 20
+	// the decrease is kept by the following updates of the throughput
+	p.transferred -= p.transferred / 100 * 20
+}
+
+// acquire reserves a slot for a low priority request to the peer, unless the peer already
+// has the given amount of requests in flight or high priority requests are waiting for it.
+// Zero limit means no limit.
+func (p *peerStat) acquire(limit int) bool {
+	p.Lock()
+	defer p.Unlock()
+	if limit > 0 && (p.inflight >= limit || p.prioritized > 0) {
+		return false
+	}
+	p.inflight++
+	return true
+}
+
+// acquirePrioritized reserves a slot for a high priority request to the peer, waiting for one
+// to be released if the peer already has the given amount of requests in flight.
+// While it waits, low priority requests can't acquire the peer, so the request jumps ahead of them.
+// Zero limit means no limit.
+func (p *peerStat) acquirePrioritized(ctx context.Context, limit int) error {
+	p.Lock()
+	defer p.Unlock()
+	if limit <= 0 || p.inflight < limit {
+		p.inflight++
+		return nil
+	}
+
+	p.prioritized++
+	defer func() { p.prioritized-- }()
+	for p.inflight >= limit {
+		if p.released == nil {
+			p.released = make(chan struct{})
+		}
+		released := p.released
+		p.Unlock()
+		select {
+		case <-released:
+		case <-ctx.Done():
+			p.Lock()
+			return ctx.Err()
+		}
+		p.Lock()
+	}
+	p.inflight++
+	return nil
+}
+
+// release frees the slot reserved by acquire or acquirePrioritized.
+func (p *peerStat) release() {
+	p.Lock()
+	defer p.Unlock()
+	p.inflight--
+	if p.released != nil {
+		close(p.released)
+		p.released = nil
+	}
+}
+
+// fail records a failed request to the peer and opens its circuit breaker for the given cooldown
+// once the peer fails the given amount of requests in a row. Zero threshold disables the breaker.
+func (p *peerStat) fail(threshold int, cooldown time.Duration) {
+	p.Lock()
+	defer p.Unlock()
+	p.failures++
+	if threshold > 0 && p.failures >= threshold {
+		p.breakerUntil = time.Now().Add(cooldown)
+	}
+}
+
+// succeed records a successful request to the peer, closing its circuit breaker.
+func (p *peerStat) succeed() {
+	p.Lock()
+	defer p.Unlock()
+	p.failures = 0
+	p.breakerUntil = time.Time{}
+}
+
+// breakerOpen returns for how long requests should not be routed to the peer
+// due to its open circuit breaker. Zero means the breaker is closed or its cooldown passed,
+// so a probe request can be reserved with reserveProbe.
+func (p *peerStat) breakerOpen() time.Duration {
+	p.RLock()
+	defer p.RUnlock()
+	if wait := time.Until(p.breakerUntil); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// reserveProbe reserves the request about to be sent to the peer. Once the cooldown of the open
+// circuit breaker passes, a single probe request is let through, while the breaker stays open for
+// others for another cooldown, until the probe succeeds. It reports false if the breaker is open.
+func (p *peerStat) reserveProbe(cooldown time.Duration) bool {
+	p.Lock()
+	defer p.Unlock()
+	if p.breakerUntil.IsZero() {
+		return true
+	}
+	if time.Until(p.breakerUntil) > 0 {
+		return false
+	}
+	p.breakerUntil = time.Now().Add(cooldown)
+	return true
+}
+
+// throttle keeps requests from being routed to the peer for the given duration.
+func (p *peerStat) throttle(d time.Duration) {
+	p.Lock()
+	defer p.Unlock()
+	p.throttledUntil = time.Now().Add(d)
+}
+
+// throttleWait returns for how long requests should not be routed to the peer
+// as it rate limits the client.
+func (p *peerStat) throttleWait() time.Duration {
+	p.RLock()
+	defer p.RUnlock()
+	if wait := time.Until(p.throttledUntil); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// setAdvertised records the lowest height retained by the peer along with its limits and features
+// advertised in the given head response.
+func (p *peerStat) setAdvertised(resp *p2p_pb.HeaderResponse) {
+	p.Lock()
+	defer p.Unlock()
+	p.tail = resp.Tail
+	p.maxRange = resp.GetLimits().GetMaxHeadersPerResponse()
+	p.features = resp.GetLimits().GetFeatures()
+}
+
+// raiseTail records the lowest height served by the peer once it responds that it pruned
+// the requested headers. The tail is never lowered, as pruning only moves it up.
+func (p *peerStat) raiseTail(tail uint64) {
+	p.Lock()
+	defer p.Unlock()
+	if tail > p.tail {
+		p.tail = tail
+	}
+}
+
+// retains reports whether the peer is expected to have the header at the given height.
+func (p *peerStat) retains(height uint64) bool {
+	return p.info().Retains(height)
+}
+
+// info describes the peer for the Scheduler.
+func (p *peerStat) info() PeerInfo {
+	p.RLock()
+	defer p.RUnlock()
+	return PeerInfo{
+		ID:       p.peerID,
+		Score:    p.peerScore,
+		Latency:  p.latency,
+		Tail:     p.tail,
+		MaxRange: p.maxRange,
+		Features: p.features,
+	}
+}
+
+// idleSince reports the time of the latest request to the peer.
+func (p *peerStat) idleSince() time.Time {
+	p.RLock()
+	defer p.RUnlock()
+	return p.lastUsed
+}
+
+// tipScore reads a peer's latest headScore.
+func (p *peerStat) tipScore() float32 {
+	p.RLock()
+	defer p.RUnlock()
+	return p.headScore
+}
+
+// headProximity returns a weight in range (0;1] describing how close the served height is
+// to the network head. The weight halves once the distance reaches headProximityWindow.
+func headProximity(served, networkHead uint64) float32 {
+	if served >= networkHead {
+		return 1
+	}
+	distance := float32(networkHead - served)
+	return 1 / (1 + distance/headProximityWindow)
+}
+
+// setProtocols sets the versions of the header exchange protocol the peer supports.
+func (p *peerStat) setProtocols(protocols []protocol.ID) {
+	p.Lock()
+	defer p.Unlock()
+	p.protocols = protocols
 }
 
 // score reads a peer's latest score from the queue
@@ -58,6 +341,20 @@ func (p *peerStat) score() float32 {
 	return p.peerScore
 }
 
+// sortByTipScore sorts the peers by their headScore in decreasing order.
+func sortByTipScore(stats []*peerStat) {
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].tipScore() > stats[j].tipScore()
+	})
+}
+
+// sortByScore sorts the peers by their score in decreasing order.
+func sortByScore(stats []*peerStat) {
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].score() > stats[j].score()
+	})
+}
+
 // peerStats implements heap.Interface, so we can be sure that we are getting the peer
 // with the highest score, each time we call Pop.
 type peerStats []*peerStat
@@ -139,6 +436,13 @@ func (p *peerQueue) waitPop(ctx context.Context) *peerStat {
 	return heap.Pop(&p.stats).(*peerStat)
 }
 
+// len returns the amount of peers in the queue.
+func (p *peerQueue) len() int {
+	p.statsLk.RLock()
+	defer p.statsLk.RUnlock()
+	return p.stats.Len()
+}
+
 // push adds the peer to the queue.
 func (p *peerQueue) push(stat *peerStat) {
 	p.statsLk.Lock()
@@ -4,6 +4,7 @@ import (
 	"container/heap"
 	"context"
 	"testing"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/stretchr/testify/require"
@@ -109,3 +110,107 @@ func Test_StatDecreaseScore(t *testing.T) {
 	pStats.decreaseScore()
 	require.Equal(t, pStats.score(), float32(80.0))
 }
+
+func Test_StatHeadScore(t *testing.T) {
+	tip := &peerStat{peerID: peer.ID("tip")}
+	archival := &peerStat{peerID: peer.ID("archival")}
+
+	// both peers serve headers equally fast
+	tip.updateStats(100, 10)
+	tip.updateHeadScore(100, 10, headProximity(1000, 1000))
+	archival.updateStats(100, 10)
+	archival.updateHeadScore(100, 10, headProximity(10, 1000))
+
+	require.Equal(t, tip.score(), archival.score())
+	require.Greater(t, tip.tipScore(), archival.tipScore())
+
+	stats := []*peerStat{archival, tip}
+	sortByTipScore(stats)
+	require.Equal(t, tip, stats[0])
+
+	require.Equal(t, float32(0.5), headProximity(1000-headProximityWindow, 1000))
+}
+
+func Test_StatCircuitBreaker(t *testing.T) {
+	stat := &peerStat{peerID: "peerID"}
+	cooldown := time.Millisecond * 50
+
+	stat.fail(2, cooldown)
+	require.Zero(t, stat.breakerOpen())
+	stat.fail(2, cooldown)
+	require.Greater(t, stat.breakerOpen(), time.Duration(0))
+	require.False(t, stat.reserveProbe(cooldown))
+
+	// once the cooldown passes, a single probe is let through,
+	// while querying the breaker does not reserve it
+	time.Sleep(cooldown)
+	require.Zero(t, stat.breakerOpen())
+	require.Zero(t, stat.breakerOpen())
+	require.True(t, stat.reserveProbe(cooldown))
+	require.Greater(t, stat.breakerOpen(), time.Duration(0))
+	require.False(t, stat.reserveProbe(cooldown))
+
+	// the successful probe closes the breaker
+	stat.succeed()
+	require.Zero(t, stat.breakerOpen())
+	require.True(t, stat.reserveProbe(cooldown))
+}
+
+func Test_StatPrioritizedAcquire(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	stat := &peerStat{peerID: "peerID"}
+	require.True(t, stat.acquire(1))
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- stat.acquirePrioritized(ctx, 1)
+	}()
+	// once the high priority request waits, low priority ones yield to it
+	require.Eventually(t, func() bool {
+		stat.RLock()
+		defer stat.RUnlock()
+		return stat.prioritized == 1
+	}, time.Second, time.Millisecond*10)
+	stat.release()
+	require.False(t, stat.acquire(1))
+	require.NoError(t, <-acquired)
+
+	stat.release()
+	require.True(t, stat.acquire(1))
+
+	// the wait is bound by the context
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, stat.acquirePrioritized(canceled, 1), context.Canceled)
+	require.Zero(t, stat.prioritized)
+}
+
+func Test_StatThroughputWeighsRequestsByDuration(t *testing.T) {
+	stat := &peerStat{peerID: peer.ID("peerID")}
+	// the peer responds with a fixed round trip of 50ms at 10 bytes per millisecond
+	stat.updateStats(100, 60)
+	stat.updateStats(10000, 1050)
+
+	// averaging the speed of single requests would be dragged down by the round trip of the small one
+	perRequest := (float32(100)/60 + float32(10000)/1050) / 2
+	require.Greater(t, stat.score(), perRequest)
+	require.InDelta(t, float32(100*transferDecay+10000)/float32(60*transferDecay+1050), stat.score(), 0.001)
+
+	// a failed request lowers the throughput
+	score := stat.score()
+	stat.updateStats(0, 100)
+	require.Less(t, stat.score(), score)
+}
+
+func Test_StatThrottle(t *testing.T) {
+	stat := &peerStat{peerID: peer.ID("peerID")}
+	require.Zero(t, stat.throttleWait())
+
+	tracker := &PeerTracker{}
+	tracker.recordStatResult(stat, &RateLimitedError{RetryAfter: time.Second})
+	require.Greater(t, stat.throttleWait(), time.Duration(0))
+	// rate limiting is not a failure of the peer
+	require.Zero(t, stat.failures)
+}
@@ -2,14 +2,22 @@ package p2p
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/event"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/libp2p/go-libp2p/p2p/net/conngater"
+
+	"github.com/celestiaorg/go-header"
+	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
 )
 
 const (
@@ -17,6 +25,11 @@ const (
 	defaultScore float32 = 1
 	// maxTrackerSize specifies the max amount of peers that can be added to the peerTracker.
 	maxPeerTrackerSize = 100
+	// headProximityWindow specifies the distance from the network head in headers
+	// at which the head score bonus of a peer halves.
+	headProximityWindow = 16
+	// defaultGCBatchSize specifies the default amount of peers gc processes at once.
+	defaultGCBatchSize = 16
 )
 
 var (
@@ -25,11 +38,22 @@ var (
 	maxAwaitingTime = time.Hour
 	// gcCycle defines the duration after which the peerTracker starts removing peers.
 	gcCycle = time.Minute * 30
+	// peerCooldown specifies the duration during which a blocked or pruned peer
+	// is not tracked again, so flapping peers do not dominate the request path.
+	peerCooldown = time.Minute * 10
 )
 
-type peerTracker struct {
+// PeerTracker discovers and tracks peers supporting the header exchange protocol,
+// scoring them by their responsiveness. It can be shared between the Exchange and
+// other header-adjacent protocols running in the same process.
+type PeerTracker struct {
 	host      host.Host
 	connGater *conngater.BasicConnectionGater
+	// transport opens the streams probe requests are sent over.
+	transport Transport
+	// protocolIDs are the versions of the header exchange protocol, any of which
+	// peers must support to be tracked.
+	protocolIDs []protocol.ID
 
 	peerLk sync.RWMutex
 	// trackedPeers contains active peers that we can request to.
@@ -39,31 +63,179 @@ type peerTracker struct {
 	// disconnectedPeers contains disconnected peers. In case if peer does not return
 	// online until pruneDeadline, it will be removed and its score will be lost.
 	disconnectedPeers map[peer.ID]*peerStat
+	// cooldowns contains blocked or pruned peers along with the time
+	// until which they can't be tracked again.
+	cooldowns map[peer.ID]time.Time
+	// allowlist, if set, restricts tracking to the peers it contains.
+	allowlist map[peer.ID]struct{}
+	// allowed are the peers added to the allowlist with allow, which disallow revokes.
+	allowed map[peer.ID]struct{}
+	// networkHead is the height of the latest network head received from trusted peers.
+	networkHead atomic.Uint64
 
 	ctx    context.Context
 	cancel context.CancelFunc
+	// probeInterval specifies how often idle peers are probed for liveness.
+	// Zero disables probing.
+	probeInterval time.Duration
+	// gcBatchSize limits the amount of peers processed by gc while holding the lock.
+	gcBatchSize int
+	// onBlocked is called once a peer gets blocked.
+	onBlocked func(peer.ID, error)
+	// peerIDStore, if set, persists tracked peers, so they are reused after restarts.
+	peerIDStore PeerIDStore
+	// peerRecordTTL defines how long persisted peers stay valid.
+	peerRecordTTL time.Duration
+	// breakerThreshold is the amount of requests a peer can fail in a row
+	// before requests stop being routed to it for breakerCooldown. Zero disables the breaker.
+	breakerThreshold int
+	breakerCooldown  time.Duration
+	// maxInflight limits the amount of concurrent requests to a single peer.
+	// Zero means no limit.
+	maxInflight int
+
 	// done is used to gracefully stop the peerTracker.
-	// It allows to wait until track() and gc() will be stopped.
+	// It allows to wait until track(), gc() and probe() will be stopped.
 	done chan struct{}
 }
 
+type trackerOption func(*PeerTracker)
+
+// withProbeInterval enables liveness probing of tracked peers that were not requested
+// for the given interval.
+func withProbeInterval(interval time.Duration) trackerOption {
+	return func(p *PeerTracker) {
+		p.probeInterval = interval
+	}
+}
+
+// withGCBatchSize sets the amount of peers gc processes at once.
+func withGCBatchSize(size int) trackerOption {
+	return func(p *PeerTracker) {
+		p.gcBatchSize = size
+	}
+}
+
+// withOnBlockedPeer sets a callback that is called every time a peer gets blocked.
+func withOnBlockedPeer(onBlocked func(peer.ID, error)) trackerOption {
+	return func(p *PeerTracker) {
+		p.onBlocked = onBlocked
+	}
+}
+
+// withMaxInflight limits the amount of concurrent requests to a single tracked peer.
+func withMaxInflight(limit int) trackerOption {
+	return func(p *PeerTracker) {
+		p.maxInflight = limit
+	}
+}
+
+// withCircuitBreaker stops routing requests to peers failing the given amount of requests
+// in a row for the given cooldown.
+func withCircuitBreaker(threshold int, cooldown time.Duration) trackerOption {
+	return func(p *PeerTracker) {
+		p.breakerThreshold = threshold
+		p.breakerCooldown = cooldown
+	}
+}
+
+// NewPeerTracker creates a new PeerTracker configured with the client options
+// that are relevant for peer tracking, like the network ID.
+func NewPeerTracker(
+	h host.Host,
+	connGater *conngater.BasicConnectionGater,
+	opts ...Option[ClientParameters],
+) (*PeerTracker, error) {
+	params := DefaultClientParameters()
+	for _, opt := range opts {
+		opt(&params)
+	}
+
+	err := params.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return newPeerTracker(
+		h,
+		connGater,
+		protocolIDs(params.networkID),
+		withProbeInterval(params.PeerProbeInterval),
+		withGCBatchSize(params.PeerGCBatchSize),
+		withOnBlockedPeer(params.onBlockedPeer),
+		withAllowlist(params.peerAllowlist),
+		withPeerIDStore(params.peerIDStore, params.peerRecordTTL),
+		withMaxInflight(params.MaxInflightPerPeer),
+		withCircuitBreaker(params.CircuitBreakerThreshold, params.CircuitBreakerCooldown),
+		withTransport(params.transport),
+	), nil
+}
+
+// withPeerIDStore makes the PeerTracker persist tracked peers in the given store,
+// keeping their records valid for the given ttl.
+func withPeerIDStore(store PeerIDStore, ttl time.Duration) trackerOption {
+	return func(p *PeerTracker) {
+		p.peerIDStore = store
+		p.peerRecordTTL = ttl
+	}
+}
+
+// withTransport makes the PeerTracker probe peers over the given Transport.
+func withTransport(transport Transport) trackerOption {
+	return func(p *PeerTracker) {
+		if transport == nil {
+			return
+		}
+		p.transport = transport
+	}
+}
+
+// withAllowlist makes the PeerTracker track only the given peers.
+func withAllowlist(peers []peer.ID) trackerOption {
+	return func(p *PeerTracker) {
+		if peers == nil {
+			return
+		}
+		p.allowlist = make(map[peer.ID]struct{}, len(peers))
+		for _, pID := range peers {
+			p.allowlist[pID] = struct{}{}
+		}
+	}
+}
+
 func newPeerTracker(
 	h host.Host,
 	connGater *conngater.BasicConnectionGater,
-) *peerTracker {
+	protocolIDs []protocol.ID,
+	options ...trackerOption,
+) *PeerTracker {
 	ctx, cancel := context.WithCancel(context.Background())
-	return &peerTracker{
+	tracker := &PeerTracker{
 		host:              h,
+		transport:         hostTransport{host: h},
 		connGater:         connGater,
+		protocolIDs:       protocolIDs,
 		disconnectedPeers: make(map[peer.ID]*peerStat),
 		trackedPeers:      make(map[peer.ID]*peerStat),
+		cooldowns:         make(map[peer.ID]time.Time),
+		gcBatchSize:       defaultGCBatchSize,
 		ctx:               ctx,
 		cancel:            cancel,
-		done:              make(chan struct{}, 2),
 	}
+
+	for _, opt := range options {
+		opt(tracker)
+	}
+
+	routines := 2 // track and gc
+	if tracker.probeInterval > 0 {
+		routines++
+	}
+	tracker.done = make(chan struct{}, routines)
+	return tracker
 }
 
-func (p *peerTracker) track() {
+func (p *PeerTracker) track() {
 	defer func() {
 		p.done <- struct{}{}
 	}()
@@ -73,9 +245,15 @@ func (p *peerTracker) track() {
 		p.connected(c.RemotePeer())
 	}
 
-	subs, err := p.host.EventBus().Subscribe(&event.EvtPeerConnectednessChanged{})
+	// supported protocols are only known once the identification is completed,
+	// so connected peers are re-checked after each identification
+	subs, err := p.host.EventBus().Subscribe([]interface{}{
+		&event.EvtPeerConnectednessChanged{},
+		&event.EvtPeerIdentificationCompleted{},
+		&event.EvtPeerProtocolsUpdated{},
+	})
 	if err != nil {
-		log.Errorw("subscribing to EvtPeerConnectednessChanged", "err", err)
+		log.Errorw("subscribing to peer events", "err", err)
 		return
 	}
 
@@ -88,18 +266,26 @@ func (p *peerTracker) track() {
 			}
 			return
 		case subscription := <-subs.Out():
-			ev := subscription.(event.EvtPeerConnectednessChanged)
-			switch ev.Connectedness {
-			case network.Connected:
+			switch ev := subscription.(type) {
+			case event.EvtPeerConnectednessChanged:
+				switch ev.Connectedness {
+				case network.Connected:
+					p.connected(ev.Peer)
+				case network.NotConnected:
+					p.disconnected(ev.Peer)
+				}
+			case event.EvtPeerIdentificationCompleted:
 				p.connected(ev.Peer)
-			case network.NotConnected:
-				p.disconnected(ev.Peer)
+			case event.EvtPeerProtocolsUpdated:
+				if p.host.Network().Connectedness(ev.Peer) == network.Connected {
+					p.connected(ev.Peer)
+				}
 			}
 		}
 	}
 }
 
-func (p *peerTracker) connected(pID peer.ID) {
+func (p *PeerTracker) connected(pID peer.ID) {
 	if p.host.ID() == pID {
 		return
 	}
@@ -111,8 +297,25 @@ func (p *peerTracker) connected(pID peer.ID) {
 		}
 	}
 
+	protocols, ok := p.supportedProtocols(pID)
+	if !ok {
+		return
+	}
+
 	p.peerLk.Lock()
 	defer p.peerLk.Unlock()
+	if stats, ok := p.trackedPeers[pID]; ok {
+		// tracked peers are re-checked as their protocols are updated
+		stats.setProtocols(protocols)
+		return
+	}
+	if _, ok := p.allowlist[pID]; p.allowlist != nil && !ok {
+		return
+	}
+	if until, ok := p.cooldowns[pID]; ok && time.Now().Before(until) {
+		log.Debugw("skipping peer in cooldown", "peer", pID, "until", until)
+		return
+	}
 	// skip adding the peer to avoid overfilling of the peerTracker with unused peers if:
 	// peerTracker reaches the maxTrackerSize and there are more connected peers
 	// than disconnected peers.
@@ -121,18 +324,44 @@ func (p *peerTracker) connected(pID peer.ID) {
 		return
 	}
 
-	// additional check in p.trackedPeers should be done,
-	// because libp2p does not emit multiple Connected events per 1 peer
 	stats, ok := p.disconnectedPeers[pID]
 	if !ok {
 		stats = &peerStat{peerID: pID, peerScore: defaultScore}
 	} else {
 		delete(p.disconnectedPeers, pID)
 	}
+	stats.agentVersion = p.agentVersion(pID)
+	stats.setProtocols(protocols)
+	log.Debugw("tracking peer", "peer", pID, "agent", stats.agentVersion, "protocols", protocols)
 	p.trackedPeers[pID] = stats
 }
 
-func (p *peerTracker) disconnected(pID peer.ID) {
+// supportedProtocols returns the versions of the header exchange protocol of the tracker
+// the peer speaks and whether it speaks any of them.
+// Peers that have not completed identification yet are not considered as supporting it.
+func (p *PeerTracker) supportedProtocols(pID peer.ID) ([]protocol.ID, bool) {
+	if len(p.protocolIDs) == 0 {
+		return nil, true
+	}
+	protocols, err := p.host.Peerstore().SupportsProtocols(pID, p.protocolIDs...)
+	if err != nil {
+		log.Debugw("getting supported protocols", "peer", pID, "err", err)
+		return nil, false
+	}
+	return protocols, len(protocols) != 0
+}
+
+// agentVersion returns the agent version the peer reported during identification.
+func (p *PeerTracker) agentVersion(pID peer.ID) string {
+	av, err := p.host.Peerstore().Get(pID, "AgentVersion")
+	if err != nil {
+		return ""
+	}
+	version, _ := av.(string)
+	return version
+}
+
+func (p *PeerTracker) disconnected(pID peer.ID) {
 	p.peerLk.Lock()
 	defer p.peerLk.Unlock()
 	stats, ok := p.trackedPeers[pID]
@@ -144,7 +373,7 @@ func (p *peerTracker) disconnected(pID peer.ID) {
 	delete(p.trackedPeers, pID)
 }
 
-func (p *peerTracker) peers() []*peerStat {
+func (p *PeerTracker) peers() []*peerStat {
 	p.peerLk.RLock()
 	defer p.peerLk.RUnlock()
 	peers := make([]*peerStat, 0, len(p.trackedPeers))
@@ -154,11 +383,243 @@ func (p *peerTracker) peers() []*peerStat {
 	return peers
 }
 
+// allow adds the given peers to the allowlist, if the PeerTracker runs in the allowlist mode.
+func (p *PeerTracker) allow(peers ...peer.ID) {
+	p.peerLk.Lock()
+	defer p.peerLk.Unlock()
+	if p.allowlist == nil {
+		return
+	}
+	if p.allowed == nil {
+		p.allowed = make(map[peer.ID]struct{}, len(peers))
+	}
+	for _, pID := range peers {
+		if _, ok := p.allowlist[pID]; ok {
+			continue
+		}
+		p.allowlist[pID] = struct{}{}
+		p.allowed[pID] = struct{}{}
+	}
+}
+
+// disallow removes the given peer from the allowlist and stops tracking it,
+// if it was added to the allowlist with allow.
+func (p *PeerTracker) disallow(pID peer.ID) {
+	p.peerLk.Lock()
+	defer p.peerLk.Unlock()
+	if _, ok := p.allowed[pID]; !ok {
+		return
+	}
+	delete(p.allowed, pID)
+	delete(p.allowlist, pID)
+	delete(p.trackedPeers, pID)
+	delete(p.disconnectedPeers, pID)
+}
+
+// headPeers returns the tracked peers sorted by their head score, so the peers
+// keeping up with the chain tip come first. Used to choose the untrusted peers
+// Head falls back to (see WithHeadFallback) and the peers PeekHead asks.
+func (p *PeerTracker) headPeers() []*peerStat {
+	peers := p.peers()
+	sortByTipScore(peers)
+	return peers
+}
+
+// updateLatency records the latency of a request to the given peer, if it is tracked.
+func (p *PeerTracker) updateLatency(pID peer.ID, duration time.Duration) {
+	p.peerLk.RLock()
+	stat, ok := p.trackedPeers[pID]
+	p.peerLk.RUnlock()
+	if ok {
+		stat.updateLatency(duration)
+	}
+}
+
+// updateThroughput records the amount of bytes received from the given peer, if it is tracked,
+// and the duration of the request in milliseconds, so the throughput measured outside sessions
+// is shared with them.
+func (p *PeerTracker) updateThroughput(pID peer.ID, amount, duration uint64) {
+	p.peerLk.RLock()
+	stat, ok := p.trackedPeers[pID]
+	p.peerLk.RUnlock()
+	if ok {
+		stat.updateStats(amount, duration)
+	}
+}
+
+// acquirePrioritized reserves a slot for a high priority request to the given peer, if it is tracked,
+// and returns the function releasing it.
+func (p *PeerTracker) acquirePrioritized(ctx context.Context, pID peer.ID) (func(), error) {
+	p.peerLk.RLock()
+	stat, ok := p.trackedPeers[pID]
+	p.peerLk.RUnlock()
+	if !ok {
+		return func() {}, nil
+	}
+	if err := stat.acquirePrioritized(ctx, p.maxInflight); err != nil {
+		return nil, err
+	}
+	return stat.release, nil
+}
+
+// retains reports whether the given peer is expected to have the header at the given height.
+// Peers not tracked are expected to have it.
+func (p *PeerTracker) retains(pID peer.ID, height uint64) bool {
+	p.peerLk.RLock()
+	stat, ok := p.trackedPeers[pID]
+	p.peerLk.RUnlock()
+	return !ok || stat.retains(height)
+}
+
+// updateAdvertised records the lowest height retained by the given peer along with its limits
+// and features advertised in the given head response, if the peer is tracked.
+func (p *PeerTracker) updateAdvertised(pID peer.ID, resp *p2p_pb.HeaderResponse) {
+	p.peerLk.RLock()
+	stat, ok := p.trackedPeers[pID]
+	p.peerLk.RUnlock()
+	if ok {
+		stat.setAdvertised(resp)
+	}
+}
+
+// recordResult records the result of a request to the given peer, if it is tracked,
+// for its circuit breaker. Missing headers do not count as failures.
+func (p *PeerTracker) recordResult(pID peer.ID, err error) {
+	p.peerLk.RLock()
+	stat, ok := p.trackedPeers[pID]
+	p.peerLk.RUnlock()
+	if !ok {
+		return
+	}
+	p.recordStatResult(stat, err)
+}
+
+// decreaseScore lowers the score of the given peer, if it is tracked.
+func (p *PeerTracker) decreaseScore(pID peer.ID) {
+	p.peerLk.RLock()
+	stat, ok := p.trackedPeers[pID]
+	p.peerLk.RUnlock()
+	if ok {
+		stat.decreaseScore()
+	}
+}
+
+func (p *PeerTracker) recordStatResult(stat *peerStat, err error) {
+	var (
+		rateErr   *RateLimitedError
+		prunedErr *PrunedError
+	)
+	switch {
+	case err == nil:
+		stat.succeed()
+	case errors.Is(err, header.ErrNotFound), errors.Is(err, ErrInvalidRequest):
+		// the peer is healthy, as the client is to blame for invalid requests
+	case errors.As(err, &rateErr):
+		// the peer is healthy, but asks to back off
+		stat.throttle(rateErr.RetryAfter)
+	case errors.As(err, &prunedErr):
+		// the peer is healthy, but does not serve the requested headers anymore
+		stat.raiseTail(prunedErr.Tail)
+	case errors.Is(err, ErrResponseLimitExceeded), errors.Is(err, errInvalidResponse):
+		// oversized or invalid responses lower the score of the peer instead of getting it blocked,
+		// so the request is retried with other peers
+		stat.decreaseScore()
+		stat.fail(p.breakerThreshold, p.breakerCooldown)
+	default:
+		stat.fail(p.breakerThreshold, p.breakerCooldown)
+	}
+}
+
+// reserveProbe reserves the request about to be sent to the given peer, if it is tracked,
+// as the probe of its circuit breaker, so other requests are not routed to the peer until it completes.
+func (p *PeerTracker) reserveProbe(pID peer.ID) {
+	p.peerLk.RLock()
+	stat, ok := p.trackedPeers[pID]
+	p.peerLk.RUnlock()
+	if ok {
+		stat.reserveProbe(p.breakerCooldown)
+	}
+}
+
+// routable filters out the given peers with the open circuit breaker
+// or rate limiting the client. If none of the peers is routable, all of them are returned,
+// so requests are never left without peers.
+func (p *PeerTracker) routable(peers peer.IDSlice) peer.IDSlice {
+	p.peerLk.RLock()
+	defer p.peerLk.RUnlock()
+	routable := make(peer.IDSlice, 0, len(peers))
+	for _, pID := range peers {
+		stat, ok := p.trackedPeers[pID]
+		if ok && (stat.throttleWait() > 0 || stat.breakerOpen() > 0) {
+			continue
+		}
+		routable = append(routable, pID)
+	}
+	if len(routable) == 0 {
+		return peers
+	}
+	return routable
+}
+
+// sortByLatency sorts the given peers by their average latency in ascending order,
+// keeping the order of peers with equal or unknown latency. Peers with unknown latency go last.
+func (p *PeerTracker) sortByLatency(peers peer.IDSlice) {
+	latencies := make(map[peer.ID]time.Duration, len(peers))
+	p.peerLk.RLock()
+	for _, pID := range peers {
+		if stat, ok := p.trackedPeers[pID]; ok {
+			latencies[pID] = stat.avgLatency()
+		}
+	}
+	p.peerLk.RUnlock()
+
+	sort.SliceStable(peers, func(i, j int) bool {
+		li, lj := latencies[peers[i]], latencies[peers[j]]
+		if li == 0 || lj == 0 {
+			return lj == 0 && li != 0
+		}
+		return li < lj
+	})
+}
+
+// sortByThroughput sorts the given peers by their throughput in descending order,
+// keeping the order of peers with equal throughput. Peers with unknown throughput go last.
+func (p *PeerTracker) sortByThroughput(peers peer.IDSlice) {
+	scores := make(map[peer.ID]float32, len(peers))
+	p.peerLk.RLock()
+	for _, pID := range peers {
+		if stat, ok := p.trackedPeers[pID]; ok {
+			scores[pID] = stat.score()
+		}
+	}
+	p.peerLk.RUnlock()
+
+	sort.SliceStable(peers, func(i, j int) bool {
+		return scores[peers[i]] > scores[peers[j]]
+	})
+}
+
+// updateNetworkHead sets the network head height if it is higher than the known one.
+func (p *PeerTracker) updateNetworkHead(height uint64) {
+	for {
+		known := p.networkHead.Load()
+		if height <= known || p.networkHead.CompareAndSwap(known, height) {
+			return
+		}
+	}
+}
+
+// headProximity weights how close the served height is to the known network head.
+func (p *PeerTracker) headProximity(served uint64) float32 {
+	return headProximity(served, p.networkHead.Load())
+}
+
 // gc goes through connected and disconnected peers once every gcPeriod
 // and removes:
 // * disconnected peers which have been disconnected for more than maxAwaitingTime;
 // * connected peers whose scores are less than or equal than defaultScore;
-func (p *peerTracker) gc() {
+// * expired cooldowns.
+func (p *PeerTracker) gc() {
 	ticker := time.NewTicker(gcCycle)
 	for {
 		select {
@@ -166,26 +627,214 @@ func (p *peerTracker) gc() {
 			p.done <- struct{}{}
 			return
 		case <-ticker.C:
-			p.peerLk.Lock()
-			now := time.Now()
-			for id, peer := range p.disconnectedPeers {
-				if peer.pruneDeadline.Before(now) {
-					delete(p.disconnectedPeers, id)
+			p.collectGarbage()
+		}
+	}
+}
+
+// collectGarbage performs a single gc cycle. Peers are processed in batches of gcBatchSize,
+// releasing the lock between batches, so large maps do not stall connected()/disconnected().
+func (p *PeerTracker) collectGarbage() {
+	now := time.Now()
+	p.peerLk.RLock()
+	disconnected := make([]peer.ID, 0, len(p.disconnectedPeers))
+	for id := range p.disconnectedPeers {
+		disconnected = append(disconnected, id)
+	}
+	tracked := make([]peer.ID, 0, len(p.trackedPeers))
+	for id := range p.trackedPeers {
+		tracked = append(tracked, id)
+	}
+	cooldowns := make([]peer.ID, 0, len(p.cooldowns))
+	for id := range p.cooldowns {
+		cooldowns = append(cooldowns, id)
+	}
+	p.peerLk.RUnlock()
+
+	// peers could have changed their state between batches, so every entry is checked again.
+	var removed []peer.ID
+	p.inBatches(disconnected, func(id peer.ID) {
+		if peer, ok := p.disconnectedPeers[id]; ok && peer.pruneDeadline.Before(now) {
+			delete(p.disconnectedPeers, id)
+			removed = append(removed, id)
+		}
+	})
+	p.inBatches(tracked, func(id peer.ID) {
+		if peer, ok := p.trackedPeers[id]; ok && peer.peerScore <= defaultScore {
+			delete(p.trackedPeers, id)
+			p.cooldowns[id] = now.Add(peerCooldown)
+			removed = append(removed, id)
+		}
+	})
+	p.inBatches(cooldowns, func(id peer.ID) {
+		if until, ok := p.cooldowns[id]; ok && until.Before(now) {
+			delete(p.cooldowns, id)
+		}
+	})
+
+	p.persist(now, removed)
+}
+
+// inBatches applies f to the given peers holding the lock for at most gcBatchSize peers at once.
+func (p *PeerTracker) inBatches(ids []peer.ID, f func(peer.ID)) {
+	for len(ids) > 0 {
+		size := p.gcBatchSize
+		if size <= 0 || size > len(ids) {
+			size = len(ids)
+		}
+
+		p.peerLk.Lock()
+		for _, id := range ids[:size] {
+			f(id)
+		}
+		p.peerLk.Unlock()
+		ids = ids[size:]
+	}
+}
+
+// probe periodically requests the head from tracked peers which were not requested
+// during the probeInterval, so the first real request after a quiet period does not
+// hit unresponsive peers. Responsive peers get their stats updated, while unresponsive
+// peers get their score decreased, so they are eventually removed by gc.
+func (p *PeerTracker) probe() {
+	ticker := time.NewTicker(p.probeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			p.done <- struct{}{}
+			return
+		case <-ticker.C:
+			idleSince := time.Now().Add(-p.probeInterval)
+			var wg sync.WaitGroup
+			for _, stat := range p.peers() {
+				if stat.idleSince().After(idleSince) {
+					continue
 				}
+
+				wg.Add(1)
+				go func(stat *peerStat) {
+					defer wg.Done()
+					p.probePeer(stat)
+				}(stat)
 			}
+			wg.Wait()
+		}
+	}
+}
 
-			for id, peer := range p.trackedPeers {
-				if peer.peerScore <= defaultScore {
-					delete(p.trackedPeers, id)
-				}
+// probePeer sends a head request to the peer and updates its stats with the outcome.
+func (p *PeerTracker) probePeer(stat *peerStat) {
+	ctx, cancel := context.WithTimeout(p.ctx, p.probeInterval/2)
+	defer cancel()
+
+	req := &p2p_pb.HeaderRequest{
+		Data:   &p2p_pb.HeaderRequest_Origin{Origin: uint64(0)},
+		Amount: 1,
+	}
+	resps, size, duration, err := sendMessage(ctx, p.transport, stat.peerID, p.protocolIDs, req, 0)
+	if err == nil && len(resps) == 0 {
+		err = errEmptyResponse
+	}
+	if err == nil {
+		err = convertStatusCodeToError(resps[0])
+	}
+	if err != nil {
+		log.Debugw("probing idle peer failed", "peer", stat.peerID, "err", err)
+		stat.decreaseScore()
+		return
+	}
+	stat.updateStats(size, duration)
+	stat.setAdvertised(resps[0])
+}
+
+// Start starts tracking peers along with the garbage collection
+// and, if enabled, liveness probing of the tracked peers.
+func (p *PeerTracker) Start(ctx context.Context) error {
+	if p.peerIDStore != nil {
+		records, err := p.peerIDStore.Load(ctx)
+		if err != nil {
+			return fmt.Errorf("loading persisted peers: %w", err)
+		}
+		go p.bootstrap(records)
+	}
+
+	go p.gc()
+	go p.track()
+	if p.probeInterval > 0 {
+		go p.probe()
+	}
+	return nil
+}
+
+// bootstrap connects to the persisted peers, so they are tracked once connected
+// with the score they were persisted with.
+func (p *PeerTracker) bootstrap(records []PeerRecord) {
+	p.peerLk.Lock()
+	pruneDeadline := time.Now().Add(maxAwaitingTime)
+	for _, record := range records {
+		pID := record.AddrInfo.ID
+		if _, ok := p.trackedPeers[pID]; ok {
+			continue
+		}
+		if _, ok := p.disconnectedPeers[pID]; ok {
+			continue
+		}
+		// the score is restored once the peer connects, unless it does not until pruneDeadline
+		p.disconnectedPeers[pID] = &peerStat{
+			peerID:        pID,
+			peerScore:     record.Score,
+			pruneDeadline: pruneDeadline,
+		}
+	}
+	p.peerLk.Unlock()
+
+	for _, record := range records {
+		go func(info peer.AddrInfo) {
+			err := p.host.Connect(p.ctx, info)
+			if err != nil {
+				log.Debugw("connecting to persisted peer", "peer", info.ID, "err", err)
 			}
-			p.peerLk.Unlock()
+		}(record.AddrInfo)
+	}
+}
+
+// persist refreshes the records of tracked peers that are about to expire
+// and removes the records of the given peers.
+func (p *PeerTracker) persist(now time.Time, removed []peer.ID) {
+	if p.peerIDStore == nil {
+		return
+	}
+
+	var records []PeerRecord
+	p.peerLk.RLock()
+	for id, stat := range p.trackedPeers {
+		stat.Lock()
+		// records are only rewritten once half of their ttl passed
+		if now.Sub(stat.persisted) >= p.peerRecordTTL/2 {
+			stat.persisted = now
+			records = append(records, PeerRecord{
+				AddrInfo: p.host.Peerstore().PeerInfo(id),
+				Score:    stat.peerScore,
+				Expiry:   now.Add(p.peerRecordTTL),
+			})
 		}
+		stat.Unlock()
+	}
+	p.peerLk.RUnlock()
+
+	ctx, cancel := context.WithTimeout(p.ctx, time.Minute)
+	defer cancel()
+	if err := p.peerIDStore.Put(ctx, records...); err != nil {
+		log.Errorw("persisting tracked peers", "err", err)
+	}
+	if err := p.peerIDStore.Delete(ctx, removed...); err != nil {
+		log.Errorw("removing persisted peers", "err", err)
 	}
 }
 
-// stop waits until all background routines will be finished.
-func (p *peerTracker) stop(ctx context.Context) error {
+// Stop stops the PeerTracker and waits until all background routines will be finished.
+func (p *PeerTracker) Stop(ctx context.Context) error {
 	p.cancel()
 
 	for i := 0; i < cap(p.done); i++ {
@@ -200,7 +849,7 @@ func (p *peerTracker) stop(ctx context.Context) error {
 }
 
 // blockPeer blocks a peer on the networking level and removes it from the local cache.
-func (p *peerTracker) blockPeer(pID peer.ID, reason error) {
+func (p *PeerTracker) blockPeer(pID peer.ID, reason error) {
 	// add peer to the blacklist, so we can't connect to it in the future.
 	err := p.connGater.BlockPeer(pID)
 	if err != nil {
@@ -215,8 +864,20 @@ func (p *peerTracker) blockPeer(pID peer.ID, reason error) {
 	log.Warnw("header/p2p: blocked peer", "pID", pID, "reason", reason)
 
 	p.peerLk.Lock()
-	defer p.peerLk.Unlock()
 	// remove peer from cache.
 	delete(p.trackedPeers, pID)
 	delete(p.disconnectedPeers, pID)
+	// and ensure it is not tracked right away if it is unblocked.
+	p.cooldowns[pID] = time.Now().Add(peerCooldown)
+	p.peerLk.Unlock()
+
+	if p.peerIDStore != nil {
+		if err := p.peerIDStore.Delete(p.ctx, pID); err != nil {
+			log.Errorw("removing blocked peer from the store", "pID", pID, "err", err)
+		}
+	}
+
+	if p.onBlocked != nil {
+		p.onBlocked(pID, reason)
+	}
 }
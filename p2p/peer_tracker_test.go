@@ -3,15 +3,22 @@ package p2p
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/ipfs/go-datastore"
 	"github.com/ipfs/go-datastore/sync"
+	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/libp2p/go-libp2p/p2p/net/conngater"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header"
+	"github.com/celestiaorg/go-header/headertest"
 )
 
 func TestPeerTracker_GC(t *testing.T) {
@@ -19,7 +26,7 @@ func TestPeerTracker_GC(t *testing.T) {
 	gcCycle = time.Millisecond * 200
 	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
 	require.NoError(t, err)
-	p := newPeerTracker(h[0], connGater)
+	p := newPeerTracker(h[0], connGater, protocolIDs(networkID))
 	maxAwaitingTime = time.Millisecond
 	pid1 := peer.ID("peer1")
 	pid2 := peer.ID("peer2")
@@ -37,20 +44,249 @@ func TestPeerTracker_GC(t *testing.T) {
 
 	time.Sleep(time.Second * 1)
 
-	err = p.stop(context.Background())
+	err = p.Stop(context.Background())
 	require.NoError(t, err)
 
 	require.Nil(t, p.trackedPeers[pid1])
 	require.Nil(t, p.disconnectedPeers[pid3])
+	require.Contains(t, p.cooldowns, pid1)
+}
+
+func TestPeerTracker_GCBatches(t *testing.T) {
+	h := createMocknet(t, 1)
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	p := newPeerTracker(h[0], connGater, protocolIDs(networkID), withGCBatchSize(2))
+	for i := 0; i < 5; i++ {
+		pid := peer.ID(fmt.Sprintf("peer%d", i))
+		p.trackedPeers[pid] = &peerStat{peerID: pid, peerScore: defaultScore}
+	}
+	good := peer.ID("good")
+	p.trackedPeers[good] = &peerStat{peerID: good, peerScore: 10}
+
+	p.collectGarbage()
+	require.Len(t, p.trackedPeers, 1)
+	require.Contains(t, p.trackedPeers, good)
+	require.Len(t, p.cooldowns, 5)
 }
 
 func TestPeerTracker_BlockPeer(t *testing.T) {
 	h := createMocknet(t, 2)
 	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
 	require.NoError(t, err)
-	p := newPeerTracker(h[0], connGater)
+	var blocked peer.ID
+	reason := errors.New("test")
+	p := newPeerTracker(h[0], connGater, protocolIDs(networkID), withOnBlockedPeer(func(pID peer.ID, err error) {
+		blocked = pID
+		require.ErrorIs(t, err, reason)
+	}))
 	maxAwaitingTime = time.Millisecond
-	p.blockPeer(h[1].ID(), errors.New("test"))
+	p.blockPeer(h[1].ID(), reason)
 	require.Len(t, connGater.ListBlockedPeers(), 1)
 	require.True(t, connGater.ListBlockedPeers()[0] == h[1].ID())
+	require.Equal(t, h[1].ID(), blocked)
+}
+
+func TestPeerTracker_Cooldown(t *testing.T) {
+	h := createMocknet(t, 2)
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	// empty protocol ID disables the protocol check
+	p := newPeerTracker(h[0], connGater, nil)
+
+	p.connected(h[1].ID())
+	require.Contains(t, p.trackedPeers, h[1].ID())
+
+	p.blockPeer(h[1].ID(), errors.New("test"))
+	require.NoError(t, connGater.UnblockPeer(h[1].ID()))
+	// peer is unblocked, but still in cooldown
+	p.connected(h[1].ID())
+	require.NotContains(t, p.trackedPeers, h[1].ID())
+
+	// cooldown is expired
+	p.cooldowns[h[1].ID()] = time.Now().Add(-time.Second)
+	p.connected(h[1].ID())
+	require.Contains(t, p.trackedPeers, h[1].ID())
+}
+
+func TestPeerTracker_TracksOnlyProtocolPeers(t *testing.T) {
+	net, err := mocknet.WithNPeers(3)
+	require.NoError(t, err)
+	h := net.Hosts()
+	// only the second host speaks the header exchange protocol
+	h[1].SetStreamHandler(protocolID(networkID), func(s network.Stream) { s.Reset() }) //nolint:errcheck
+
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	p := newPeerTracker(h[0], connGater, protocolIDs(networkID))
+	go p.track()
+	go p.gc()
+	t.Cleanup(func() {
+		require.NoError(t, p.Stop(context.Background()))
+	})
+
+	require.NoError(t, net.LinkAll())
+	require.NoError(t, net.ConnectAllButSelf())
+
+	require.Eventually(t, func() bool {
+		p.peerLk.RLock()
+		defer p.peerLk.RUnlock()
+		_, ok := p.trackedPeers[h[1].ID()]
+		return ok
+	}, time.Second, time.Millisecond*10)
+
+	p.peerLk.RLock()
+	defer p.peerLk.RUnlock()
+	require.NotContains(t, p.trackedPeers, h[2].ID())
+	// the versions of the protocol the peer supports are recorded
+	stat := p.trackedPeers[h[1].ID()]
+	stat.RLock()
+	defer stat.RUnlock()
+	require.Equal(t, []protocol.ID{protocolID(networkID)}, stat.protocols)
+}
+
+func TestPeerTracker_Probe(t *testing.T) {
+	h := createMocknet(t, 2)
+	_ = server(context.Background(), t, h[1], headertest.NewDummyStore(t))
+
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	p := newPeerTracker(h[0], connGater, protocolIDs(""), withProbeInterval(time.Millisecond*100))
+
+	alive := &peerStat{peerID: h[1].ID(), peerScore: defaultScore}
+	dead := &peerStat{peerID: peer.ID("dead"), peerScore: 10}
+	p.trackedPeers[alive.peerID] = alive
+	p.trackedPeers[dead.peerID] = dead
+
+	go p.probe()
+	t.Cleanup(func() {
+		p.cancel()
+		<-p.done
+	})
+
+	require.Eventually(t, func() bool {
+		return !alive.idleSince().IsZero() && dead.score() < 10
+	}, time.Second, time.Millisecond*10)
+	require.NotEqual(t, defaultScore, alive.score())
+}
+
+func TestPeerTracker_SharedWithExchange(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	h := createMocknet(t, 2)
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	tracker, err := NewPeerTracker(h[0], connGater, WithNetworkID[ClientParameters](networkID))
+	require.NoError(t, err)
+	require.NoError(t, tracker.Start(ctx))
+
+	ex, err := NewExchange[*headertest.DummyHeader](h[0], []peer.ID{h[1].ID()}, connGater,
+		WithNetworkID[ClientParameters](networkID),
+		WithPeerTracker[ClientParameters](tracker),
+	)
+	require.NoError(t, err)
+	require.Same(t, tracker, ex.peerTracker)
+	require.NoError(t, ex.Start(ctx))
+	require.NoError(t, ex.Stop(ctx))
+
+	// the tracker is still running after the Exchange is stopped
+	select {
+	case <-tracker.ctx.Done():
+		t.Fatal("shared tracker is stopped by the exchange")
+	default:
+	}
+	require.NoError(t, tracker.Stop(ctx))
+}
+
+func TestPeerTracker_Allowlist(t *testing.T) {
+	h := createMocknet(t, 4)
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	// empty protocol ID disables the protocol check
+	p := newPeerTracker(h[0], connGater, nil, withAllowlist([]peer.ID{h[1].ID()}))
+	p.allow(h[2].ID())
+
+	for _, peer := range h[1:] {
+		p.connected(peer.ID())
+	}
+	require.Len(t, p.trackedPeers, 2)
+	require.Contains(t, p.trackedPeers, h[1].ID())
+	require.Contains(t, p.trackedPeers, h[2].ID())
+
+	// only the peers added with allow are revoked
+	p.allow(h[1].ID())
+	p.disallow(h[1].ID())
+	p.disallow(h[2].ID())
+	require.Len(t, p.trackedPeers, 1)
+	require.Contains(t, p.trackedPeers, h[1].ID())
+	p.connected(h[2].ID())
+	require.NotContains(t, p.trackedPeers, h[2].ID())
+
+	// allow is a noop without the allowlist mode
+	p = newPeerTracker(h[0], connGater, nil)
+	p.allow(h[1].ID())
+	require.Nil(t, p.allowlist)
+}
+
+func TestPeerTracker_SortByLatency(t *testing.T) {
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	p := newPeerTracker(nil, connGater, nil)
+
+	slow, fast, unknown := peer.ID("slow"), peer.ID("fast"), peer.ID("unknown")
+	for _, pID := range []peer.ID{slow, fast, unknown} {
+		p.trackedPeers[pID] = &peerStat{peerID: pID}
+	}
+	p.updateLatency(slow, time.Second)
+	p.updateLatency(fast, time.Millisecond*10)
+	p.updateLatency(fast, time.Millisecond*30)
+	require.Equal(t, time.Millisecond*20, p.trackedPeers[fast].avgLatency())
+
+	peers := peer.IDSlice{unknown, slow, fast}
+	p.sortByLatency(peers)
+	require.Equal(t, peer.IDSlice{fast, slow, unknown}, peers)
+}
+
+func TestPeerTracker_SortByThroughput(t *testing.T) {
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	p := newPeerTracker(nil, connGater, nil)
+
+	low, high, unknown, untracked := peer.ID("low"), peer.ID("high"), peer.ID("unknown"), peer.ID("untracked")
+	for _, pID := range []peer.ID{low, high, unknown} {
+		p.trackedPeers[pID] = &peerStat{peerID: pID}
+	}
+	// the peer transferring the most is not necessarily the quickest one to respond
+	p.updateThroughput(low, 1000, 100)
+	p.updateThroughput(high, 100000, 200)
+	p.updateThroughput(untracked, 100000, 1)
+	require.EqualValues(t, 500, p.trackedPeers[high].score())
+
+	peers := peer.IDSlice{untracked, unknown, low, high}
+	p.sortByThroughput(peers)
+	require.Equal(t, peer.IDSlice{high, low, untracked, unknown}, peers)
+}
+
+func TestPeerTracker_CircuitBreaker(t *testing.T) {
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	p := newPeerTracker(nil, connGater, nil, withCircuitBreaker(2, time.Minute))
+
+	broken, healthy := peer.ID("broken"), peer.ID("healthy")
+	for _, pID := range []peer.ID{broken, healthy} {
+		p.trackedPeers[pID] = &peerStat{peerID: pID}
+	}
+	// missing headers do not trip the breaker
+	for i := 0; i < 3; i++ {
+		p.recordResult(broken, header.ErrNotFound)
+	}
+	require.Len(t, p.routable(peer.IDSlice{broken, healthy}), 2)
+
+	p.recordResult(broken, errEmptyResponse)
+	p.recordResult(broken, errEmptyResponse)
+	require.Equal(t, peer.IDSlice{healthy}, p.routable(peer.IDSlice{broken, healthy}))
+	// the peer is not blocked, so it is still used if no other peers are left
+	require.Equal(t, peer.IDSlice{broken}, p.routable(peer.IDSlice{broken}))
+	require.Contains(t, p.trackedPeers, broken)
 }
@@ -0,0 +1,50 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// ProofProvider returns an opaque proof the server attaches to the response carrying the given
+// header, e.g. commit signatures or an inclusion proof. Nil proof attaches nothing.
+type ProofProvider[H header.Header] func(ctx context.Context, h H) ([]byte, error)
+
+// ProofVerifier verifies the given header with the opaque proof attached to it by the serving peer.
+// The proof is nil if the peer attached none. Light clients use it to verify headers
+// beyond the hash-chain checks.
+type ProofVerifier[H header.Header] func(ctx context.Context, h H, proof []byte) error
+
+// WithProofProvider is a functional option that configures the
+// `proofProvider` parameter.
+func WithProofProvider[T ServerParameters, H header.Header](provider ProofProvider[H]) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ServerParameters:
+			t.proofProvider = provider
+		}
+	}
+}
+
+// WithProofVerifier is a functional option that configures the
+// `proofVerifier` parameter.
+func WithProofVerifier[T ClientParameters, H header.Header](verifier ProofVerifier[H]) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.proofVerifier = verifier
+		}
+	}
+}
+
+// verify verifies the header with the proof. Nil verifier accepts any header.
+func (v ProofVerifier[H]) verify(ctx context.Context, h H, proof []byte) error {
+	if v == nil {
+		return nil
+	}
+	if err := v(ctx, h, proof); err != nil {
+		return fmt.Errorf("header/p2p: verifying proof of header %d: %w", h.Height(), err)
+	}
+	return nil
+}
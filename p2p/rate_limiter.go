@@ -0,0 +1,158 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// rateLimiterGCInterval specifies how often the rate limiter forgets peers that stayed idle
+// long enough to refill their buckets.
+var rateLimiterGCInterval = time.Minute
+
+// tokenBucket holds up to burst tokens, refilled at the given rate per second.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst float64, now time.Time) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: now}
+}
+
+// refill adds the tokens accumulated since the last refill.
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+// wait returns how long to wait until the given amount of tokens is available.
+func (b *tokenBucket) wait(amount float64) time.Duration {
+	if b.tokens >= amount {
+		return 0
+	}
+	return time.Duration((amount - b.tokens) / b.rate * float64(time.Second))
+}
+
+// full reports whether the bucket is refilled completely.
+func (b *tokenBucket) full() bool {
+	return b.tokens >= b.burst
+}
+
+// peerBuckets are the token buckets limiting a single peer.
+type peerBuckets struct {
+	requests *tokenBucket
+	headers  *tokenBucket
+}
+
+// rateLimiter limits the requests and the headers served to every peer with token buckets.
+// A nil rateLimiter does not limit anything.
+type rateLimiter struct {
+	lk sync.Mutex
+	// requests and headers are the amounts allowed per second to a single peer.
+	// Zero means no limit.
+	requests float64
+	headers  float64
+	// headersBurst is the amount of headers a peer can request at once.
+	headersBurst float64
+	peers        map[peer.ID]*peerBuckets
+	lastGC       time.Time
+}
+
+// newRateLimiter creates a new rateLimiter allowing the given amounts of requests and headers
+// per second to every peer. A peer can always request up to maxHeaders at once.
+// Zero amounts return nil, meaning no limits.
+func newRateLimiter(requests, headers, maxHeaders uint64) *rateLimiter {
+	if requests == 0 && headers == 0 {
+		return nil
+	}
+	headersBurst := headers
+	if headersBurst < maxHeaders {
+		headersBurst = maxHeaders
+	}
+	return &rateLimiter{
+		requests:     float64(requests),
+		headers:      float64(headers),
+		headersBurst: float64(headersBurst),
+		peers:        make(map[peer.ID]*peerBuckets),
+		lastGC:       time.Now(),
+	}
+}
+
+// allow accounts a request for the given amount of headers from the given peer.
+// It returns zero if the request is allowed, or how long the peer should wait before
+// the request would be allowed otherwise. Rejected requests consume nothing.
+func (l *rateLimiter) allow(from peer.ID, headers uint64) time.Duration {
+	if l == nil {
+		return 0
+	}
+
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	now := time.Now()
+	l.gc(now)
+
+	buckets, ok := l.peers[from]
+	if !ok {
+		buckets = &peerBuckets{}
+		if l.requests > 0 {
+			buckets.requests = newTokenBucket(l.requests, l.requests, now)
+		}
+		if l.headers > 0 {
+			buckets.headers = newTokenBucket(l.headers, l.headersBurst, now)
+		}
+		l.peers[from] = buckets
+	}
+
+	var wait time.Duration
+	if buckets.requests != nil {
+		buckets.requests.refill(now)
+		wait = buckets.requests.wait(1)
+	}
+	if buckets.headers != nil {
+		buckets.headers.refill(now)
+		if headersWait := buckets.headers.wait(float64(headers)); headersWait > wait {
+			wait = headersWait
+		}
+	}
+	if wait > 0 {
+		return wait
+	}
+
+	if buckets.requests != nil {
+		buckets.requests.tokens--
+	}
+	if buckets.headers != nil {
+		buckets.headers.tokens -= float64(headers)
+	}
+	return 0
+}
+
+// gc forgets the peers whose buckets are full, as they would be created full anyway.
+func (l *rateLimiter) gc(now time.Time) {
+	if now.Sub(l.lastGC) < rateLimiterGCInterval {
+		return
+	}
+	l.lastGC = now
+	for id, buckets := range l.peers {
+		if buckets.requests != nil {
+			buckets.requests.refill(now)
+			if !buckets.requests.full() {
+				continue
+			}
+		}
+		if buckets.headers != nil {
+			buckets.headers.refill(now)
+			if !buckets.headers.full() {
+				continue
+			}
+		}
+		delete(l.peers, id)
+	}
+}
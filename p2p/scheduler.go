@@ -0,0 +1,118 @@
+package p2p
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
+)
+
+// Range is a range of adjacent headers requested from a single peer.
+type Range struct {
+	// From is the height of the first header of the range.
+	From uint64
+	// Amount is the amount of headers in the range.
+	Amount uint64
+}
+
+// PeerInfo describes a tracked peer a Range can be assigned to.
+type PeerInfo struct {
+	ID peer.ID
+	// Score is the average speed of requests to the peer.
+	Score float32
+	// Latency is the average duration of requests to the peer. Zero means the peer was not requested yet.
+	Latency time.Duration
+	// Tail is the lowest height retained by the peer, as advertised in its head responses.
+	// Zero means the peer did not advertise it.
+	Tail uint64
+	// MaxRange is the max amount of headers the peer serves per request, as advertised
+	// in its head responses. Zero means the peer did not advertise it.
+	MaxRange uint64
+	// Features are the optional features of the peer, as advertised in its head responses.
+	Features []p2p_pb.Feature
+}
+
+// Retains reports whether the peer is expected to have the header at the given height.
+// Peers which did not advertise their tail are assumed to be archival.
+func (p PeerInfo) Retains(height uint64) bool {
+	return p.Tail == 0 || p.Tail <= height
+}
+
+// Scheduler controls how the ranges requested from the network are split into Ranges
+// requested from single peers, in which order they are requested and which peers they are assigned to.
+// This allows to experiment with strategies like rarest-first or locality-aware scheduling.
+type Scheduler interface {
+	// Chunk splits the given amount of headers starting from the given height into Ranges
+	// of at most maxAmount headers, which are requested in the returned order.
+	// The Ranges must cover all the headers exactly once.
+	Chunk(from, amount, maxAmount uint64) []Range
+	// Suits reports whether the Range is preferably assigned to the peer.
+	// The available peers are offered in the order of their score and the Range is assigned to
+	// the first one suiting it. Peers not suiting the Range are only assigned it if no other peer is available.
+	Suits(r Range, p PeerInfo) bool
+}
+
+// sequentialScheduler is the default Scheduler requesting Ranges of maxAmount headers in ascending order
+// from the best scored peers, preferring the ones retaining the Range.
+type sequentialScheduler struct{}
+
+func (sequentialScheduler) Chunk(from, amount, maxAmount uint64) []Range {
+	ranges := make([]Range, 0, amount/maxAmount+1)
+	for amount > 0 {
+		size := maxAmount
+		if amount < size {
+			size = amount
+		}
+		ranges = append(ranges, Range{From: from, Amount: size})
+		from += size
+		amount -= size
+	}
+	return ranges
+}
+
+func (sequentialScheduler) Suits(r Range, p PeerInfo) bool {
+	return p.Retains(r.From)
+}
+
+// scheduleRequests splits the range into HeaderRequests with the given Scheduler and
+// ensures the Ranges it returned cover the range exactly once.
+func scheduleRequests(scheduler Scheduler, from, amount, maxAmount uint64) ([]*p2p_pb.HeaderRequest, error) {
+	ranges := scheduler.Chunk(from, amount, maxAmount)
+	sorted := append(make([]Range, 0, len(ranges)), ranges...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].From < sorted[j].From })
+	next := from
+	for _, r := range sorted {
+		if r.Amount == 0 || r.Amount > maxAmount {
+			return nil, fmt.Errorf("header/p2p: scheduler returned range of invalid size %d", r.Amount)
+		}
+		if r.From != next {
+			return nil, fmt.Errorf("header/p2p: scheduler returned range %d:%d, expected it to start from %d",
+				r.From, r.Amount, next)
+		}
+		next += r.Amount
+	}
+	if next != from+amount {
+		return nil, fmt.Errorf("header/p2p: scheduler covered headers up to %d out of %d", next, from+amount)
+	}
+	return rangeRequests(ranges), nil
+}
+
+// rangeRequests converts the Ranges into HeaderRequests.
+func rangeRequests(ranges []Range) []*p2p_pb.HeaderRequest {
+	requests := make([]*p2p_pb.HeaderRequest, len(ranges))
+	for i, r := range ranges {
+		requests[i] = &p2p_pb.HeaderRequest{
+			Data:   &p2p_pb.HeaderRequest_Origin{Origin: r.From},
+			Amount: r.Amount,
+		}
+	}
+	return requests
+}
+
+// requestRange returns the Range requested by the given request.
+func requestRange(req *p2p_pb.HeaderRequest) Range {
+	return Range{From: req.GetOrigin(), Amount: req.Amount}
+}
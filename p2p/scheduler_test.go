@@ -0,0 +1,116 @@
+package p2p
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/net/conngater"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header/headertest"
+)
+
+// TestExchange_Scheduler ensures ranges are requested in the chunks of the configured Scheduler.
+func TestExchange_Scheduler(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	hosts := createMocknet(t, 2)
+	store := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 20)
+	serv, err := NewExchangeServer[*headertest.DummyHeader](hosts[1], store,
+		WithNetworkID[ServerParameters](networkID),
+	)
+	require.NoError(t, err)
+	require.NoError(t, serv.Start(ctx))
+	t.Cleanup(func() {
+		serv.Stop(ctx) //nolint:errcheck
+	})
+
+	scheduler := &reverseScheduler{}
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	exchg, err := NewExchange[*headertest.DummyHeader](hosts[0], []peer.ID{hosts[1].ID()}, connGater,
+		WithNetworkID[ClientParameters](networkID),
+		WithChainID(networkID),
+		WithMaxHeadersPerRangeRequest[ClientParameters](4),
+		WithScheduler[ClientParameters](scheduler),
+	)
+	require.NoError(t, err)
+	require.NoError(t, exchg.Start(ctx))
+	t.Cleanup(func() {
+		exchg.Stop(ctx) //nolint:errcheck
+	})
+	exchg.peerTracker.peerLk.Lock()
+	exchg.peerTracker.trackedPeers[hosts[1].ID()] = &peerStat{peerID: hosts[1].ID(), peerScore: 100}
+	exchg.peerTracker.peerLk.Unlock()
+
+	headers, err := exchg.GetRangeByHeight(ctx, 3, 10)
+	require.NoError(t, err)
+	require.Len(t, headers, 10)
+	for i, h := range headers {
+		assert.EqualValues(t, 3+i, h.Height())
+	}
+	assert.EqualValues(t, 1, scheduler.chunked.Load())
+	assert.EqualValues(t, 3, scheduler.suited.Load())
+}
+
+// Test_ScheduleRequestsRejectsInvalidRanges ensures Ranges not covering the requested range
+// exactly once are rejected.
+func Test_ScheduleRequestsRejectsInvalidRanges(t *testing.T) {
+	tests := map[string][]Range{
+		"gap":      {{From: 1, Amount: 2}, {From: 4, Amount: 2}},
+		"overlap":  {{From: 1, Amount: 3}, {From: 3, Amount: 3}},
+		"short":    {{From: 1, Amount: 4}},
+		"oversize": {{From: 1, Amount: 5}},
+		"empty":    {{From: 1, Amount: 0}, {From: 1, Amount: 5}},
+	}
+	for name, ranges := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := scheduleRequests(staticScheduler(ranges), 1, 5, 4)
+			assert.Error(t, err)
+		})
+	}
+
+	requests, err := scheduleRequests(staticScheduler{{From: 4, Amount: 2}, {From: 1, Amount: 3}}, 1, 5, 4)
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+	assert.EqualValues(t, 4, requests[0].GetOrigin())
+	assert.EqualValues(t, 1, requests[1].GetOrigin())
+}
+
+// reverseScheduler requests the chunks from the highest to the lowest.
+type reverseScheduler struct {
+	sequentialScheduler
+	chunked, suited atomic.Int64
+}
+
+func (s *reverseScheduler) Chunk(from, amount, maxAmount uint64) []Range {
+	s.chunked.Add(1)
+	ranges := s.sequentialScheduler.Chunk(from, amount, maxAmount)
+	for i, j := 0, len(ranges)-1; i < j; i, j = i+1, j-1 {
+		ranges[i], ranges[j] = ranges[j], ranges[i]
+	}
+	return ranges
+}
+
+func (s *reverseScheduler) Suits(r Range, p PeerInfo) bool {
+	s.suited.Add(1)
+	return s.sequentialScheduler.Suits(r, p)
+}
+
+// staticScheduler returns the same Ranges regardless of the requested range.
+type staticScheduler []Range
+
+func (s staticScheduler) Chunk(uint64, uint64, uint64) []Range {
+	return s
+}
+
+func (staticScheduler) Suits(Range, PeerInfo) bool {
+	return true
+}
@@ -1,10 +1,15 @@
 package p2p
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/protocol"
@@ -15,6 +20,7 @@ import (
 
 	"github.com/celestiaorg/go-header"
 	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
+	"github.com/celestiaorg/go-header/store"
 	"github.com/celestiaorg/go-libp2p-messenger/serde"
 )
 
@@ -22,13 +28,44 @@ var (
 	tracer = otel.Tracer("header/server")
 )
 
+// errPruned is returned when the requested headers are below the window of recent headers
+// served by the server.
+var errPruned = errors.New("header/p2p: requested headers pruned")
+
 // ExchangeServer represents the server-side component for
 // responding to inbound header-related requests.
 type ExchangeServer[H header.Header] struct {
-	protocolID protocol.ID
+	protocolIDs []protocol.ID
 
 	host  host.Host
 	store header.Store[H]
+	// key signs head responses if signing is enabled
+	key crypto.PrivKey
+	// proofs attaches proofs to the served headers if set
+	proofs ProofProvider[H]
+	// attestor attaches attestations to the served heads if set
+	attestor HeadAttestor[H]
+	// cold serves the historical headers missing from the store if set
+	cold header.Getter[H]
+	// limiter limits the requests served to every peer, if set
+	limiter *rateLimiter
+	// cache keeps recently served ranges marshaled, if set
+	cache *responseCache[H]
+	// workers bound the amount of requests served concurrently, if set
+	workers *workerPool
+
+	metrics *serverMetrics
+
+	// inflightLk guards the tracking of in-flight requests
+	inflightLk sync.Mutex
+	// inflight is the amount of requests being served, which Stop waits for
+	inflight int
+	// draining is closed once the server stops accepting requests
+	draining chan struct{}
+	// drained is closed once the draining server has no requests in flight
+	drained chan struct{}
+	// subscriptions is the amount of head subscriptions held open
+	subscriptions atomic.Int64
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -50,35 +87,144 @@ func NewExchangeServer[H header.Header](
 	if err := params.Validate(); err != nil {
 		return nil, err
 	}
+	proofs, err := typedOption[ProofProvider[H]]("proof provider", params.proofProvider)
+	if err != nil {
+		return nil, err
+	}
+	attestor, err := typedOption[HeadAttestor[H]]("head attestor", params.headAttestor)
+	if err != nil {
+		return nil, err
+	}
+	cold, err := typedOption[header.Getter[H]]("cold store", params.coldStore)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := newResponseCache[H](params.ResponseCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	if r, ok := store.(remover); ok && cache != nil {
+		r.OnRemove(cache.invalidate)
+	}
 
-	return &ExchangeServer[H]{
-		protocolID: protocolID(params.networkID),
-		host:       host,
-		store:      store,
-		Params:     params,
-	}, nil
+	serv := &ExchangeServer[H]{
+		protocolIDs: protocolIDs(params.networkID),
+		host:        host,
+		store:       store,
+		proofs:      proofs,
+		attestor:    attestor,
+		cold:        cold,
+		cache:       cache,
+		workers:     newWorkerPool(params.MaxConcurrentRequests, params.RequestQueueSize),
+		limiter:     newRateLimiter(params.PeerRequestsPerSecond, params.PeerHeadersPerSecond, params.MaxHeadersPerResponse),
+		Params:      params,
+	}
+	if params.metrics {
+		if err = serv.InitMetrics(); err != nil {
+			return nil, err
+		}
+	}
+	return serv, nil
 }
 
 // Start sets the stream handler for inbound header-related requests.
 func (serv *ExchangeServer[H]) Start(context.Context) error {
+	if serv.Params.signHead {
+		serv.key = serv.host.Peerstore().PrivKey(serv.host.ID())
+		if serv.key == nil {
+			return fmt.Errorf("header/p2p: no private key to sign head responses with")
+		}
+	}
+
 	serv.ctx, serv.cancel = context.WithCancel(context.Background())
-	log.Infow("server: listening for inbound header requests", "protocol ID", serv.protocolID)
+	serv.draining, serv.drained = make(chan struct{}), make(chan struct{})
+	log.Infow("server: listening for inbound header requests", "protocol IDs", serv.protocolIDs)
 
-	serv.host.SetStreamHandler(serv.protocolID, serv.requestHandler)
+	// all the protocol versions are served by the same handler, which downgrades the status codes
+	// of the responses to the versions before statusCodesVersion
+	for _, id := range serv.protocolIDs {
+		serv.host.SetStreamHandler(id, serv.requestHandler)
+	}
 
 	return nil
 }
 
-// Stop removes the stream handler for serving header-related requests.
-func (serv *ExchangeServer[H]) Stop(context.Context) error {
+// Stop removes the stream handler for serving header-related requests and drains the server:
+// new requests are refused, head subscriptions are ended and the requests in flight are served
+// until the given context is done, so clients do not see streams broken midway, e.g. during
+// rolling restarts. Requests still in flight once the context is done are aborted.
+func (serv *ExchangeServer[H]) Stop(ctx context.Context) error {
 	log.Info("server: stopping server")
-	serv.cancel()
-	serv.host.RemoveStreamHandler(serv.protocolID)
-	return nil
+	for _, id := range serv.protocolIDs {
+		serv.host.RemoveStreamHandler(id)
+	}
+	defer serv.cancel()
+
+	serv.inflightLk.Lock()
+	select {
+	case <-serv.draining:
+	default:
+		close(serv.draining)
+		if serv.inflight == 0 {
+			close(serv.drained)
+		}
+	}
+	inflight := serv.inflight
+	serv.inflightLk.Unlock()
+
+	if inflight > 0 {
+		log.Infow("server: draining requests in flight", "amount", inflight)
+	}
+	select {
+	case <-serv.drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("header/p2p: draining server: %w", ctx.Err())
+	}
+}
+
+// track accounts a request in flight, so Stop waits for it to be served.
+// It returns false if the server is draining and the request must be refused.
+func (serv *ExchangeServer[H]) track() bool {
+	serv.inflightLk.Lock()
+	defer serv.inflightLk.Unlock()
+	select {
+	case <-serv.draining:
+		return false
+	default:
+		serv.inflight++
+		return true
+	}
+}
+
+// untrack accounts a request tracked by track as served.
+func (serv *ExchangeServer[H]) untrack() {
+	serv.inflightLk.Lock()
+	defer serv.inflightLk.Unlock()
+	serv.inflight--
+	select {
+	case <-serv.draining:
+		if serv.inflight == 0 {
+			close(serv.drained)
+		}
+	default:
+	}
 }
 
 // requestHandler handles inbound HeaderRequests.
 func (serv *ExchangeServer[H]) requestHandler(stream network.Stream) {
+	if !serv.track() {
+		log.Debugw("server: refusing request of draining server", "peer", stream.Conn().RemotePeer())
+		stream.Reset() //nolint:errcheck
+		return
+	}
+	defer serv.untrack()
+	if serv.blocked(stream.Conn()) {
+		log.Debugw("server: refusing request of blocked peer", "peer", stream.Conn().RemotePeer())
+		stream.Reset() //nolint:errcheck
+		return
+	}
+
 	err := stream.SetReadDeadline(time.Now().Add(serv.Params.ReadDeadline))
 	if err != nil {
 		log.Debugf("error setting deadline: %s", err)
@@ -94,58 +240,182 @@ func (serv *ExchangeServer[H]) requestHandler(stream network.Stream) {
 	if err = stream.CloseRead(); err != nil {
 		log.Error(err)
 	}
+	var (
+		start  = time.Now()
+		status = requestError
+		// resp is the last response written and reqErr is the error failing the request, if any
+		resp   *p2p_pb.HeaderResponse
+		reqErr error
+	)
+	defer func() {
+		serv.metrics.observeRequest(serv.ctx, stream.Conn().RemotePeer(), pbreq, status)
+		if serv.Params.requestHook != nil {
+			serv.Params.requestHook(stream.Conn().RemotePeer(), pbreq, resp, reqErr, time.Since(start))
+		}
+	}()
+	if wait := serv.limiter.allow(stream.Conn().RemotePeer(), serv.requestedHeaders(pbreq)); wait > 0 {
+		status = requestRateLimited
+		resp, reqErr = serv.writeRateLimited(stream, wait)
+		return
+	}
+	// servers with disabled subscriptions serve the head only, closing the stream afterwards
+	// as do servers holding MaxHeadSubscriptions open already
+	if pbreq.Subscribe && isHeadRequest(pbreq) && serv.Params.headSubscriptionInterval > 0 &&
+		serv.acquireSubscription() {
+		defer serv.subscriptions.Add(-1)
+		status = requestOK
+		serv.handleHeadSubscription(stream, pbreq)
+		return
+	}
 
-	var headers []H
+	ctx, cancel := context.WithTimeout(serv.ctx, serv.Params.RangeRequestTimeout)
+	err = serv.workers.acquire(ctx, pbreq.Priority == p2p_pb.Priority_HIGH)
+	cancel()
+	if err != nil {
+		log.Debugw("server: rejecting request", "peer", stream.Conn().RemotePeer(), "err", err)
+		status = requestBusy
+		resp, reqErr = serv.writeRateLimited(stream, busyRetryAfter)
+		if reqErr == nil {
+			reqErr = err
+		}
+		return
+	}
+	defer serv.workers.release()
+
+	var (
+		served []servedHeader[H]
+		// continuation is the origin of the next page of the requested range, if it is truncated
+		continuation uint64
+		// streamed and streamErr stream the rest of the range following the served Headers
+		// from the store, if the range is streamed, and expected is the amount of its Headers
+		streamed  <-chan H
+		streamErr <-chan error
+		expected  uint64
+	)
 	// retrieve and write Headers
 	switch pbreq.Data.(type) {
 	case *p2p_pb.HeaderRequest_Hash:
-		headers, err = serv.handleRequestByHash(pbreq.GetHash())
+		served, err = serv.marshal(serv.checkRecent(serv.handleRequestByHash(pbreq.GetHash())))
+	case *p2p_pb.HeaderRequest_Hashes:
+		served, err = serv.marshal(serv.checkRecent(serv.handleRequestByHashes(pbreq.GetHashes().GetHashes())))
 	case *p2p_pb.HeaderRequest_Origin:
-		headers, err = serv.handleRequest(pbreq.GetOrigin(), pbreq.GetOrigin()+pbreq.Amount)
+		if pbreq.Descending {
+			served, err = serv.marshal(serv.handleRequestDescending(pbreq.GetOrigin(), pbreq.Amount))
+			break
+		}
+		from, to := pbreq.GetOrigin(), pbreq.GetOrigin()+pbreq.Amount
+		if from != 0 && to-from > serv.Params.MaxHeadersPerResponse {
+			// the range is served in pages, so clients with higher limits follow up on the rest
+			to = from + serv.Params.MaxHeadersPerResponse
+			continuation = to
+		}
+		if streamer, ok := serv.streamer(from); ok {
+			ctx, cancel := context.WithTimeout(serv.ctx, serv.Params.RangeRequestTimeout)
+			defer cancel()
+			served, streamed, streamErr, err = serv.streamRange(ctx, streamer, from, to)
+			expected = to - from
+			break
+		}
+		served, err = serv.serveRange(from, to)
+		if uint64(len(served)) < to-from {
+			// the store does not have the rest of the range anyway
+			continuation = 0
+		}
 	default:
 		log.Error("server: invalid data type received")
-		stream.Reset() //nolint:errcheck
-		return
+		err = fmt.Errorf("%w: unknown data type %T", ErrInvalidRequest, pbreq.Data)
 	}
+	reqErr = err
 	var code p2p_pb.StatusCode
-	switch err {
-	case nil:
+	switch {
+	case err == nil:
 		code = p2p_pb.StatusCode_OK
-	case header.ErrNotFound:
+		status = requestOK
+	case errors.Is(err, header.ErrNotFound):
 		code = p2p_pb.StatusCode_NOT_FOUND
+		status = requestNotFound
+	case errors.Is(err, errPruned):
+		code = p2p_pb.StatusCode_PRUNED
+		status = requestPruned
+	case errors.Is(err, ErrInvalidRequest), errors.Is(err, header.ErrHeadersLimitExceeded):
+		code = p2p_pb.StatusCode_INVALID_REQUEST
+		status = requestInvalid
 	default:
+		code = p2p_pb.StatusCode_INTERNAL_ERROR
+	}
+	var ok bool
+	if code, ok = legacyStatusCode(stream.Protocol(), code); !ok {
+		log.Debugw("server: resetting stream of legacy peer", "peer", stream.Conn().RemotePeer(), "err", err)
 		stream.Reset() //nolint:errcheck
 		return
 	}
 
 	// reallocate headers with 1 nil Header if code is not StatusCode_OK
 	if code != p2p_pb.StatusCode_OK {
-		headers = make([]H, 1)
+		served, streamed = make([]servedHeader[H], 1), nil
 	}
 
 	if err := stream.SetWriteDeadline(time.Now().Add(serv.Params.WriteDeadline)); err != nil {
 		log.Debugf("error setting deadline: %s", err)
 	}
 
-	// write all headers to stream
-	for _, h := range headers {
-		var bin []byte
-		// if header is not nil, then marshal it to []byte.
-		// if header is nil, then error was received,so we will set empty []byte to proto.
-		if !h.IsZero() {
-			bin, err = h.MarshalBinary()
-			if err != nil {
-				log.Errorw("server: marshaling header to proto", "height", h.Height, "err", err)
-				stream.Reset() //nolint:errcheck
-				return
-			}
+	// write all headers to stream, as they are read if the range is streamed
+	var (
+		readErr error
+		i       int
+	)
+	next := func() (servedHeader[H], bool) {
+		if i < len(served) {
+			i++
+			return served[i-1], true
 		}
-		_, err = serde.Write(stream, &p2p_pb.HeaderResponse{Body: bin, StatusCode: code})
+		if streamed == nil {
+			return servedHeader[H]{}, false
+		}
+		h, ok := <-streamed
+		if !ok {
+			readErr = <-streamErr
+			return servedHeader[H]{}, false
+		}
+		marshaled, err := serv.marshal([]H{h}, nil)
 		if err != nil {
-			log.Errorw("server: writing header to stream", "err", err)
-			stream.Reset() //nolint:errcheck
-			return
+			readErr = err
+			return servedHeader[H]{}, false
 		}
+		return marshaled[0], true
+	}
+
+	w := serv.newResponseWriter(stream, pbreq)
+	var written uint64
+	err = nil
+	// every Header is held back until the next one is read, so the continuation is set on the last one
+	for h, ok := next(); ok && err == nil; written++ {
+		following, more := next()
+		if !more && readErr == nil {
+			if streamed != nil && written+1 < expected {
+				// the store does not have the rest of the range anyway
+				continuation = 0
+			}
+			h.continuation = continuation
+		}
+		var r *p2p_pb.HeaderResponse
+		if r, err = serv.response(pbreq, h, code); err == nil {
+			err = w.write(r)
+		}
+		h, ok = following, more
+	}
+	if err == nil {
+		err = readErr
+	}
+	if err == nil {
+		err = w.flush()
+	}
+	resp = w.last
+	if err != nil {
+		status, reqErr = requestError, err
+		log.Errorw("server: writing header to stream", "err", err)
+		stream.Reset() //nolint:errcheck
+		return
 	}
 
 	err = stream.Close()
@@ -154,6 +424,327 @@ func (serv *ExchangeServer[H]) requestHandler(stream network.Stream) {
 	}
 }
 
+// blocked reports whether the peer of the given connection, its address or subnet
+// is blocked by the connection gater, if set.
+func (serv *ExchangeServer[H]) blocked(conn network.Conn) bool {
+	gater := serv.Params.connGater
+	return gater != nil && !gater.InterceptSecured(network.DirInbound, conn.RemotePeer(), conn)
+}
+
+// requestedHeaders returns the amount of headers requested by the given request
+// accounted by the rate limiter.
+func (serv *ExchangeServer[H]) requestedHeaders(req *p2p_pb.HeaderRequest) uint64 {
+	switch req.Data.(type) {
+	case *p2p_pb.HeaderRequest_Hashes:
+		return uint64(len(req.GetHashes().GetHashes()))
+	case *p2p_pb.HeaderRequest_Origin:
+		if isHeadRequest(req) || req.Amount == 0 {
+			return 1
+		}
+		if req.Amount > serv.Params.MaxHeadersPerResponse {
+			// the request is rejected anyway, but it still costs the whole response
+			return serv.Params.MaxHeadersPerResponse
+		}
+		return req.Amount
+	default:
+		return 1
+	}
+}
+
+// writeRateLimited responds to the peer that exceeded the rate limits, or to any peer if the server
+// is too busy, with the time it should wait before sending the next request.
+// The streams of the peers not supporting the status code are reset instead.
+func (serv *ExchangeServer[H]) writeRateLimited(
+	stream network.Stream,
+	wait time.Duration,
+) (*p2p_pb.HeaderResponse, error) {
+	if _, ok := legacyStatusCode(stream.Protocol(), p2p_pb.StatusCode_RATE_LIMITED); !ok {
+		log.Debugw("server: resetting stream of legacy peer", "peer", stream.Conn().RemotePeer(), "retryAfter", wait)
+		stream.Reset() //nolint:errcheck
+		return nil, nil
+	}
+	log.Debugw("server: asking peer to back off", "peer", stream.Conn().RemotePeer(), "retryAfter", wait)
+	if err := stream.SetWriteDeadline(time.Now().Add(serv.Params.WriteDeadline)); err != nil {
+		log.Debugf("error setting deadline: %s", err)
+	}
+	resp := &p2p_pb.HeaderResponse{
+		StatusCode: p2p_pb.StatusCode_RATE_LIMITED,
+		// rounded up, so the peer does not retry right before the tokens are available
+		RetryAfter: uint64((wait + time.Millisecond - 1) / time.Millisecond),
+	}
+	n, err := serde.Write(stream, resp)
+	serv.metrics.observeSent(serv.ctx, stream.Conn().RemotePeer(), n)
+	if err != nil {
+		log.Debugw("server: writing rate limited response", "err", err)
+		stream.Reset() //nolint:errcheck
+		return resp, err
+	}
+	if err := stream.Close(); err != nil {
+		log.Debugw("while closing inbound stream", "err", err)
+	}
+	return resp, nil
+}
+
+// servedHeader is a Header served along with its marshaled body.
+type servedHeader[H header.Header] struct {
+	header H
+	body   []byte
+	// continuation is the origin of the next page of the requested range, if any.
+	continuation uint64
+}
+
+// marshal marshals the given Headers to be served, passing the given error through.
+func (serv *ExchangeServer[H]) marshal(headers []H, err error) ([]servedHeader[H], error) {
+	if err != nil {
+		return nil, err
+	}
+	served := make([]servedHeader[H], len(headers))
+	for i, h := range headers {
+		served[i].header = h
+		served[i].body, err = h.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("marshaling header %d: %w", h.Height(), err)
+		}
+	}
+	return served, nil
+}
+
+// serveRange returns the marshaled Headers in range [from; to), serving recently requested ranges
+// from the response cache, if enabled.
+func (serv *ExchangeServer[H]) serveRange(from, to uint64) ([]servedHeader[H], error) {
+	if from != 0 && !serv.recent(from) {
+		log.Debugw("server: requested headers pruned", "from", from, "to", to)
+		return nil, errPruned
+	}
+	if from == 0 || serv.cache == nil {
+		return serv.marshal(serv.handleRequest(from, to))
+	}
+
+	height := serv.store.Height()
+	if served, ok := serv.cache.get(from, to, height); ok {
+		log.Debugw("server: serving cached range", "from", from, "to", to)
+		return served, nil
+	}
+	served, err := serv.marshal(serv.handleRequest(from, to))
+	if err != nil {
+		return nil, err
+	}
+	serv.cache.add(from, to, height, served)
+	return served, nil
+}
+
+// response returns the response with the given Header to the request.
+// The Header is zero if the code is not StatusCode_OK.
+func (serv *ExchangeServer[H]) response(
+	req *p2p_pb.HeaderRequest,
+	served servedHeader[H],
+	code p2p_pb.StatusCode,
+) (*p2p_pb.HeaderResponse, error) {
+	var err error
+	h := served.header
+	resp := &p2p_pb.HeaderResponse{Body: served.body, StatusCode: code, Continuation: served.continuation}
+	if serv.proofs != nil && code == p2p_pb.StatusCode_OK {
+		resp.Proof, err = serv.proofs(serv.ctx, h)
+		if err != nil {
+			return nil, fmt.Errorf("getting proof of header %d: %w", h.Height(), err)
+		}
+	}
+	if code == p2p_pb.StatusCode_PRUNED {
+		resp.Tail = serv.tail()
+	}
+	if code == p2p_pb.StatusCode_OK && isHeadRequest(req) {
+		resp.Tail = serv.tail()
+		resp.Limits = serv.limits()
+		if serv.attestor != nil {
+			resp.Attestation, err = serv.attestor(serv.ctx, h)
+			if err != nil {
+				return nil, fmt.Errorf("attesting head %d: %w", h.Height(), err)
+			}
+		}
+	}
+	if serv.key != nil && code == p2p_pb.StatusCode_OK && isHeadRequest(req) {
+		if err = signHead(serv.key, resp); err != nil {
+			return nil, fmt.Errorf("signing head: %w", err)
+		}
+	}
+	if uint64(resp.Size()) > serv.Params.MaxMessageSize {
+		return nil, fmt.Errorf("response of %d bytes above the max message size", resp.Size())
+	}
+	return resp, nil
+}
+
+// writeResponses writes the given responses to the request to the stream,
+// compressed as a whole if the client accepts it. It returns the last of the responses.
+func (serv *ExchangeServer[H]) writeResponses(
+	stream network.Stream,
+	req *p2p_pb.HeaderRequest,
+	resps []*p2p_pb.HeaderResponse,
+) (*p2p_pb.HeaderResponse, error) {
+	w := serv.newResponseWriter(stream, req)
+	for _, resp := range resps {
+		if err := w.write(resp); err != nil {
+			return nil, err
+		}
+	}
+	return w.last, w.flush()
+}
+
+// responseWriter writes the responses to a request to the stream as they are built,
+// or buffers them if the client accepts the codec of the server, so they are compressed
+// as a whole once all of them are built and zstd exploits the redundancy across the headers.
+// Signatures cover the raw bodies, so the responses are compressed after being signed.
+type responseWriter[H header.Header] struct {
+	serv   *ExchangeServer[H]
+	stream network.Stream
+	codec  Compression
+	// buf buffers the responses to be compressed, if the client accepts compression.
+	buf *bytes.Buffer
+	// last is the last response written.
+	last *p2p_pb.HeaderResponse
+}
+
+func (serv *ExchangeServer[H]) newResponseWriter(stream network.Stream, req *p2p_pb.HeaderRequest) *responseWriter[H] {
+	w := &responseWriter[H]{serv: serv, stream: stream, codec: req.Compression}
+	if w.codec != NoCompression && w.codec == serv.Params.compression {
+		w.buf = new(bytes.Buffer)
+	}
+	return w
+}
+
+// write writes the response to the stream, or buffers it if the responses are compressed.
+func (w *responseWriter[H]) write(resp *p2p_pb.HeaderResponse) error {
+	w.last = resp
+	if w.buf != nil {
+		_, err := serde.Write(w.buf, resp)
+		return err
+	}
+	return w.send(resp)
+}
+
+// flush writes the buffered responses, if any, compressed as a whole into a single response if they
+// are above CompressionThreshold and compression makes them smaller.
+func (w *responseWriter[H]) flush() error {
+	if w.buf == nil || w.buf.Len() == 0 {
+		return nil
+	}
+	raw := w.buf.Bytes()
+	if len(raw) >= w.serv.Params.CompressionThreshold {
+		body, err := compress(w.codec, raw)
+		if err != nil {
+			return fmt.Errorf("compressing response: %w", err)
+		}
+		w.serv.metrics.observeCompression(w.serv.ctx, w.codec, len(raw), len(body))
+		resp := &p2p_pb.HeaderResponse{Body: body, StatusCode: p2p_pb.StatusCode_OK, Compression: w.codec}
+		// incompressible responses are sent as they are
+		if len(body) < len(raw) && uint64(resp.Size()) <= w.serv.Params.MaxMessageSize {
+			return w.send(resp)
+		}
+	}
+	// the buffered responses are delimited the same way as the ones written one by one
+	n, err := w.stream.Write(raw)
+	w.serv.metrics.observeSent(w.serv.ctx, w.stream.Conn().RemotePeer(), n)
+	return err
+}
+
+// send writes the message to the stream.
+func (w *responseWriter[H]) send(msg *p2p_pb.HeaderResponse) error {
+	n, err := serde.Write(w.stream, msg)
+	w.serv.metrics.observeSent(w.serv.ctx, w.stream.Conn().RemotePeer(), n)
+	return err
+}
+
+// limits returns the limits and the optional features of the server advertised to clients.
+func (serv *ExchangeServer[H]) limits() *p2p_pb.ServerLimits {
+	limits := &p2p_pb.ServerLimits{
+		MaxHeadersPerResponse: serv.Params.MaxHeadersPerResponse,
+		MaxMessageSize:        serv.Params.MaxMessageSize,
+	}
+	if serv.Params.headSubscriptionInterval > 0 {
+		limits.Features = append(limits.Features, p2p_pb.Feature_FEATURE_HEAD_SUBSCRIPTION)
+	}
+	if serv.key != nil {
+		limits.Features = append(limits.Features, p2p_pb.Feature_FEATURE_HEAD_SIGNATURE)
+	}
+	if serv.proofs != nil {
+		limits.Features = append(limits.Features, p2p_pb.Feature_FEATURE_PROOFS)
+	}
+	if serv.attestor != nil {
+		limits.Features = append(limits.Features, p2p_pb.Feature_FEATURE_HEAD_ATTESTATION)
+	}
+	if serv.Params.compression != NoCompression {
+		limits.Features = append(limits.Features, p2p_pb.Feature_FEATURE_COMPRESSION)
+	}
+	return limits
+}
+
+// remover is implemented by stores reporting the ranges of Headers they remove or replace,
+// e.g. on pruning or reorgs.
+type remover interface {
+	OnRemove(hook store.RemoveHook)
+}
+
+// tailer is implemented by stores tracking the lowest header they retain.
+type tailer[H header.Header] interface {
+	Tail(context.Context) (H, error)
+}
+
+// tail returns the lowest height retained by the store, or the lowest one within RecentHeaders
+// if it is higher, so clients can tell pruned peers from archival ones.
+// It returns zero if neither the store tracks it nor RecentHeaders is set.
+// The tail of the store is not advertised if the cold store serves the history below it.
+func (serv *ExchangeServer[H]) tail() uint64 {
+	tail := serv.recentTail()
+	t, ok := serv.store.(tailer[H])
+	if !ok || serv.cold != nil {
+		return tail
+	}
+	ctx, cancel := context.WithTimeout(serv.ctx, serv.Params.RangeRequestTimeout)
+	defer cancel()
+	h, err := t.Tail(ctx)
+	if err != nil {
+		log.Debugw("server: getting tail", "err", err)
+		return tail
+	}
+	if uint64(h.Height()) > tail {
+		tail = uint64(h.Height())
+	}
+	return tail
+}
+
+// recentTail returns the lowest height within RecentHeaders of the stored head.
+// It returns zero if RecentHeaders is not set or the store has fewer headers.
+func (serv *ExchangeServer[H]) recentTail() uint64 {
+	if serv.Params.RecentHeaders == 0 {
+		return 0
+	}
+	height := serv.store.Height()
+	if height < serv.Params.RecentHeaders {
+		return 0
+	}
+	return height - serv.Params.RecentHeaders + 1
+}
+
+// recent reports whether the header at the given height is within RecentHeaders
+// and so is served.
+func (serv *ExchangeServer[H]) recent(height uint64) bool {
+	return height >= serv.recentTail()
+}
+
+// checkRecent returns errPruned if any of the given Headers is not within RecentHeaders,
+// passing the given error through otherwise.
+func (serv *ExchangeServer[H]) checkRecent(headers []H, err error) ([]H, error) {
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range headers {
+		if !serv.recent(uint64(h.Height())) {
+			log.Debugw("server: requested header pruned", "height", h.Height())
+			return nil, errPruned
+		}
+	}
+	return headers, nil
+}
+
 // handleRequestByHash returns the Header at the given hash
 // if it exists.
 func (serv *ExchangeServer[H]) handleRequestByHash(hash []byte) ([]H, error) {
@@ -165,7 +756,7 @@ func (serv *ExchangeServer[H]) handleRequestByHash(hash []byte) ([]H, error) {
 	))
 	defer span.End()
 
-	h, err := serv.store.Get(ctx, hash)
+	h, err := serv.get(ctx, hash)
 	if err != nil {
 		log.Errorw("server: getting header by hash", "hash", header.Hash(hash).String(), "err", err)
 		span.SetStatus(codes.Error, err.Error())
@@ -180,6 +771,68 @@ func (serv *ExchangeServer[H]) handleRequestByHash(hash []byte) ([]H, error) {
 	return []H{h}, nil
 }
 
+// manyGetter is implemented by stores resolving many hashes in a single batched lookup.
+type manyGetter[H header.Header] interface {
+	GetMany(context.Context, []header.Hash) ([]H, error)
+}
+
+// getMany returns the Headers at the given hashes in the same order, in a single batched lookup
+// if the store supports it.
+func (serv *ExchangeServer[H]) getMany(ctx context.Context, hashes [][]byte) ([]H, error) {
+	if g, ok := serv.store.(manyGetter[H]); ok {
+		typed := make([]header.Hash, len(hashes))
+		for i, hash := range hashes {
+			typed[i] = hash
+		}
+		headers, err := g.GetMany(ctx, typed)
+		if serv.cold == nil || !errors.Is(err, header.ErrNotFound) {
+			return headers, err
+		}
+		// some of the headers are missing from the store, so they are looked up one by one
+	}
+
+	headers := make([]H, 0, len(hashes))
+	for _, hash := range hashes {
+		h, err := serv.get(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, h)
+	}
+	return headers, nil
+}
+
+// handleRequestByHashes returns the Headers at the given hashes in the same order.
+// All the Headers must exist, otherwise header.ErrNotFound is returned.
+func (serv *ExchangeServer[H]) handleRequestByHashes(hashes [][]byte) ([]H, error) {
+	log.Debugw("server: handling headers request by hashes", "amount", len(hashes))
+	ctx, cancel := context.WithTimeout(serv.ctx, serv.Params.RangeRequestTimeout)
+	defer cancel()
+	ctx, span := tracer.Start(ctx, "request-by-hashes", trace.WithAttributes(
+		attribute.Int("amount", len(hashes)),
+	))
+	defer span.End()
+
+	if uint64(len(hashes)) > serv.Params.MaxHeadersPerResponse {
+		log.Errorw("server: skip request for too many headers.", "amount", len(hashes))
+		span.SetStatus(codes.Error, header.ErrHeadersLimitExceeded.Error())
+		return nil, header.ErrHeadersLimitExceeded
+	}
+
+	headers, err := serv.getMany(ctx, hashes)
+	if err != nil {
+		log.Errorw("server: getting headers by hashes", "amount", len(hashes), "err", err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.AddEvent("fetched-headers-from-store", trace.WithAttributes(
+		attribute.Int("amount", len(headers))),
+	)
+	span.SetStatus(codes.Ok, "")
+	return headers, nil
+}
+
 // handleRequest fetches the Header at the given origin and
 // writes it to the stream.
 func (serv *ExchangeServer[H]) handleRequest(from, to uint64) ([]H, error) {
@@ -192,40 +845,32 @@ func (serv *ExchangeServer[H]) handleRequest(from, to uint64) ([]H, error) {
 		attribute.Int64("to", int64(to))))
 	defer span.End()
 
-	if to-from > header.MaxRangeRequestSize {
+	if to-from > serv.Params.MaxHeadersPerResponse {
 		log.Errorw("server: skip request for too many headers.", "amount", to-from)
 		span.SetStatus(codes.Error, header.ErrHeadersLimitExceeded.Error())
 		return nil, header.ErrHeadersLimitExceeded
 	}
 
 	log.Debugw("server: handling headers request", "from", from, "to", to)
-	// check that store has the requested height
-	if !serv.store.HasAt(ctx, to-1) {
-		head, err := serv.store.Head(ctx)
+	// the range is split at the height the store has the headers from, serving the history below from the cold store
+	var history []H
+	if end := serv.historyEnd(ctx, from, to); end > from {
+		headers, err := serv.getColdRange(ctx, from, end)
 		if err != nil {
 			span.SetStatus(codes.Error, err.Error())
-			log.Debugw("server: could not get current head", "err", err)
+			log.Debugw("server: getting headers from cold store", "from", from, "to", end, "err", err)
 			return nil, err
 		}
-
-		// might be a case when store hasn't synced yet to the requested range
-		if uint64(head.Height()) < from {
-			span.SetStatus(codes.Error, header.ErrNotFound.Error())
-			log.Debugw("server: requested headers not stored",
-				"from", from,
-				"to", to,
-				"currentHead",
-				head.Height(),
-			)
-			return nil, header.ErrNotFound
+		if end == to || uint64(len(headers)) < end-from {
+			span.SetStatus(codes.Ok, "")
+			return headers, nil
 		}
-
-		log.Debugw("server: serving partial range",
-			"prevMaxHeight", to,
-			"newMaxHeight", uint64(head.Height())+1,
-		)
-		// change `to` height to return a partial range
-		to = uint64(head.Height()) + 1
+		history, from = headers, end
+	}
+	to, err := serv.storedEnd(ctx, from, to)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
 	}
 
 	headersByRange, err := serv.store.GetRangeByHeight(ctx, from, to)
@@ -242,7 +887,118 @@ func (serv *ExchangeServer[H]) handleRequest(from, to uint64) ([]H, error) {
 	span.AddEvent("fetched-range-of-headers", trace.WithAttributes(
 		attribute.Int("amount", len(headersByRange))))
 	span.SetStatus(codes.Ok, "")
-	return headersByRange, nil
+	return append(history, headersByRange...), nil
+}
+
+// storedEnd returns the end of range [from; to) the store has the Headers up to,
+// which is lower than the requested one if the store has not synced the whole range yet.
+// It returns header.ErrNotFound if the store has none of the range.
+func (serv *ExchangeServer[H]) storedEnd(ctx context.Context, from, to uint64) (uint64, error) {
+	// check that store has the requested height
+	if serv.store.HasAt(ctx, to-1) {
+		return to, nil
+	}
+	head, err := serv.store.Head(ctx)
+	if err != nil {
+		log.Debugw("server: could not get current head", "err", err)
+		return 0, err
+	}
+
+	// might be a case when store hasn't synced yet to the requested range
+	if uint64(head.Height()) < from {
+		log.Debugw("server: requested headers not stored",
+			"from", from,
+			"to", to,
+			"currentHead",
+			head.Height(),
+		)
+		return 0, header.ErrNotFound
+	}
+
+	log.Debugw("server: serving partial range",
+		"prevMaxHeight", to,
+		"newMaxHeight", uint64(head.Height())+1,
+	)
+	// change `to` height to return a partial range
+	return uint64(head.Height()) + 1, nil
+}
+
+// rangeStreamer is implemented by stores streaming ranges of Headers, e.g. store.Store.
+type rangeStreamer[H header.Header] interface {
+	GetRangeStream(ctx context.Context, from, to uint64) (<-chan H, <-chan error)
+}
+
+// streamer returns the store streaming the range starting at the given height, so the reads
+// of the range are pipelined with the writes of the responses instead of allocating the range.
+// Ranges are streamed unless they are served from the response cache or the cold store.
+func (serv *ExchangeServer[H]) streamer(from uint64) (rangeStreamer[H], bool) {
+	streamer, ok := serv.store.(rangeStreamer[H])
+	if !ok || from == 0 || serv.cache != nil || serv.cold != nil || !serv.recent(from) {
+		return nil, false
+	}
+	return streamer, true
+}
+
+// streamRange streams the range of Headers [from; to) from the store, truncated to the stored head.
+// It returns the first Header of the range along with the stream of the rest,
+// so failures to serve the range are known before any response is written.
+func (serv *ExchangeServer[H]) streamRange(
+	ctx context.Context,
+	streamer rangeStreamer[H],
+	from, to uint64,
+) ([]servedHeader[H], <-chan H, <-chan error, error) {
+	if to-from > serv.Params.MaxHeadersPerResponse {
+		return nil, nil, nil, header.ErrHeadersLimitExceeded
+	}
+	to, err := serv.storedEnd(ctx, from, to)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	log.Debugw("server: streaming headers", "from", from, "to", to)
+	headers, errs := streamer.GetRangeStream(ctx, from, to)
+	h, ok := <-headers
+	if !ok {
+		err = <-errs
+		switch {
+		case err == nil:
+			err = header.ErrNotFound
+		case errors.Is(err, context.DeadlineExceeded):
+			log.Warnw("server: requested headers not found", "from", from, "to", to)
+			err = header.ErrNotFound
+		}
+		return nil, nil, nil, err
+	}
+	served, err := serv.marshal([]H{h}, nil)
+	return served, headers, errs, err
+}
+
+// handleRequestDescending returns the range of the given amount of Headers ending at the given
+// height in descending order. Unlike ascending ranges, the range is never served partially,
+// as its top header must exist.
+func (serv *ExchangeServer[H]) handleRequestDescending(to, amount uint64) ([]H, error) {
+	if to == 0 || amount == 0 {
+		return nil, fmt.Errorf("%w: descending range(%d,%d)", ErrInvalidRequest, to, amount)
+	}
+	if amount > to {
+		amount = to
+	}
+	if !serv.recent(to - amount + 1) {
+		log.Debugw("server: requested headers pruned", "from", to-amount+1, "to", to)
+		return nil, errPruned
+	}
+	if !serv.store.HasAt(serv.ctx, to) {
+		log.Debugw("server: requested headers not stored", "to", to)
+		return nil, header.ErrNotFound
+	}
+
+	headers, err := serv.handleRequest(to-amount+1, to+1)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(headers)-1; i < j; i, j = i+1, j-1 {
+		headers[i], headers[j] = headers[j], headers[i]
+	}
+	return headers, nil
 }
 
 // handleHeadRequest returns the latest stored head.
@@ -2,13 +2,25 @@ package p2p
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/net/conngater"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/celestiaorg/go-header"
 	"github.com/celestiaorg/go-header/headertest"
+	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
 	"github.com/celestiaorg/go-header/store"
+	"github.com/celestiaorg/go-libp2p-messenger/serde"
 )
 
 func TestExchangeServer_handleRequestTimeout(t *testing.T) {
@@ -30,3 +42,671 @@ func TestExchangeServer_handleRequestTimeout(t *testing.T) {
 	_, err = server.handleRequest(1, 200)
 	require.Error(t, err)
 }
+
+func TestExchangeServer_Drain(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	store := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10)
+	slow := &slowStore{Store: store, delay: time.Millisecond * 200}
+	server, err := NewExchangeServer[*headertest.DummyHeader](hosts[1], slow,
+		WithNetworkID[ServerParameters](networkID),
+	)
+	require.NoError(t, err)
+	require.NoError(t, server.Start(context.Background()))
+
+	send := func() ([]*p2p_pb.HeaderResponse, error) {
+		req := &p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 1}, Amount: 5}
+		resps, _, _, err := sendMessage(context.Background(), hostTransport{host: hosts[0]},
+			hosts[1].ID(), protocolIDs(networkID), req, 0)
+		return resps, err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		resps, err := send()
+		if err == nil && len(resps) != 5 {
+			err = fmt.Errorf("got %d responses", len(resps))
+		}
+		errCh <- err
+	}()
+	require.Eventually(t, func() bool { return slow.calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	// the request in flight is served before the server stops
+	require.NoError(t, server.Stop(context.Background()))
+	require.NoError(t, <-errCh)
+	_, err = send()
+	require.Error(t, err)
+}
+
+func TestExchangeServer_RequestHook(t *testing.T) {
+	type call struct {
+		from peer.ID
+		req  *p2p_pb.HeaderRequest
+		resp *p2p_pb.HeaderResponse
+		err  error
+	}
+	calls := make(chan call, 2)
+	hook := func(from peer.ID, req *p2p_pb.HeaderRequest, resp *p2p_pb.HeaderResponse, err error, _ time.Duration) {
+		calls <- call{from: from, req: req, resp: resp, err: err}
+	}
+
+	hosts := createMocknet(t, 2)
+	s := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 5)
+	server, err := NewExchangeServer[*headertest.DummyHeader](hosts[1], s,
+		WithNetworkID[ServerParameters](networkID),
+		WithRequestHook[ServerParameters](hook),
+	)
+	require.NoError(t, err)
+	require.NoError(t, server.Start(context.Background()))
+	t.Cleanup(func() {
+		server.Stop(context.Background()) //nolint:errcheck
+	})
+
+	send := func(origin uint64) {
+		req := &p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: origin}, Amount: 2}
+		_, _, _, err := sendMessage(context.Background(), hostTransport{host: hosts[0]},
+			hosts[1].ID(), protocolIDs(networkID), req, 0)
+		require.NoError(t, err)
+	}
+
+	send(1)
+	c := <-calls
+	assert.Equal(t, hosts[0].ID(), c.from)
+	assert.EqualValues(t, 1, c.req.GetOrigin())
+	assert.Equal(t, p2p_pb.StatusCode_OK, c.resp.StatusCode)
+	assert.NoError(t, c.err)
+
+	send(10)
+	c = <-calls
+	assert.Equal(t, p2p_pb.StatusCode_NOT_FOUND, c.resp.StatusCode)
+	assert.ErrorIs(t, c.err, header.ErrNotFound)
+}
+
+func TestExchangeServer_RefusesBlockedPeers(t *testing.T) {
+	hosts := createMocknet(t, 3)
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	s := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 5)
+	server, err := NewExchangeServer[*headertest.DummyHeader](hosts[2], s,
+		WithNetworkID[ServerParameters](networkID),
+		WithConnectionGater[ServerParameters](connGater),
+	)
+	require.NoError(t, err)
+	require.NoError(t, server.Start(context.Background()))
+	t.Cleanup(func() {
+		server.Stop(context.Background()) //nolint:errcheck
+	})
+	// the peer is blocked after connecting, e.g. by the PeerTracker
+	require.NoError(t, connGater.BlockPeer(hosts[0].ID()))
+
+	send := func(from int) error {
+		req := &p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 1}, Amount: 1}
+		_, _, _, err := sendMessage(context.Background(), hostTransport{host: hosts[from]},
+			hosts[2].ID(), protocolIDs(networkID), req, 0)
+		return err
+	}
+	require.Error(t, send(0))
+	require.NoError(t, send(1))
+}
+
+func TestExchangeServer_ColdStore(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	hosts := createMocknet(t, 2)
+	suite := headertest.NewTestSuite(t)
+	archive, err := store.NewStoreWithHead(ctx, sync.MutexWrap(datastore.NewMapDatastore()), suite.Head())
+	require.NoError(t, err)
+	require.NoError(t, archive.Start(ctx))
+	t.Cleanup(func() {
+		archive.Stop(ctx) //nolint:errcheck
+	})
+	headers := append([]*headertest.DummyHeader{suite.Head()}, suite.GenDummyHeaders(9)...)
+	require.NoError(t, archive.Append(ctx, headers[1:]...))
+	_, err = archive.GetByHeight(ctx, 10)
+	require.NoError(t, err)
+	// the store pruned the headers below 6
+	pruned := &headertest.Store[*headertest.DummyHeader]{
+		Headers:    make(map[int64]*headertest.DummyHeader),
+		HeadHeight: 10,
+	}
+	for _, h := range headers[5:] {
+		pruned.Headers[h.Height()] = h
+	}
+	server, err := NewExchangeServer[*headertest.DummyHeader](hosts[1], &tailStore{Store: pruned, tail: 6},
+		WithNetworkID[ServerParameters](networkID),
+		WithColdStore[ServerParameters, *headertest.DummyHeader](archive),
+	)
+	require.NoError(t, err)
+	require.NoError(t, server.Start(ctx))
+	t.Cleanup(func() {
+		server.Stop(ctx) //nolint:errcheck
+	})
+
+	send := func(req *p2p_pb.HeaderRequest) []*p2p_pb.HeaderResponse {
+		resps, _, _, err := sendMessage(ctx, hostTransport{host: hosts[0]},
+			hosts[1].ID(), protocolIDs(networkID), req, 0)
+		require.NoError(t, err)
+		for _, resp := range resps {
+			require.NoError(t, convertStatusCodeToError(resp))
+		}
+		return resps
+	}
+	requireHeights := func(resps []*p2p_pb.HeaderResponse, from, amount int) {
+		require.Len(t, resps, amount)
+		for i, resp := range resps {
+			h := new(headertest.DummyHeader)
+			require.NoError(t, h.UnmarshalBinary(resp.Body))
+			assert.EqualValues(t, from+i, h.Height())
+		}
+	}
+
+	// the history is served by the cold store, so the tail is not advertised
+	resps := send(&p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 0}, Amount: 1})
+	assert.Zero(t, resps[0].Tail)
+	resps = send(&p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 2}, Amount: 3})
+	requireHeights(resps, 2, 3)
+	// the range crossing the tail is served from both stores
+	resps = send(&p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 4}, Amount: 5})
+	requireHeights(resps, 4, 5)
+	send(&p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Hash{Hash: headers[0].Hash()}, Amount: 1})
+}
+
+func TestExchangeServer_StatusCodes(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	s := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 5)
+	server, err := NewExchangeServer[*headertest.DummyHeader](hosts[1], &failingStore{Store: s},
+		WithNetworkID[ServerParameters](networkID),
+		WithMaxHeadersPerResponse[ServerParameters](2),
+	)
+	require.NoError(t, err)
+	require.NoError(t, server.Start(context.Background()))
+	t.Cleanup(func() {
+		server.Stop(context.Background()) //nolint:errcheck
+	})
+
+	tests := []struct {
+		name string
+		req  *p2p_pb.HeaderRequest
+		err  error
+	}{
+		{
+			name: "not found",
+			req:  &p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 10}, Amount: 1},
+			err:  header.ErrNotFound,
+		},
+		{
+			name: "no data",
+			req:  &p2p_pb.HeaderRequest{Amount: 1},
+			err:  ErrInvalidRequest,
+		},
+		{
+			name: "too many hashes",
+			req: &p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Hashes{Hashes: &p2p_pb.HashList{
+				Hashes: [][]byte{s.Headers[1].Hash(), s.Headers[2].Hash(), s.Headers[3].Hash()},
+			}}, Amount: 3},
+			err: ErrInvalidRequest,
+		},
+		{
+			name: "internal",
+			req:  &p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Hash{Hash: s.Headers[1].Hash()}, Amount: 1},
+			err:  ErrPeerInternal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resps, _, _, err := sendMessage(context.Background(), hostTransport{host: hosts[0]},
+				hosts[1].ID(), protocolIDs(networkID), tt.req, 0)
+			require.NoError(t, err)
+			require.Len(t, resps, 1)
+			require.ErrorIs(t, convertStatusCodeToError(resps[0]), tt.err)
+		})
+	}
+}
+
+// failingStore fails to get any header by hash.
+type failingStore struct {
+	*headertest.Store[*headertest.DummyHeader]
+}
+
+func (s *failingStore) Get(context.Context, header.Hash, ...header.CallOption) (*headertest.DummyHeader, error) {
+	return nil, errors.New("disk failure")
+}
+
+func TestExchangeServer_RecentHeaders(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	s := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10)
+	server, err := NewExchangeServer[*headertest.DummyHeader](hosts[1], s,
+		WithNetworkID[ServerParameters](networkID),
+		WithRecentHeaders[ServerParameters](3),
+	)
+	require.NoError(t, err)
+	require.NoError(t, server.Start(context.Background()))
+	t.Cleanup(func() {
+		server.Stop(context.Background()) //nolint:errcheck
+	})
+
+	send := func(req *p2p_pb.HeaderRequest) *p2p_pb.HeaderResponse {
+		resps, _, _, err := sendMessage(context.Background(), hostTransport{host: hosts[0]},
+			hosts[1].ID(), protocolIDs(networkID), req, 0)
+		require.NoError(t, err)
+		require.NotEmpty(t, resps)
+		return resps[0]
+	}
+
+	// only the heights [8;10] are served
+	resp := send(&p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 0}, Amount: 1})
+	assert.EqualValues(t, 8, resp.Tail)
+	resp = send(&p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 8}, Amount: 3})
+	require.NoError(t, convertStatusCodeToError(resp))
+
+	pruned := []*p2p_pb.HeaderRequest{
+		{Data: &p2p_pb.HeaderRequest_Origin{Origin: 7}, Amount: 2},
+		{Data: &p2p_pb.HeaderRequest_Origin{Origin: 9}, Amount: 3, Descending: true},
+		{Data: &p2p_pb.HeaderRequest_Hash{Hash: s.Headers[2].Hash()}, Amount: 1},
+	}
+	for _, req := range pruned {
+		var prunedErr *PrunedError
+		require.ErrorAs(t, convertStatusCodeToError(send(req)), &prunedErr)
+		assert.EqualValues(t, 8, prunedErr.Tail)
+	}
+}
+
+func TestExchangeServer_LegacyStatusCodes(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	s := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10)
+	server, err := NewExchangeServer[*headertest.DummyHeader](hosts[1], s,
+		WithNetworkID[ServerParameters](networkID),
+		WithRecentHeaders[ServerParameters](3),
+		WithPeerRequestsPerSecond[ServerParameters](1),
+	)
+	require.NoError(t, err)
+	require.NoError(t, server.Start(context.Background()))
+	t.Cleanup(func() {
+		server.Stop(context.Background()) //nolint:errcheck
+	})
+
+	// the client only speaks the version before the status codes beyond NOT_FOUND
+	legacy := protocolIDs(networkID)[1:]
+	req := &p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 7}, Amount: 2}
+	resps, _, _, err := sendMessage(context.Background(), hostTransport{host: hosts[0]},
+		hosts[1].ID(), legacy, req, 0)
+	require.NoError(t, err)
+	require.Len(t, resps, 1)
+	assert.Equal(t, p2p_pb.StatusCode_NOT_FOUND, resps[0].StatusCode)
+	assert.Zero(t, resps[0].Tail)
+
+	// and is not told to back off, but has the stream reset
+	_, _, _, err = sendMessage(context.Background(), hostTransport{host: hosts[0]},
+		hosts[1].ID(), legacy, req, 0)
+	require.Error(t, err)
+}
+
+func TestExchangeServer_RateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option[ServerParameters]
+		amounts []uint64
+	}{
+		{
+			name:    "requests",
+			opts:    []Option[ServerParameters]{WithPeerRequestsPerSecond[ServerParameters](1)},
+			amounts: []uint64{1, 1},
+		},
+		{
+			name: "headers",
+			opts: []Option[ServerParameters]{
+				WithPeerHeadersPerSecond[ServerParameters](5),
+				WithMaxHeadersPerResponse[ServerParameters](5),
+			},
+			amounts: []uint64{5, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts := createMocknet(t, 2)
+			s := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10)
+			opts := append([]Option[ServerParameters]{WithNetworkID[ServerParameters](networkID)}, tt.opts...)
+			server, err := NewExchangeServer[*headertest.DummyHeader](hosts[1], s, opts...)
+			require.NoError(t, err)
+			require.NoError(t, server.Start(context.Background()))
+			t.Cleanup(func() {
+				server.Stop(context.Background()) //nolint:errcheck
+			})
+
+			send := func(amount uint64) *p2p_pb.HeaderResponse {
+				req := &p2p_pb.HeaderRequest{
+					Data:   &p2p_pb.HeaderRequest_Origin{Origin: 1},
+					Amount: amount,
+				}
+				resps, _, _, err := sendMessage(context.Background(), hostTransport{host: hosts[0]},
+					hosts[1].ID(), protocolIDs(networkID), req, 0)
+				require.NoError(t, err)
+				require.NotEmpty(t, resps)
+				return resps[0]
+			}
+
+			require.NoError(t, convertStatusCodeToError(send(tt.amounts[0])))
+			// the peer exhausted its budget, so it is asked to back off
+			err = convertStatusCodeToError(send(tt.amounts[1]))
+			var rateErr *RateLimitedError
+			require.ErrorAs(t, err, &rateErr)
+			require.Greater(t, rateErr.RetryAfter, time.Duration(0))
+			require.LessOrEqual(t, rateErr.RetryAfter, time.Second)
+		})
+	}
+}
+
+func TestExchangeServer_ResponseCache(t *testing.T) {
+	hosts := createMocknet(t, 1)
+	suite := headertest.NewTestSuite(t)
+	s := headertest.NewStore[*headertest.DummyHeader](t, suite, 10)
+	server, err := NewExchangeServer[*headertest.DummyHeader](
+		hosts[0],
+		s,
+		WithNetworkID[ServerParameters](networkID),
+		WithResponseCacheSize[ServerParameters](4),
+	)
+	require.NoError(t, err)
+	require.NoError(t, server.Start(context.Background()))
+	t.Cleanup(func() {
+		server.Stop(context.Background()) //nolint:errcheck
+	})
+
+	served, err := server.serveRange(1, 5)
+	require.NoError(t, err)
+	require.Len(t, served, 4)
+	// the cached range is served without hitting the store
+	original := s.Headers[2]
+	delete(s.Headers, 2)
+	served, err = server.serveRange(1, 5)
+	require.NoError(t, err)
+	require.Equal(t, original, served[1].header)
+	s.Headers[2] = original
+
+	// the partially served range is refreshed once new headers are appended
+	served, err = server.serveRange(9, 13)
+	require.NoError(t, err)
+	require.Len(t, served, 2)
+	require.NoError(t, s.Append(context.Background(), suite.NextHeader()))
+	served, err = server.serveRange(9, 13)
+	require.NoError(t, err)
+	require.Len(t, served, 3)
+}
+
+// TestExchangeServer_ResponseCacheInvalidation ensures the cached ranges are dropped
+// once the store removes the headers they contain.
+func TestExchangeServer_ResponseCacheInvalidation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	hosts := createMocknet(t, 1)
+	suite := headertest.NewTestSuite(t)
+	s, err := store.NewStoreWithHead(ctx, sync.MutexWrap(datastore.NewMapDatastore()), suite.Head())
+	require.NoError(t, err)
+	require.NoError(t, s.Start(ctx))
+	t.Cleanup(func() {
+		s.Stop(ctx) //nolint:errcheck
+	})
+	require.NoError(t, s.Append(ctx, suite.GenDummyHeaders(9)...))
+	// waits for the headers to be published
+	_, err = s.GetByHeight(ctx, 10)
+	require.NoError(t, err)
+	server, err := NewExchangeServer[*headertest.DummyHeader](
+		hosts[0],
+		s,
+		WithNetworkID[ServerParameters](networkID),
+		WithResponseCacheSize[ServerParameters](4),
+	)
+	require.NoError(t, err)
+	require.NoError(t, server.Start(ctx))
+	t.Cleanup(func() {
+		server.Stop(ctx) //nolint:errcheck
+	})
+
+	served, err := server.serveRange(2, 6)
+	require.NoError(t, err)
+	require.Len(t, served, 4)
+	served, err = server.serveRange(7, 11)
+	require.NoError(t, err)
+	require.Len(t, served, 4)
+
+	// deleted headers are not served from the cache
+	require.NoError(t, s.DeleteRange(ctx, 4, 6))
+	_, err = server.serveRange(2, 6)
+	require.ErrorIs(t, err, header.ErrNotFound)
+
+	// neither are the rolled back ones
+	require.NoError(t, s.DeleteRange(ctx, 9, 11))
+	served, err = server.serveRange(7, 11)
+	require.NoError(t, err)
+	require.Len(t, served, 2)
+}
+
+func TestExchangeServer_Pagination(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	s := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10)
+	server, err := NewExchangeServer[*headertest.DummyHeader](
+		hosts[1],
+		s,
+		WithNetworkID[ServerParameters](networkID),
+		WithMaxHeadersPerResponse[ServerParameters](3),
+	)
+	require.NoError(t, err)
+	require.NoError(t, server.Start(context.Background()))
+	t.Cleanup(func() {
+		server.Stop(context.Background()) //nolint:errcheck
+	})
+
+	// the range above the server limit is received in pages
+	req := &p2p_pb.HeaderRequest{
+		Data:   &p2p_pb.HeaderRequest_Origin{Origin: 2},
+		Amount: 8,
+	}
+	resps, _, _, err := sendMessage(context.Background(), hostTransport{host: hosts[0]},
+		hosts[1].ID(), protocolIDs(networkID), req, 0)
+	require.NoError(t, err)
+	require.Len(t, resps, 8)
+	for i, resp := range resps {
+		h, err := header.Unmarshal[*headertest.DummyHeader](resp.Body)
+		require.NoError(t, err)
+		require.EqualValues(t, 2+i, h.Height())
+	}
+
+	// pages stop at the head of the server
+	req = &p2p_pb.HeaderRequest{
+		Data:   &p2p_pb.HeaderRequest_Origin{Origin: 6},
+		Amount: 8,
+	}
+	resps, _, _, err = sendMessage(context.Background(), hostTransport{host: hosts[0]},
+		hosts[1].ID(), protocolIDs(networkID), req, 0)
+	require.NoError(t, err)
+	require.Len(t, resps, 5)
+	require.Zero(t, resps[len(resps)-1].Continuation)
+}
+
+func TestExchangeServer_StreamRange(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	s := &streamingStore{
+		Store: headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10),
+	}
+	s.failAfter.Store(-1)
+	server, err := NewExchangeServer[*headertest.DummyHeader](
+		hosts[1],
+		s,
+		WithNetworkID[ServerParameters](networkID),
+		WithMaxHeadersPerResponse[ServerParameters](4),
+		WithCompression[ServerParameters](ZstdCompression),
+		WithCompressionThreshold[ServerParameters](0),
+	)
+	require.NoError(t, err)
+	require.NoError(t, server.Start(context.Background()))
+	t.Cleanup(func() {
+		server.Stop(context.Background()) //nolint:errcheck
+	})
+
+	request := func(origin, amount uint64, codec Compression) ([]*p2p_pb.HeaderResponse, error) {
+		req := &p2p_pb.HeaderRequest{
+			Data:        &p2p_pb.HeaderRequest_Origin{Origin: origin},
+			Amount:      amount,
+			Compression: codec,
+		}
+		resps, _, _, err := sendMessage(context.Background(), hostTransport{host: hosts[0]},
+			hosts[1].ID(), protocolIDs(networkID), req, 0)
+		return resps, err
+	}
+	requireHeights := func(t *testing.T, resps []*p2p_pb.HeaderResponse, from int) {
+		for i, resp := range resps {
+			h, err := header.Unmarshal[*headertest.DummyHeader](resp.Body)
+			require.NoError(t, err)
+			require.EqualValues(t, from+i, h.Height())
+		}
+	}
+
+	for _, codec := range []Compression{NoCompression, ZstdCompression} {
+		t.Run(fmt.Sprintf("paged range %s", codec), func(t *testing.T) {
+			streamed := s.streamed.Load()
+			resps, err := request(2, 7, codec)
+			require.NoError(t, err)
+			require.Len(t, resps, 7)
+			requireHeights(t, resps, 2)
+			// both pages are streamed from the store
+			assert.EqualValues(t, streamed+2, s.streamed.Load())
+		})
+	}
+
+	t.Run("range above the head", func(t *testing.T) {
+		resps, err := request(8, 4, NoCompression)
+		require.NoError(t, err)
+		require.Len(t, resps, 3)
+		requireHeights(t, resps, 8)
+		require.Zero(t, resps[len(resps)-1].Continuation)
+	})
+
+	t.Run("range not stored", func(t *testing.T) {
+		resps, err := request(11, 4, NoCompression)
+		require.NoError(t, err)
+		require.Len(t, resps, 1)
+		require.ErrorIs(t, convertStatusCodeToError(resps[0]), header.ErrNotFound)
+	})
+
+	t.Run("failing read", func(t *testing.T) {
+		s.failAfter.Store(3)
+		t.Cleanup(func() { s.failAfter.Store(-1) })
+		// the stream is reset once the reading fails, so the range is never received in full
+		resps, err := request(2, 4, NoCompression)
+		require.Error(t, err)
+		require.Less(t, len(resps), 4)
+		requireHeights(t, resps, 2)
+	})
+}
+
+// streamingStore streams ranges of Headers, failing after the given amount of them, if not negative.
+type streamingStore struct {
+	*headertest.Store[*headertest.DummyHeader]
+	failAfter atomic.Int32
+	streamed  atomic.Int32
+}
+
+func (s *streamingStore) GetRangeStream(
+	ctx context.Context,
+	from, to uint64,
+) (<-chan *headertest.DummyHeader, <-chan error) {
+	s.streamed.Add(1)
+	headers, errs := make(chan *headertest.DummyHeader), make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(headers)
+		for height := from; height < to; height++ {
+			if failAfter := s.failAfter.Load(); failAfter >= 0 && height-from == uint64(failAfter) {
+				errs <- errors.New("disk failure")
+				return
+			}
+			select {
+			case headers <- s.Headers[int64(height)]:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return headers, errs
+}
+
+func TestExchangeServer_EmptyPage(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	store := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10)
+
+	// the peer promises a continuation, but serves no headers on the following pages
+	var pages int
+	hosts[1].SetStreamHandler(protocolID(networkID), func(stream network.Stream) {
+		req := new(p2p_pb.HeaderRequest)
+		if _, err := serde.Read(stream, req); err != nil {
+			stream.Reset() //nolint:errcheck
+			return
+		}
+		pages++
+		if req.GetOrigin() == 2 {
+			bin, _ := store.Headers[2].MarshalBinary()
+			serde.Write(stream, &p2p_pb.HeaderResponse{ //nolint:errcheck
+				Body:         bin,
+				StatusCode:   p2p_pb.StatusCode_OK,
+				Continuation: 3,
+			})
+		}
+		stream.Close() //nolint:errcheck
+	})
+
+	req := &p2p_pb.HeaderRequest{
+		Data:   &p2p_pb.HeaderRequest_Origin{Origin: 2},
+		Amount: 8,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+	resps, _, _, err := sendMessage(ctx, hostTransport{host: hosts[0]},
+		hosts[1].ID(), protocolIDs(networkID), req, 0)
+	require.NoError(t, err)
+	require.Len(t, resps, 1)
+	assert.Equal(t, 2, pages)
+}
+
+func TestExchangeServer_Metrics(t *testing.T) {
+	hosts := createMocknet(t, 2)
+	s := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10)
+	server, err := NewExchangeServer[*headertest.DummyHeader](
+		hosts[1],
+		s,
+		WithNetworkID[ServerParameters](networkID),
+		WithMetrics[ServerParameters](),
+	)
+	require.NoError(t, err)
+	require.NotNil(t, server.metrics)
+	require.NoError(t, server.Start(context.Background()))
+	t.Cleanup(func() {
+		server.Stop(context.Background()) //nolint:errcheck
+	})
+
+	req := &p2p_pb.HeaderRequest{
+		Data:   &p2p_pb.HeaderRequest_Origin{Origin: 1},
+		Amount: 5,
+	}
+	resps, _, _, err := sendMessage(context.Background(), hostTransport{host: hosts[0]},
+		hosts[1].ID(), protocolIDs(networkID), req, 0)
+	require.NoError(t, err)
+	require.Len(t, resps, 5)
+
+	tests := []struct {
+		req  *p2p_pb.HeaderRequest
+		want string
+	}{
+		{&p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{}, Amount: 1}, "head"},
+		{&p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{}, Amount: 1, Subscribe: true}, "head_subscription"},
+		{&p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 5}, Amount: 1}, "height"},
+		{&p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 5}, Amount: 3}, "range"},
+		{&p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 5}, Amount: 3, Descending: true}, "range_descending"},
+		{&p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Hash{}}, "hash"},
+		{&p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Hashes{}}, "hashes"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, requestType(tt.req))
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 
-	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 
 	"github.com/celestiaorg/go-header"
 	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
@@ -18,6 +21,10 @@ import (
 // response.
 var errEmptyResponse = errors.New("empty response")
 
+// busyPeerDelay specifies how long a peer at the limit of concurrent requests
+// is kept out of the session queue.
+var busyPeerDelay = time.Millisecond * 100
+
 type option[H header.Header] func(*session[H])
 
 func withValidation[H header.Header](from H) option[H] {
@@ -26,30 +33,157 @@ func withValidation[H header.Header](from H) option[H] {
 	}
 }
 
+// withRand makes the session order equally scored peers using the given source of randomness.
+func withRand[H header.Header](rand *lockedRand) option[H] {
+	return func(s *session[H]) {
+		s.rand = rand
+	}
+}
+
+// withPacer paces the requests of the session within the bandwidth of the given pacer.
+func withPacer[H header.Header](pacer *pacer) option[H] {
+	return func(s *session[H]) {
+		s.pacer = pacer
+	}
+}
+
+// withParallelism limits the amount of concurrent requests of the session with the given parallelism.
+func withParallelism[H header.Header](parallelism *parallelism) option[H] {
+	return func(s *session[H]) {
+		s.parallelism = parallelism
+	}
+}
+
+// withMetrics makes the session record its requests to the given metrics.
+func withMetrics[H header.Header](metrics *metrics) option[H] {
+	return func(s *session[H]) {
+		s.metrics = metrics
+	}
+}
+
+// withCompression makes the session accept responses compressed with the given codec.
+func withCompression[H header.Header](codec Compression) option[H] {
+	return func(s *session[H]) {
+		s.compression = codec
+	}
+}
+
+// withProofVerifier makes the session verify the received headers with their proofs.
+func withProofVerifier[H header.Header](verifier ProofVerifier[H]) option[H] {
+	return func(s *session[H]) {
+		s.proofs = verifier
+	}
+}
+
+// withScheduler makes the session split ranges and assign them to peers with the given Scheduler.
+func withScheduler[H header.Header](scheduler Scheduler) option[H] {
+	return func(s *session[H]) {
+		if scheduler != nil {
+			s.scheduler = scheduler
+		}
+	}
+}
+
+// withPeer makes the session send all the requests to the given peer only.
+func withPeer[H header.Header](pid peer.ID) option[H] {
+	return func(s *session[H]) {
+		s.peer = pid
+	}
+}
+
+// withNoRetry makes the session fail with the error of the first failed request
+// instead of retrying it with other peers.
+func withNoRetry[H header.Header]() option[H] {
+	return func(s *session[H]) {
+		s.noRetry = true
+	}
+}
+
+// withMaxMessageSize makes the session reject responses above the given size.
+func withMaxMessageSize[H header.Header](size uint64) option[H] {
+	return func(s *session[H]) {
+		s.maxMsgSize = size
+	}
+}
+
+// withPriority makes the session send its requests with the given priority.
+func withPriority[H header.Header](priority p2p_pb.Priority) option[H] {
+	return func(s *session[H]) {
+		s.priority = priority
+	}
+}
+
+// withStream makes the session pass the received headers to the given function in ascending
+// order, as soon as they extend the contiguous prefix of the range received so far.
+func withStream[H header.Header](stream func(H) error) option[H] {
+	return func(s *session[H]) {
+		s.stream = stream
+	}
+}
+
 // session aims to divide a range of headers
 // into several smaller requests among different peers.
 type session[H header.Header] struct {
-	host       host.Host
-	protocolID protocol.ID
-	queue      *peerQueue
+	transport   Transport
+	protocolIDs []protocol.ID
+	queue       *peerQueue
 	// peerTracker contains discovered peers with records that describes their activity.
-	peerTracker *peerTracker
+	peerTracker *PeerTracker
 
 	// `from` is set when additional validation for range is needed.
 	// Otherwise, it will be nil.
 	from           H
 	requestTimeout time.Duration
+	// rand, if set, defines the order in which equally scored peers are selected.
+	rand *lockedRand
+	// pacer, if set, limits the bandwidth used by the session.
+	pacer *pacer
+	// parallelism, if set, adaptively limits the amount of concurrent requests of the session.
+	parallelism *parallelism
+	// metrics, if set, records requests of the session.
+	metrics *metrics
+	// compression is the codec the session accepts responses to be compressed with.
+	compression Compression
+	// proofs, if set, verifies the received headers with their proofs.
+	proofs ProofVerifier[H]
+	// maxMsgSize is the max size of a single response message. Zero means no limit.
+	maxMsgSize uint64
+	// scheduler splits the requested ranges and chooses the peers they are assigned to.
+	scheduler Scheduler
+	// peer, if set, is the only peer the session sends requests to.
+	peer peer.ID
+	// noRetry makes the session fail on the first failed request.
+	noRetry bool
+	// priority is the priority of the requests of the session, low by default.
+	priority p2p_pb.Priority
+	// stream, if set, receives the contiguous prefix of the range as the responses arrive.
+	stream func(H) error
+
+	sourcesLk sync.Mutex
+	// sources are the peers the received headers came from by their height.
+	sources map[int64]peer.ID
+
+	// shared reports whether the queue is shared with the session the session is derived from.
+	shared bool
+	// droppedLk guards dropped and closed.
+	droppedLk sync.Mutex
+	// dropped are the peers taken out of the shared queue for failing the requests of the session.
+	// They are returned to the queue once the session is closed, so other sessions can use them.
+	dropped []*peerStat
+	closed  bool
 
 	ctx    context.Context
 	cancel context.CancelFunc
 	reqCh  chan *p2p_pb.HeaderRequest
+	// errCh receives the errors of failed requests, if the session does not retry them.
+	errCh chan error
 }
 
 func newSession[H header.Header](
 	ctx context.Context,
-	h host.Host,
-	peerTracker *peerTracker,
-	protocolID protocol.ID,
+	transport Transport,
+	peerTracker *PeerTracker,
+	protocolIDs []protocol.ID,
 	requestTimeout time.Duration,
 	options ...option[H],
 ) *session[H] {
@@ -57,29 +191,131 @@ func newSession[H header.Header](
 	ses := &session[H]{
 		ctx:            ctx,
 		cancel:         cancel,
-		protocolID:     protocolID,
-		host:           h,
-		queue:          newPeerQueue(ctx, peerTracker.peers()),
+		protocolIDs:    protocolIDs,
+		transport:      transport,
 		peerTracker:    peerTracker,
 		requestTimeout: requestTimeout,
+		scheduler:      sequentialScheduler{},
+		sources:        make(map[int64]peer.ID),
 	}
 
 	for _, opt := range options {
 		opt(ses)
 	}
+
+	peers := peerTracker.peers()
+	if ses.peer != "" {
+		peers = filterPeer(peers, ses.peer)
+	}
+	if ses.rand != nil {
+		// peers come in the map order, so sort them first to make the shuffle reproducible
+		sort.Slice(peers, func(i, j int) bool { return peers[i].peerID < peers[j].peerID })
+		ses.rand.Shuffle(len(peers), func(i, j int) { peers[i], peers[j] = peers[j], peers[i] })
+	}
+	ses.queue = newPeerQueue(ctx, peers)
+	return ses
+}
+
+// derive returns a new session for a single call of the given one, configured with its options
+// and the given ones on top. The new session shares the queue of peers with the given one,
+// unless it is restricted to another peer, and is closed along with it.
+func (s *session[H]) derive(options ...option[H]) *session[H] {
+	ctx, cancel := context.WithCancel(s.ctx)
+	ses := &session[H]{
+		ctx:            ctx,
+		cancel:         cancel,
+		protocolIDs:    s.protocolIDs,
+		transport:      s.transport,
+		peerTracker:    s.peerTracker,
+		requestTimeout: s.requestTimeout,
+		from:           s.from,
+		rand:           s.rand,
+		pacer:          s.pacer,
+		parallelism:    s.parallelism,
+		metrics:        s.metrics,
+		compression:    s.compression,
+		proofs:         s.proofs,
+		maxMsgSize:     s.maxMsgSize,
+		scheduler:      s.scheduler,
+		peer:           s.peer,
+		noRetry:        s.noRetry,
+		priority:       s.priority,
+		stream:         s.stream,
+		sources:        make(map[int64]peer.ID),
+		queue:          s.queue,
+		shared:         true,
+	}
+	for _, opt := range options {
+		opt(ses)
+	}
+	if ses.peer != s.peer {
+		ses.queue = newPeerQueue(ctx, filterPeer(s.peerTracker.peers(), ses.peer))
+		ses.shared = false
+	}
 	return ses
 }
 
 // getRangeByHeight requests headers from different peers.
+// If the context is done before the whole range is received, the contiguous prefix of the range
+// received so far is returned along with ErrPartialResponse.
 func (s *session[H]) getRangeByHeight(
 	ctx context.Context,
 	from, amount, headersPerPeer uint64,
 ) ([]H, error) {
 	log.Debugw("requesting headers", "from", from, "to", from+amount-1) // -1 need to exclude to+1 height
+	requests, err := scheduleRequests(s.scheduler, from, amount, headersPerPeer)
+	if err != nil {
+		return nil, err
+	}
+	headers, err := s.fetch(ctx, requests, amount)
+	if err != nil && ctx.Err() != nil {
+		headers = contiguousPrefix(headers, from)
+		if len(headers) == 0 {
+			return nil, err
+		}
+		err = fmt.Errorf("%w: received %d out of %d headers: %w", ErrPartialResponse, len(headers), amount, err)
+	}
+	if err != nil && !errors.Is(err, ErrPartialResponse) {
+		return nil, err
+	}
+	if linkErr := s.verifyChain(headers); linkErr != nil {
+		return nil, linkErr
+	}
+	return headers, err
+}
 
-	requests := prepareRequests(from, amount, headersPerPeer)
+// verifyChain ensures the headers received from different peers are linked by their hashes.
+// Breaks within the responses of single peers are caught and the peers blocked while processing
+// the responses, so a break here is between the responses of two peers. As it is unknown which one
+// of them is at fault, both get their score lowered.
+func (s *session[H]) verifyChain(headers []H) error {
+	for i := 1; i < len(headers); i++ {
+		if linked(headers[i-1], headers[i]) {
+			continue
+		}
+		s.sourcesLk.Lock()
+		parentPeer, childPeer := s.sources[headers[i-1].Height()], s.sources[headers[i].Height()]
+		s.sourcesLk.Unlock()
+		s.peerTracker.decreaseScore(parentPeer)
+		s.peerTracker.decreaseScore(childPeer)
+		return fmt.Errorf("%w: header %d received from %s is not the parent of header %d received from %s",
+			errBrokenChain, headers[i-1].Height(), parentPeer, headers[i].Height(), childPeer)
+	}
+	return nil
+}
+
+// fetch sends the given requests to different peers until the given amount of headers
+// is received and returns the headers sorted by height.
+// If the context is done beforehand, the headers received so far are returned
+// sorted by height along with the error.
+func (s *session[H]) fetch(
+	ctx context.Context,
+	requests []*p2p_pb.HeaderRequest,
+	amount uint64,
+) ([]H, error) {
 	result := make(chan []H, len(requests))
 	s.reqCh = make(chan *p2p_pb.HeaderRequest, len(requests))
+	s.errCh = make(chan error, len(requests))
 
 	go s.handleOutgoingRequests(ctx, result)
 	for _, req := range requests {
@@ -87,25 +323,37 @@ func (s *session[H]) getRangeByHeight(
 	}
 
 	headers := make([]H, 0, amount)
+	origin, streamed := requests[0].GetOrigin(), 0
+	for _, req := range requests {
+		if req.GetOrigin() < origin {
+			origin = req.GetOrigin()
+		}
+	}
 LOOP:
 	for {
 		select {
 		case <-s.ctx.Done():
 			return nil, errors.New("header/p2p: exchange is closed")
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			sortByHeight(headers)
+			return headers, ctx.Err()
+		case err := <-s.errCh:
+			return nil, err
 		case res := <-result:
 			headers = append(headers, res...)
+			if s.stream != nil {
+				var err error
+				if streamed, err = s.streamPrefix(headers, origin, streamed); err != nil {
+					return nil, err
+				}
+			}
 			if uint64(len(headers)) == amount {
 				break LOOP
 			}
 		}
 	}
 
-	sort.Slice(headers, func(i, j int) bool {
-		return headers[i].Height() < headers[j].Height()
-	})
-
+	sortByHeight(headers)
 	log.Debugw("received headers range",
 		"from", headers[0].Height(),
 		"to", headers[len(headers)-1].Height(),
@@ -113,12 +361,97 @@ LOOP:
 	return headers, nil
 }
 
-// close stops the session.
+// streamPrefix sorts the given headers by height and streams the ones extending the given amount
+// of headers streamed so far, as long as they are contiguous from the origin height.
+// Every streamed header must be linked to the previous one, which is the header
+// the session is validated against for the first one. It returns the amount of streamed headers.
+func (s *session[H]) streamPrefix(headers []H, origin uint64, streamed int) (int, error) {
+	sortByHeight(headers)
+	for ; streamed < len(headers); streamed++ {
+		h := headers[streamed]
+		if uint64(h.Height()) != origin+uint64(streamed) {
+			break
+		}
+		switch {
+		case streamed > 0:
+			if err := s.verifyChain(headers[streamed-1 : streamed+1]); err != nil {
+				return streamed, err
+			}
+		case !s.from.IsZero() && s.from.Height()+1 == h.Height() && !linked(s.from, h):
+			s.sourcesLk.Lock()
+			pid := s.sources[h.Height()]
+			s.sourcesLk.Unlock()
+			s.peerTracker.decreaseScore(pid)
+			return streamed, fmt.Errorf("%w: header %d received from %s is not the child of the trusted header",
+				errBrokenChain, h.Height(), pid)
+		}
+		if err := s.stream(h); err != nil {
+			return streamed, err
+		}
+	}
+	return streamed, nil
+}
+
+// filterPeer returns the stat of the given peer among the given ones,
+// or a new one if the peer is not tracked.
+func filterPeer(peers []*peerStat, pid peer.ID) []*peerStat {
+	for _, stat := range peers {
+		if stat.peerID == pid {
+			return []*peerStat{stat}
+		}
+	}
+	return []*peerStat{{peerID: pid}}
+}
+
+// sortByHeight sorts the headers by height in ascending order.
+func sortByHeight[H header.Header](headers []H) {
+	sort.Slice(headers, func(i, j int) bool {
+		return headers[i].Height() < headers[j].Height()
+	})
+}
+
+// contiguousPrefix returns the leading headers of the given sorted ones that are
+// adjacent to each other, starting from the given height.
+func contiguousPrefix[H header.Header](headers []H, from uint64) []H {
+	for i, h := range headers {
+		if uint64(h.Height()) != from+uint64(i) {
+			return headers[:i]
+		}
+	}
+	return headers
+}
+
+// close stops the session, returning the peers it dropped to the shared queue, if any.
 func (s *session[H]) close() {
 	if s.cancel != nil {
 		s.cancel()
 		s.cancel = nil
 	}
+	s.droppedLk.Lock()
+	dropped := s.dropped
+	s.dropped, s.closed = nil, true
+	s.droppedLk.Unlock()
+	for _, stat := range dropped {
+		s.queue.push(stat)
+	}
+}
+
+// drop takes the peer failing a request out of the session. Peers of the shared queue are kept
+// until the session is closed, so they are not retried by the session, but remain available
+// to other sessions afterwards.
+func (s *session[H]) drop(stat *peerStat) {
+	if !s.shared {
+		return
+	}
+	s.droppedLk.Lock()
+	if !s.closed {
+		s.dropped = append(s.dropped, stat)
+		stat = nil
+	}
+	s.droppedLk.Unlock()
+	if stat != nil {
+		s.queue.push(stat)
+	}
 }
 
 // handleOutgoingRequests pops a peer from the queue and sends a prepared request to the peer.
@@ -131,60 +464,178 @@ func (s *session[H]) handleOutgoingRequests(ctx context.Context, result chan []H
 		case <-s.ctx.Done():
 			return
 		case req := <-s.reqCh:
+			reserved, err := s.pacer.wait(ctx, req.Amount)
+			if err != nil {
+				return
+			}
+			if err := s.parallelism.acquire(ctx); err != nil {
+				s.pacer.consume(reserved, 0, 0)
+				return
+			}
 			// select peer with the highest score among the available ones for the request
-			stats := s.queue.waitPop(ctx)
-			if stats.peerID == "" {
+			stats := s.acquirePeer(ctx, req)
+			if stats == nil {
+				s.pacer.consume(reserved, 0, 0)
+				s.parallelism.cancel()
 				return
 			}
-			go s.doRequest(ctx, stats, req, result)
+			go s.doRequest(ctx, stats, req, reserved, result)
 		}
 	}
 }
 
+// acquirePeer pops the peer with the highest score that has capacity for the request.
+// Peers busy with requests of other sessions, with the open circuit breaker or rate limiting
+// the client are skipped, so the request is routed to the next best peer, and are returned
+// to the queue after busyPeerDelay, once the breaker lets a probe request through
+// or once the rate limit passes respectively.
+// Peers not suiting the requested range according to the Scheduler, e.g. the ones advertising
+// they pruned it, are skipped in favour of others, unless no other peer is available.
+// It returns nil once the session is closed.
+func (s *session[H]) acquirePeer(ctx context.Context, req *p2p_pb.HeaderRequest) *peerStat {
+	var unsuited []*peerStat
+	defer func() {
+		for _, stat := range unsuited {
+			s.queue.push(stat)
+		}
+	}()
+
+	for {
+		var stat *peerStat
+		if len(unsuited) > 0 && s.queue.len() == 0 {
+			// none of the available peers suits the range, so the best unsuited one is tried anyway
+			stat, unsuited = unsuited[0], unsuited[1:]
+		} else {
+			stat = s.queue.waitPop(ctx)
+			if stat.peerID == "" {
+				return nil
+			}
+			if !s.scheduler.Suits(requestRange(req), stat.info()) {
+				unsuited = append(unsuited, stat)
+				continue
+			}
+		}
+
+		delay := stat.breakerOpen()
+		if wait := stat.throttleWait(); wait > delay {
+			delay = wait
+		}
+		if delay == 0 {
+			switch {
+			case !stat.acquire(s.peerTracker.maxInflight):
+				delay = busyPeerDelay
+			case !stat.reserveProbe(s.peerTracker.breakerCooldown):
+				// another probe was let through in the meantime
+				stat.release()
+				delay = stat.breakerOpen()
+			default:
+				return stat
+			}
+		}
+
+		log.Debugw("peer is unavailable, routing the request to another peer", "peer", stat.peerID, "for", delay)
+		s.pushLater(stat, delay)
+	}
+}
+
 // doRequest chooses the best peer to fetch headers and sends a request in range of available
-// maxRetryAttempts.
+// maxRetryAttempts. The bandwidth reserved for the request by the pacer is settled with the response.
 func (s *session[H]) doRequest(
 	ctx context.Context,
 	stat *peerStat,
 	req *p2p_pb.HeaderRequest,
+	reserved uint64,
 	headers chan []H,
 ) {
 	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
 	defer cancel()
-
-	r, size, duration, err := sendMessage(ctx, s.host, stat.peerID, s.protocolID, req)
-	if err != nil {
+	ctx, span := startRequestSpan(ctx, stat.peerID, req)
+	defer span.End()
+
+	req = s.capRequest(stat, req)
+	req.Compression = s.compression
+	req.Priority = s.priority
+	r, size, duration, sendErr := sendMessage(ctx, s.transport, stat.peerID, s.protocolIDs, req, s.maxMsgSize)
+	span.SetAttributes(attribute.Int64("bytes", int64(size)))
+	stat.release()
+	s.pacer.consume(reserved, size, uint64(len(r)))
+	s.parallelism.release(size, errors.Is(ctx.Err(), context.DeadlineExceeded))
+	if sendErr != nil {
 		// we should not punish peer at this point and should try to parse responses, despite that error
 		// was received.
-		log.Debugw("requesting headers from peer failed", "peer", stat.peerID, "err", err)
+		log.Debugw("requesting headers from peer failed", "peer", stat.peerID, "err", sendErr)
 	}
 
-	h, err := s.processResponse(r)
+	h, err := s.processResponse(req, r)
+	if errors.Is(sendErr, ErrResponseLimitExceeded) {
+		// responses violating the limits are not trusted even if they are parsed successfully
+		h, err = nil, sendErr
+	}
+	s.metrics.observeResponse(ctx, stat.peerID, size, duration, err)
+	s.peerTracker.recordStatResult(stat, err)
 	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
 		logFn := log.Errorw
+		blocked := false
 
-		switch err {
-		case header.ErrNotFound, errEmptyResponse:
+		switch {
+		case errors.Is(err, header.ErrNotFound), errors.Is(err, errEmptyResponse):
 			logFn = log.Debugw
 			stat.decreaseScore()
+		case errors.Is(err, ErrResponseLimitExceeded):
+			// the score is already lowered when recording the result
+			logFn = log.Debugw
+		case errors.As(err, new(*RateLimitedError)):
+			// the peer is throttled when recording the result
+			logFn = log.Debugw
+		case errors.As(err, new(*PrunedError)):
+			// the tail of the peer is raised when recording the result,
+			// so the Scheduler routes the request to peers retaining it
+			logFn = log.Debugw
+		case errors.Is(err, ErrPeerInternal), errors.Is(err, ErrInvalidRequest):
+			// the peer responded honestly, so it is not blocked,
+			// while its internal errors count towards its circuit breaker when recording the result
+			logFn = log.Warnw
 		default:
-			s.peerTracker.blockPeer(stat.peerID, err)
+			s.metrics.observeBlocked(ctx, stat.peerID)
+			s.peerTracker.blockPeer(stat.peerID, &InvalidResponseError{Request: req, Err: err})
+			blocked = true
 		}
-
-		select {
-		case <-s.ctx.Done():
-			return
-		case s.reqCh <- req:
+		if !blocked {
+			s.drop(stat)
 		}
 		logFn("processing response",
 			"from", req.GetOrigin(),
 			"to", req.Amount+req.GetOrigin()-1,
+			"verified", len(h),
 			"err", err,
 			"peer", stat.peerID,
 		)
+
+		if s.noRetry {
+			select {
+			case <-s.ctx.Done():
+			case s.errCh <- err:
+			}
+			return
+		}
+		// the request is retried with another peer
+		s.metrics.observeRetry(ctx, stat.peerID)
+		if len(h) == 0 {
+			select {
+			case <-s.ctx.Done():
+			case s.reqCh <- req:
+			}
+			return
+		}
+		// the verified prefix is kept and only the remainder is requested from another peer
+		s.requestRemainder(req, h)
+		s.recordSources(stat.peerID, h)
+		headers <- h
 		return
 	}
 
+	span.SetStatus(codes.Ok, "")
 	log.Debugw("request headers from peer succeeded",
 		"peer", stat.peerID,
 		"receivedAmount", len(h),
@@ -193,73 +644,152 @@ func (s *session[H]) doRequest(
 
 	// update peer stats
 	stat.updateStats(size, duration)
+	stat.updateHeadScore(size, duration, s.peerTracker.headProximity(uint64(h[len(h)-1].Height())))
 
-	responseLn := uint64(len(h))
 	// ensure that we received the correct amount of headers.
-	if responseLn < req.Amount {
-		from := uint64(h[responseLn-1].Height())
-		amount := req.Amount - responseLn
-
-		select {
-		case <-s.ctx.Done():
-			return
-		// create a new request with the remaining headers.
-		// prepareRequests will return a slice with 1 element at this point
-		case s.reqCh <- prepareRequests(from+1, amount, req.Amount)[0]:
-			log.Debugw("sending additional request to get remaining headers")
-		}
-	}
+	s.requestRemainder(req, h)
 
 	// send headers to the channel, return peer to the queue, so it can be
 	// re-used in case if there are other requests awaiting
+	s.recordSources(stat.peerID, h)
 	headers <- h
+	if sendErr != nil {
+		// the peer dropped the stream midway, so the remainder is preferably routed to another peer
+		s.pushLater(stat, busyPeerDelay)
+		return
+	}
 	s.queue.push(stat)
 }
 
-// processResponse converts HeaderResponse to Header.
-func (s *session[H]) processResponse(responses []*p2p_pb.HeaderResponse) ([]H, error) {
+// capRequest caps the request to the amount of headers the given peer advertised it serves
+// per request and requests the rest of the range separately, so other peers may serve it.
+func (s *session[H]) capRequest(stat *peerStat, req *p2p_pb.HeaderRequest) *p2p_pb.HeaderRequest {
+	maxRange := stat.info().MaxRange
+	if maxRange == 0 || req.Amount <= maxRange {
+		return req
+	}
+	from := req.GetOrigin()
+	select {
+	case <-s.ctx.Done():
+	case s.reqCh <- prepareRequests(from+maxRange, req.Amount-maxRange, req.Amount)[0]:
+		log.Debugw("capping request to the max range of peer", "peer", stat.peerID, "maxRange", maxRange)
+	}
+	return prepareRequests(from, maxRange, maxRange)[0]
+}
+
+// recordSources records the given peer as the source of the given headers.
+func (s *session[H]) recordSources(from peer.ID, headers []H) {
+	s.sourcesLk.Lock()
+	defer s.sourcesLk.Unlock()
+	for _, h := range headers {
+		s.sources[h.Height()] = from
+	}
+}
+
+// requestRemainder requests the headers of the given request,
+// which are missing from the received ones, if any.
+func (s *session[H]) requestRemainder(req *p2p_pb.HeaderRequest, received []H) {
+	responseLn := uint64(len(received))
+	if responseLn >= req.Amount {
+		return
+	}
+	from := uint64(received[responseLn-1].Height())
+	amount := req.Amount - responseLn
+
+	select {
+	case <-s.ctx.Done():
+	// create a new request with the remaining headers.
+	// prepareRequests will return a slice with 1 element at this point
+	case s.reqCh <- prepareRequests(from+1, amount, req.Amount)[0]:
+		log.Debugw("sending additional request to get remaining headers", "from", from+1, "amount", amount)
+	}
+}
+
+// pushLater returns the peer to the queue after the given delay.
+// Peers of the shared queue are returned right away once the session is closed.
+func (s *session[H]) pushLater(stat *peerStat, delay time.Duration) {
+	go func() {
+		select {
+		case <-time.After(delay):
+			s.queue.push(stat)
+		case <-s.ctx.Done():
+			if s.shared {
+				s.queue.push(stat)
+			}
+		}
+	}()
+}
+
+// processResponse converts HeaderResponse to the given request to Header.
+// Responses are processed in order until the first invalid one, the one not at the requested
+// height or the one not linked to the previous header by its hash, so the verified prefix
+// of a partially invalid response is returned along with the error.
+func (s *session[H]) processResponse(
+	req *p2p_pb.HeaderRequest,
+	responses []*p2p_pb.HeaderResponse,
+) ([]H, error) {
 	if len(responses) == 0 {
 		return nil, errEmptyResponse
 	}
 
-	headers := make([]H, 0)
-	for _, resp := range responses {
-		err := convertStatusCodeToError(resp.StatusCode)
+	var err error
+	headers := make([]H, 0, len(responses))
+	for i, resp := range responses {
+		err = convertStatusCodeToError(resp)
 		if err != nil {
-			return nil, err
+			break
 		}
 
-		var empty H
-		header := empty.New()
-		err = header.UnmarshalBinary(resp.Body)
+		var h H
+		h, err = header.Unmarshal[H](resp.Body)
 		if err != nil {
-			return nil, err
+			break
+		}
+		// the kept prefix determines the remainder to request, so it must start at the origin
+		if height := req.GetOrigin() + uint64(i); uint64(h.Height()) != height {
+			err = fmt.Errorf("%w: unexpected header: expected height %d, received %d",
+				errInvalidResponse, height, h.Height())
+			break
 		}
-		headers = append(headers, header.(H))
+		if err = s.proofs.verify(s.ctx, h, resp.Proof); err != nil {
+			break
+		}
+		if len(headers) > 0 && !linked(headers[len(headers)-1], h) {
+			err = fmt.Errorf("%w: header %d is not the parent of header %d",
+				errBrokenChain, headers[len(headers)-1].Height(), h.Height())
+			break
+		}
+		headers = append(headers, h)
 	}
 
-	if len(headers) == 0 {
-		return nil, header.ErrNotFound
+	valid, verr := s.verifiedPrefix(headers)
+	if verr != nil {
+		headers, err = headers[:valid], verr
 	}
-
-	err := s.validate(headers)
 	return headers, err
 }
 
 // validate checks that the received range of headers is adjacent and is valid against the provided
 // header.
 func (s *session[H]) validate(headers []H) error {
+	_, err := s.verifiedPrefix(headers)
+	return err
+}
+
+// verifiedPrefix validates the received range of headers the same way as validate does and
+// returns the amount of leading headers that are valid along with the error of the first invalid one.
+func (s *session[H]) verifiedPrefix(headers []H) (int, error) {
 	// if `s.from` is empty, then additional validation for the header`s range is not needed.
 	if s.from.IsZero() {
-		return nil
+		return len(headers), nil
 	}
 
 	trusted := s.from
 	// verify that the whole range is valid and adjacent.
-	for _, untrusted := range headers {
-		err := trusted.Verify(untrusted)
+	for i, untrusted := range headers {
+		err := header.Verify(trusted, untrusted)
 		if err != nil {
-			return err
+			return i, err
 		}
 
 		// extra check for the adjacency should be performed only for the received range,
@@ -267,7 +797,7 @@ func (s *session[H]) validate(headers []H) error {
 		if trusted.Height() != s.from.Height() {
 			if trusted.Height()+1 != untrusted.Height() {
 				// Exchange requires requested ranges to always consist of adjacent headers
-				return fmt.Errorf("peer sent valid but non-adjacent header. expected:%d, received:%d",
+				return i, fmt.Errorf("peer sent valid but non-adjacent header. expected:%d, received:%d",
 					trusted.Height()+1,
 					untrusted.Height(),
 				)
@@ -276,29 +806,10 @@ func (s *session[H]) validate(headers []H) error {
 		// as `untrusted` was verified against previous trusted header, we can assume that it is valid
 		trusted = untrusted
 	}
-	return nil
+	return len(headers), nil
 }
 
 // prepareRequests converts incoming range into separate HeaderRequest.
 func prepareRequests(from, amount, headersPerPeer uint64) []*p2p_pb.HeaderRequest {
-	requests := make([]*p2p_pb.HeaderRequest, 0, amount/headersPerPeer)
-	for amount > uint64(0) {
-		var requestSize uint64
-		request := &p2p_pb.HeaderRequest{
-			Data: &p2p_pb.HeaderRequest_Origin{Origin: from},
-		}
-
-		if amount < headersPerPeer {
-			requestSize = amount
-			amount = 0
-		} else {
-			amount -= headersPerPeer
-			from += headersPerPeer
-			requestSize = headersPerPeer
-		}
-
-		request.Amount = requestSize
-		requests = append(requests, request)
-	}
-	return requests
+	return rangeRequests(sequentialScheduler{}.Chunk(from, amount, headersPerPeer))
 }
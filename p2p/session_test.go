@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/celestiaorg/go-header/headertest"
+	p2p_pb "github.com/celestiaorg/go-header/p2p/pb"
 )
 
 func Test_PrepareRequests(t *testing.T) {
@@ -21,6 +22,30 @@ func Test_PrepareRequests(t *testing.T) {
 	require.Equal(t, requests[1].GetOrigin(), uint64(6))
 }
 
+// Test_CapRequest ensures the requests above the max range advertised by the peer
+// are capped, while the rest of the range is requested separately.
+func Test_CapRequest(t *testing.T) {
+	ses := newSession[*headertest.DummyHeader](
+		context.Background(),
+		nil,
+		&PeerTracker{trackedPeers: make(map[peer.ID]*peerStat)},
+		nil, time.Second,
+	)
+	ses.reqCh = make(chan *p2p_pb.HeaderRequest, 1)
+
+	req := prepareRequests(1, 40, 40)[0]
+	capped := ses.capRequest(&peerStat{}, req)
+	assert.Equal(t, req, capped)
+	require.Empty(t, ses.reqCh)
+
+	capped = ses.capRequest(&peerStat{maxRange: 16}, req)
+	assert.EqualValues(t, 1, capped.GetOrigin())
+	assert.EqualValues(t, 16, capped.Amount)
+	rest := <-ses.reqCh
+	assert.EqualValues(t, 17, rest.GetOrigin())
+	assert.EqualValues(t, 24, rest.Amount)
+}
+
 // Test_Validate ensures that headers range is adjacent and valid.
 func Test_Validate(t *testing.T) {
 	suite := headertest.NewTestSuite(t)
@@ -28,8 +53,8 @@ func Test_Validate(t *testing.T) {
 	ses := newSession(
 		context.Background(),
 		nil,
-		&peerTracker{trackedPeers: make(map[peer.ID]*peerStat)},
-		"", time.Second,
+		&PeerTracker{trackedPeers: make(map[peer.ID]*peerStat)},
+		nil, time.Second,
 		withValidation(head),
 	)
 
@@ -45,8 +70,8 @@ func Test_ValidateFails(t *testing.T) {
 	ses := newSession(
 		context.Background(),
 		nil,
-		&peerTracker{trackedPeers: make(map[peer.ID]*peerStat)},
-		"", time.Second,
+		&PeerTracker{trackedPeers: make(map[peer.ID]*peerStat)},
+		nil, time.Second,
 		withValidation(head),
 	)
 
@@ -56,3 +81,197 @@ func Test_ValidateFails(t *testing.T) {
 	err := ses.validate(headers)
 	assert.Error(t, err)
 }
+
+// Test_ProcessResponseKeepsVerifiedPrefix ensures that headers preceding an invalid one are kept.
+func Test_ProcessResponseKeepsVerifiedPrefix(t *testing.T) {
+	suite := headertest.NewTestSuite(t)
+	head := suite.Head()
+	ses := newSession(
+		context.Background(),
+		nil,
+		&PeerTracker{trackedPeers: make(map[peer.ID]*peerStat)},
+		nil, time.Second,
+		withValidation(head),
+	)
+
+	headers := suite.GenDummyHeaders(5)
+	// break adjacency of the fourth header
+	headers[3] = headers[4]
+	responses := make([]*p2p_pb.HeaderResponse, len(headers))
+	for i, h := range headers {
+		bin, err := h.MarshalBinary()
+		require.NoError(t, err)
+		responses[i] = &p2p_pb.HeaderResponse{Body: bin, StatusCode: p2p_pb.StatusCode_OK}
+	}
+
+	req := prepareRequests(uint64(headers[0].Height()), 5, 5)[0]
+	received, err := ses.processResponse(req, responses)
+	require.Error(t, err)
+	require.Len(t, received, 3)
+	for i, h := range received {
+		assert.Equal(t, headers[i].Hash(), h.Hash())
+	}
+
+	// an undecodable header cuts the response as well
+	responses[1].Body = []byte("invalid")
+	received, err = ses.processResponse(req, responses)
+	require.Error(t, err)
+	require.Len(t, received, 1)
+
+	// so does a header of another height, even at the start of the response
+	received, err = ses.processResponse(prepareRequests(uint64(headers[0].Height())+1, 5, 5)[0], responses)
+	require.ErrorIs(t, err, errInvalidResponse)
+	require.Empty(t, received)
+}
+
+// Test_ProcessResponseRejectsBrokenChain ensures that headers of a response must be linked by their hashes,
+// even if the range is not validated against a trusted header.
+func Test_ProcessResponseRejectsBrokenChain(t *testing.T) {
+	suite := headertest.NewTestSuite(t)
+	ses := newSession[*headertest.DummyHeader](
+		context.Background(),
+		nil,
+		&PeerTracker{trackedPeers: make(map[peer.ID]*peerStat)},
+		nil, time.Second,
+	)
+
+	headers := suite.GenDummyHeaders(4)
+	// the third header is of the right height, but from another chain
+	forked := *headers[2]
+	forked.Raw.PreviousHash = headertest.RandBytes(32)
+	headers[2] = &forked
+	responses := make([]*p2p_pb.HeaderResponse, len(headers))
+	for i, h := range headers {
+		bin, err := h.MarshalBinary()
+		require.NoError(t, err)
+		responses[i] = &p2p_pb.HeaderResponse{Body: bin, StatusCode: p2p_pb.StatusCode_OK}
+	}
+
+	received, err := ses.processResponse(prepareRequests(uint64(headers[0].Height()), 4, 4)[0], responses)
+	require.ErrorIs(t, err, errBrokenChain)
+	require.Len(t, received, 2)
+}
+
+// Test_VerifyChainAcrossResponses ensures that breaks between the responses of different peers
+// are detected and both peers get their score lowered.
+func Test_VerifyChainAcrossResponses(t *testing.T) {
+	suite := headertest.NewTestSuite(t)
+	first := &peerStat{peerID: "first", peerScore: 10}
+	second := &peerStat{peerID: "second", peerScore: 10}
+	ses := newSession[*headertest.DummyHeader](
+		context.Background(),
+		nil,
+		&PeerTracker{trackedPeers: map[peer.ID]*peerStat{first.peerID: first, second.peerID: second}},
+		nil, time.Second,
+	)
+
+	headers := suite.GenDummyHeaders(4)
+	ses.recordSources(first.peerID, headers[:2])
+	ses.recordSources(second.peerID, headers[2:])
+	require.NoError(t, ses.verifyChain(headers))
+
+	other := headertest.NewTestSuite(t).GenDummyHeaders(4)
+	ses.recordSources(second.peerID, other[2:])
+	broken := append(headers[:2:2], other[2:]...)
+	require.ErrorIs(t, ses.verifyChain(broken), errBrokenChain)
+	assert.Less(t, first.score(), float32(10))
+	assert.Less(t, second.score(), float32(10))
+}
+
+// Test_StreamPrefix ensures that headers received out of order are streamed
+// as soon as they extend the contiguous prefix of the range.
+func Test_StreamPrefix(t *testing.T) {
+	suite := headertest.NewTestSuite(t)
+	trusted := suite.GenDummyHeaders(1)[0]
+	headers := suite.GenDummyHeaders(6)
+
+	var streamed []*headertest.DummyHeader
+	ses := newSession[*headertest.DummyHeader](
+		context.Background(),
+		nil,
+		&PeerTracker{trackedPeers: map[peer.ID]*peerStat{}},
+		nil, time.Second,
+		withValidation(trusted),
+		withStream(func(h *headertest.DummyHeader) error {
+			streamed = append(streamed, h)
+			return nil
+		}),
+	)
+
+	origin := uint64(headers[0].Height())
+	received := append([]*headertest.DummyHeader{}, headers[4:]...)
+	n, err := ses.streamPrefix(received, origin, 0)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	received = append(received, headers[:2]...)
+	n, err = ses.streamPrefix(received, origin, n)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, headers[:2], streamed)
+
+	received = append(received, headers[2:4]...)
+	n, err = ses.streamPrefix(received, origin, n)
+	require.NoError(t, err)
+	assert.Equal(t, 6, n)
+	assert.Equal(t, headers, streamed)
+
+	// the first header must be the child of the trusted one
+	other := headertest.NewTestSuite(t).GenDummyHeaders(2)[1:]
+	streamed = nil
+	_, err = ses.streamPrefix(other, uint64(other[0].Height()), 0)
+	require.ErrorIs(t, err, errBrokenChain)
+	assert.Empty(t, streamed)
+}
+
+func Test_AcquirePeerRoutesAroundBusyPeers(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	busy := &peerStat{peerID: "busy", peerScore: 10}
+	free := &peerStat{peerID: "free", peerScore: 1}
+	// the best peer is busy with a request of another session
+	require.True(t, busy.acquire(1))
+
+	ses := &session[*headertest.DummyHeader]{
+		ctx:         ctx,
+		peerTracker: &PeerTracker{maxInflight: 1},
+		scheduler:   sequentialScheduler{},
+		queue:       newPeerQueue(ctx, []*peerStat{busy, free}),
+	}
+	req := &p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 1}, Amount: 1}
+	stat := ses.acquirePeer(ctx, req)
+	require.Equal(t, free.peerID, stat.peerID)
+
+	// the busy peer is returned to the queue and can be acquired once it is released
+	busy.release()
+	stat = ses.acquirePeer(ctx, req)
+	require.Equal(t, busy.peerID, stat.peerID)
+}
+
+func Test_AcquirePeerPrefersArchivalPeers(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	pruned := &peerStat{peerID: "pruned", peerScore: 10, tail: 50}
+	archival := &peerStat{peerID: "archival", peerScore: 1}
+	ses := &session[*headertest.DummyHeader]{
+		ctx:         ctx,
+		peerTracker: &PeerTracker{},
+		scheduler:   sequentialScheduler{},
+		queue:       newPeerQueue(ctx, []*peerStat{pruned, archival}),
+	}
+
+	deep := &p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 10}, Amount: 10}
+	stat := ses.acquirePeer(ctx, deep)
+	require.Equal(t, archival.peerID, stat.peerID)
+	// the pruned peer is returned to the queue and serves recent ranges
+	recent := &p2p_pb.HeaderRequest{Data: &p2p_pb.HeaderRequest_Origin{Origin: 60}, Amount: 10}
+	stat = ses.acquirePeer(ctx, recent)
+	require.Equal(t, pruned.peerID, stat.peerID)
+
+	// without archival peers, the pruned peer is tried anyway
+	ses.queue.push(pruned)
+	stat = ses.acquirePeer(ctx, deep)
+	require.Equal(t, pruned.peerID, stat.peerID)
+}
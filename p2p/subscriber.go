@@ -55,9 +55,7 @@ func (p *Subscriber[H]) Stop(context.Context) error {
 // AddValidator applies basic pubsub validator for the topic.
 func (p *Subscriber[H]) AddValidator(val func(context.Context, H) pubsub.ValidationResult) error {
 	pval := func(ctx context.Context, p peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
-		var empty H
-		maybeHead := empty.New()
-		err := maybeHead.UnmarshalBinary(msg.Data)
+		maybeHead, err := header.Unmarshal[H](msg.Data)
 		if err != nil {
 			log.Errorw("unmarshalling header",
 				"from", p.ShortString(),
@@ -65,7 +63,7 @@ func (p *Subscriber[H]) AddValidator(val func(context.Context, H) pubsub.Validat
 			return pubsub.ValidationReject
 		}
 		msg.ValidatorData = maybeHead
-		return val(ctx, maybeHead.(H))
+		return val(ctx, maybeHead)
 	}
 	return p.pubsub.RegisterTopicValidator(p.pubsubTopicID, pval)
 }
@@ -0,0 +1,48 @@
+package p2p
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// Stream is a bidirectional stream carrying the header exchange messages of a single request.
+type Stream interface {
+	io.ReadWriteCloser
+	// CloseWrite closes the stream for writing, signaling the end of the request.
+	CloseWrite() error
+	// Reset closes both ends of the stream, aborting the request.
+	Reset() error
+	// SetDeadline sets the read and write deadline of the stream.
+	SetDeadline(time.Time) error
+}
+
+// Transport opens streams to peers the header exchange messages are sent over.
+// It allows the client to run the exchange protocol over transports other than
+// the libp2p host, like direct QUIC or WebTransport connections of browser light clients.
+type Transport interface {
+	// OpenStream opens a new stream to the given peer negotiating the newest of
+	// the given protocols supported by it.
+	OpenStream(ctx context.Context, to peer.ID, protocols ...protocol.ID) (Stream, error)
+}
+
+// hostTransport is the default Transport opening streams with the libp2p host.
+type hostTransport struct {
+	host host.Host
+}
+
+func (t hostTransport) OpenStream(ctx context.Context, to peer.ID, protocols ...protocol.ID) (Stream, error) {
+	return t.host.NewStream(ctx, to, protocols...)
+}
+
+// newTransport returns the given Transport or the default one using the host, if it is nil.
+func newTransport(h host.Host, t Transport) Transport {
+	if t != nil {
+		return t
+	}
+	return hostTransport{host: h}
+}
@@ -0,0 +1,74 @@
+package p2p
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-libp2p/p2p/net/conngater"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header/headertest"
+)
+
+// TestExchange_Transport ensures requests are sent over the streams of the configured Transport.
+func TestExchange_Transport(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	hosts := createMocknet(t, 2)
+	store := headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 10)
+	serv, err := NewExchangeServer[*headertest.DummyHeader](hosts[1], store,
+		WithNetworkID[ServerParameters](networkID),
+	)
+	require.NoError(t, err)
+	require.NoError(t, serv.Start(ctx))
+	t.Cleanup(func() {
+		serv.Stop(ctx) //nolint:errcheck
+	})
+
+	transport := &countingTransport{Transport: hostTransport{host: hosts[0]}}
+	connGater, err := conngater.NewBasicConnectionGater(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	exchg, err := NewExchange[*headertest.DummyHeader](hosts[0], []peer.ID{hosts[1].ID()}, connGater,
+		WithNetworkID[ClientParameters](networkID),
+		WithChainID(networkID),
+		WithTransport[ClientParameters](transport),
+	)
+	require.NoError(t, err)
+	require.NoError(t, exchg.Start(ctx))
+	t.Cleanup(func() {
+		exchg.Stop(ctx) //nolint:errcheck
+	})
+	exchg.peerTracker.peerLk.Lock()
+	exchg.peerTracker.trackedPeers[hosts[1].ID()] = &peerStat{peerID: hosts[1].ID(), peerScore: 100}
+	exchg.peerTracker.peerLk.Unlock()
+
+	head, err := exchg.Head(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, head.Height())
+	opened := transport.opened.Load()
+	assert.NotZero(t, opened)
+
+	headers, err := exchg.GetRangeByHeight(ctx, 1, 5)
+	require.NoError(t, err)
+	assert.Len(t, headers, 5)
+	assert.Greater(t, transport.opened.Load(), opened)
+}
+
+// countingTransport counts the streams opened by the underlying Transport.
+type countingTransport struct {
+	Transport
+	opened atomic.Int64
+}
+
+func (t *countingTransport) OpenStream(ctx context.Context, to peer.ID, protocols ...protocol.ID) (Stream, error) {
+	t.opened.Add(1)
+	return t.Transport.OpenStream(ctx, to, protocols...)
+}
@@ -0,0 +1,119 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// errServerBusy is returned when the worker pool of the server has no room for another request.
+var errServerBusy = errors.New("header/p2p: server busy")
+
+// busyRetryAfter is how long clients rejected by the busy server are asked to wait before retrying.
+var busyRetryAfter = time.Second
+
+// workerPool bounds the amount of inbound requests served concurrently, queueing the ones above
+// the limit and rejecting them once the queue is full as well. High priority requests waiting
+// in the queue are served ahead of low priority ones.
+// A nil workerPool does not limit anything.
+type workerPool struct {
+	lk sync.Mutex
+	// workers is the max amount of requests served concurrently.
+	workers int
+	// queueSize is the max amount of requests waiting for a worker.
+	queueSize int
+	// active is the amount of requests currently served.
+	active int
+	// high and low are the requests waiting for a worker by their priority.
+	// A waiting request is handed a worker by closing its channel.
+	high, low []chan struct{}
+}
+
+// newWorkerPool creates a new workerPool of the given amount of workers and queue size.
+// Zero workers return nil, meaning no limits.
+func newWorkerPool(workers, queueSize int) *workerPool {
+	if workers == 0 {
+		return nil
+	}
+	return &workerPool{workers: workers, queueSize: queueSize}
+}
+
+// acquire reserves a worker for a request of the given priority, waiting in the queue
+// if all the workers are busy. It returns errServerBusy if the queue is full.
+func (p *workerPool) acquire(ctx context.Context, high bool) error {
+	if p == nil {
+		return nil
+	}
+
+	p.lk.Lock()
+	if p.active < p.workers {
+		p.active++
+		p.lk.Unlock()
+		return nil
+	}
+	if len(p.high)+len(p.low) >= p.queueSize {
+		p.lk.Unlock()
+		return errServerBusy
+	}
+	ready := make(chan struct{})
+	if high {
+		p.high = append(p.high, ready)
+	} else {
+		p.low = append(p.low, ready)
+	}
+	p.lk.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		p.lk.Lock()
+		defer p.lk.Unlock()
+		if p.dequeue(ready) {
+			return ctx.Err()
+		}
+		// the worker was handed over in the meantime, so it is passed on
+		p.handOver()
+		return ctx.Err()
+	}
+}
+
+// release frees the worker reserved by acquire, handing it over to the next waiting request, if any.
+func (p *workerPool) release() {
+	if p == nil {
+		return
+	}
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	p.handOver()
+}
+
+// handOver hands the worker over to the next waiting request or frees it if there is none.
+func (p *workerPool) handOver() {
+	switch {
+	case len(p.high) > 0:
+		close(p.high[0])
+		p.high = p.high[1:]
+	case len(p.low) > 0:
+		close(p.low[0])
+		p.low = p.low[1:]
+	default:
+		p.active--
+	}
+}
+
+// dequeue removes the given waiting request from the queue.
+// It returns false if the request is not waiting anymore.
+func (p *workerPool) dequeue(ready chan struct{}) bool {
+	for _, queue := range []*[]chan struct{}{&p.high, &p.low} {
+		for i, ch := range *queue {
+			if ch == ready {
+				*queue = append((*queue)[:i], (*queue)[i+1:]...)
+				return true
+			}
+		}
+	}
+	return false
+}
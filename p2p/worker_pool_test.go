@@ -0,0 +1,53 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPool(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	// nil pool does not limit anything
+	require.Nil(t, newWorkerPool(0, 0))
+	require.NoError(t, (*workerPool)(nil).acquire(ctx, false))
+
+	p := newWorkerPool(1, 2)
+	require.NoError(t, p.acquire(ctx, false))
+
+	// requests above the limit are queued, serving high priority ones first
+	served := make(chan string, 2)
+	wait := func(name string, high bool) {
+		go func() {
+			if p.acquire(ctx, high) == nil {
+				served <- name
+			}
+		}()
+		require.Eventually(t, func() bool {
+			p.lk.Lock()
+			defer p.lk.Unlock()
+			return len(p.high)+len(p.low) > 0 && (!high || len(p.high) > 0)
+		}, time.Second, time.Millisecond)
+	}
+	wait("low", false)
+	wait("high", true)
+
+	// and rejected once the queue is full
+	require.ErrorIs(t, p.acquire(ctx, true), errServerBusy)
+
+	p.release()
+	require.Equal(t, "high", <-served)
+	p.release()
+	require.Equal(t, "low", <-served)
+
+	// requests leaving the queue on their own do not hold workers
+	waitCtx, waitCancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer waitCancel()
+	require.ErrorIs(t, p.acquire(waitCtx, false), context.DeadlineExceeded)
+	p.release()
+	require.NoError(t, p.acquire(ctx, false))
+}
@@ -0,0 +1,140 @@
+package header
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	"go.opentelemetry.io/otel/metric/unit"
+)
+
+// HeadDivergence describes the heads reported by the primary and the shadow Exchange
+// of WithShadowExchange when they differ.
+type HeadDivergence[H Header] struct {
+	Primary H
+	Shadow  H
+}
+
+// Forked reports whether the heads are at the same height but differ,
+// meaning at least one of the networks serves a fork of the chain.
+// Otherwise, one of the networks lags behind the other.
+func (d HeadDivergence[H]) Forked() bool {
+	return d.Primary.Height() == d.Shadow.Height()
+}
+
+// WithShadowExchange wraps the given primary Exchange so that every Head request is also sent
+// to the shadow Exchange, e.g. one connected to a second, independent set of peers.
+// Heads of both are compared and divergences are reported to the given callback, if any,
+// and counted by Otel metrics, which is useful for detecting eclipse attacks and network partitions.
+// Heads at the same height diverge if their hashes differ, while heads at different heights
+// diverge only if they are more than shadowHeightTolerance apart, as the exchanges may observe
+// new heads at slightly different times.
+// The result of the primary Exchange is returned as is, without waiting for the shadow one.
+// The shadow request outlives the call, so it is detached from the context of the call and
+// bounded by shadowHeadTimeout instead, and failed shadow requests are ignored.
+// At most maxShadowRequests shadow requests are in flight, so calls above it are not shadowed.
+func WithShadowExchange[H Header](
+	primary, shadow Exchange[H],
+	onDivergence func(HeadDivergence[H]),
+) (Exchange[H], error) {
+	divergences, err := meter.
+		SyncInt64().
+		Counter(
+			"header_shadow_head_divergences",
+			instrument.WithUnit(unit.Dimensionless),
+			instrument.WithDescription("Amount of heads diverging between the primary and the shadow exchanges"),
+		)
+	if err != nil {
+		return nil, err
+	}
+	return &shadowExchange[H]{
+		Exchange:     primary,
+		shadow:       shadow,
+		onDivergence: onDivergence,
+		divergences:  divergences,
+		inflight:     make(chan struct{}, maxShadowRequests),
+	}, nil
+}
+
+// shadowHeadTimeout bounds the Head requests to the shadow Exchange.
+var shadowHeadTimeout = time.Second * 10
+
+// shadowHeightTolerance is the max difference between the heights of the primary and the shadow heads
+// that is not reported as a divergence.
+var shadowHeightTolerance uint64 = 2
+
+// maxShadowRequests limits the amount of concurrent Head requests to the shadow Exchange.
+const maxShadowRequests = 8
+
+type shadowExchange[H Header] struct {
+	Exchange[H]
+
+	shadow       Exchange[H]
+	onDivergence func(HeadDivergence[H])
+	divergences  syncint64.Counter
+	// inflight holds a slot for every shadow request in flight.
+	inflight chan struct{}
+}
+
+type shadowHead[H Header] struct {
+	head H
+	err  error
+}
+
+func (s *shadowExchange[H]) Head(ctx context.Context, opts ...CallOption) (H, error) {
+	var shadowCh chan shadowHead[H]
+	select {
+	case s.inflight <- struct{}{}:
+		shadowCh = make(chan shadowHead[H], 1)
+		go func() {
+			defer func() { <-s.inflight }()
+			ctx, cancel := context.WithTimeout(context.Background(), shadowHeadTimeout)
+			defer cancel()
+			head, err := s.shadow.Head(ctx)
+			shadowCh <- shadowHead[H]{head: head, err: err}
+		}()
+	default:
+		// the shadow Exchange is busy, so the call is not shadowed
+	}
+
+	head, err := s.Exchange.Head(ctx, opts...)
+	if err != nil || shadowCh == nil {
+		return head, err
+	}
+
+	go func() {
+		res := <-shadowCh
+		if res.err != nil {
+			return
+		}
+		s.compare(context.Background(), head, res.head)
+	}()
+	return head, nil
+}
+
+// compare reports the given heads if they diverge.
+func (s *shadowExchange[H]) compare(ctx context.Context, primary, shadow H) {
+	switch {
+	case primary.Height() == shadow.Height():
+		if bytes.Equal(primary.Hash(), shadow.Hash()) {
+			return
+		}
+	case primary.Height() > shadow.Height():
+		if uint64(primary.Height()-shadow.Height()) <= shadowHeightTolerance {
+			return
+		}
+	default:
+		if uint64(shadow.Height()-primary.Height()) <= shadowHeightTolerance {
+			return
+		}
+	}
+
+	div := HeadDivergence[H]{Primary: primary, Shadow: shadow}
+	s.divergences.Add(ctx, 1, attribute.Bool("forked", div.Forked()))
+	if s.onDivergence != nil {
+		s.onDivergence(div)
+	}
+}
@@ -0,0 +1,120 @@
+package header_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header"
+	"github.com/celestiaorg/go-header/headertest"
+)
+
+func TestWithShadowExchange(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	primary := headertest.NewDummyStore(t)
+	divergences := make(chan header.HeadDivergence[*headertest.DummyHeader], 1)
+	onDivergence := func(div header.HeadDivergence[*headertest.DummyHeader]) {
+		divergences <- div
+	}
+
+	tests := []struct {
+		name     string
+		shadow   *headertest.Store[*headertest.DummyHeader]
+		diverges bool
+		forked   bool
+	}{
+		{"same chain", primary, false, false},
+		{"forked chain", headertest.NewDummyStore(t), true, true},
+		{"lagging chain", headertest.NewStore[*headertest.DummyHeader](t, headertest.NewTestSuite(t), 5), true, false},
+		// heads of the same chain a block apart are observed at slightly different times
+		{"chain a block behind", &headertest.Store[*headertest.DummyHeader]{
+			Headers:    primary.Headers,
+			HeadHeight: primary.HeadHeight - 1,
+		}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex, err := header.WithShadowExchange[*headertest.DummyHeader](primary, tt.shadow, onDivergence)
+			require.NoError(t, err)
+
+			head, err := ex.Head(ctx)
+			require.NoError(t, err)
+			// the primary head is returned regardless of the shadow one
+			assert.Equal(t, primary.HeadHeight, head.Height())
+
+			select {
+			case div := <-divergences:
+				require.True(t, tt.diverges)
+				assert.Equal(t, head, div.Primary)
+				assert.Equal(t, tt.shadow.HeadHeight, div.Shadow.Height())
+				assert.Equal(t, tt.forked, div.Forked())
+			case <-time.After(time.Millisecond * 100):
+				require.False(t, tt.diverges)
+			}
+		})
+	}
+}
+
+func TestWithShadowExchange_OutlivesCall(t *testing.T) {
+	primary := headertest.NewDummyStore(t)
+	divergences := make(chan header.HeadDivergence[*headertest.DummyHeader], 1)
+	shadow := &slowExchange{Store: headertest.NewDummyStore(t), delay: time.Millisecond * 50}
+	ex, err := header.WithShadowExchange[*headertest.DummyHeader](primary, shadow,
+		func(div header.HeadDivergence[*headertest.DummyHeader]) {
+			divergences <- div
+		})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err = ex.Head(ctx)
+	require.NoError(t, err)
+	// the shadow head is compared after the call returns
+	cancel()
+
+	select {
+	case div := <-divergences:
+		assert.True(t, div.Forked())
+	case <-time.After(time.Second):
+		t.Fatal("shadow head is not compared")
+	}
+}
+
+func TestWithShadowExchange_BoundsShadowRequests(t *testing.T) {
+	primary := headertest.NewDummyStore(t)
+	shadow := &slowExchange{Store: primary, delay: time.Second}
+	ex, err := header.WithShadowExchange[*headertest.DummyHeader](primary, shadow, nil)
+	require.NoError(t, err)
+
+	const calls = 20
+	for i := 0; i < calls; i++ {
+		_, err = ex.Head(context.Background())
+		require.NoError(t, err)
+	}
+	// the shadow requests of the calls above the limit are skipped instead of piling up
+	require.Eventually(t, func() bool { return shadow.calls.Load() > 0 }, time.Second, time.Millisecond)
+	time.Sleep(time.Millisecond * 50)
+	assert.Less(t, shadow.calls.Load(), int32(calls))
+}
+
+// slowExchange serves the head of the Store after the delay, unless the context is done.
+type slowExchange struct {
+	*headertest.Store[*headertest.DummyHeader]
+	delay time.Duration
+	calls atomic.Int32
+}
+
+func (e *slowExchange) Head(ctx context.Context, _ ...header.CallOption) (*headertest.DummyHeader, error) {
+	e.calls.Add(1)
+	select {
+	case <-time.After(e.delay):
+		return e.Store.Head(ctx)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"sync/atomic"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// Cache is an in-memory cache of the headers read by the Store.
+// Implementations must be safe for concurrent use, e.g. an ARC or ristretto cache.
+// A single memory-bounded Cache can be shared across several Stores, as the keys of
+// every Store are distinct. See WithCache.
+type Cache interface {
+	// Get returns the value of the given key, if cached.
+	Get(key any) (value any, ok bool)
+	// Add caches the value under the given key.
+	Add(key, value any)
+	// Remove evicts the given key.
+	Remove(key any)
+}
+
+// storeIDs issues the IDs keeping the cache entries of Stores apart.
+var storeIDs atomic.Uint64
+
+// cacheKey is the key of a header in the Cache. It includes the ID of the Store,
+// so Stores sharing the Cache do not return the headers of each other.
+type cacheKey struct {
+	store uint64
+	hash  string
+}
+
+// cacheKey returns the key of the header of the given hash string in the Cache.
+func (s *Store[H]) cacheKey(hash string) cacheKey {
+	return cacheKey{store: s.cacheID, hash: hash}
+}
+
+// cached returns the header of the given hash, if cached.
+func (s *Store[H]) cached(hash header.Hash) (H, bool) {
+	v, ok := s.cache.Get(s.cacheKey(hash.String()))
+	if !ok {
+		var zero H
+		return zero, false
+	}
+	h, ok := v.(H)
+	return h, ok
+}
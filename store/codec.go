@@ -0,0 +1,84 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// Stored headers are prefixed with a format byte telling how the rest of the value is encoded,
+// so the stores keep working when Parameters.Compression is toggled.
+// Headers written before the format byte was introduced are prefixed with it
+// by the migration to version 6 of the schema.
+const (
+	// formatRaw prefixes the headers stored as they are marshaled.
+	formatRaw byte = 0x00
+	// formatZstd prefixes the headers compressed with zstd.
+	formatZstd byte = 0x5a
+)
+
+// maxHeaderSize limits the size of a single decompressed header
+// to protect against corrupted values.
+const maxHeaderSize = 16 << 20
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxHeaderSize))
+)
+
+// encodeHeader marshals the given header to be stored, compressing it if Parameters.Compression is set.
+func (s *Store[H]) encodeHeader(h H) ([]byte, error) {
+	b, err := h.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if !s.Params.Compression {
+		return append([]byte{formatRaw}, b...), nil
+	}
+
+	compressed := make([]byte, 1, len(b)/2+1)
+	compressed[0] = formatZstd
+	return zstdEncoder.EncodeAll(b, compressed), nil
+}
+
+// decodeHeader unmarshals the stored header in the format of its format byte.
+func decodeHeader[H header.Header](b []byte) (H, error) {
+	var zero H
+	if len(b) == 0 {
+		return zero, fmt.Errorf("header/store: empty header")
+	}
+	switch b[0] {
+	case formatRaw:
+		return header.Unmarshal[H](b[1:])
+	case formatZstd:
+		raw, err := zstdDecoder.DecodeAll(b[1:], nil)
+		if err != nil {
+			return zero, fmt.Errorf("header/store: decompressing header: %w", err)
+		}
+		return header.Unmarshal[H](raw)
+	default:
+		return zero, fmt.Errorf("header/store: unknown header format %#x", b[0])
+	}
+}
+
+// decodeLegacyHeader unmarshals the header of the given hash stored before version 6 of the schema,
+// which is prefixed with the format byte or not, depending on when it was written.
+// The formats are told apart by the hash of the decoded header. It reports whether the header
+// is prefixed with the format byte.
+func decodeLegacyHeader[H header.Header](b []byte, hash header.Hash) (H, bool, error) {
+	if h, err := decodeHeader[H](b); err == nil && bytes.Equal(h.Hash(), hash) {
+		return h, true, nil
+	}
+	var zero H
+	h, err := header.Unmarshal[H](b)
+	if err != nil {
+		return zero, false, err
+	}
+	if !bytes.Equal(h.Hash(), hash) {
+		return zero, false, fmt.Errorf("header/store: header stored under %s hashes to %s", hash, h.Hash())
+	}
+	return h, false, nil
+}
@@ -0,0 +1,194 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ipfs/go-datastore"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// scheduleCompaction queues removal of headers and their index entries within [from:to)
+// once the range is pruned. The removal happens in the background, so that neither
+// stale entries are left behind nor the caller is blocked on a large range.
+// Ranges are scheduled by DeleteTo and the pruning of stored ranges, while loadTail
+// reschedules the ones left uncompacted by a restart.
+func (s *Store[H]) scheduleCompaction(from, to uint64) {
+	if from >= to {
+		return
+	}
+
+	s.compactionLk.Lock()
+	s.compactions = append(s.compactions, Range{From: from, To: to})
+	s.compactionLk.Unlock()
+	s.metrics.compactionScheduled(context.Background(), to-from)
+
+	select {
+	case s.compactionSignal <- struct{}{}:
+	default:
+	}
+}
+
+// compactionLoop removes the scheduled ranges in batches of Parameters.CompactionBatchSize,
+// releasing the datastore between batches.
+func (s *Store[H]) compactionLoop() {
+	defer close(s.compactionDn)
+	ctx := context.Background()
+	for {
+		select {
+		case <-s.compactionQuit:
+			return
+		case <-s.compactionSignal:
+		}
+
+		for {
+			s.compactionLk.Lock()
+			if len(s.compactions) == 0 {
+				s.compactionLk.Unlock()
+				break
+			}
+			rng := s.compactions[0]
+			to := rng.From + uint64(s.Params.CompactionBatchSize)
+			if to > rng.To {
+				to = rng.To
+			}
+			// the headers retained by snapshots are compacted once the snapshots are released
+			if pinned := s.pinned(); pinned != 0 && pinned < to {
+				if pinned <= rng.From {
+					s.compactionLk.Unlock()
+					break
+				}
+				to = pinned
+			}
+			if to == rng.To {
+				s.compactions = s.compactions[1:]
+			} else {
+				s.compactions[0].From = to
+			}
+			s.compacting = Range{From: rng.From, To: to}
+			s.compactionLk.Unlock()
+
+			err := s.compact(ctx, rng.From, to)
+			if err != nil {
+				log.Errorw("compacting pruned headers", "from", rng.From, "to", to, "err", err)
+			}
+			s.compactionLk.Lock()
+			s.compacting = Range{}
+			s.compactionLk.Unlock()
+
+			select {
+			case <-s.compactionQuit:
+				return
+			default:
+			}
+		}
+	}
+}
+
+// compact removes headers within [from:to) together with their height, hash and time index entries.
+// The ranges stored below the tail are kept, as the tail may have moved above them after
+// the compaction was scheduled, e.g. by DeleteRange.
+func (s *Store[H]) compact(ctx context.Context, from, to uint64) error {
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+
+	compacted := []Range{{From: from, To: to}}
+	s.rangesLk.RLock()
+	for _, r := range s.ranges {
+		compacted = subtractRange(compacted, r)
+	}
+	s.rangesLk.RUnlock()
+
+	hashes := make([][]string, len(compacted))
+	for i, r := range compacted {
+		if hashes[i], err = s.deleteHeaders(ctx, batch, r.From, r.To); err != nil {
+			return err
+		}
+	}
+	// compaction progress is persisted, so it is resumed after restarts
+	if err = batch.Put(ctx, compactedKey, encodeHeight(to)); err != nil {
+		return err
+	}
+
+	if err = batch.Commit(ctx); err != nil {
+		return err
+	}
+
+	for i, r := range compacted {
+		s.uncache(hashes[i], r.From, r.To)
+	}
+	s.metrics.compactionProgressed(ctx, to-from)
+	log.Debugw("compacted pruned headers", "from", from, "to", to)
+	return nil
+}
+
+// deleteHeaders adds the removal of the headers within [from:to) together with their height,
+// hash and time index entries, and the headers of forks at the same heights, to the batch.
+// It returns the hashes of the removed headers.
+func (s *Store[H]) deleteHeaders(ctx context.Context, batch datastore.Batch, from, to uint64) ([]string, error) {
+	hashes := make([]string, 0, to-from)
+	for height := from; height < to; height++ {
+		hash, err := s.heightIndex.HashByHeight(ctx, height)
+		if err != nil {
+			if errors.Is(err, datastore.ErrNotFound) {
+				// already removed
+				continue
+			}
+			return nil, err
+		}
+
+		// the time index entry is keyed by the time of the header, so it is read before removal
+		h, err := s.get(ctx, hash)
+		switch {
+		case err == nil:
+			if err = batch.Delete(ctx, timeKey(h.Time(), height)); err != nil {
+				return nil, err
+			}
+		case !errors.Is(err, header.ErrNotFound):
+			return nil, err
+		}
+		if err = batch.Delete(ctx, blockKey(height, hash)); err != nil {
+			return nil, err
+		}
+		if err = batch.Delete(ctx, hashKey(hash)); err != nil {
+			return nil, err
+		}
+		if err = batch.Delete(ctx, heightKey(height)); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash.String())
+	}
+
+	forks, err := s.deleteForks(ctx, batch, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return append(hashes, forks...), nil
+}
+
+// compactingRange reports whether the given range overlaps with the ranges scheduled for compaction
+// or being compacted.
+func (s *Store[H]) compactingRange(rng Range) bool {
+	s.compactionLk.Lock()
+	defer s.compactionLk.Unlock()
+	for _, r := range append(s.compactions, s.compacting) {
+		if rng.From < r.To && r.From < rng.To {
+			return true
+		}
+	}
+	return false
+}
+
+// uncache drops the cached entries of the removed headers, so they are not served after removal.
+func (s *Store[H]) uncache(hashes []string, from, to uint64) {
+	for _, hash := range hashes {
+		s.cache.Remove(s.cacheKey(hash))
+	}
+	for height := from; height < to; height++ {
+		s.heightIndex.cache.Remove(height)
+	}
+	s.recent.remove(from, to)
+}
@@ -0,0 +1,183 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// DeleteRange removes the headers within [from:to) together with their hash, height and time
+// index entries, e.g. to clean up accidentally stored junk or to roll the chain back.
+//
+// Depending on the range, the Store is left:
+//   - with the head rolled back to the header right below the range, if the range covers the head.
+//     It must not race with Append, e.g. the Syncer must be stopped;
+//   - with the tail moved above the range, if the range covers the tail;
+//   - with the headers between the tail and the range kept as a range below the new tail,
+//     if the range is in the middle of the chain. See AppendRange and MissingRanges.
+//
+// The removed headers are not served right away, and are removed from disk before returning.
+func (s *Store[H]) DeleteRange(ctx context.Context, from, to uint64) error {
+	if from == 0 || from >= to {
+		return fmt.Errorf("header/store: invalid range(%d,%d)", from, to)
+	}
+	// ensures the store is initialized and its tail is known
+	if _, err := s.Tail(ctx); err != nil {
+		return err
+	}
+
+	s.pruneLk.Lock()
+	defer s.pruneLk.Unlock()
+
+	if to > s.Height() {
+		return s.rollbackTo(ctx, from)
+	}
+
+	tail := s.tailHeight.Load()
+	s.rangesLk.RLock()
+	ranges := subtractRange(s.ranges, Range{From: from, To: to})
+	s.rangesLk.RUnlock()
+	switch {
+	case to <= tail:
+	case from <= tail:
+		tail = to
+	default:
+		ranges, tail = mergeRange(ranges, Range{From: tail, To: from}), to
+	}
+
+	// the removed headers are hidden first, so the readers never see them partially removed
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	if err = batch.Put(ctx, tailKey, encodeHeight(tail)); err != nil {
+		return err
+	}
+	if err = putRanges(ctx, batch, ranges); err != nil {
+		return err
+	}
+	if err = batch.Commit(ctx); err != nil {
+		return err
+	}
+	s.rangesLk.Lock()
+	s.ranges = ranges
+	s.rangesLk.Unlock()
+	s.tailHeight.Store(tail)
+	s.callRemoveHooks(ctx, from, to)
+
+	if err = s.removeRange(ctx, from, to); err != nil {
+		return err
+	}
+	log.Infow("deleted headers", "from", from, "to", to, "tail", tail)
+	return nil
+}
+
+// rollbackTo requests the writing routine to roll the head back below the given height.
+func (s *Store[H]) rollbackTo(ctx context.Context, from uint64) error {
+	done := make(chan error, 1)
+	select {
+	case s.writes <- write[H]{rollback: from, flushed: done}:
+	case <-s.writesDn:
+		return errStoppedStore
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rollback rolls the head back to the header right below the given height,
+// removing the headers above. It is called from the writing routine, so no write interleaves.
+func (s *Store[H]) rollback(ctx context.Context, from uint64) error {
+	// pending headers are written first, so they are removed from disk as well
+	if err := s.flushPending(ctx); err != nil {
+		return err
+	}
+
+	if from <= s.tailHeight.Load() {
+		return fmt.Errorf("header/store: can not roll back to %d below the tail %d", from-1, s.tailHeight.Load())
+	}
+	head := s.heightSub.Height()
+	newHead, err := s.GetByHeight(ctx, from-1)
+	if err != nil {
+		return err
+	}
+
+	// the head is moved first, so the headers above are unreachable even if removal is interrupted
+	b, err := newHead.Hash().MarshalJSON()
+	if err != nil {
+		return err
+	}
+	if err = s.ds.Put(ctx, headKey, b); err != nil {
+		return err
+	}
+	s.heightSub.SetHeight(from - 1)
+	s.writeHead.Store(&newHead)
+	s.callRemoveHooks(ctx, from, head+1)
+
+	if err = s.removeRange(ctx, from, head+1); err != nil {
+		return err
+	}
+	log.Warnw("rolled back head", "from", head, "to", newHead.Height(), "hash", newHead.Hash())
+	return nil
+}
+
+// flushPending writes the pending headers on disk right away.
+// It is called from the writing routine only.
+func (s *Store[H]) flushPending(ctx context.Context) error {
+	pending := s.pending.GetAll()
+	if err := s.flush(ctx, pending...); err != nil {
+		return err
+	}
+	s.callHooks(ctx, pending...)
+	s.pending.Reset()
+	return nil
+}
+
+// removeRange removes the headers within [from:to) with their indexes from disk
+// in batches of Parameters.CompactionBatchSize.
+func (s *Store[H]) removeRange(ctx context.Context, from, to uint64) error {
+	for from < to {
+		end := from + uint64(s.Params.CompactionBatchSize)
+		if end > to {
+			end = to
+		}
+
+		batch, err := s.ds.Batch(ctx)
+		if err != nil {
+			return err
+		}
+		hashes, err := s.deleteHeaders(ctx, batch, from, end)
+		if err != nil {
+			return err
+		}
+		if err = batch.Commit(ctx); err != nil {
+			return err
+		}
+		s.uncache(hashes, from, end)
+		from = end
+	}
+	return nil
+}
+
+// subtractRange returns the parts of the sorted ranges outside the given range.
+func subtractRange(ranges []Range, rng Range) []Range {
+	result := make([]Range, 0, len(ranges)+1)
+	for _, r := range ranges {
+		if r.To <= rng.From || rng.To <= r.From {
+			result = append(result, r)
+			continue
+		}
+		if r.From < rng.From {
+			result = append(result, Range{From: r.From, To: rng.From})
+		}
+		if rng.To < r.To {
+			result = append(result, Range{From: rng.To, To: r.To})
+		}
+	}
+	return result
+}
@@ -0,0 +1,242 @@
+package store
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// forksPrefix is the prefix of the index of headers stored apart from the canonical chain.
+var forksPrefix = datastore.NewKey("forks")
+
+// forkKey returns the key indexing the header of a fork with the given height and hash.
+// Heights are zero-padded, so the keys are ordered by height.
+func forkKey(height uint64, hash header.Hash) datastore.Key {
+	key := forksPrefix.ChildString(fmt.Sprintf("%020d", height))
+	if len(hash) == 0 {
+		return key
+	}
+	return key.ChildString(hash.String())
+}
+
+// AppendFork stores the given adjacent headers of a branch competing with the canonical chain.
+// The first header must be a child of a stored header, either canonical or of another branch,
+// and all the headers are verified. The headers are retrievable by their hashes only,
+// leaving the height and time lookups of the canonical chain intact until SetCanonical
+// selects the branch.
+func (s *Store[H]) AppendFork(ctx context.Context, headers ...H) error {
+	if len(headers) == 0 {
+		return nil
+	}
+	if err := verifyRange(headers); err != nil {
+		return err
+	}
+
+	parent, err := s.Get(ctx, headers[0].LastHeader())
+	if err != nil {
+		return fmt.Errorf("header/store: getting parent of fork %d: %w", headers[0].Height(), err)
+	}
+	if s.pruned(uint64(parent.Height())) {
+		return fmt.Errorf("header/store: parent of fork %d is pruned", headers[0].Height())
+	}
+	if err = verifyLink(parent, headers[0]); err != nil {
+		return err
+	}
+
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	for _, h := range headers {
+		if err = s.putHeader(ctx, batch, h); err != nil {
+			return err
+		}
+		if err = batch.Put(ctx, forkKey(uint64(h.Height()), h.Hash()), []byte{}); err != nil {
+			return err
+		}
+	}
+	if err = batch.Commit(ctx); err != nil {
+		return err
+	}
+	log.Infow("stored fork", "from", headers[0].Height(), "to", headers[len(headers)-1].Height(),
+		"parent", parent.Hash())
+	return nil
+}
+
+// SetCanonical selects the branch ending with the stored header of the given hash as the canonical
+// chain, making the header the new head. The height and time indexes are rolled back to the common
+// ancestor of the branch and the canonical chain and rewritten with the branch, while the headers
+// of the previous canonical chain are kept as a fork, so the reorg can be reverted.
+// It must not race with Append, e.g. the Syncer must be stopped.
+func (s *Store[H]) SetCanonical(ctx context.Context, hash header.Hash) error {
+	done := make(chan error, 1)
+	select {
+	case s.writes <- write[H]{canonical: hash, flushed: done}:
+	case <-s.writesDn:
+		return errStoppedStore
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reorg makes the branch ending with the header of the given hash canonical.
+// It is called from the writing routine, so no write interleaves.
+func (s *Store[H]) reorg(ctx context.Context, hash header.Hash) error {
+	if err := s.flushPending(ctx); err != nil {
+		return err
+	}
+
+	newHead, err := s.Get(ctx, hash)
+	if err != nil {
+		return err
+	}
+	// collect the branch down to the common ancestor with the canonical chain
+	var branch []H
+	for h := newHead; ; {
+		height := uint64(h.Height())
+		if s.pruned(height) {
+			return fmt.Errorf("header/store: branch of %s forks below the tail", hash)
+		}
+		canonical, err := s.heightIndex.HashByHeight(ctx, height)
+		if err == nil && canonical.String() == h.Hash().String() {
+			break
+		}
+		if err != nil && !errors.Is(err, datastore.ErrNotFound) {
+			return err
+		}
+		branch = append([]H{h}, branch...)
+
+		h, err = s.Get(ctx, h.LastHeader())
+		if err != nil {
+			return fmt.Errorf("header/store: getting parent of %d: %w", height, err)
+		}
+	}
+
+	oldHead := s.heightSub.Height()
+	newHeight := uint64(newHead.Height())
+	ancestor := newHeight - uint64(len(branch))
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	// the headers of the previous canonical chain become a fork
+	for height := ancestor + 1; height <= oldHead; height++ {
+		hash, err := s.heightIndex.HashByHeight(ctx, height)
+		if err != nil {
+			return err
+		}
+		h, err := s.Get(ctx, hash)
+		if err != nil {
+			return err
+		}
+		if err = batch.Put(ctx, forkKey(height, hash), []byte{}); err != nil {
+			return err
+		}
+		if err = batch.Delete(ctx, timeKey(h.Time(), height)); err != nil {
+			return err
+		}
+		if height > newHeight {
+			if err = batch.Delete(ctx, heightKey(height)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, h := range branch {
+		if err = batch.Delete(ctx, forkKey(uint64(h.Height()), h.Hash())); err != nil {
+			return err
+		}
+	}
+	if err = s.heightIndex.IndexTo(ctx, batch, branch...); err != nil {
+		return err
+	}
+	if err = indexTime(ctx, batch, branch...); err != nil {
+		return err
+	}
+	b, err := newHead.Hash().MarshalJSON()
+	if err != nil {
+		return err
+	}
+	if err = batch.Put(ctx, headKey, b); err != nil {
+		return err
+	}
+	if err = batch.Commit(ctx); err != nil {
+		return err
+	}
+
+	top := oldHead
+	if newHeight > top {
+		top = newHeight
+	}
+	s.uncache(nil, ancestor+1, top+1)
+	s.writeHead.Store(&newHead)
+	s.callRemoveHooks(ctx, ancestor+1, top+1)
+	// the headers of the branch are persisted to the height index only now
+	s.callHooks(ctx, branch...)
+	if newHeight > oldHead {
+		// notify the waiters of the heights the canonical chain did not reach before
+		s.heightSub.Pub(branch[len(branch)-int(newHeight-oldHead):]...)
+	} else {
+		s.heightSub.SetHeight(newHeight)
+	}
+	log.Warnw("reorganized canonical chain", "ancestor", ancestor, "old_head", oldHead,
+		"new_head", newHeight, "hash", newHead.Hash())
+	return nil
+}
+
+// deleteForks adds the removal of the headers of forks within [from:to) to the batch.
+// It returns the hashes of the removed headers.
+func (s *Store[H]) deleteForks(ctx context.Context, batch datastore.Batch, from, to uint64) ([]string, error) {
+	res, err := s.ds.Query(ctx, query.Query{
+		Prefix:   forksPrefix.String(),
+		KeysOnly: true,
+		Filters: []query.Filter{
+			query.FilterKeyCompare{Op: query.GreaterThanOrEqual, Key: forkKey(from, nil).String()},
+			query.FilterKeyCompare{Op: query.LessThan, Key: forkKey(to, nil).String()},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	var hashes []string
+	for entry := range res.Next() {
+		if entry.Error != nil {
+			return nil, entry.Error
+		}
+		key := datastore.NewKey(entry.Key)
+		height, err := strconv.ParseUint(key.Parent().BaseNamespace(), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := hex.DecodeString(key.BaseNamespace())
+		if err != nil {
+			return nil, err
+		}
+		if err = batch.Delete(ctx, key); err != nil {
+			return nil, err
+		}
+		if err = batch.Delete(ctx, blockKey(height, hash)); err != nil {
+			return nil, err
+		}
+		if err = batch.Delete(ctx, hashKey(hash)); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, key.BaseNamespace())
+	}
+	return hashes, nil
+}
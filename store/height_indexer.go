@@ -2,9 +2,11 @@ package store
 
 import (
 	"context"
+	"strconv"
 
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
 
 	"github.com/celestiaorg/go-header"
 )
@@ -45,6 +47,46 @@ func (hi *heightIndexer[H]) HashByHeight(ctx context.Context, h uint64) (header.
 	return val, nil
 }
 
+// HashesByRange loads the header hashes of the contiguous heights indexed from the beginning of
+// the range [from:to), stopping at the first missing height. Every bucket of the range is read
+// with a single prefix scan.
+func (hi *heightIndexer[H]) HashesByRange(ctx context.Context, from, to uint64) ([]header.Hash, error) {
+	hashes := make([]header.Hash, 0, to-from)
+	for bucket := from - from%heightBucketSize; bucket < to; bucket += heightBucketSize {
+		res, err := hi.ds.Query(ctx, query.Query{
+			Prefix: heightBucketKey(bucket).String(),
+			Filters: []query.Filter{
+				query.FilterKeyCompare{Op: query.GreaterThanOrEqual, Key: heightKey(from).String()},
+				query.FilterKeyCompare{Op: query.LessThan, Key: heightKey(to).String()},
+			},
+			Orders: []query.Order{query.OrderByKey{}},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		next := from + uint64(len(hashes))
+		for entry := range res.Next() {
+			if entry.Error != nil {
+				res.Close()
+				return nil, entry.Error
+			}
+			height, err := strconv.ParseUint(datastore.NewKey(entry.Key).BaseNamespace(), 10, 64)
+			if err != nil || height != next {
+				break
+			}
+			hashes = append(hashes, entry.Value)
+			next++
+		}
+		res.Close()
+		if next < to && next < bucket+heightBucketSize {
+			// a gap within the bucket
+			break
+		}
+	}
+	return hashes, nil
+}
+
 // IndexTo saves mapping between header Height and Hash to the given batch.
 func (hi *heightIndexer[H]) IndexTo(ctx context.Context, batch datastore.Batch, headers ...H) error {
 	for _, h := range headers {
@@ -19,6 +19,9 @@ type heightSub[H header.Header] struct {
 	height       atomic.Uint64
 	heightReqsLk sync.Mutex
 	heightReqs   map[uint64][]chan H
+	// updated is closed once the height changes, if anyone waits for it.
+	updatedLk sync.Mutex
+	updated   chan struct{}
 }
 
 // newHeightSub instantiates new heightSub.
@@ -36,6 +39,23 @@ func (hs *heightSub[H]) Height() uint64 {
 // SetHeight sets the new head height for heightSub.
 func (hs *heightSub[H]) SetHeight(height uint64) {
 	hs.height.Store(height)
+
+	hs.updatedLk.Lock()
+	defer hs.updatedLk.Unlock()
+	if hs.updated != nil {
+		close(hs.updated)
+		hs.updated = nil
+	}
+}
+
+// Updated returns the channel closed once the height changes after the call.
+func (hs *heightSub[H]) Updated() <-chan struct{} {
+	hs.updatedLk.Lock()
+	defer hs.updatedLk.Unlock()
+	if hs.updated == nil {
+		hs.updated = make(chan struct{})
+	}
+	return hs.updated
 }
 
 // Sub subscribes for a header of a given height.
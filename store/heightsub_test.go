@@ -45,4 +45,23 @@ func TestHeightSub(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotNil(t, h)
 	}
+
+	// assert height updates are broadcast
+	{
+		updated := hs.Updated()
+		select {
+		case <-updated:
+			t.Fatal("updated before the height changed")
+		default:
+		}
+
+		h := headertest.RandDummyHeader(t)
+		h.Raw.Height = 103
+		hs.Pub(h)
+		select {
+		case <-updated:
+		default:
+			t.Fatal("not updated after the height changed")
+		}
+	}
 }
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+)
+
+// AppendHook is called with every header persisted by the Store.
+type AppendHook[H any] func(context.Context, H)
+
+// RemoveHook is called with every range of heights [from:to) the headers of which are removed
+// or replaced by the Store.
+type RemoveHook func(ctx context.Context, from, to uint64)
+
+// OnAppend registers the given hook to be called with every header persisted by the Store,
+// in ascending order of heights. Hooks registered before Init are called with the initial header too.
+// Unlike head subscriptions, no header is skipped, so indexers and application subsystems
+// can rely on seeing all of them. On reorgs, hooks are called with the headers of the branch
+// made canonical, so heights already seen may be called again. Hooks are called sequentially from the writing routine
+// once the headers are written on disk, thus they must be fast and must not write to the Store.
+func (s *Store[H]) OnAppend(hook AppendHook[H]) {
+	s.hooksLk.Lock()
+	defer s.hooksLk.Unlock()
+	s.hooks = append(s.hooks, hook)
+}
+
+// callHooks calls the registered hooks with the given persisted headers.
+func (s *Store[H]) callHooks(ctx context.Context, headers ...H) {
+	s.hooksLk.RLock()
+	defer s.hooksLk.RUnlock()
+	if len(s.hooks) == 0 {
+		return
+	}
+	for _, h := range headers {
+		for _, hook := range s.hooks {
+			hook(ctx, h)
+		}
+	}
+}
+
+// OnRemove registers the given hook to be called with every range of heights the headers of which
+// stop being served by the Store, as they are pruned, deleted, rolled back or replaced by a reorg.
+// It lets the caches built on top of the Store, e.g. the ones of the exchange server, drop the stale
+// headers. Hooks are called once the headers are hidden from readers, thus they must be fast
+// and must not write to the Store.
+func (s *Store[H]) OnRemove(hook RemoveHook) {
+	s.hooksLk.Lock()
+	defer s.hooksLk.Unlock()
+	s.removeHooks = append(s.removeHooks, hook)
+}
+
+// callRemoveHooks calls the registered remove hooks with the given range.
+func (s *Store[H]) callRemoveHooks(ctx context.Context, from, to uint64) {
+	s.hooksLk.RLock()
+	defer s.hooksLk.RUnlock()
+	for _, hook := range s.removeHooks {
+		hook(ctx, from, to)
+	}
+}
@@ -8,13 +8,19 @@ import (
 
 // Init ensures a Store is initialized. If it is not already initialized,
 // it initializes the Store by requesting the header with the given hash.
+// If the hash is empty, the Store is initialized with the head of the Exchange's trusted peers.
 func Init[H header.Header](ctx context.Context, store header.Store[H], ex header.Exchange[H], hash header.Hash) error {
 	_, err := store.Head(ctx)
 	switch err {
 	default:
 		return err
 	case header.ErrNoHead:
-		initial, err := ex.Get(ctx, hash)
+		var initial H
+		if len(hash) == 0 {
+			initial, err = ex.Head(ctx)
+		} else {
+			initial, err = ex.Get(ctx, hash)
+		}
 		if err != nil {
 			return err
 		}
@@ -22,3 +28,23 @@ func Init[H header.Header](ctx context.Context, store header.Store[H], ex header
 		return store.Init(ctx, initial)
 	}
 }
+
+// WaitInit blocks until the Store is initialized and usable, either with Init or with the head
+// stored by previous runs loaded on Start, or until the context is done.
+// It lets consumers wait for the asynchronous initialization, e.g. by the Syncer,
+// instead of polling Head.
+func (s *Store[H]) WaitInit(ctx context.Context) error {
+	select {
+	case <-s.initDn:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// markInit marks the Store as initialized, releasing the waiters of WaitInit.
+func (s *Store[H]) markInit() {
+	s.initOnce.Do(func() {
+		close(s.initDn)
+	})
+}
@@ -54,3 +54,76 @@ func TestInitStore_NoReinit(t *testing.T) {
 	err = reopenedStore.Stop(ctx)
 	require.NoError(t, err)
 }
+
+func TestInitStore_ReinitExpiredHead(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	// genesis of the test suite is 10 seconds old
+	oldChain := headertest.NewTestSuite(t)
+	store, err := NewStoreWithHead(ctx, ds, oldChain.Head())
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	require.NoError(t, store.Stop(ctx))
+
+	// the network was restarted
+	newChain := headertest.NewTestSuite(t)
+	newHead := newChain.NextHeader()
+	exchange := local.NewExchange(NewTestStore(ctx, t, newHead))
+
+	reopenedStore, err := NewStore[*headertest.DummyHeader](ds, WithHeadTTL(time.Second*5))
+	require.NoError(t, err)
+	require.NoError(t, reopenedStore.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, reopenedStore.Stop(ctx))
+	})
+
+	err = Init[*headertest.DummyHeader](ctx, reopenedStore, exchange, nil)
+	require.NoError(t, err)
+
+	head, err := reopenedStore.Head(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, newHead.Hash(), head.Hash())
+
+	// headers of the previous chain are wiped
+	ok, err := reopenedStore.Has(ctx, oldChain.Head().Hash())
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestStore_WaitInit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	store, err := NewStore[*headertest.DummyHeader](ds)
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, time.Millisecond*50)
+	defer waitCancel()
+	assert.ErrorIs(t, store.WaitInit(waitCtx), context.DeadlineExceeded)
+	assert.False(t, store.HasAt(ctx, 1))
+
+	inited := make(chan error, 1)
+	go func() {
+		inited <- store.WaitInit(ctx)
+	}()
+	require.NoError(t, store.Init(ctx, suite.Head()))
+	require.NoError(t, <-inited)
+	assert.True(t, store.HasAt(ctx, 1))
+	require.NoError(t, store.Append(ctx, suite.GenDummyHeaders(5)...))
+	require.NoError(t, store.Stop(ctx))
+
+	// the reopened store is initialized with the stored head right on Start
+	reopenedStore, err := NewStore[*headertest.DummyHeader](ds)
+	require.NoError(t, err)
+	require.NoError(t, reopenedStore.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, reopenedStore.Stop(ctx))
+	})
+	require.NoError(t, reopenedStore.WaitInit(ctx))
+	assert.True(t, reopenedStore.HasAt(ctx, 6))
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ipfs/go-datastore"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// errCorruptedHeader is wrapped by the errors of the headers failing the integrity verification.
+var errCorruptedHeader = errors.New("corrupted header")
+
+// IntegrityError reports the stored headers which failed the integrity verification.
+type IntegrityError struct {
+	Failures []VerifyFailure
+}
+
+func (e *IntegrityError) Error() string {
+	heights := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		heights[i] = fmt.Sprint(f.Height)
+	}
+	return fmt.Sprintf("header/store: corrupted headers at heights %s", strings.Join(heights, ","))
+}
+
+// VerifyIntegrity walks the headers stored on disk in the range [from:to) and verifies that:
+//   - the height index points to a stored header,
+//   - the header unmarshals and matches its height and hash,
+//   - the header links to the previous one by its hash,
+//   - the hash index points back to the header's height.
+//
+// Unlike Reverify, it reads the datastore directly, bypassing the caches, so it detects
+// corruption caused by disk errors or unclean shutdowns. Headers pending to be written and
+// pruned ones are skipped. All the corrupted heights are reported with the IntegrityError.
+func (s *Store[H]) VerifyIntegrity(ctx context.Context, from, to uint64) error {
+	if from == 0 || from >= to {
+		return fmt.Errorf("header/store: invalid range(%d,%d)", from, to)
+	}
+	if head := s.Height(); to-1 > head {
+		return fmt.Errorf("header/store: range end %d is above the head %d", to-1, head)
+	}
+
+	var (
+		zero, prev H
+		failures   []VerifyFailure
+	)
+	for height := from; height < to; height++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if s.pruned(height) {
+			prev = zero
+			continue
+		}
+		if h := s.pending.GetByHeight(height); !h.IsZero() {
+			prev = h
+			continue
+		}
+
+		h, hash, err := s.readStored(ctx, height)
+		if err == nil && !prev.IsZero() && h.LastHeader().String() != prev.Hash().String() {
+			err = fmt.Errorf("%w: links to %s instead of the previous header %s",
+				errCorruptedHeader, h.LastHeader(), prev.Hash())
+		}
+		if err != nil {
+			if !errors.Is(err, errCorruptedHeader) {
+				return err
+			}
+			log.Warnw("stored header is corrupted", "height", height, "err", err)
+			failures = append(failures, VerifyFailure{Height: height, Hash: hash, Err: err})
+		}
+		prev = h
+	}
+
+	if len(failures) > 0 {
+		return &IntegrityError{Failures: failures}
+	}
+	return nil
+}
+
+// readStored reads the header at the given height from the datastore, bypassing the caches.
+// Inconsistencies are reported by errors wrapping errCorruptedHeader.
+func (s *Store[H]) readStored(ctx context.Context, height uint64) (H, header.Hash, error) {
+	var zero H
+	hash, err := s.ds.Get(ctx, heightKey(height))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return zero, nil, fmt.Errorf("%w: missing height index", errCorruptedHeader)
+		}
+		return zero, nil, err
+	}
+
+	b, err := s.ds.Get(ctx, blockKey(height, hash))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return zero, hash, fmt.Errorf("%w: height index points to missing header %s",
+				errCorruptedHeader, header.Hash(hash))
+		}
+		return zero, hash, err
+	}
+
+	h, err := decodeHeader[H](b)
+	switch {
+	case err != nil:
+		return zero, hash, fmt.Errorf("%w: unmarshalling: %w", errCorruptedHeader, err)
+	case uint64(h.Height()) != height:
+		return zero, hash, fmt.Errorf("%w: indexed at %d, but is at %d", errCorruptedHeader, height, h.Height())
+	case h.Hash().String() != header.Hash(hash).String():
+		return zero, hash, fmt.Errorf("%w: stored under %s, but hashes to %s",
+			errCorruptedHeader, header.Hash(hash), h.Hash())
+	}
+
+	indexed, err := readHeight(ctx, s.ds, hashKey(hash))
+	switch {
+	case errors.Is(err, datastore.ErrNotFound):
+		return zero, hash, fmt.Errorf("%w: missing hash index", errCorruptedHeader)
+	case errors.Is(err, strconv.ErrSyntax), errors.Is(err, strconv.ErrRange):
+		return zero, hash, fmt.Errorf("%w: decoding hash index: %w", errCorruptedHeader, err)
+	case err != nil:
+		return zero, hash, err
+	case indexed != height:
+		return zero, hash, fmt.Errorf("%w: hash index points to %d", errCorruptedHeader, indexed)
+	}
+	return h, hash, nil
+}
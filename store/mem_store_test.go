@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header"
+	"github.com/celestiaorg/go-header/headertest"
+)
+
+func TestMemStore(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	mem, err := NewMemStore[*headertest.DummyHeader](5)
+	require.NoError(t, err)
+	var store header.Store[*headertest.DummyHeader] = mem
+
+	_, err = store.Head(ctx)
+	assert.ErrorIs(t, err, header.ErrNoHead)
+	require.NoError(t, store.Init(ctx, suite.Head()))
+	assert.Error(t, store.Init(ctx, suite.Head()))
+
+	// waiting for a header not appended yet
+	in := suite.GenDummyHeaders(10)
+	waited := make(chan *headertest.DummyHeader)
+	go func() {
+		h, err := store.GetByHeight(ctx, 4)
+		assert.NoError(t, err)
+		waited <- h
+	}()
+
+	require.NoError(t, store.Append(ctx, in[:5]...))
+	assert.Equal(t, in[2].Hash(), (<-waited).Hash())
+	var errNonAdj *header.ErrNonAdjacent
+	assert.ErrorAs(t, store.Append(ctx, in[6:]...), &errNonAdj)
+	require.NoError(t, store.Append(ctx, in[5:]...))
+	assert.EqualValues(t, 11, store.Height())
+
+	// the oldest headers are evicted
+	tail, err := mem.Tail(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 7, tail.Height())
+	assert.False(t, store.HasAt(ctx, 6))
+	assert.True(t, store.HasAt(ctx, 7))
+	_, err = store.GetByHeight(ctx, 6)
+	assert.ErrorIs(t, err, header.ErrNotFound)
+	has, err := store.Has(ctx, in[4].Hash())
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	h, err := store.Get(ctx, in[7].Hash())
+	require.NoError(t, err)
+	assert.EqualValues(t, 9, h.Height())
+	headers, err := store.GetRangeByHeight(ctx, 7, 12)
+	require.NoError(t, err)
+	assert.Len(t, headers, 5)
+	headers, err = store.GetVerifiedRange(ctx, in[5], 12)
+	require.NoError(t, err)
+	assert.Equal(t, in[6:], headers)
+	_, err = store.GetRangeByHeight(ctx, 5, 8)
+	assert.ErrorIs(t, err, header.ErrNotFound)
+
+	require.NoError(t, store.Stop(ctx))
+	assert.Error(t, store.Append(ctx, suite.GenDummyHeaders(1)...))
+}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	"go.opentelemetry.io/otel/metric/unit"
+)
+
+var meter = global.MeterProvider().Meter("header/store")
+
+type metrics struct {
+	compacted         syncint64.Counter
+	compactionPending syncint64.UpDownCounter
+
+	cacheReads    syncint64.Counter
+	flushDuration syncfloat64.Histogram
+	flushedAmount syncint64.Counter
+}
+
+// InitMetrics enables Otel metrics to monitor the Store.
+// See also WithMetrics.
+func (s *Store[H]) InitMetrics() error {
+	compacted, err := meter.
+		SyncInt64().
+		Counter(
+			"header_store_compacted_headers",
+			instrument.WithDescription("amount of pruned headers removed together with their indexes"),
+		)
+	if err != nil {
+		return err
+	}
+
+	compactionPending, err := meter.
+		SyncInt64().
+		UpDownCounter(
+			"header_store_compaction_pending_headers",
+			instrument.WithDescription("amount of pruned headers awaiting removal of their indexes"),
+		)
+	if err != nil {
+		return err
+	}
+
+	cacheReads, err := meter.
+		SyncInt64().
+		Counter(
+			"header_store_cache_reads",
+			instrument.WithDescription("amount of headers read from the cache, hit or missed"),
+		)
+	if err != nil {
+		return err
+	}
+
+	flushDuration, err := meter.
+		SyncFloat64().
+		Histogram(
+			"header_store_flush_duration",
+			instrument.WithUnit(unit.Milliseconds),
+			instrument.WithDescription("duration of writing a batch of pending headers on disk"),
+		)
+	if err != nil {
+		return err
+	}
+
+	flushedAmount, err := meter.
+		SyncInt64().
+		Counter(
+			"header_store_flushed_headers",
+			instrument.WithDescription("amount of headers written on disk"),
+		)
+	if err != nil {
+		return err
+	}
+
+	pending, err := meter.
+		AsyncInt64().
+		Gauge(
+			"header_store_pending_headers",
+			instrument.WithDescription("amount of headers pending to be written on disk"),
+		)
+	if err != nil {
+		return err
+	}
+
+	diskUsage, err := meter.
+		AsyncInt64().
+		Gauge(
+			"header_store_disk_usage",
+			instrument.WithUnit(unit.Bytes),
+			instrument.WithDescription("approximate size of the datastore on disk, if reported by the datastore"),
+		)
+	if err != nil {
+		return err
+	}
+
+	err = meter.RegisterCallback(
+		[]instrument.Asynchronous{
+			pending,
+			diskUsage,
+		},
+		func(ctx context.Context) {
+			pending.Observe(ctx, int64(s.pending.Len()))
+
+			// add timeout to limit the time it takes to estimate the size
+			ctx, cancel := context.WithTimeout(ctx, time.Second)
+			defer cancel()
+			size, err := datastore.DiskUsage(ctx, s.ds)
+			if err != nil {
+				return
+			}
+			diskUsage.Observe(ctx, int64(size))
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	s.metrics = &metrics{
+		compacted:         compacted,
+		compactionPending: compactionPending,
+		cacheReads:        cacheReads,
+		flushDuration:     flushDuration,
+		flushedAmount:     flushedAmount,
+	}
+	return nil
+}
+
+// compactionScheduled records the amount of headers scheduled for compaction.
+func (m *metrics) compactionScheduled(ctx context.Context, amount uint64) {
+	if m == nil {
+		return
+	}
+	m.compactionPending.Add(ctx, int64(amount))
+}
+
+// compactionProgressed records the amount of headers removed during compaction.
+func (m *metrics) compactionProgressed(ctx context.Context, amount uint64) {
+	if m == nil {
+		return
+	}
+	m.compacted.Add(ctx, int64(amount))
+	m.compactionPending.Add(ctx, -int64(amount))
+}
+
+// cacheRead records a header read from the cache.
+func (m *metrics) cacheRead(ctx context.Context, hit bool) {
+	if m == nil {
+		return
+	}
+	m.cacheReads.Add(ctx, 1, attribute.Bool("hit", hit))
+}
+
+// flushed records a write of the given amount of headers on disk.
+func (m *metrics) flushed(ctx context.Context, amount int, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.flushDuration.Record(ctx, float64(duration.Milliseconds()), attribute.Bool("failed", err != nil))
+	if err == nil {
+		m.flushedAmount.Add(ctx, int64(amount))
+	}
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// LegacyLayout describes how headers are laid out in a legacy header store,
+// e.g. one created by older versions of celestia-node.
+type LegacyLayout[H header.Header] struct {
+	// Prefix is the namespace of the legacy store within the Datastore.
+	Prefix datastore.Key
+	// HeadKey is the key of the JSON encoded hash of the legacy head.
+	HeadKey datastore.Key
+	// HeightKey returns the key of the hash of the header at the given height.
+	HeightKey func(uint64) datastore.Key
+	// HeaderKey returns the key of the header with the given hash.
+	HeaderKey func(header.Hash) datastore.Key
+	// Decode decodes a header in the legacy encoding.
+	Decode func([]byte) (H, error)
+}
+
+// DefaultLegacyLayout returns the LegacyLayout of the key scheme used by celestia-node
+// header stores, which is the same as of the first version of this Store's schema,
+// with headers in the given legacy encoding.
+func DefaultLegacyLayout[H header.Header](decode func([]byte) (H, error)) LegacyLayout[H] {
+	return LegacyLayout[H]{
+		Prefix:    storePrefix,
+		HeadKey:   headKey,
+		HeightKey: legacyHeightKey,
+		HeaderKey: legacyHeaderKey,
+		Decode:    decode,
+	}
+}
+
+// Migrate migrates headers from the legacy store in the given Datastore into the started Store.
+// Headers are decoded with the legacy layout, verified and appended to the Store in batches.
+// The migration is resumable, as it continues from the current head of the Store,
+// so it can be safely rerun after interruptions.
+// If the Store is not initialized yet, it is initialized with the legacy header at the given height.
+func Migrate[H header.Header](
+	ctx context.Context,
+	legacy datastore.Batching,
+	layout LegacyLayout[H],
+	store header.Store[H],
+	from uint64,
+) error {
+	ds := namespace.Wrap(legacy, layout.Prefix)
+	legacyHead, err := readLegacyHead(ctx, ds, layout)
+	if err != nil {
+		return fmt.Errorf("header/store: reading legacy head: %w", err)
+	}
+	to := uint64(legacyHead.Height())
+
+	head, err := store.Head(ctx)
+	switch {
+	case errors.Is(err, header.ErrNoHead):
+		initial, err := readLegacyHeader(ctx, ds, layout, from)
+		if err != nil {
+			return fmt.Errorf("header/store: reading initial legacy header %d: %w", from, err)
+		}
+		if err = store.Init(ctx, initial); err != nil {
+			return err
+		}
+		head = initial
+	case err != nil:
+		return err
+	}
+
+	for height := uint64(head.Height()) + 1; height <= to; {
+		batch := make([]H, 0, header.MaxRangeRequestSize)
+		for ; height <= to && uint64(len(batch)) < header.MaxRangeRequestSize; height++ {
+			h, err := readLegacyHeader(ctx, ds, layout, height)
+			if err != nil {
+				return fmt.Errorf("header/store: reading legacy header %d: %w", height, err)
+			}
+			batch = append(batch, h)
+		}
+
+		// Append verifies the headers against the current head
+		if err = store.Append(ctx, batch...); err != nil {
+			return fmt.Errorf("header/store: migrating headers [%d:%d]: %w",
+				batch[0].Height(), batch[len(batch)-1].Height(), err)
+		}
+		log.Infow("migrated legacy headers", "to", height-1, "legacy_head", to)
+	}
+	return nil
+}
+
+func readLegacyHead[H header.Header](ctx context.Context, ds datastore.Batching, layout LegacyLayout[H]) (H, error) {
+	var zero H
+	b, err := ds.Get(ctx, layout.HeadKey)
+	if err != nil {
+		return zero, err
+	}
+
+	var hash header.Hash
+	if err = hash.UnmarshalJSON(b); err != nil {
+		return zero, err
+	}
+	return readLegacyHeaderByHash(ctx, ds, layout, hash)
+}
+
+func readLegacyHeader[H header.Header](
+	ctx context.Context,
+	ds datastore.Batching,
+	layout LegacyLayout[H],
+	height uint64,
+) (H, error) {
+	var zero H
+	hash, err := ds.Get(ctx, layout.HeightKey(height))
+	if err != nil {
+		return zero, err
+	}
+	return readLegacyHeaderByHash(ctx, ds, layout, hash)
+}
+
+func readLegacyHeaderByHash[H header.Header](
+	ctx context.Context,
+	ds datastore.Batching,
+	layout LegacyLayout[H],
+	hash header.Hash,
+) (H, error) {
+	var zero H
+	b, err := ds.Get(ctx, layout.HeaderKey(hash))
+	if err != nil {
+		return zero, err
+	}
+	return layout.Decode(b)
+}
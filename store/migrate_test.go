@@ -0,0 +1,173 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header"
+	"github.com/celestiaorg/go-header/headertest"
+)
+
+func TestMigrate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	// legacy store keeps JSON encoded headers under its own key scheme
+	layout := LegacyLayout[*headertest.DummyHeader]{
+		Prefix:  datastore.NewKey("legacy"),
+		HeadKey: datastore.NewKey("tip"),
+		HeightKey: func(height uint64) datastore.Key {
+			return datastore.NewKey("height/" + strconv.FormatUint(height, 10))
+		},
+		HeaderKey: func(hash header.Hash) datastore.Key {
+			return datastore.NewKey("header/" + hash.String())
+		},
+		Decode: func(b []byte) (*headertest.DummyHeader, error) {
+			h := &headertest.DummyHeader{}
+			return h, json.Unmarshal(b, h)
+		},
+	}
+
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	legacy := namespace.Wrap(ds, layout.Prefix)
+	writeLegacy := func(headers ...*headertest.DummyHeader) {
+		for _, h := range headers {
+			b, err := json.Marshal(h)
+			require.NoError(t, err)
+			require.NoError(t, legacy.Put(ctx, layout.HeaderKey(h.Hash()), b))
+			require.NoError(t, legacy.Put(ctx, layout.HeightKey(uint64(h.Height())), h.Hash()))
+		}
+		b, err := headers[len(headers)-1].Hash().MarshalJSON()
+		require.NoError(t, err)
+		require.NoError(t, legacy.Put(ctx, layout.HeadKey, b))
+	}
+
+	suite := headertest.NewTestSuite(t)
+	writeLegacy(suite.Head())
+	writeLegacy(suite.GenDummyHeaders(20)...)
+
+	store, err := NewStore[*headertest.DummyHeader](ds)
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+
+	err = Migrate[*headertest.DummyHeader](ctx, ds, layout, store, 1)
+	require.NoError(t, err)
+
+	head, err := store.GetByHeight(ctx, 21)
+	require.NoError(t, err)
+	assert.Equal(t, suite.Head().Hash(), head.Hash())
+
+	// the legacy store kept growing, so the migration resumes from the migrated head
+	writeLegacy(suite.GenDummyHeaders(600)...)
+	err = Migrate[*headertest.DummyHeader](ctx, ds, layout, store, 1)
+	require.NoError(t, err)
+
+	head, err = store.GetByHeight(ctx, 621)
+	require.NoError(t, err)
+	assert.Equal(t, suite.Head().Hash(), head.Hash())
+}
+
+func TestStore_MigrateSchema(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	store, err := NewStoreWithHead(ctx, ds, suite.Head(), WithWriteBatchSize(1))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	in := suite.GenDummyHeaders(10)
+	require.NoError(t, store.Append(ctx, in...))
+	require.Eventually(t, func() bool {
+		return store.pending.Len() == 0
+	}, time.Second, time.Millisecond*10)
+	require.NoError(t, store.Stop(ctx))
+
+	// datastores of the first version have neither the version nor the time index,
+	// both their height index and headers are flat, and the headers have no format byte
+	require.NoError(t, store.ds.Delete(ctx, versionKey))
+	for _, h := range in {
+		require.NoError(t, store.ds.Delete(ctx, timeKey(h.Time(), uint64(h.Height()))))
+	}
+	for height := uint64(1); height <= 11; height++ {
+		hash, err := store.ds.Get(ctx, heightKey(height))
+		require.NoError(t, err)
+		require.NoError(t, store.ds.Put(ctx, legacyHeightKey(height), hash))
+		require.NoError(t, store.ds.Delete(ctx, heightKey(height)))
+		b, err := store.ds.Get(ctx, blockKey(height, hash))
+		require.NoError(t, err)
+		require.NoError(t, store.ds.Put(ctx, legacyHeaderKey(hash), b[1:]))
+		require.NoError(t, store.ds.Delete(ctx, blockKey(height, hash)))
+		require.NoError(t, store.ds.Delete(ctx, hashKey(hash)))
+	}
+
+	store, err = NewStore[*headertest.DummyHeader](ds)
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+
+	version, err := readHeight(ctx, store.ds, versionKey)
+	require.NoError(t, err)
+	assert.EqualValues(t, schemaVersion, version)
+	migrations := store.migrations()
+	assert.EqualValues(t, schemaVersion, migrations[len(migrations)-1].version)
+
+	for _, h := range in {
+		got, err := store.GetByTime(ctx, h.Time())
+		require.NoError(t, err)
+		assert.Equal(t, h.Time(), got.Time())
+	}
+	out, err := store.GetRangeByHeight(ctx, 2, 12)
+	require.NoError(t, err)
+	assert.Equal(t, in, out)
+}
+
+func TestStore_MigrateTimeIndex(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	store, err := NewStoreWithHead(ctx, ds, suite.Head(), WithWriteBatchSize(1))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	in := suite.GenDummyHeaders(10)
+	require.NoError(t, store.Append(ctx, in...))
+	require.NoError(t, store.Stop(ctx))
+
+	// datastores of the third version have the time index keyed by timestamps only
+	require.NoError(t, store.ds.Put(ctx, versionKey, encodeHeight(3)))
+	for _, h := range in {
+		height := uint64(h.Height())
+		require.NoError(t, store.ds.Delete(ctx, timeKey(h.Time(), height)))
+		legacy := timePrefix.ChildString(fmt.Sprintf("%020d", h.Time().UnixNano()))
+		require.NoError(t, store.ds.Put(ctx, legacy, encodeHeight(height)))
+	}
+
+	store, err = NewStore[*headertest.DummyHeader](ds)
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+	for _, h := range in {
+		got, err := store.GetByTime(ctx, h.Time())
+		require.NoError(t, err)
+		assert.Equal(t, h.Hash(), got.Hash())
+	}
+}
@@ -1,7 +1,9 @@
 package store
 
 import (
+	"errors"
 	"fmt"
+	"time"
 )
 
 // Option is the functional option that is applied to the store instance
@@ -11,22 +13,79 @@ type Option func(*Parameters)
 // Parameters is the set of parameters that must be configured for the store.
 type Parameters struct {
 	// StoreCacheSize defines the maximum amount of entries in the Header Store cache.
+	// It is ignored if the Cache is provided.
 	StoreCacheSize int
 
+	// Cache overrides the default adaptive replacement cache of headers of StoreCacheSize,
+	// e.g. to share one memory-bounded cache across several Stores.
+	Cache Cache
+
+	// RecentHeadsCacheSize defines the amount of the most recent heads kept in memory apart from
+	// the Header Store cache, so the reads near the head never touch the Datastore.
+	// Zero disables it.
+	RecentHeadsCacheSize int
+
 	// IndexCacheSize defines the maximum amount of entries in the Height to Hash index cache.
 	IndexCacheSize int
 
 	// WriteBatchSize defines the size of the batched header write.
 	// Headers are written in batches not to thrash the underlying Datastore with writes.
 	WriteBatchSize int
+
+	// CompactionBatchSize defines the amount of pruned headers removed together with their indexes
+	// in one batch. Smaller batches release the underlying Datastore more often.
+	CompactionBatchSize int
+
+	// PruningWindow defines the amount of the most recent headers retained by the Store.
+	// Older headers are pruned in the background every PruningInterval. Zero disables the limit.
+	PruningWindow uint64
+
+	// PruningPeriod defines the age of the oldest headers retained by the Store by their time.
+	// Older headers are pruned in the background every PruningInterval. Zero disables the limit.
+	PruningPeriod time.Duration
+
+	// PruningInterval defines how often the headers outside PruningWindow and PruningPeriod
+	// are pruned.
+	PruningInterval time.Duration
+
+	// HeadTTL defines the age after which a head loaded from the Datastore on startup is considered
+	// invalid. In such case, the Store is wiped, so it can be reinitialized from trusted peers.
+	// Useful for frequently restarted networks that do not finalize. Zero disables the check.
+	HeadTTL time.Duration
+
+	// Strict makes the Store panic with a dump of its state on violations of its internal invariants,
+	// like non-contiguous or double appends and height index mismatches, instead of logging them.
+	// Intended for integration environments to surface bugs early.
+	Strict bool
+
+	// WriteAheadLog makes the Store record appended headers in the Datastore before acknowledging
+	// the appends, so the headers pending to be written in a batch are not lost on a crash and are
+	// replayed on Start instead. It trades an extra write per append for crash consistency.
+	WriteAheadLog bool
+
+	// Compression makes the Store compress the headers with zstd on write.
+	// Headers are decompressed on read transparently, so the option can be switched
+	// for existing datastores, where stored headers are left as they are.
+	Compression bool
+
+	// PrefetchWindow defines the amount of heights above the requested one read together with it in
+	// a single batched read, when GetByHeight misses the cache, as sequential reads are dominant.
+	// Zero disables prefetching.
+	PrefetchWindow int
+
+	// metrics enables Otel metrics of the Store.
+	metrics bool
 }
 
 // DefaultParameters returns the default params to configure the store.
 func DefaultParameters() Parameters {
 	return Parameters{
-		StoreCacheSize: 4096,
-		IndexCacheSize: 16384,
-		WriteBatchSize: 2048,
+		StoreCacheSize:       4096,
+		RecentHeadsCacheSize: 64,
+		IndexCacheSize:       16384,
+		WriteBatchSize:       2048,
+		CompactionBatchSize:  512,
+		PruningInterval:      time.Minute,
 	}
 }
 
@@ -36,12 +95,24 @@ func (p *Parameters) Validate() error {
 	if p.StoreCacheSize <= 0 {
 		return fmt.Errorf("invalid store cache size:%s", errSuffix)
 	}
+	if p.RecentHeadsCacheSize < 0 {
+		return errors.New("invalid recent heads cache size:value should not be negative")
+	}
 	if p.IndexCacheSize <= 0 {
 		return fmt.Errorf("invalid indexer cache size:%s", errSuffix)
 	}
 	if p.WriteBatchSize <= 0 {
 		return fmt.Errorf("invalid batch size:%s", errSuffix)
 	}
+	if p.CompactionBatchSize <= 0 {
+		return fmt.Errorf("invalid compaction batch size:%s", errSuffix)
+	}
+	if p.PrefetchWindow < 0 {
+		return errors.New("invalid prefetch window:value should not be negative")
+	}
+	if p.PruningInterval <= 0 && (p.PruningWindow > 0 || p.PruningPeriod > 0) {
+		return fmt.Errorf("invalid pruning interval:%s", errSuffix)
+	}
 	return nil
 }
 
@@ -53,6 +124,22 @@ func WithStoreCacheSize(size int) Option {
 	}
 }
 
+// WithCache is a functional option that configures the
+// `Cache` parameter.
+func WithCache(cache Cache) Option {
+	return func(p *Parameters) {
+		p.Cache = cache
+	}
+}
+
+// WithRecentHeadsCacheSize is a functional option that configures the
+// `RecentHeadsCacheSize` parameter.
+func WithRecentHeadsCacheSize(size int) Option {
+	return func(p *Parameters) {
+		p.RecentHeadsCacheSize = size
+	}
+}
+
 // WithIndexCacheSize is a functional option that configures the
 // `IndexCacheSize` parameter.
 func WithIndexCacheSize(size int) Option {
@@ -69,6 +156,86 @@ func WithWriteBatchSize(size int) Option {
 	}
 }
 
+// WithCompactionBatchSize is a functional option that configures the
+// `CompactionBatchSize` parameter.
+func WithCompactionBatchSize(size int) Option {
+	return func(p *Parameters) {
+		p.CompactionBatchSize = size
+	}
+}
+
+// WithPruningWindow is a functional option that configures the
+// `PruningWindow` parameter.
+func WithPruningWindow(window uint64) Option {
+	return func(p *Parameters) {
+		p.PruningWindow = window
+	}
+}
+
+// WithPruningPeriod is a functional option that configures the
+// `PruningPeriod` parameter.
+func WithPruningPeriod(period time.Duration) Option {
+	return func(p *Parameters) {
+		p.PruningPeriod = period
+	}
+}
+
+// WithPruningInterval is a functional option that configures the
+// `PruningInterval` parameter.
+func WithPruningInterval(interval time.Duration) Option {
+	return func(p *Parameters) {
+		p.PruningInterval = interval
+	}
+}
+
+// WithHeadTTL is a functional option that configures the
+// `HeadTTL` parameter.
+func WithHeadTTL(ttl time.Duration) Option {
+	return func(p *Parameters) {
+		p.HeadTTL = ttl
+	}
+}
+
+// WithStrict is a functional option that configures the
+// `Strict` parameter.
+func WithStrict(strict bool) Option {
+	return func(p *Parameters) {
+		p.Strict = strict
+	}
+}
+
+// WithWriteAheadLog is a functional option that configures the
+// `WriteAheadLog` parameter.
+func WithWriteAheadLog(enabled bool) Option {
+	return func(p *Parameters) {
+		p.WriteAheadLog = enabled
+	}
+}
+
+// WithCompression is a functional option that configures the
+// `Compression` parameter.
+func WithCompression(enabled bool) Option {
+	return func(p *Parameters) {
+		p.Compression = enabled
+	}
+}
+
+// WithPrefetchWindow is a functional option that configures the
+// `PrefetchWindow` parameter.
+func WithPrefetchWindow(window int) Option {
+	return func(p *Parameters) {
+		p.PrefetchWindow = window
+	}
+}
+
+// WithMetrics is a functional option that enables Otel metrics of the Store,
+// such as cache hits and misses, pending headers, flush durations and disk usage.
+func WithMetrics() Option {
+	return func(p *Parameters) {
+		p.metrics = true
+	}
+}
+
 // WithParams is a functional option that overrides Parameters.
 func WithParams(new Parameters) Option {
 	return func(old *Parameters) {
@@ -3,7 +3,12 @@ package store
 import (
 	"testing"
 
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header/headertest"
 )
 
 func TestOptionsWithParams(t *testing.T) {
@@ -17,3 +22,9 @@ func TestOptionsWithParams(t *testing.T) {
 	opt(&params)
 	assert.Equal(t, size, params.StoreCacheSize)
 }
+
+func TestOptionsWithMetrics(t *testing.T) {
+	store, err := NewStore[*headertest.DummyHeader](sync.MutexWrap(datastore.NewMapDatastore()), WithMetrics())
+	require.NoError(t, err)
+	assert.NotNil(t, store.metrics)
+}
@@ -0,0 +1,30 @@
+package store
+
+import (
+	"context"
+)
+
+// prefetch reads the headers from the given height up to Parameters.PrefetchWindow heights above
+// in a single batched read, caching them with their height index entries, so the sequential reads
+// to follow hit the caches. Failures are left to be surfaced by the reads themselves.
+func (s *Store[H]) prefetch(ctx context.Context, height uint64) {
+	to := height + uint64(s.Params.PrefetchWindow) + 1
+	if head := s.Height(); to > head+1 {
+		to = head + 1
+	}
+	if height >= to {
+		return
+	}
+
+	hashes, err := s.heightIndex.HashesByRange(ctx, height, to)
+	if err != nil {
+		log.Debugw("prefetching height index", "from", height, "to", to, "err", err)
+		return
+	}
+	for i, hash := range hashes {
+		s.heightIndex.cache.Add(height+uint64(i), hash)
+	}
+	if _, err = s.getRange(ctx, height, hashes); err != nil {
+		log.Debugw("prefetching headers", "from", height, "to", to, "err", err)
+	}
+}
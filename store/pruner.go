@@ -0,0 +1,175 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+)
+
+// DeleteTo prunes the headers below the given height, moving the tail of the Store to it.
+// The new tail is persisted before anything is removed, so the pruned headers are not served
+// right away, while the headers and their indexes are removed in the background by compaction.
+// Compaction interrupted by a restart is resumed on Start.
+// The head can not be pruned.
+func (s *Store[H]) DeleteTo(ctx context.Context, to uint64) error {
+	s.pruneLk.Lock()
+	defer s.pruneLk.Unlock()
+
+	if head := s.Height(); to > head {
+		return fmt.Errorf("header/store: can not prune to %d above the head %d", to, head)
+	}
+	if err := s.pruneRanges(ctx, to); err != nil {
+		return err
+	}
+	tail := s.tailHeight.Load()
+	if to <= tail {
+		return nil
+	}
+
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	if err = batch.Put(ctx, tailKey, encodeHeight(to)); err != nil {
+		return err
+	}
+	if _, err = readHeight(ctx, s.ds, compactedKey); errors.Is(err, datastore.ErrNotFound) {
+		// compaction starts from the previous tail, so it can be resumed after restarts
+		err = batch.Put(ctx, compactedKey, encodeHeight(tail))
+	}
+	if err != nil {
+		return err
+	}
+	if err = batch.Commit(ctx); err != nil {
+		return err
+	}
+
+	s.tailHeight.Store(to)
+	if tail == 0 {
+		// the tail of stores initialized before it was tracked is unknown
+		tail = 1
+	}
+	s.callRemoveHooks(ctx, tail, to)
+	s.scheduleCompaction(tail, to)
+	log.Infow("pruned headers", "from", tail, "to", to)
+	return nil
+}
+
+// loadTail loads the tail from the datastore and resumes the compaction of the headers
+// pruned before the last shutdown, if it was interrupted.
+func (s *Store[H]) loadTail(ctx context.Context) error {
+	tail, err := readHeight(ctx, s.ds, tailKey)
+	if errors.Is(err, datastore.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.tailHeight.Store(tail)
+
+	compacted, err := readHeight(ctx, s.ds, compactedKey)
+	switch {
+	case errors.Is(err, datastore.ErrNotFound):
+		// the store was never pruned
+		return nil
+	case err != nil:
+		return err
+	}
+	if compacted == 0 {
+		compacted = 1
+	}
+	s.scheduleCompaction(compacted, tail)
+	return nil
+}
+
+// pruned reports whether the header at the given height is pruned
+// or not stored below the tail otherwise.
+func (s *Store[H]) pruned(height uint64) bool {
+	return height < s.tailHeight.Load() && !s.inRanges(height)
+}
+
+// pruningLoop periodically prunes the headers outside the retention window,
+// if Parameters.PruningWindow or Parameters.PruningPeriod is set.
+func (s *Store[H]) pruningLoop() {
+	defer close(s.pruningDn)
+	if s.Params.PruningWindow == 0 && s.Params.PruningPeriod == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.Params.PruningInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.compactionQuit:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), s.Params.PruningInterval)
+		to, err := s.retainedFrom(ctx)
+		if err == nil {
+			err = s.DeleteTo(ctx, to)
+		}
+		cancel()
+		if err != nil {
+			log.Errorw("pruning headers", "err", err)
+		}
+	}
+}
+
+// retainedFrom returns the lowest height within the retention window.
+// Headers within both Parameters.PruningWindow and Parameters.PruningPeriod are retained.
+func (s *Store[H]) retainedFrom(ctx context.Context) (uint64, error) {
+	head := s.Height()
+	if head == 0 {
+		return 0, nil
+	}
+
+	var from uint64
+	if window := s.Params.PruningWindow; window > 0 && head > window {
+		from = head - window + 1
+	}
+	if s.Params.PruningPeriod == 0 {
+		return from, nil
+	}
+
+	// headers are ordered by time, so the oldest header within the period is searched for
+	tail := s.tailHeight.Load()
+	if tail == 0 {
+		tail = 1
+	}
+	if from < tail {
+		from = tail
+	}
+	cutoff := time.Now().Add(-s.Params.PruningPeriod)
+	var searchErr error
+	offset := sort.Search(int(head-from), func(i int) bool {
+		h, err := s.GetByHeight(ctx, from+uint64(i))
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return h.Time().After(cutoff)
+	})
+	if searchErr != nil {
+		return 0, searchErr
+	}
+	return from + uint64(offset), nil
+}
+
+func encodeHeight(height uint64) []byte {
+	return []byte(strconv.FormatUint(height, 10))
+}
+
+func readHeight(ctx context.Context, ds datastore.Read, key datastore.Key) (uint64, error) {
+	b, err := ds.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(string(b), 10, 64)
+}
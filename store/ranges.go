@@ -0,0 +1,272 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// Range is a range of heights [From:To).
+type Range struct {
+	From, To uint64
+}
+
+// AppendRange stores the given range of adjacent headers below the tail, apart from the
+// contiguous chain of headers from the tail to the head, e.g. checkpoints synced ahead of history.
+// The range is trusted as verified by the caller, but it is verified against the headers stored
+// right above and below it, if any. The gaps left between the stored ranges and the tail are
+// reported by MissingRanges, and a range filling the gap below the tail extends the chain down.
+func (s *Store[H]) AppendRange(ctx context.Context, headers ...H) error {
+	if len(headers) == 0 {
+		return nil
+	}
+	if err := verifyRange(headers); err != nil {
+		return err
+	}
+	// ensures the store is initialized and its tail is known
+	if _, err := s.Tail(ctx); err != nil {
+		return err
+	}
+
+	s.pruneLk.Lock()
+	defer s.pruneLk.Unlock()
+
+	first, last := headers[0], headers[len(headers)-1]
+	rng := Range{From: uint64(first.Height()), To: uint64(last.Height()) + 1}
+	tail := s.tailHeight.Load()
+	if rng.To > tail {
+		return fmt.Errorf("header/store: range [%d:%d) overlaps with the headers stored from %d",
+			rng.From, rng.To, tail)
+	}
+
+	// the pruned headers awaiting compaction are removed regardless of the ranges stored in between
+	if s.compactingRange(rng) {
+		return fmt.Errorf("header/store: range [%d:%d) overlaps with pruned headers awaiting compaction",
+			rng.From, rng.To)
+	}
+
+	s.rangesLk.RLock()
+	ranges := s.ranges
+	s.rangesLk.RUnlock()
+	for _, r := range ranges {
+		if rng.From < r.To && r.From < rng.To {
+			return fmt.Errorf("header/store: range [%d:%d) overlaps with the stored range [%d:%d)",
+				rng.From, rng.To, r.From, r.To)
+		}
+	}
+	// the range is linked with its stored neighbours, so no fork sneaks in between
+	if s.stored(rng.From - 1) {
+		if err := s.link(ctx, rng.From-1, first); err != nil {
+			return err
+		}
+	}
+	if s.stored(rng.To) {
+		next, err := s.GetByHeight(ctx, rng.To)
+		if err != nil {
+			return err
+		}
+		if err = verifyLink(last, next); err != nil {
+			return err
+		}
+	}
+
+	ranges = mergeRange(ranges, rng)
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	if err = s.putHeaders(ctx, batch, headers...); err != nil {
+		return err
+	}
+	// the range adjacent to the tail joins the chain
+	if highest := ranges[len(ranges)-1]; highest.To == tail {
+		tail, ranges = highest.From, ranges[:len(ranges)-1]
+		if err = batch.Put(ctx, tailKey, encodeHeight(tail)); err != nil {
+			return err
+		}
+	}
+	if err = putRanges(ctx, batch, ranges); err != nil {
+		return err
+	}
+	if err = batch.Commit(ctx); err != nil {
+		return err
+	}
+
+	s.rangesLk.Lock()
+	s.ranges = ranges
+	s.rangesLk.Unlock()
+	s.tailHeight.Store(tail)
+	log.Infow("stored range", "from", rng.From, "to", rng.To, "tail", tail)
+	return nil
+}
+
+// MissingRanges returns the gaps between the ranges stored by AppendRange and the tail,
+// in ascending order. The history below the lowest stored range is not reported.
+func (s *Store[H]) MissingRanges() []Range {
+	s.rangesLk.RLock()
+	defer s.rangesLk.RUnlock()
+	if len(s.ranges) == 0 {
+		return nil
+	}
+
+	gaps := make([]Range, 0, len(s.ranges))
+	for i, r := range s.ranges {
+		next := s.tailHeight.Load()
+		if i+1 < len(s.ranges) {
+			next = s.ranges[i+1].From
+		}
+		gaps = append(gaps, Range{From: r.To, To: next})
+	}
+	return gaps
+}
+
+// stored reports whether the header at the given height is stored,
+// either in the chain or in one of the ranges below the tail.
+func (s *Store[H]) stored(height uint64) bool {
+	if height == 0 || height > s.Height() {
+		return false
+	}
+	return !s.pruned(height)
+}
+
+// inRanges reports whether the given height is within the ranges stored below the tail.
+func (s *Store[H]) inRanges(height uint64) bool {
+	s.rangesLk.RLock()
+	defer s.rangesLk.RUnlock()
+	for _, r := range s.ranges {
+		if r.From <= height && height < r.To {
+			return true
+		}
+	}
+	return false
+}
+
+// link verifies the given header against the stored one at the given height.
+func (s *Store[H]) link(ctx context.Context, height uint64, h H) error {
+	prev, err := s.GetByHeight(ctx, height)
+	if err != nil {
+		return err
+	}
+	return verifyLink(prev, h)
+}
+
+// verifyLink verifies the given adjacent headers are valid against each other and hash-linked.
+func verifyLink[H header.Header](prev, next H) error {
+	if err := header.Verify(prev, next); err != nil {
+		return err
+	}
+	if next.LastHeader().String() != prev.Hash().String() {
+		return fmt.Errorf("header/store: header %d does not link to the stored header %d",
+			next.Height(), prev.Height())
+	}
+	return nil
+}
+
+// pruneRanges removes the parts of the stored ranges below the given height.
+// Must be called under pruneLk.
+func (s *Store[H]) pruneRanges(ctx context.Context, to uint64) error {
+	s.rangesLk.RLock()
+	ranges := s.ranges
+	s.rangesLk.RUnlock()
+	if len(ranges) == 0 || ranges[0].From >= to {
+		return nil
+	}
+
+	var (
+		retained []Range
+		pruned   []Range
+	)
+	for _, r := range ranges {
+		switch {
+		case r.To <= to:
+			pruned = append(pruned, r)
+		case r.From < to:
+			pruned = append(pruned, Range{From: r.From, To: to})
+			retained = append(retained, Range{From: to, To: r.To})
+		default:
+			retained = append(retained, r)
+		}
+	}
+
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	if err = putRanges(ctx, batch, retained); err != nil {
+		return err
+	}
+	if err = batch.Commit(ctx); err != nil {
+		return err
+	}
+
+	s.rangesLk.Lock()
+	s.ranges = retained
+	s.rangesLk.Unlock()
+	for _, r := range pruned {
+		s.callRemoveHooks(ctx, r.From, r.To)
+		s.scheduleCompaction(r.From, r.To)
+	}
+	return nil
+}
+
+// loadRanges loads the ranges stored below the tail from the datastore.
+func (s *Store[H]) loadRanges(ctx context.Context) error {
+	b, err := s.ds.Get(ctx, rangesKey)
+	if errors.Is(err, datastore.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var ranges []Range
+	if err = json.Unmarshal(b, &ranges); err != nil {
+		return err
+	}
+	s.rangesLk.Lock()
+	s.ranges = ranges
+	s.rangesLk.Unlock()
+	return nil
+}
+
+func putRanges(ctx context.Context, batch datastore.Batch, ranges []Range) error {
+	if len(ranges) == 0 {
+		return batch.Delete(ctx, rangesKey)
+	}
+	b, err := json.Marshal(ranges)
+	if err != nil {
+		return err
+	}
+	return batch.Put(ctx, rangesKey, b)
+}
+
+// mergeRange inserts the given range into the sorted disjoint ranges,
+// merging it with the adjacent ones. The given ranges are not modified.
+func mergeRange(ranges []Range, rng Range) []Range {
+	merged := make([]Range, 0, len(ranges)+1)
+	for _, r := range ranges {
+		switch {
+		case r.To == rng.From:
+			rng.From = r.From
+		case r.From == rng.To:
+			rng.To = r.To
+		case r.To < rng.From:
+			merged = append(merged, r)
+		default:
+			if rng.To != 0 {
+				merged = append(merged, rng)
+				rng = Range{}
+			}
+			merged = append(merged, r)
+		}
+	}
+	if rng.To != 0 {
+		merged = append(merged, rng)
+	}
+	return merged
+}
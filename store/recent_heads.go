@@ -0,0 +1,90 @@
+package store
+
+import (
+	"sync"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// recentHeads keeps the most recent heads apart from the cache of headers, so the reads near
+// the head, e.g. by gossip validation and RPC, are always served from memory regardless of
+// the pressure of historical reads on the cache.
+type recentHeads[H header.Header] struct {
+	lk sync.RWMutex
+	// headers is the ring of the recent heads indexed by height
+	headers []H
+	// heights maps hashes of the recent heads to their heights
+	heights map[string]uint64
+}
+
+// newRecentHeads creates new recentHeads keeping the given amount of heads, zero disables it.
+func newRecentHeads[H header.Header](size int) *recentHeads[H] {
+	return &recentHeads[H]{
+		headers: make([]H, size),
+		heights: make(map[string]uint64, size),
+	}
+}
+
+// add keeps the given headers, replacing the oldest ones.
+func (r *recentHeads[H]) add(headers ...H) {
+	if len(r.headers) == 0 {
+		return
+	}
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	for _, h := range headers {
+		i := uint64(h.Height()) % uint64(len(r.headers))
+		if old := r.headers[i]; !old.IsZero() {
+			delete(r.heights, old.Hash().String())
+		}
+		r.headers[i] = h
+		r.heights[h.Hash().String()] = uint64(h.Height())
+	}
+}
+
+// get returns the recent head of the given hash.
+func (r *recentHeads[H]) get(hash header.Hash) (H, bool) {
+	r.lk.RLock()
+	height, ok := r.heights[hash.String()]
+	r.lk.RUnlock()
+	if !ok {
+		var zero H
+		return zero, false
+	}
+	return r.getByHeight(height)
+}
+
+// getByHeight returns the recent head of the given height.
+func (r *recentHeads[H]) getByHeight(height uint64) (H, bool) {
+	var zero H
+	if len(r.headers) == 0 {
+		return zero, false
+	}
+
+	r.lk.RLock()
+	defer r.lk.RUnlock()
+	h := r.headers[height%uint64(len(r.headers))]
+	if h.IsZero() || uint64(h.Height()) != height {
+		return zero, false
+	}
+	return h, true
+}
+
+// remove drops the recent heads within [from:to).
+func (r *recentHeads[H]) remove(from, to uint64) {
+	if len(r.headers) == 0 {
+		return
+	}
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	var zero H
+	for i, h := range r.headers {
+		if h.IsZero() || uint64(h.Height()) < from || uint64(h.Height()) >= to {
+			continue
+		}
+		delete(r.heights, h.Hash().String())
+		r.headers[i] = zero
+	}
+}
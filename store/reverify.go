@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// VerifyFailure describes a stored header that failed re-verification.
+type VerifyFailure struct {
+	Height uint64
+	Hash   header.Hash
+	Err    error
+}
+
+// Reverify re-runs validation and verification over the stored headers in the range [from:to)
+// using the current verification logic. It is intended for operators who upgraded verification
+// rules and want to validate existing stored history.
+//
+// Every header is verified against the previous stored one. Headers failing verification are
+// reported and stay in the Store, so the operator can decide how to handle them.
+func (s *Store[H]) Reverify(ctx context.Context, from, to uint64) ([]VerifyFailure, error) {
+	if from == 0 || from >= to {
+		return nil, fmt.Errorf("header/store: invalid range(%d,%d)", from, to)
+	}
+	if head := s.Height(); to-1 > head {
+		return nil, fmt.Errorf("header/store: range end %d is above the head %d", to-1, head)
+	}
+
+	// the header at 'from' is verified against its parent, unless it is the first stored header.
+	var (
+		trusted    H
+		hasTrusted bool
+	)
+	if from > 1 {
+		parent, err := s.GetByHeight(ctx, from-1)
+		switch {
+		case err == nil:
+			trusted, hasTrusted = parent, true
+		case !errors.Is(err, header.ErrNotFound):
+			return nil, err
+		}
+	}
+
+	var failures []VerifyFailure
+	for from < to {
+		end := from + header.MaxRangeRequestSize
+		if end > to {
+			end = to
+		}
+
+		headers, err := s.GetRangeByHeight(ctx, from, end)
+		if err != nil {
+			return failures, err
+		}
+
+		for _, h := range headers {
+			err := h.Validate()
+			if err == nil && hasTrusted {
+				err = header.Verify(trusted, h)
+			}
+			if err != nil {
+				log.Warnw("stored header failed re-verification", "height", h.Height(), "hash", h.Hash(), "err", err)
+				failures = append(failures, VerifyFailure{
+					Height: uint64(h.Height()),
+					Hash:   h.Hash(),
+					Err:    err,
+				})
+			}
+			trusted, hasTrusted = h, true
+		}
+		from = end
+	}
+	return failures, nil
+}
@@ -0,0 +1,373 @@
+package store
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// schemaVersion is the current version of the schema, which new datastores are laid out with.
+// Version 1 is the original layout of headers, their height index and the head.
+// It must match the version of the last migration.
+const schemaVersion = 6
+
+// schemaMigration upgrades the layout of the datastore to the given version of the schema in place.
+type schemaMigration struct {
+	version uint64
+	name    string
+	migrate func(context.Context) error
+}
+
+// migrations returns the upgrades of the schema in ascending order of versions.
+// Every change of the key layout, e.g. a new index, registers a migration here,
+// so existing datastores are upgraded on Start instead of being resynced.
+func (s *Store[H]) migrations() []schemaMigration {
+	return []schemaMigration{
+		{version: 2, name: "index headers by time", migrate: s.indexTimes},
+		{version: 3, name: "shard height index into buckets", migrate: s.shardHeights},
+		{version: 4, name: "key time index by buckets and heights", migrate: s.rekeyTimes},
+		{version: 5, name: "shard headers into buckets", migrate: s.shardHeaders},
+		{version: 6, name: "prefix headers with their format", migrate: s.prefixFormats},
+	}
+}
+
+// migrateSchema runs the migrations of the schema above the version of the datastore.
+// Datastores which did not record their version are of the first one.
+// The version is persisted after every migration, so interrupted upgrades are resumed.
+func (s *Store[H]) migrateSchema(ctx context.Context) error {
+	version, err := readHeight(ctx, s.ds, versionKey)
+	switch {
+	case errors.Is(err, datastore.ErrNotFound):
+		if _, err = s.ds.Get(ctx, headKey); errors.Is(err, datastore.ErrNotFound) {
+			// nothing to migrate before Init
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		version = 1
+	case err != nil:
+		return err
+	}
+	if version > schemaVersion {
+		return fmt.Errorf("datastore schema version %d is newer than supported %d", version, schemaVersion)
+	}
+
+	for _, m := range s.migrations() {
+		if m.version <= version {
+			continue
+		}
+		log.Infow("migrating datastore schema", "version", m.version, "migration", m.name)
+		if err = m.migrate(ctx); err != nil {
+			return fmt.Errorf("migrating to version %d (%s): %w", m.version, m.name, err)
+		}
+		if err = s.ds.Put(ctx, versionKey, encodeHeight(m.version)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexTimes indexes the stored headers by time, walking them down from the head to the tail.
+// The headers are keyed by their hashes only, as they are sharded by a later version.
+func (s *Store[H]) indexTimes(ctx context.Context) error {
+	b, err := s.ds.Get(ctx, headKey)
+	if err != nil {
+		return err
+	}
+	var head header.Hash
+	if err = head.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	h, err := s.getLegacy(ctx, head)
+	if err != nil {
+		return err
+	}
+
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	for indexed := 1; ; indexed++ {
+		if err = indexTime(ctx, batch, h); err != nil {
+			return err
+		}
+		if indexed%s.Params.CompactionBatchSize == 0 {
+			if err = batch.Commit(ctx); err != nil {
+				return err
+			}
+			if batch, err = s.ds.Batch(ctx); err != nil {
+				return err
+			}
+		}
+
+		if h.Height() == 1 {
+			break
+		}
+		h, err = s.getLegacy(ctx, h.LastHeader())
+		if errors.Is(err, datastore.ErrNotFound) {
+			// reached the tail
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return batch.Commit(ctx)
+}
+
+// shardHeights moves the entries of the flat height index under the prefixes of their buckets.
+func (s *Store[H]) shardHeights(ctx context.Context) error {
+	res, err := s.ds.Query(ctx, query.Query{KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	// the heights are collected first, so the datastore is not modified while being queried
+	var heights []uint64
+	for entry := range res.Next() {
+		if entry.Error != nil {
+			res.Close()
+			return entry.Error
+		}
+		key := datastore.NewKey(entry.Key)
+		if len(key.Namespaces()) != 1 {
+			continue
+		}
+		// the legacy height index shares the root with the headers keyed by hashes,
+		// which never parse as decimal heights
+		height, err := strconv.ParseUint(key.BaseNamespace(), 10, 64)
+		if err != nil {
+			continue
+		}
+		heights = append(heights, height)
+	}
+	res.Close()
+
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	for i, height := range heights {
+		hash, err := s.ds.Get(ctx, legacyHeightKey(height))
+		if err != nil {
+			return err
+		}
+		if err = batch.Put(ctx, heightKey(height), hash); err != nil {
+			return err
+		}
+		if err = batch.Delete(ctx, legacyHeightKey(height)); err != nil {
+			return err
+		}
+		if (i+1)%s.Params.CompactionBatchSize == 0 {
+			if err = batch.Commit(ctx); err != nil {
+				return err
+			}
+			if batch, err = s.ds.Batch(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return batch.Commit(ctx)
+}
+
+// rekeyTimes moves the entries of the time index keyed by timestamps only under the keys
+// of their buckets, timestamps and heights.
+func (s *Store[H]) rekeyTimes(ctx context.Context) error {
+	res, err := s.ds.Query(ctx, query.Query{Prefix: timePrefix.String()})
+	if err != nil {
+		return err
+	}
+	// the entries are collected first, so the datastore is not modified while being queried
+	type entry struct {
+		key    datastore.Key
+		ts     int64
+		height uint64
+	}
+	var entries []entry
+	for e := range res.Next() {
+		if e.Error != nil {
+			res.Close()
+			return e.Error
+		}
+		key := datastore.NewKey(e.Key)
+		if len(key.Namespaces()) != 2 {
+			// already rekeyed
+			continue
+		}
+		ts, err := strconv.ParseInt(key.BaseNamespace(), 10, 64)
+		if err != nil {
+			res.Close()
+			return err
+		}
+		height, err := strconv.ParseUint(string(e.Value), 10, 64)
+		if err != nil {
+			res.Close()
+			return err
+		}
+		entries = append(entries, entry{key: key, ts: ts, height: height})
+	}
+	res.Close()
+
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	for i, e := range entries {
+		if err = batch.Put(ctx, timeKey(time.Unix(0, e.ts), e.height), []byte{}); err != nil {
+			return err
+		}
+		if err = batch.Delete(ctx, e.key); err != nil {
+			return err
+		}
+		if (i+1)%s.Params.CompactionBatchSize == 0 {
+			if err = batch.Commit(ctx); err != nil {
+				return err
+			}
+			if batch, err = s.ds.Batch(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return batch.Commit(ctx)
+}
+
+// shardHeaders moves the headers keyed by their hashes only under the keys of their buckets
+// of heights, indexing their hashes.
+func (s *Store[H]) shardHeaders(ctx context.Context) error {
+	res, err := s.ds.Query(ctx, query.Query{KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	// the hashes are collected first, so the datastore is not modified while being queried
+	var hashes []header.Hash
+	for entry := range res.Next() {
+		if entry.Error != nil {
+			res.Close()
+			return entry.Error
+		}
+		key := datastore.NewKey(entry.Key)
+		if len(key.Namespaces()) != 1 {
+			continue
+		}
+		// the headers share the root with the keys of the Store's state, which never decode
+		// as hex encoded hashes, while the flat height index is sharded by version 3
+		hash, err := hex.DecodeString(key.BaseNamespace())
+		if err != nil {
+			continue
+		}
+		hashes = append(hashes, hash)
+	}
+	res.Close()
+
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	for i, hash := range hashes {
+		b, err := s.ds.Get(ctx, legacyHeaderKey(hash))
+		if err != nil {
+			return err
+		}
+		h, _, err := decodeLegacyHeader[H](b, hash)
+		if err != nil {
+			return err
+		}
+		height := uint64(h.Height())
+		// the values are moved as they are, whatever their format
+		if err = batch.Put(ctx, blockKey(height, hash), b); err != nil {
+			return err
+		}
+		if err = batch.Put(ctx, hashKey(hash), encodeHeight(height)); err != nil {
+			return err
+		}
+		if err = batch.Delete(ctx, legacyHeaderKey(hash)); err != nil {
+			return err
+		}
+		if (i+1)%s.Params.CompactionBatchSize == 0 {
+			if err = batch.Commit(ctx); err != nil {
+				return err
+			}
+			if batch, err = s.ds.Batch(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return batch.Commit(ctx)
+}
+
+// prefixFormats prefixes the stored headers written before the format byte was introduced
+// with the raw format, so the headers are decoded by their format byte only.
+func (s *Store[H]) prefixFormats(ctx context.Context) error {
+	res, err := s.ds.Query(ctx, query.Query{Prefix: blocksPrefix.String(), KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	// the keys are collected first, so the datastore is not modified while being queried
+	var keys []string
+	for entry := range res.Next() {
+		if entry.Error != nil {
+			res.Close()
+			return entry.Error
+		}
+		keys = append(keys, entry.Key)
+	}
+	res.Close()
+
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	prefixed := 0
+	for _, key := range keys {
+		_, hashStr, err := parseBlockKey(key)
+		if err != nil {
+			return err
+		}
+		hash, err := hex.DecodeString(hashStr)
+		if err != nil {
+			return fmt.Errorf("decoding hash of header %s: %w", key, err)
+		}
+		b, err := s.ds.Get(ctx, datastore.NewKey(key))
+		if err != nil {
+			return err
+		}
+		_, ok, err := decodeLegacyHeader[H](b, hash)
+		if err != nil {
+			return fmt.Errorf("decoding header %s: %w", key, err)
+		}
+		if ok {
+			continue
+		}
+		if err = batch.Put(ctx, datastore.NewKey(key), append([]byte{formatRaw}, b...)); err != nil {
+			return err
+		}
+		if prefixed++; prefixed%s.Params.CompactionBatchSize == 0 {
+			if err = batch.Commit(ctx); err != nil {
+				return err
+			}
+			if batch, err = s.ds.Batch(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return batch.Commit(ctx)
+}
+
+// getLegacy reads the header of the given hash keyed by the hash only, as before version 5.
+func (s *Store[H]) getLegacy(ctx context.Context, hash header.Hash) (H, error) {
+	var zero H
+	b, err := s.ds.Get(ctx, legacyHeaderKey(hash))
+	if err != nil {
+		return zero, err
+	}
+	h, _, err := decodeLegacyHeader[H](b, hash)
+	return h, err
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// snapshotMagic identifies snapshots of the Store and the version of their format.
+//
+// A snapshot starts with the magic followed by the big-endian heights of the range [from:to)
+// it covers. Every header of the range follows in ascending order, as the big-endian length
+// and CRC-32C checksum of the marshaled header followed by the marshaled header itself.
+var snapshotMagic = [8]byte{'h', 'd', 'r', 's', 'n', 'a', 'p', 1}
+
+// maxSnapshotRecord limits the size of a single header read from a snapshot.
+const maxSnapshotRecord = 16 << 20
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrCorruptedSnapshot is returned when a snapshot is malformed or fails its checksums.
+var ErrCorruptedSnapshot = errors.New("header/store: corrupted snapshot")
+
+// Export writes the stored headers in the range [from:to) to the given writer as a portable
+// snapshot, which Import seeds other Stores with instead of syncing the range from the network.
+func (s *Store[H]) Export(ctx context.Context, w io.Writer, from, to uint64) error {
+	if from == 0 || from >= to {
+		return fmt.Errorf("header/store: invalid range(%d,%d)", from, to)
+	}
+
+	bw := bufio.NewWriter(w)
+	var prefix [24]byte
+	copy(prefix[:8], snapshotMagic[:])
+	binary.BigEndian.PutUint64(prefix[8:16], from)
+	binary.BigEndian.PutUint64(prefix[16:], to)
+	if _, err := bw.Write(prefix[:]); err != nil {
+		return err
+	}
+
+	// the reads are pipelined with the writes and the reading is stopped once a write fails
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	headers, errs := s.GetRangeStream(ctx, from, to)
+	for h := range headers {
+		if err := writeRecord(bw, h); err != nil {
+			return fmt.Errorf("header/store: exporting headers [%d:%d): %w", from, to, err)
+		}
+	}
+	if err := <-errs; err != nil {
+		return fmt.Errorf("header/store: exporting headers [%d:%d): %w", from, to, err)
+	}
+	return bw.Flush()
+}
+
+// writeRecord writes the header as a record of the snapshot.
+func writeRecord[H header.Header](w io.Writer, h H) error {
+	b, err := h.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	var record [8]byte
+	binary.BigEndian.PutUint32(record[:4], uint32(len(b)))
+	binary.BigEndian.PutUint32(record[4:], crc32.Checksum(b, crcTable))
+	if _, err = w.Write(record[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// Import seeds the given Store with the headers of the snapshot written by Export.
+// If the Store is not initialized yet, it is initialized with the first header of the snapshot,
+// which must have the given trusted hash, as checksums do not authenticate the snapshot.
+// Otherwise, the headers above the current head are appended and the trusted hash is not used.
+// Headers are verified and appended to the Store in batches, so the import is resumable
+// and can be safely rerun after interruptions.
+func Import[H header.Header](ctx context.Context, r io.Reader, store header.Store[H], trusted header.Hash) error {
+	br := bufio.NewReader(r)
+	var prefix [24]byte
+	if _, err := io.ReadFull(br, prefix[:]); err != nil {
+		return fmt.Errorf("%w: reading prefix: %w", ErrCorruptedSnapshot, err)
+	}
+	if [8]byte(prefix[:8]) != snapshotMagic {
+		return fmt.Errorf("%w: unknown format", ErrCorruptedSnapshot)
+	}
+	from, to := binary.BigEndian.Uint64(prefix[8:16]), binary.BigEndian.Uint64(prefix[16:])
+	if from == 0 || from >= to {
+		return fmt.Errorf("%w: invalid range(%d,%d)", ErrCorruptedSnapshot, from, to)
+	}
+
+	head, err := store.Head(ctx)
+	switch {
+	case errors.Is(err, header.ErrNoHead):
+	case err != nil:
+		return err
+	case uint64(head.Height()) < from-1:
+		return fmt.Errorf("header/store: snapshot of [%d:%d) is not adjacent to the head %d",
+			from, to, head.Height())
+	}
+
+	batch := make([]H, 0, header.MaxRangeRequestSize)
+	for height := from; height < to; height++ {
+		h, err := readSnapshotRecord[H](br)
+		if err != nil {
+			return fmt.Errorf("reading header %d: %w", height, err)
+		}
+		if uint64(h.Height()) != height {
+			return fmt.Errorf("%w: header %d is at height %d", ErrCorruptedSnapshot, height, h.Height())
+		}
+
+		switch {
+		case head.IsZero():
+			if h.Hash().String() != trusted.String() {
+				return fmt.Errorf("header/store: first header %d of snapshot %s does not match the trusted %s",
+					height, h.Hash(), trusted)
+			}
+			if err = store.Init(ctx, h); err != nil {
+				return err
+			}
+			head = h
+			continue
+		case h.Height() <= head.Height():
+			// already stored
+			continue
+		}
+
+		batch = append(batch, h)
+		if uint64(len(batch)) == header.MaxRangeRequestSize || height == to-1 {
+			// Append verifies the headers against the current head
+			if err = store.Append(ctx, batch...); err != nil {
+				return fmt.Errorf("header/store: importing headers [%d:%d]: %w",
+					batch[0].Height(), batch[len(batch)-1].Height(), err)
+			}
+			log.Infow("imported headers", "to", height, "snapshot_to", to-1)
+			batch = batch[:0]
+		}
+	}
+	return nil
+}
+
+// readSnapshotRecord reads the next header from the snapshot, verifying its checksum.
+func readSnapshotRecord[H header.Header](r io.Reader) (H, error) {
+	var zero H
+	var record [8]byte
+	if _, err := io.ReadFull(r, record[:]); err != nil {
+		return zero, fmt.Errorf("%w: %w", ErrCorruptedSnapshot, err)
+	}
+	size, checksum := binary.BigEndian.Uint32(record[:4]), binary.BigEndian.Uint32(record[4:])
+	if size > maxSnapshotRecord {
+		return zero, fmt.Errorf("%w: header of %d bytes", ErrCorruptedSnapshot, size)
+	}
+	b := make([]byte, size)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return zero, fmt.Errorf("%w: %w", ErrCorruptedSnapshot, err)
+	}
+	if crc32.Checksum(b, crcTable) != checksum {
+		return zero, fmt.Errorf("%w: checksum mismatch", ErrCorruptedSnapshot)
+	}
+	return header.Unmarshal[H](b)
+}
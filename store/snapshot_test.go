@@ -0,0 +1,62 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header/headertest"
+)
+
+func TestSnapshot(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	source := NewTestStore(ctx, t, suite.Head()).(*Store[*headertest.DummyHeader])
+	in := suite.GenDummyHeaders(20)
+	require.NoError(t, source.Append(ctx, in...))
+	require.Eventually(t, func() bool {
+		return source.Height() == 21
+	}, time.Second, time.Millisecond*10)
+
+	var buf bytes.Buffer
+	require.NoError(t, source.Export(ctx, &buf, 5, 22))
+	snapshot := buf.Bytes()
+
+	// the uninitialized store trusts the first header of the snapshot
+	store, err := NewStore[*headertest.DummyHeader](sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+	// which must be the trusted one
+	err = Import[*headertest.DummyHeader](ctx, bytes.NewReader(snapshot), store, in[4].Hash())
+	require.Error(t, err)
+	assert.False(t, store.HasAt(ctx, 5))
+	require.NoError(t, Import[*headertest.DummyHeader](ctx, bytes.NewReader(snapshot), store, in[3].Hash()))
+	require.Eventually(t, func() bool {
+		return store.Height() == 21
+	}, time.Second, time.Millisecond*10)
+	head, err := store.Head(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, in[19].Hash(), head.Hash())
+	assert.False(t, store.HasAt(ctx, 4))
+
+	// the import is resumable
+	require.NoError(t, Import[*headertest.DummyHeader](ctx, bytes.NewReader(snapshot), store, nil))
+
+	corrupted := bytes.Clone(snapshot)
+	corrupted[len(corrupted)-1] ^= 0xff
+	fresh, err := NewStore[*headertest.DummyHeader](sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	err = Import[*headertest.DummyHeader](ctx, bytes.NewReader(corrupted), fresh, in[3].Hash())
+	assert.ErrorIs(t, err, ErrCorruptedSnapshot)
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+)
+
+// StoreStats describes the headers kept by the Store.
+type StoreStats struct {
+	// HeadHeight is the height of the head.
+	HeadHeight uint64
+	// TailHeight is the height of the lowest header of the contiguous chain up to the head.
+	TailHeight uint64
+	// Stored is the total amount of stored headers, including the ones pending to be written
+	// and the ranges stored below the tail.
+	Stored uint64
+	// Pending is the amount of headers pending to be written on disk.
+	Pending int
+	// Gaps are the missing ranges between the ranges stored below the tail and the tail.
+	// See MissingRanges.
+	Gaps []Range
+}
+
+// Stats returns the statistics of the Store, e.g. for health endpoints and debugging.
+func (s *Store[H]) Stats(ctx context.Context) (StoreStats, error) {
+	// ensures the store is initialized and its tail is known
+	tail, err := s.Tail(ctx)
+	if err != nil {
+		return StoreStats{}, err
+	}
+	head := s.Height()
+	stats := StoreStats{
+		HeadHeight: head,
+		TailHeight: uint64(tail.Height()),
+		Pending:    s.pending.Len(),
+		Gaps:       s.MissingRanges(),
+	}
+	if stats.TailHeight <= head {
+		stats.Stored = head - stats.TailHeight + 1
+	}
+	s.rangesLk.RLock()
+	for _, r := range s.ranges {
+		stats.Stored += r.To - r.From
+	}
+	s.rangesLk.RUnlock()
+	return stats, nil
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/ipfs/go-datastore"
 	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
 	logging "github.com/ipfs/go-log/v2"
 
 	"github.com/celestiaorg/go-header"
@@ -27,8 +31,12 @@ type Store[H header.Header] struct {
 	//
 	// underlying KV store
 	ds datastore.Batching
-	// adaptive replacement cache of headers
-	cache *lru.ARCCache
+	// cache of headers, adaptive replacement one unless provided with Parameters.Cache
+	cache Cache
+	// cacheID keeps the entries of the Store apart from the ones of other Stores sharing the cache
+	cacheID uint64
+	// recent keeps the most recent heads apart from the cache
+	recent *recentHeads[H]
 
 	// header heights management
 	//
@@ -37,11 +45,14 @@ type Store[H header.Header] struct {
 	// manages current store read head height (1) and
 	// allows callers to wait until header for a height is stored (2)
 	heightSub *heightSub[H]
+	// initDn is closed once the Store is initialized, either with Init or with the stored head
+	initDn   chan struct{}
+	initOnce sync.Once
 
 	// writing to datastore
 	//
 	// queue of headers to be written
-	writes chan []H
+	writes chan write[H]
 	// signals when writes are finished
 	writesDn chan struct{}
 	// writeHead maintains the current write head
@@ -49,12 +60,50 @@ type Store[H header.Header] struct {
 	// pending keeps headers pending to be written in one batch
 	pending *batch[H]
 
+	// removal of pruned headers and their indexes
+	//
+	// compactions keeps ranges awaiting removal
+	compactionLk sync.Mutex
+	compactions  []Range
+	// compacting is the range being removed, if any
+	compacting Range
+	// signals about newly scheduled compactions
+	compactionSignal chan struct{}
+	// signals to stop compacting and when compaction is stopped
+	compactionQuit, compactionDn chan struct{}
+
+	// pruning of headers outside the retention window
+	//
+	// pruneLk serializes pruning
+	pruneLk sync.Mutex
+	// tailHeight is the lowest height retained, zero if unknown
+	tailHeight atomic.Uint64
+	// signals when the pruning loop is stopped
+	pruningDn chan struct{}
+	// ranges are the sorted ranges stored below the tail
+	rangesLk sync.RWMutex
+	ranges   []Range
+	// pins counts the snapshots retaining the headers from the heights from compaction
+	pinsLk sync.Mutex
+	pins   map[uint64]int
+
+	// hooks called with every persisted header and every removed range
+	hooksLk     sync.RWMutex
+	hooks       []AppendHook[H]
+	removeHooks []RemoveHook
+
+	metrics *metrics
+
 	Params Parameters
 }
 
 // NewStore constructs a Store over datastore.
 // The datastore must have a head there otherwise Start will error.
 // For first initialization of Store use NewStoreWithHead.
+//
+// The Store is agnostic of the datastore implementation, so tuning its options
+// (e.g. table size, compression or value threshold of badger) is up to the caller.
+// BenchmarkStore_Append and BenchmarkStore_GetByHeight can be used to measure them.
 func NewStore[H header.Header](ds datastore.Batching, opts ...Option) (*Store[H], error) {
 	return newStore[H](ds, opts...)
 }
@@ -84,9 +133,13 @@ func newStore[H header.Header](ds datastore.Batching, opts ...Option) (*Store[H]
 		return nil, fmt.Errorf("header/store: store creation failed: %w", err)
 	}
 
-	cache, err := lru.NewARC(params.StoreCacheSize)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create index cache: %w", err)
+	var cache Cache = params.Cache
+	if cache == nil {
+		arc, err := lru.NewARC(params.StoreCacheSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create index cache: %w", err)
+		}
+		cache = arc
 	}
 
 	wrappedStore := namespace.Wrap(ds, storePrefix)
@@ -95,16 +148,31 @@ func newStore[H header.Header](ds datastore.Batching, opts ...Option) (*Store[H]
 		return nil, fmt.Errorf("failed to create height indexer: %w", err)
 	}
 
-	return &Store[H]{
+	store := &Store[H]{
 		Params:      params,
 		ds:          wrappedStore,
 		heightSub:   newHeightSub[H](),
-		writes:      make(chan []H, 16),
+		initDn:      make(chan struct{}),
+		pins:        make(map[uint64]int),
+		writes:      make(chan write[H], 16),
 		writesDn:    make(chan struct{}),
 		cache:       cache,
+		cacheID:     storeIDs.Add(1),
+		recent:      newRecentHeads[H](params.RecentHeadsCacheSize),
 		heightIndex: index,
 		pending:     newBatch[H](params.WriteBatchSize),
-	}, nil
+
+		compactionSignal: make(chan struct{}, 1),
+		compactionQuit:   make(chan struct{}),
+		compactionDn:     make(chan struct{}),
+		pruningDn:        make(chan struct{}),
+	}
+	if params.metrics {
+		if err = store.InitMetrics(); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
 }
 
 func (s *Store[H]) Init(ctx context.Context, initial H) error {
@@ -116,14 +184,51 @@ func (s *Store[H]) Init(ctx context.Context, initial H) error {
 	if err != nil {
 		return err
 	}
+	// nothing is stored below the initial header
+	height := uint64(initial.Height())
+	if err = s.ds.Put(ctx, tailKey, encodeHeight(height)); err != nil {
+		return err
+	}
+	s.tailHeight.Store(height)
+	// new datastores are laid out with the current schema
+	if err = s.ds.Put(ctx, versionKey, encodeHeight(schemaVersion)); err != nil {
+		return err
+	}
+
+	s.callHooks(ctx, initial)
 
 	log.Infow("initialized head", "height", initial.Height(), "hash", initial.Hash())
+	// the initial header is not necessarily the genesis one, e.g. when imported from a snapshot
+	s.recent.add(initial)
+	s.heightSub.SetHeight(height - 1)
 	s.heightSub.Pub(initial)
+	s.markInit()
 	return nil
 }
 
-func (s *Store[H]) Start(context.Context) error {
+func (s *Store[H]) Start(ctx context.Context) error {
+	if err := s.migrateSchema(ctx); err != nil {
+		return fmt.Errorf("header/store: migrating schema: %w", err)
+	}
+	if err := s.expireHead(ctx); err != nil {
+		return fmt.Errorf("header/store: expiring head: %w", err)
+	}
+	if err := s.loadTail(ctx); err != nil {
+		return fmt.Errorf("header/store: loading tail: %w", err)
+	}
+	if err := s.loadRanges(ctx); err != nil {
+		return fmt.Errorf("header/store: loading ranges: %w", err)
+	}
+	if err := s.replayWrites(ctx); err != nil {
+		return fmt.Errorf("header/store: replaying write-ahead log: %w", err)
+	}
+	// the head stored by previous runs makes the Store usable right away
+	if _, err := s.Head(ctx); err != nil && !errors.Is(err, header.ErrNoHead) {
+		return fmt.Errorf("header/store: loading head: %w", err)
+	}
 	go s.flushLoop()
+	go s.compactionLoop()
+	go s.pruningLoop()
 	return nil
 }
 
@@ -134,15 +239,26 @@ func (s *Store[H]) Stop(ctx context.Context) error {
 	default:
 	}
 	// signal to prevent further writes to Store
-	s.writes <- nil
+	s.writes <- write[H]{}
 	select {
 	case <-s.writesDn: // wait till it is done writing
 	case <-ctx.Done():
 		return ctx.Err()
 	}
+	// stop pruning and compaction of pruned headers
+	close(s.compactionQuit)
+	for _, done := range []chan struct{}{s.compactionDn, s.pruningDn} {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
 
-	// cleanup caches
-	s.cache.Purge()
+	// cleanup caches, except the provided one, which may be shared
+	if arc, ok := s.cache.(*lru.ARCCache); ok && s.Params.Cache == nil {
+		arc.Purge()
+	}
 	s.heightIndex.cache.Purge()
 	return nil
 }
@@ -151,7 +267,13 @@ func (s *Store[H]) Height() uint64 {
 	return s.heightSub.Height()
 }
 
-func (s *Store[H]) Head(ctx context.Context) (H, error) {
+// HeadUpdates returns the channel closed once the head of the Store changes after the call,
+// so readers, e.g. head subscriptions of the exchange server, learn about new heads without polling.
+func (s *Store[H]) HeadUpdates() <-chan struct{} {
+	return s.heightSub.Updated()
+}
+
+func (s *Store[H]) Head(ctx context.Context, _ ...header.CallOption) (H, error) {
 	head, err := s.GetByHeight(ctx, s.heightSub.Height())
 	if err == nil {
 		return head, nil
@@ -166,46 +288,195 @@ func (s *Store[H]) Head(ctx context.Context) (H, error) {
 		return zero, header.ErrNoHead
 	case nil:
 		s.heightSub.SetHeight(uint64(head.Height()))
+		s.markInit()
 		log.Infow("loaded head", "height", head.Height(), "hash", head.Hash())
 		return head, nil
 	}
 }
 
-func (s *Store[H]) Get(ctx context.Context, hash header.Hash) (H, error) {
+func (s *Store[H]) Get(ctx context.Context, hash header.Hash, _ ...header.CallOption) (H, error) {
 	var zero H
-	if v, ok := s.cache.Get(hash.String()); ok {
-		return v.(H), nil
+	h, err := s.get(ctx, hash)
+	if err != nil {
+		return zero, err
 	}
+	// the caches may still keep the headers being pruned
+	if s.pruned(uint64(h.Height())) {
+		return zero, header.ErrNotFound
+	}
+	return h, nil
+}
+
+// get returns the header of the given hash regardless of whether it is pruned.
+func (s *Store[H]) get(ctx context.Context, hash header.Hash) (H, error) {
+	if h, ok := s.recent.get(hash); ok {
+		return h, nil
+	}
+	if h, ok := s.cached(hash); ok {
+		s.metrics.cacheRead(ctx, true)
+		return h, nil
+	}
+	s.metrics.cacheRead(ctx, false)
 	// check if the requested header is not yet written on disk
 	if h := s.pending.Get(hash); !h.IsZero() {
 		return h, nil
 	}
 
-	b, err := s.ds.Get(ctx, datastore.NewKey(hash.String()))
+	return s.readHeader(ctx, s.ds, hash)
+}
+
+// GetMany returns the Headers corresponding to the given hashes in the same order.
+// Headers missing in the cache are read from the datastore in a single read-only transaction,
+// if the datastore supports them. All the Headers must exist, otherwise header.ErrNotFound is returned.
+func (s *Store[H]) GetMany(ctx context.Context, hashes []header.Hash) ([]H, error) {
+	headers := make([]H, len(hashes))
+	missing := make([]int, 0, len(hashes))
+	for i, hash := range hashes {
+		if h, ok := s.cached(hash); ok {
+			s.metrics.cacheRead(ctx, true)
+			headers[i] = h
+			continue
+		}
+		s.metrics.cacheRead(ctx, false)
+		// check if the requested header is not yet written on disk
+		if h := s.pending.Get(hash); !h.IsZero() {
+			headers[i] = h
+			continue
+		}
+		missing = append(missing, i)
+	}
+	if len(missing) == 0 {
+		return headers, nil
+	}
+
+	var r datastore.Read = s.ds
+	if txnDs, ok := s.ds.(datastore.TxnDatastore); ok {
+		txn, err := txnDs.NewTransaction(ctx, true)
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Discard(ctx)
+		r = txn
+	}
+	for _, i := range missing {
+		h, err := s.readHeader(ctx, r, hashes[i])
+		if err != nil {
+			return nil, err
+		}
+		headers[i] = h
+	}
+	for _, h := range headers {
+		if s.pruned(uint64(h.Height())) {
+			return nil, header.ErrNotFound
+		}
+	}
+	return headers, nil
+}
+
+// getRange returns the headers of the given hashes of the contiguous heights starting at from.
+// Headers missing in the cache are read with a prefix scan of every bucket of the range,
+// skipping the headers of forks. All the Headers must exist, otherwise header.ErrNotFound is returned.
+func (s *Store[H]) getRange(ctx context.Context, from uint64, hashes []header.Hash) ([]H, error) {
+	headers := make([]H, len(hashes))
+	missing := 0
+	for i, hash := range hashes {
+		if h, ok := s.cached(hash); ok {
+			s.metrics.cacheRead(ctx, true)
+			headers[i] = h
+			continue
+		}
+		s.metrics.cacheRead(ctx, false)
+		missing++
+	}
+	if missing == 0 {
+		return headers, nil
+	}
+
+	to := from + uint64(len(hashes))
+	for bucket := from - from%heightBucketSize; bucket < to; bucket += heightBucketSize {
+		res, err := s.ds.Query(ctx, query.Query{
+			Prefix: blockBucketKey(bucket).String(),
+			Filters: []query.Filter{
+				query.FilterKeyCompare{Op: query.GreaterThanOrEqual, Key: blockKey(from, nil).String()},
+				query.FilterKeyCompare{Op: query.LessThan, Key: blockKey(to, nil).String()},
+			},
+			Orders: []query.Order{query.OrderByKey{}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for entry := range res.Next() {
+			if entry.Error != nil {
+				res.Close()
+				return nil, entry.Error
+			}
+			height, hash, err := parseBlockKey(entry.Key)
+			if err != nil {
+				res.Close()
+				return nil, err
+			}
+			i := height - from
+			if !headers[i].IsZero() || hash != hashes[i].String() {
+				continue
+			}
+			h, err := decodeHeader[H](entry.Value)
+			if err != nil {
+				res.Close()
+				return nil, err
+			}
+			s.cache.Add(s.cacheKey(hash), h)
+			headers[i] = h
+		}
+		res.Close()
+	}
+	for _, h := range headers {
+		if h.IsZero() {
+			return nil, header.ErrNotFound
+		}
+	}
+	return headers, nil
+}
+
+// readHeader reads the stored header of the given hash, locating its height with the hash index.
+func (s *Store[H]) readHeader(ctx context.Context, r datastore.Read, hash header.Hash) (H, error) {
+	var zero H
+	height, err := readHeight(ctx, r, hashKey(hash))
 	if err != nil {
-		if err == datastore.ErrNotFound {
+		if errors.Is(err, datastore.ErrNotFound) {
 			return zero, header.ErrNotFound
 		}
-
 		return zero, err
 	}
+	return s.readHeaderAt(ctx, r, height, hash)
+}
 
-	var empty H
-	h := empty.New()
-	err = h.UnmarshalBinary(b)
+// readHeaderAt reads the stored header of the given height and hash.
+func (s *Store[H]) readHeaderAt(ctx context.Context, r datastore.Read, height uint64, hash header.Hash) (H, error) {
+	var zero H
+	b, err := r.Get(ctx, blockKey(height, hash))
 	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return zero, header.ErrNotFound
+		}
 		return zero, err
 	}
 
-	s.cache.Add(h.Hash().String(), h)
-	return h.(H), nil
+	h, err := decodeHeader[H](b)
+	if err != nil {
+		return zero, err
+	}
+	s.cache.Add(s.cacheKey(h.Hash().String()), h)
+	return h, nil
 }
 
-func (s *Store[H]) GetByHeight(ctx context.Context, height uint64) (H, error) {
+func (s *Store[H]) GetByHeight(ctx context.Context, height uint64, _ ...header.CallOption) (H, error) {
 	var zero H
 	if height == 0 {
 		return zero, fmt.Errorf("header/store: height must be bigger than zero")
 	}
+	if s.pruned(height) {
+		return zero, header.ErrNotFound
+	}
 	// if the requested 'height' was not yet published
 	// we subscribe to it
 	h, err := s.heightSub.Sub(ctx, height)
@@ -215,10 +486,16 @@ func (s *Store[H]) GetByHeight(ctx context.Context, height uint64) (H, error) {
 	// otherwise, the errElapsedHeight is thrown,
 	// which means the requested 'height' should be present
 	//
+	if h, ok := s.recent.getByHeight(height); ok {
+		return h, nil
+	}
 	// check if the requested header is not yet written on disk
 	if h := s.pending.GetByHeight(height); !h.IsZero() {
 		return h, nil
 	}
+	if s.Params.PrefetchWindow > 0 && !s.heightIndex.cache.Contains(height) {
+		s.prefetch(ctx, height)
+	}
 
 	hash, err := s.heightIndex.HashByHeight(ctx, height)
 	if err != nil {
@@ -229,26 +506,52 @@ func (s *Store[H]) GetByHeight(ctx context.Context, height uint64) (H, error) {
 		return zero, err
 	}
 
-	return s.Get(ctx, hash)
+	// the height is known, so the hash index is not needed to locate the header
+	h, ok := s.cached(hash)
+	s.metrics.cacheRead(ctx, ok)
+	if !ok {
+		h, err = s.readHeaderAt(ctx, s.ds, height, hash)
+		if errors.Is(err, header.ErrNotFound) {
+			// the header may be stored at another height, which is checked below
+			h, err = s.readHeader(ctx, s.ds, hash)
+		}
+		if err != nil {
+			return zero, err
+		}
+	}
+	if uint64(h.Height()) != height {
+		s.invariant("height index mismatch", "height", height, "hash", hash, "indexed_height", h.Height())
+		return zero, fmt.Errorf("header/store: height index mismatch: %d is indexed as %d", h.Height(), height)
+	}
+	return h, nil
 }
 
-func (s *Store[H]) GetRangeByHeight(ctx context.Context, from, to uint64) ([]H, error) {
-	h, err := s.GetByHeight(ctx, to-1)
-	if err != nil {
+func (s *Store[H]) GetRangeByHeight(ctx context.Context, from, to uint64, _ ...header.CallOption) ([]H, error) {
+	if s.pruned(from) {
+		return nil, header.ErrNotFound
+	}
+	// waits for the end of the range, if it is not published yet
+	if _, err := s.GetByHeight(ctx, to-1); err != nil {
 		return nil, err
 	}
 
-	ln := to - from
-	headers := make([]H, ln)
-	for i := ln - 1; i > 0; i-- {
-		headers[i] = h
-		h, err = s.Get(ctx, h.LastHeader())
+	// the range is read from the height index with prefix scans of its buckets
+	hashes, err := s.heightIndex.HashesByRange(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	headers, err := s.getRange(ctx, from, hashes)
+	if err != nil {
+		return nil, err
+	}
+	// the rest of the range is either pending to be written or missing
+	for height := from + uint64(len(headers)); height < to; height++ {
+		h, err := s.GetByHeight(ctx, height)
 		if err != nil {
 			return nil, err
 		}
+		headers = append(headers, h)
 	}
-	headers[0] = h
-
 	return headers, nil
 }
 
@@ -256,6 +559,7 @@ func (s *Store[H]) GetVerifiedRange(
 	ctx context.Context,
 	from H,
 	to uint64,
+	_ ...header.CallOption,
 ) ([]H, error) {
 	if uint64(from.Height()) >= to {
 		return nil, fmt.Errorf("header/store: invalid range(%d,%d)", from.Height(), to)
@@ -266,7 +570,7 @@ func (s *Store[H]) GetVerifiedRange(
 	}
 
 	for _, h := range headers {
-		err := from.Verify(h)
+		err := header.Verify(from, h)
 		if err != nil {
 			return nil, err
 		}
@@ -276,19 +580,26 @@ func (s *Store[H]) GetVerifiedRange(
 }
 
 func (s *Store[H]) Has(ctx context.Context, hash header.Hash) (bool, error) {
-	if ok := s.cache.Contains(hash.String()); ok {
-		return ok, nil
+	if h, ok := s.cached(hash); ok {
+		return !s.pruned(uint64(h.Height())), nil
 	}
 	// check if the requested header is not yet written on disk
-	if ok := s.pending.Has(hash); ok {
-		return ok, nil
+	if h := s.pending.Get(hash); !h.IsZero() {
+		return !s.pruned(uint64(h.Height())), nil
 	}
 
-	return s.ds.Has(ctx, datastore.NewKey(hash.String()))
+	height, err := readHeight(ctx, s.ds, hashKey(hash))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return !s.pruned(height), nil
 }
 
 func (s *Store[H]) HasAt(_ context.Context, height uint64) bool {
-	return height != uint64(0) && s.Height() >= height
+	return height != uint64(0) && s.Height() >= height && !s.pruned(height)
 }
 
 func (s *Store[H]) Append(ctx context.Context, headers ...H) error {
@@ -322,7 +633,7 @@ func (s *Store[H]) Append(ctx context.Context, headers ...H) error {
 			}
 		}
 
-		err = head.Verify(h)
+		err = header.Verify(head, h)
 		if err != nil {
 			var verErr *header.VerifyError
 			if errors.As(err, &verErr) {
@@ -344,9 +655,13 @@ func (s *Store[H]) Append(ctx context.Context, headers ...H) error {
 		verified, head = append(verified, h), h
 	}
 
+	// log headers, so they are not lost until written on disk
+	if logErr := s.logWrites(ctx, verified...); logErr != nil {
+		return fmt.Errorf("header/store: logging headers: %w", logErr)
+	}
 	// queue headers to be written on disk
 	select {
-	case s.writes <- verified:
+	case s.writes <- write[H]{headers: verified}:
 		ln := len(verified)
 		s.writeHead.Store(&verified[ln-1])
 		wh := *s.writeHead.Load()
@@ -361,6 +676,20 @@ func (s *Store[H]) Append(ctx context.Context, headers ...H) error {
 	}
 }
 
+// write is a request to write the given headers on disk.
+type write[H header.Header] struct {
+	headers []H
+	// flushed, if set, requests the pending headers to be flushed right away
+	// and receives the result
+	flushed chan error
+	// rollback, if set, requests the head to be rolled back below the height instead
+	rollback uint64
+	// canonical, if set, requests the branch ending with the header of the hash to become canonical
+	canonical header.Hash
+	// commit, if set, requests the headers to be written right away and published only once written
+	commit bool
+}
+
 // flushLoop performs writing task to the underlying datastore in a separate routine
 // This way writes are controlled and manageable from one place allowing
 // (1) Appends not to be blocked on long disk IO writes and underlying DB compactions
@@ -368,26 +697,57 @@ func (s *Store[H]) Append(ctx context.Context, headers ...H) error {
 func (s *Store[H]) flushLoop() {
 	defer close(s.writesDn)
 	ctx := context.Background()
-	for headers := range s.writes {
+	for w := range s.writes {
+		if w.rollback != 0 {
+			w.flushed <- s.rollback(ctx, w.rollback)
+			continue
+		}
+		if w.canonical != nil {
+			w.flushed <- s.reorg(ctx, w.canonical)
+			continue
+		}
+		if w.commit {
+			w.flushed <- s.commitBatch(ctx, w.headers)
+			continue
+		}
+		headers := w.headers
+		if s.Params.Strict && len(headers) > 0 {
+			// non-strict mode relies on the check within heightSub.Pub
+			height, from := s.heightSub.Height(), uint64(headers[0].Height())
+			switch {
+			case from <= height:
+				s.invariant("headers are appended twice", "height", height, "from", from)
+			case from != height+1:
+				s.invariant("non-contiguous headers are published", "height", height, "from", from)
+			}
+		}
 		// add headers to the pending and ensure they are accessible
 		s.pending.Append(headers...)
+		s.recent.add(headers...)
 		// and notify waiters if any + increase current read head height
 		// it is important to do Pub after updating pending
 		// so pending is consistent with atomic Height counter on the heightSub
 		s.heightSub.Pub(headers...)
 		// don't flush and continue if pending batch is not grown enough,
-		// and Store is not stopping(headers == nil)
-		if s.pending.Len() < s.Params.WriteBatchSize && headers != nil {
+		// the flush is not requested and Store is not stopping(headers == nil)
+		if s.pending.Len() < s.Params.WriteBatchSize && w.flushed == nil && headers != nil {
 			continue
 		}
 
-		err := s.flush(ctx, s.pending.GetAll()...)
+		pending := s.pending.GetAll()
+		start := time.Now()
+		err := s.flush(ctx, pending...)
+		s.metrics.flushed(ctx, len(pending), time.Since(start), err)
+		if w.flushed != nil {
+			w.flushed <- err
+		}
 		if err != nil {
 			// TODO(@Wondertan): Should this be a fatal error case with os.Exit?
 			from, to := uint64(headers[0].Height()), uint64(headers[len(headers)-1].Height())
 			log.Errorw("writing header batch", "from", from, "to", to)
 			continue
 		}
+		s.callHooks(ctx, pending...)
 		// reset pending
 		s.pending.Reset()
 
@@ -410,17 +770,9 @@ func (s *Store[H]) flush(ctx context.Context, headers ...H) error {
 		return err
 	}
 
-	// collect all the headers in the batch to be written
-	for _, h := range headers {
-		b, err := h.MarshalBinary()
-		if err != nil {
-			return err
-		}
-
-		err = batch.Put(ctx, headerKey(h), b)
-		if err != nil {
-			return err
-		}
+	err = s.putHeaders(ctx, batch, headers...)
+	if err != nil {
+		return err
 	}
 
 	// marshal and add to batch reference to the new head
@@ -434,8 +786,8 @@ func (s *Store[H]) flush(ctx context.Context, headers ...H) error {
 		return err
 	}
 
-	// write height indexes for headers as well
-	err = s.heightIndex.IndexTo(ctx, batch, headers...)
+	// written headers are not needed in the write-ahead log anymore
+	err = s.unlogWrites(ctx, batch, headers...)
 	if err != nil {
 		return err
 	}
@@ -444,6 +796,93 @@ func (s *Store[H]) flush(ctx context.Context, headers ...H) error {
 	return batch.Commit(ctx)
 }
 
+// putHeaders adds the given headers with their height and time indexes to the batch.
+func (s *Store[H]) putHeaders(ctx context.Context, batch datastore.Batch, headers ...H) error {
+	// collect all the headers in the batch to be written
+	for _, h := range headers {
+		if err := s.putHeader(ctx, batch, h); err != nil {
+			return err
+		}
+	}
+
+	// write height indexes for headers as well
+	err := s.heightIndex.IndexTo(ctx, batch, headers...)
+	if err != nil {
+		return err
+	}
+	return indexTime(ctx, batch, headers...)
+}
+
+// putHeader adds the given header with its hash index entry to the batch.
+func (s *Store[H]) putHeader(ctx context.Context, batch datastore.Batch, h H) error {
+	b, err := s.encodeHeader(h)
+	if err != nil {
+		return err
+	}
+	if err = batch.Put(ctx, headerKey(h), b); err != nil {
+		return err
+	}
+	return batch.Put(ctx, hashKey(h.Hash()), encodeHeight(uint64(h.Height())))
+}
+
+// wipe removes all the headers and indexes from the datastore.
+func (s *Store[H]) wipe(ctx context.Context) error {
+	res, err := s.ds.Query(ctx, query.Query{KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	for entry := range res.Next() {
+		if entry.Error != nil {
+			return entry.Error
+		}
+		key := datastore.NewKey(entry.Key)
+		if err = batch.Delete(ctx, key); err != nil {
+			return err
+		}
+		// the keys of headers end with their hashes, so the cache of wiped ones is evicted one by one,
+		// as the provided cache may be shared
+		s.cache.Remove(s.cacheKey(key.BaseNamespace()))
+	}
+	if err = batch.Commit(ctx); err != nil {
+		return err
+	}
+
+	s.heightIndex.cache.Purge()
+	s.recent.remove(0, math.MaxUint64)
+	s.tailHeight.Store(0)
+	s.rangesLk.Lock()
+	s.ranges = nil
+	s.rangesLk.Unlock()
+	return nil
+}
+
+// expireHead wipes the Store, if the stored head is older than Parameters.HeadTTL.
+// It is checked once on Start, so a live Store is never wiped.
+func (s *Store[H]) expireHead(ctx context.Context) error {
+	if s.Params.HeadTTL == 0 {
+		return nil
+	}
+	head, err := s.readHead(ctx)
+	switch {
+	case errors.Is(err, datastore.ErrNotFound), errors.Is(err, header.ErrNotFound):
+		return nil
+	case err != nil:
+		return err
+	case time.Since(head.Time()) <= s.Params.HeadTTL:
+		return nil
+	}
+
+	log.Warnw("stored head is older than TTL, wiping the store",
+		"height", head.Height(), "time", head.Time(), "ttl", s.Params.HeadTTL)
+	return s.wipe(ctx)
+}
+
 // readHead loads the head from the datastore.
 func (s *Store[H]) readHead(ctx context.Context) (H, error) {
 	var zero H
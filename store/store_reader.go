@@ -0,0 +1,215 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// StoreReader is a read-only view of the Store.
+type StoreReader[H header.Header] interface {
+	// Head returns the head of the view.
+	Head(context.Context) (H, error)
+	// Tail returns the lowest header of the contiguous chain of the view.
+	Tail(context.Context) (H, error)
+	// Height returns the height of the head of the view.
+	Height() uint64
+	// Ranges returns the ranges stored below the tail of the view.
+	Ranges() []Range
+	// Get returns the header of the given hash within the view.
+	Get(context.Context, header.Hash) (H, error)
+	// GetByHeight returns the header of the given height within the view.
+	GetByHeight(context.Context, uint64) (H, error)
+	// GetRangeByHeight returns the headers in the range [from:to) within the view.
+	GetRangeByHeight(ctx context.Context, from, to uint64) ([]H, error)
+	// HasAt reports whether the header of the given height is within the view.
+	HasAt(context.Context, uint64) bool
+	// Release releases the view, so the headers it retains can be compacted.
+	Release()
+}
+
+// snapshot is a point-in-time StoreReader over the Store.
+type snapshot[H header.Header] struct {
+	store  *Store[H]
+	head   uint64
+	tail   uint64
+	ranges []Range
+	// pin is the lowest height retained from compaction for the snapshot
+	pin     uint64
+	release sync.Once
+}
+
+// Snapshot returns a point-in-time view of the Store, fixing its head, tail and ranges, for
+// multi-step reads, e.g. building an export or responding to a paginated request.
+// Headers appended afterward are not visible within the view, and headers pruned afterward
+// remain readable, as their removal from disk is postponed until the view is released.
+// Removals with DeleteRange, either of the head, the tail or the middle of the chain,
+// and reorgs with SetCanonical are not postponed, so the headers they remove are not readable
+// within the view anymore.
+//
+// The view must be released once done with Release.
+func (s *Store[H]) Snapshot() StoreReader[H] {
+	s.pruneLk.Lock()
+	defer s.pruneLk.Unlock()
+
+	tail := s.tailHeight.Load()
+	if tail == 0 {
+		// unknown tail, nothing is pruned yet
+		tail = 1
+	}
+	s.rangesLk.RLock()
+	ranges := append([]Range(nil), s.ranges...)
+	s.rangesLk.RUnlock()
+
+	snap := &snapshot[H]{
+		store:  s,
+		head:   s.Height(),
+		tail:   tail,
+		ranges: ranges,
+		pin:    tail,
+	}
+	if len(ranges) > 0 {
+		snap.pin = ranges[0].From
+	}
+	s.pinsLk.Lock()
+	s.pins[snap.pin]++
+	s.pinsLk.Unlock()
+	return snap
+}
+
+func (v *snapshot[H]) Head(ctx context.Context) (H, error) {
+	if v.head == 0 {
+		var zero H
+		return zero, header.ErrNoHead
+	}
+	return v.GetByHeight(ctx, v.head)
+}
+
+func (v *snapshot[H]) Tail(ctx context.Context) (H, error) {
+	if v.head == 0 {
+		var zero H
+		return zero, header.ErrNoHead
+	}
+	return v.GetByHeight(ctx, v.tail)
+}
+
+func (v *snapshot[H]) Height() uint64 {
+	return v.head
+}
+
+func (v *snapshot[H]) Ranges() []Range {
+	return append([]Range(nil), v.ranges...)
+}
+
+func (v *snapshot[H]) Get(ctx context.Context, hash header.Hash) (H, error) {
+	var zero H
+	h, err := v.store.get(ctx, hash)
+	if err != nil {
+		return h, err
+	}
+	height := uint64(h.Height())
+	if !v.HasAt(ctx, height) {
+		return zero, header.ErrNotFound
+	}
+	// headers of forks share the heights of the view, but are not within it
+	if v.store.pending.Has(hash) {
+		return h, nil
+	}
+	canonical, err := v.store.heightIndex.HashByHeight(ctx, height)
+	switch {
+	case errors.Is(err, datastore.ErrNotFound):
+		return zero, header.ErrNotFound
+	case err != nil:
+		return zero, err
+	case canonical.String() != hash.String():
+		return zero, header.ErrNotFound
+	}
+	return h, nil
+}
+
+func (v *snapshot[H]) GetByHeight(ctx context.Context, height uint64) (H, error) {
+	var zero H
+	if !v.HasAt(ctx, height) {
+		return zero, header.ErrNotFound
+	}
+	// the pruning check of the Store is bypassed, as the pruned headers are retained for the view
+	if h := v.store.pending.GetByHeight(height); !h.IsZero() {
+		return h, nil
+	}
+	hash, err := v.store.heightIndex.HashByHeight(ctx, height)
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return zero, header.ErrNotFound
+		}
+		return zero, err
+	}
+	return v.store.get(ctx, hash)
+}
+
+func (v *snapshot[H]) GetRangeByHeight(ctx context.Context, from, to uint64) ([]H, error) {
+	if from == 0 || from >= to {
+		return nil, fmt.Errorf("header/store: invalid range(%d,%d)", from, to)
+	}
+	headers := make([]H, 0, to-from)
+	for height := from; height < to; height++ {
+		h, err := v.GetByHeight(ctx, height)
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, h)
+	}
+	return headers, nil
+}
+
+func (v *snapshot[H]) HasAt(_ context.Context, height uint64) bool {
+	if height == 0 || height > v.head {
+		return false
+	}
+	if height >= v.tail {
+		return true
+	}
+	for _, r := range v.ranges {
+		if r.From <= height && height < r.To {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *snapshot[H]) Release() {
+	v.release.Do(func() {
+		v.store.unpin(v.pin)
+	})
+}
+
+// unpin releases the given height retained from compaction and resumes the postponed compactions.
+func (s *Store[H]) unpin(height uint64) {
+	s.pinsLk.Lock()
+	if s.pins[height]--; s.pins[height] == 0 {
+		delete(s.pins, height)
+	}
+	s.pinsLk.Unlock()
+
+	select {
+	case s.compactionSignal <- struct{}{}:
+	default:
+	}
+}
+
+// pinned returns the lowest height retained from compaction by snapshots, zero if none.
+func (s *Store[H]) pinned() uint64 {
+	s.pinsLk.Lock()
+	defer s.pinsLk.Unlock()
+	var lowest uint64
+	for height := range s.pins {
+		if lowest == 0 || height < lowest {
+			lowest = height
+		}
+	}
+	return lowest
+}
@@ -2,14 +2,20 @@ package store
 
 import (
 	"context"
+	"errors"
+	gosync "sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
 	"github.com/ipfs/go-datastore/sync"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/celestiaorg/go-header"
 	"github.com/celestiaorg/go-header/headertest"
 )
 
@@ -84,6 +90,40 @@ func TestStore(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestStore_GetMany(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	store, err := NewStoreWithHead(ctx, ds, suite.Head())
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+
+	in := suite.GenDummyHeaders(10)
+	require.NoError(t, store.Append(ctx, in...))
+	require.NoError(t, store.Stop(ctx))
+
+	// the restarted store has nothing cached, so the headers are read from the datastore
+	store, err = NewStore[*headertest.DummyHeader](ds)
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		store.Stop(ctx) //nolint:errcheck
+	})
+
+	hashes := []header.Hash{in[7].Hash(), in[2].Hash(), in[5].Hash()}
+	out, err := store.GetMany(ctx, hashes)
+	require.NoError(t, err)
+	require.Len(t, out, len(hashes))
+	for i, h := range out {
+		assert.Equal(t, hashes[i], h.Hash())
+	}
+
+	_, err = store.GetMany(ctx, append(hashes, headertest.RandBytes(32)))
+	require.ErrorIs(t, err, header.ErrNotFound)
+}
+
 func TestStorePendingCacheMiss(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	t.Cleanup(cancel)
@@ -134,3 +174,1203 @@ func TestBatch_GetByHeightBeforeInit(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, h)
 }
+
+func TestStore_Compaction(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	store, err := NewStoreWithHead(ctx, ds, suite.Head(),
+		WithWriteBatchSize(1),
+		WithCompactionBatchSize(3),
+	)
+	require.NoError(t, err)
+
+	err = store.Start(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+
+	in := suite.GenDummyHeaders(10)
+	err = store.Append(ctx, in...)
+	require.NoError(t, err)
+	// ensure the headers are flushed
+	require.Eventually(t, func() bool {
+		return store.pending.Len() == 0
+	}, time.Second, time.Millisecond*10)
+
+	// compact heights [2:9)
+	store.scheduleCompaction(2, 9)
+	require.Eventually(t, func() bool {
+		ok, err := store.Has(ctx, in[6].Hash()) // height 8
+		return err == nil && !ok
+	}, time.Second, time.Millisecond*10)
+
+	for _, h := range in[:7] {
+		ok, err := store.Has(ctx, h.Hash())
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		_, err = store.GetByHeight(ctx, uint64(h.Height()))
+		assert.ErrorIs(t, err, header.ErrNotFound)
+	}
+
+	// headers out of the range are kept
+	for _, h := range in[7:] {
+		ok, err := store.Has(ctx, h.Hash())
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+}
+
+func TestStore_DeleteTo(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	store, err := NewStoreWithHead(ctx, ds, suite.Head(), WithWriteBatchSize(1))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+
+	in := suite.GenDummyHeaders(10)
+	require.NoError(t, store.Append(ctx, in...))
+	require.Eventually(t, func() bool {
+		return store.pending.Len() == 0
+	}, time.Second, time.Millisecond*10)
+
+	require.Error(t, store.DeleteTo(ctx, 12))
+	require.NoError(t, store.DeleteTo(ctx, 6))
+	// pruned headers are not served right away
+	_, err = store.GetByHeight(ctx, 5)
+	assert.ErrorIs(t, err, header.ErrNotFound)
+	assert.False(t, store.HasAt(ctx, 5))
+	_, err = store.GetRangeByHeight(ctx, 4, 8)
+	assert.ErrorIs(t, err, header.ErrNotFound)
+	// neither by their hashes
+	_, err = store.Get(ctx, in[3].Hash())
+	assert.ErrorIs(t, err, header.ErrNotFound)
+	_, err = store.GetMany(ctx, []header.Hash{in[3].Hash(), in[4].Hash()})
+	assert.ErrorIs(t, err, header.ErrNotFound)
+	ok, err := store.Has(ctx, in[3].Hash())
+	require.NoError(t, err)
+	assert.False(t, ok)
+	// and removed in the background
+	require.Eventually(t, func() bool {
+		ok, err := store.Has(ctx, in[3].Hash()) // height 5
+		return err == nil && !ok
+	}, time.Second, time.Millisecond*10)
+
+	h, err := store.GetByHeight(ctx, 6)
+	require.NoError(t, err)
+	assert.Equal(t, in[4].Hash(), h.Hash())
+	require.NoError(t, store.Stop(ctx))
+
+	// the tail is persisted
+	store, err = NewStore[*headertest.DummyHeader](ds)
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+	_, err = store.Head(ctx)
+	require.NoError(t, err)
+	_, err = store.GetByHeight(ctx, 5)
+	assert.ErrorIs(t, err, header.ErrNotFound)
+	_, err = store.GetByHeight(ctx, 6)
+	assert.NoError(t, err)
+}
+
+func TestStore_Tail(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	store, err := NewStoreWithHead(ctx, ds, suite.Head(), WithWriteBatchSize(1))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+
+	require.NoError(t, store.Append(ctx, suite.GenDummyHeaders(10)...))
+	require.Eventually(t, func() bool {
+		return store.pending.Len() == 0
+	}, time.Second, time.Millisecond*10)
+
+	tail, err := store.Tail(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, tail.Height())
+
+	require.NoError(t, store.DeleteTo(ctx, 6))
+	tail, err = store.Tail(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 6, tail.Height())
+	require.Eventually(t, func() bool {
+		has, err := store.ds.Has(ctx, heightKey(5))
+		return err == nil && !has
+	}, time.Second, time.Millisecond*10)
+	require.NoError(t, store.Stop(ctx))
+
+	// the tail of stores which did not track it is searched for
+	require.NoError(t, store.ds.Delete(ctx, tailKey))
+	store, err = NewStore[*headertest.DummyHeader](ds)
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+	tail, err = store.Tail(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 6, tail.Height())
+	assert.EqualValues(t, 6, store.tailHeight.Load())
+}
+
+func TestStore_AppendRange(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	in := append([]*headertest.DummyHeader{suite.Head()}, suite.GenDummyHeaders(29)...)
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	// the store is initialized with a recent header
+	store, err := NewStoreWithHead(ctx, ds, in[19])
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	require.NoError(t, store.Append(ctx, in[20:]...))
+
+	// a checkpoint below the tail
+	require.NoError(t, store.AppendRange(ctx, in[4:9]...))
+	assert.Equal(t, []Range{{From: 10, To: 20}}, store.MissingRanges())
+	h, err := store.GetByHeight(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, in[6].Hash(), h.Hash())
+	assert.False(t, store.HasAt(ctx, 12))
+
+	assert.Error(t, store.AppendRange(ctx, in[8:11]...))
+	assert.Error(t, store.AppendRange(ctx, in[25:]...))
+	// the range linking to the tail must be of the same chain
+	other := headertest.NewTestSuite(t).GenDummyHeaders(19)
+	assert.Error(t, store.AppendRange(ctx, other[11:]...))
+
+	require.NoError(t, store.Stop(ctx))
+	store, err = NewStore[*headertest.DummyHeader](ds)
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+	assert.Equal(t, []Range{{From: 10, To: 20}}, store.MissingRanges())
+
+	// the range adjacent to the tail extends the chain down
+	require.NoError(t, store.AppendRange(ctx, in[11:19]...))
+	assert.Equal(t, []Range{{From: 10, To: 12}}, store.MissingRanges())
+	tail, err := store.Tail(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 12, tail.Height())
+
+	require.NoError(t, store.AppendRange(ctx, in[9:11]...))
+	assert.Empty(t, store.MissingRanges())
+	tail, err = store.Tail(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, tail.Height())
+
+	// pruning removes the ranges below the tail as well
+	require.NoError(t, store.AppendRange(ctx, in[1:3]...))
+	require.NoError(t, store.DeleteTo(ctx, 4))
+	assert.False(t, store.HasAt(ctx, 3))
+	assert.True(t, store.HasAt(ctx, 5))
+	assert.Empty(t, store.MissingRanges())
+}
+
+func TestStore_AppendRangeCompacting(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	store, err := NewStoreWithHead(ctx, sync.MutexWrap(datastore.NewMapDatastore()), suite.Head(), WithWriteBatchSize(4))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+	in := suite.GenDummyHeaders(20)
+	require.NoError(t, store.Append(ctx, in...))
+	require.Eventually(t, func() bool {
+		return store.Height() == 21
+	}, time.Second, time.Millisecond*10)
+
+	// the snapshot postpones the compaction of the pruned headers
+	snap := store.Snapshot()
+	require.NoError(t, store.DeleteTo(ctx, 10))
+	assert.Error(t, store.AppendRange(ctx, in[2:5]...))
+
+	snap.Release()
+	require.Eventually(t, func() bool {
+		compacted, err := readHeight(ctx, store.ds, compactedKey)
+		return err == nil && compacted == 10
+	}, time.Second, time.Millisecond*10)
+	require.NoError(t, store.AppendRange(ctx, in[2:5]...))
+	h, err := store.GetByHeight(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, in[3].Hash(), h.Hash())
+}
+
+func TestStore_DeleteRange(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	store, err := NewStoreWithHead(ctx, sync.MutexWrap(datastore.NewMapDatastore()), suite.Head(), WithWriteBatchSize(4))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+
+	in := suite.GenDummyHeaders(20)
+	require.NoError(t, store.Append(ctx, in...))
+	require.Eventually(t, func() bool {
+		return store.Height() == 21
+	}, time.Second, time.Millisecond*10)
+
+	// the middle of the chain
+	require.NoError(t, store.DeleteRange(ctx, 8, 11))
+	_, err = store.GetByHeight(ctx, 9)
+	assert.ErrorIs(t, err, header.ErrNotFound)
+	has, err := store.ds.Has(ctx, heightKey(9))
+	require.NoError(t, err)
+	assert.False(t, has)
+	assert.True(t, store.HasAt(ctx, 5))
+	assert.Equal(t, []Range{{From: 8, To: 11}}, store.MissingRanges())
+
+	// below the tail
+	require.NoError(t, store.DeleteRange(ctx, 3, 5))
+	assert.False(t, store.HasAt(ctx, 4))
+	assert.Equal(t, []Range{{From: 3, To: 5}, {From: 8, To: 11}}, store.MissingRanges())
+
+	// the bottom of the chain
+	require.NoError(t, store.DeleteRange(ctx, 11, 13))
+	tail, err := store.Tail(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 13, tail.Height())
+
+	// the head is rolled back
+	require.NoError(t, store.DeleteRange(ctx, 18, 30))
+	head, err := store.Head(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, in[15].Hash(), head.Hash())
+	_, err = store.Get(ctx, in[17].Hash())
+	assert.ErrorIs(t, err, header.ErrNotFound)
+	// the time index does not point to the removed headers
+	h, err := store.GetByTime(ctx, in[17].Time())
+	require.NoError(t, err)
+	assert.Equal(t, in[15].Hash(), h.Hash())
+	require.NoError(t, store.Append(ctx, in[16:]...))
+	require.Eventually(t, func() bool {
+		return store.Height() == 21
+	}, time.Second, time.Millisecond*10)
+	assert.Error(t, store.DeleteRange(ctx, 10, 30))
+}
+
+func TestStore_DeleteRangeRestart(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	store, err := NewStoreWithHead(ctx, ds, suite.Head(), WithWriteBatchSize(4))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	in := suite.GenDummyHeaders(20)
+	require.NoError(t, store.Append(ctx, in...))
+	require.Eventually(t, func() bool {
+		return store.Height() == 21
+	}, time.Second, time.Millisecond*10)
+
+	require.NoError(t, store.DeleteTo(ctx, 5))
+	require.NoError(t, store.DeleteRange(ctx, 10, 12))
+	require.NoError(t, store.Stop(ctx))
+
+	// the compaction resumed on start keeps the range left below the tail
+	store, err = NewStore[*headertest.DummyHeader](ds)
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+	assert.Equal(t, []Range{{From: 5, To: 10}}, store.ranges)
+	require.Eventually(t, func() bool {
+		compacted, err := readHeight(ctx, store.ds, compactedKey)
+		return err == nil && compacted == 12
+	}, time.Second, time.Millisecond*10)
+	for height := uint64(5); height < 10; height++ {
+		h, err := store.GetByHeight(ctx, height)
+		require.NoError(t, err)
+		assert.Equal(t, in[height-2].Hash(), h.Hash())
+	}
+	_, err = store.GetByHeight(ctx, 4)
+	assert.ErrorIs(t, err, header.ErrNotFound)
+}
+
+func TestStore_PruningWindow(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	store, err := NewStoreWithHead(ctx, ds, suite.Head(),
+		WithPruningWindow(3),
+		WithPruningInterval(time.Millisecond*10),
+	)
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+
+	in := suite.GenDummyHeaders(10)
+	require.NoError(t, store.Append(ctx, in...))
+	// only the heights [9:11] are retained
+	require.Eventually(t, func() bool {
+		return !store.HasAt(ctx, 8)
+	}, time.Second, time.Millisecond*10)
+	assert.True(t, store.HasAt(ctx, 9))
+}
+
+func TestStore_Iterate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	store := NewTestStore(ctx, t, suite.Head()).(*Store[*headertest.DummyHeader])
+	in := suite.GenDummyHeaders(10)
+	require.NoError(t, store.Append(ctx, in...))
+	require.Eventually(t, func() bool {
+		return store.Height() == 11
+	}, time.Second, time.Millisecond*10)
+
+	collect := func(from, to uint64, ascending bool, limit int) []int64 {
+		var heights []int64
+		err := store.Iterate(ctx, from, to, ascending, func(h *headertest.DummyHeader) (bool, error) {
+			heights = append(heights, h.Height())
+			return len(heights) == limit, nil
+		})
+		require.NoError(t, err)
+		return heights
+	}
+	assert.Equal(t, []int64{3, 4, 5, 6}, collect(3, 7, true, 0))
+	assert.Equal(t, []int64{6, 5, 4, 3}, collect(3, 7, false, 0))
+	assert.Equal(t, []int64{11, 10}, collect(1, 12, false, 2))
+
+	errStop := errors.New("stop")
+	err := store.Iterate(ctx, 1, 5, true, func(*headertest.DummyHeader) (bool, error) {
+		return false, errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Error(t, store.Iterate(ctx, 5, 13, true, nil))
+}
+
+func TestStore_GetRangeStream(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	store := NewTestStore(ctx, t, suite.Head()).(*Store[*headertest.DummyHeader])
+	in := suite.GenDummyHeaders(600)
+	require.NoError(t, store.Append(ctx, in...))
+	require.Eventually(t, func() bool {
+		return store.Height() == 601
+	}, time.Second, time.Millisecond*10)
+
+	headers, errs := store.GetRangeStream(ctx, 2, 602)
+	var out []*headertest.DummyHeader
+	for h := range headers {
+		out = append(out, h)
+	}
+	require.NoError(t, <-errs)
+	assert.Equal(t, in, out)
+
+	// the reading stops once the context is canceled
+	streamCtx, streamCancel := context.WithCancel(ctx)
+	headers, errs = store.GetRangeStream(streamCtx, 2, 602)
+	<-headers
+	streamCancel()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+
+	_, errs = store.GetRangeStream(ctx, 5, 700)
+	assert.Error(t, <-errs)
+}
+
+func TestStore_GetByTime(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	genesis := suite.Head()
+	store, err := NewStoreWithHead(ctx, sync.MutexWrap(datastore.NewMapDatastore()), genesis, WithWriteBatchSize(4))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+
+	// the last headers stay pending to be written
+	in := suite.GenDummyHeaders(10)
+	require.NoError(t, store.Append(ctx, in...))
+	require.Eventually(t, func() bool {
+		return store.Height() == 11
+	}, time.Second, time.Millisecond*10)
+
+	for _, h := range in {
+		got, err := store.GetByTime(ctx, h.Time())
+		require.NoError(t, err)
+		assert.Equal(t, h.Time(), got.Time())
+	}
+	got, err := store.GetByTime(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, in[9].Hash(), got.Hash())
+
+	_, err = store.GetByTime(ctx, genesis.Time().Add(-time.Nanosecond))
+	assert.ErrorIs(t, err, header.ErrNotFound)
+}
+
+func TestStore_GetByTimeBuckets(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	// headers hours apart, with two of the same time
+	base := time.Now().Add(-time.Hour * 5).UTC()
+	in := []*headertest.DummyHeader{{Raw: headertest.Raw{Height: 1, Time: base}}}
+	for _, offset := range []time.Duration{time.Minute, time.Hour * 2, time.Hour * 2, time.Hour * 4} {
+		prev := in[len(in)-1]
+		in = append(in, &headertest.DummyHeader{Raw: headertest.Raw{
+			PreviousHash: prev.Hash(),
+			Height:       prev.Height() + 1,
+			Time:         base.Add(offset),
+		}})
+	}
+	in[len(in)-1].Hash()
+
+	store, err := NewStoreWithHead(ctx, sync.MutexWrap(datastore.NewMapDatastore()), in[0], WithWriteBatchSize(1))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+	require.NoError(t, store.Append(ctx, in[1:]...))
+	require.Eventually(t, func() bool {
+		return store.pending.Len() == 0
+	}, time.Second, time.Millisecond*10)
+
+	for at, expected := range map[time.Duration]int{
+		time.Minute:                   1,
+		time.Hour:                     1,
+		time.Hour * 2:                 3,
+		time.Hour*2 + time.Nanosecond: 3,
+		time.Hour * 3:                 3,
+		time.Hour * 5:                 4,
+	} {
+		got, err := store.GetByTime(ctx, base.Add(at))
+		require.NoError(t, err)
+		assert.Equal(t, in[expected].Hash(), got.Hash(), at)
+	}
+	_, err = store.GetByTime(ctx, base.Add(-time.Nanosecond))
+	assert.ErrorIs(t, err, header.ErrNotFound)
+
+	// compaction of a header does not remove the index of another one of the same time
+	require.NoError(t, store.DeleteTo(ctx, 4))
+	require.Eventually(t, func() bool {
+		compacted, err := readHeight(ctx, store.ds, compactedKey)
+		return err == nil && compacted == 4
+	}, time.Second, time.Millisecond*10)
+	got, err := store.GetByTime(ctx, base.Add(time.Hour*3))
+	require.NoError(t, err)
+	assert.Equal(t, in[3].Hash(), got.Hash())
+}
+
+func TestStore_OnAppend(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	store, err := NewStore[*headertest.DummyHeader](sync.MutexWrap(datastore.NewMapDatastore()), WithWriteBatchSize(3))
+	require.NoError(t, err)
+
+	var (
+		lk      gosync.Mutex
+		heights []int64
+	)
+	store.OnAppend(func(_ context.Context, h *headertest.DummyHeader) {
+		lk.Lock()
+		defer lk.Unlock()
+		heights = append(heights, h.Height())
+	})
+	require.NoError(t, store.Init(ctx, suite.Head()))
+	require.NoError(t, store.Start(ctx))
+
+	require.NoError(t, store.Append(ctx, suite.GenDummyHeaders(10)...))
+	// the last pending headers are persisted on stop
+	require.NoError(t, store.Stop(ctx))
+
+	lk.Lock()
+	defer lk.Unlock()
+	assert.Equal(t, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, heights)
+}
+
+func TestStore_OnRemove(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	store := NewTestStore(ctx, t, suite.Head()).(*Store[*headertest.DummyHeader])
+
+	var (
+		lk      gosync.Mutex
+		removed []Range
+	)
+	store.OnRemove(func(_ context.Context, from, to uint64) {
+		lk.Lock()
+		defer lk.Unlock()
+		removed = append(removed, Range{From: from, To: to})
+	})
+	require.NoError(t, store.Append(ctx, suite.GenDummyHeaders(10)...))
+	_, err := store.GetByHeight(ctx, 11)
+	require.NoError(t, err)
+
+	require.NoError(t, store.DeleteTo(ctx, 3))
+	require.NoError(t, store.DeleteRange(ctx, 5, 7))
+	// rolls the head back
+	require.NoError(t, store.DeleteRange(ctx, 10, 12))
+
+	lk.Lock()
+	defer lk.Unlock()
+	assert.Equal(t, []Range{{From: 1, To: 3}, {From: 5, To: 7}, {From: 10, To: 12}}, removed)
+}
+
+func TestStore_Batch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	store := NewTestStore(ctx, t, suite.Head()).(*Store[*headertest.DummyHeader])
+	in := suite.GenDummyHeaders(10)
+
+	batch := store.Batch()
+	require.NoError(t, batch.Append(in[5:]...))
+	require.NoError(t, batch.Append(in[:3]...))
+	// non-adjacent headers are rejected right away
+	assert.Error(t, batch.Append(in[3], in[5]))
+	// the gap fails the whole batch
+	var errNonAdj *header.ErrNonAdjacent
+	require.ErrorAs(t, batch.Commit(ctx), &errNonAdj)
+	assert.EqualValues(t, 1, store.Height())
+
+	require.NoError(t, batch.Append(in[3:5]...))
+	require.NoError(t, batch.Commit(ctx))
+	assert.Zero(t, batch.Len())
+	// the batch is on disk once committed
+	assert.EqualValues(t, 11, store.Height())
+	assert.Zero(t, store.pending.Len())
+	head, err := store.readHead(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, in[9].Hash(), head.Hash())
+}
+
+// failingDatastore fails the batches once failing is set.
+type failingDatastore struct {
+	datastore.Batching
+	failing atomic.Bool
+}
+
+func (f *failingDatastore) Batch(ctx context.Context) (datastore.Batch, error) {
+	if f.failing.Load() {
+		return nil, errors.New("failing datastore")
+	}
+	return f.Batching.Batch(ctx)
+}
+
+func TestStore_BatchFailure(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	ds := &failingDatastore{Batching: sync.MutexWrap(datastore.NewMapDatastore())}
+	store, err := NewStoreWithHead(ctx, ds, suite.Head())
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		ds.failing.Store(false)
+		require.NoError(t, store.Stop(ctx))
+	})
+	in := suite.GenDummyHeaders(5)
+
+	// the headers of a failed commit are not published
+	ds.failing.Store(true)
+	batch := store.Batch()
+	require.NoError(t, batch.Append(in...))
+	require.Error(t, batch.Commit(ctx))
+	assert.EqualValues(t, 1, store.Height())
+	_, err = store.Get(ctx, in[0].Hash())
+	assert.ErrorIs(t, err, header.ErrNotFound)
+
+	// and the batch can be committed again
+	ds.failing.Store(false)
+	require.NoError(t, batch.Commit(ctx))
+	assert.EqualValues(t, 6, store.Height())
+}
+
+func TestStore_WriteAheadLog(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	store, err := NewStoreWithHead(ctx, ds, suite.Head(), WithWriteAheadLog(true))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+
+	in := suite.GenDummyHeaders(10)
+	require.NoError(t, store.Append(ctx, in...))
+	require.Eventually(t, func() bool {
+		return store.Height() == 11
+	}, time.Second, time.Millisecond*10)
+	// the headers are still pending to be written in a batch when the store crashes
+	// and the log has an entry not linking to them
+	forged := headertest.NewTestSuite(t).GenDummyHeaders(12)[11]
+	b, err := forged.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, ds.Put(ctx, storePrefix.Child(walKey(12)), b))
+
+	store, err = NewStore[*headertest.DummyHeader](ds, WithWriteAheadLog(true))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+	head, err := store.Head(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, in[9].Hash(), head.Hash())
+
+	// the log is cleaned up after the replay
+	res, err := ds.Query(ctx, query.Query{Prefix: storePrefix.Child(walPrefix).String(), KeysOnly: true})
+	require.NoError(t, err)
+	entries, err := res.Rest()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestStore_Compression(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	store, err := NewStoreWithHead(ctx, ds, suite.Head(), WithWriteBatchSize(1))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	in := suite.GenDummyHeaders(10)
+	require.NoError(t, store.Append(ctx, in[:5]...))
+	require.NoError(t, store.Stop(ctx))
+
+	// compression is enabled for the existing store
+	store, err = NewStore[*headertest.DummyHeader](ds, WithWriteBatchSize(1), WithCompression(true),
+		WithRecentHeadsCacheSize(0))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+	_, err = store.Head(ctx)
+	require.NoError(t, err)
+	require.NoError(t, store.Append(ctx, in[5:]...))
+	require.Eventually(t, func() bool {
+		return store.pending.Len() == 0 && store.Height() == 11
+	}, time.Second, time.Millisecond*10)
+
+	raw, err := store.ds.Get(ctx, headerKey(in[0]))
+	require.NoError(t, err)
+	assert.Equal(t, formatRaw, raw[0])
+	raw, err = store.ds.Get(ctx, headerKey(in[9]))
+	require.NoError(t, err)
+	assert.Equal(t, formatZstd, raw[0])
+	// headers of unknown formats are rejected instead of guessed
+	_, err = decodeHeader[*headertest.DummyHeader](append([]byte{0x01}, raw[1:]...))
+	require.Error(t, err)
+
+	// headers written before the format byte was introduced are prefixed by the migration
+	legacy, err := in[1].MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, store.ds.Put(ctx, headerKey(in[1]), legacy))
+	require.NoError(t, store.Stop(ctx))
+	require.NoError(t, store.ds.Put(ctx, versionKey, encodeHeight(5)))
+	store, err = NewStore[*headertest.DummyHeader](ds, WithCompression(true), WithRecentHeadsCacheSize(0))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+
+	raw, err = store.ds.Get(ctx, headerKey(in[1]))
+	require.NoError(t, err)
+	assert.Equal(t, append([]byte{formatRaw}, legacy...), raw)
+	for _, h := range in {
+		got, err := store.GetByHeight(ctx, uint64(h.Height()))
+		require.NoError(t, err)
+		assert.Equal(t, h.Hash(), got.Hash())
+	}
+}
+
+func TestStore_Reverify(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	store, err := NewStoreWithHead(ctx, ds, suite.Head())
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+
+	in := suite.GenDummyHeaders(10)
+	require.NoError(t, store.Append(ctx, in...))
+	require.NoError(t, store.Stop(ctx))
+
+	failures, err := store.Reverify(ctx, 1, 12)
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+
+	// tamper the header at height 6, so it is older than its parent
+	tampered := *in[4]
+	tampered.Raw.Time = in[3].Time().Add(-time.Second)
+	b, err := tampered.MarshalBinary()
+	require.NoError(t, err)
+	err = ds.Put(ctx, storePrefix.Child(headerKey(in[4])), append([]byte{formatRaw}, b...))
+	require.NoError(t, err)
+
+	store, err = NewStore[*headertest.DummyHeader](ds)
+	require.NoError(t, err)
+	_, err = store.Head(ctx)
+	require.NoError(t, err)
+
+	failures, err = store.Reverify(ctx, 2, 12)
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	assert.EqualValues(t, 6, failures[0].Height)
+
+	_, err = store.Reverify(ctx, 2, 13)
+	require.Error(t, err)
+}
+
+// BenchmarkStore_Append and BenchmarkStore_GetByHeight measure the header workload
+// of the Store, so the options of the underlying Datastore can be tuned against them.
+func BenchmarkStore_Append(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(b)
+	store := NewTestStore(ctx, b, suite.Head())
+	headers := suite.GenDummyHeaders(b.N)
+
+	b.ResetTimer()
+	for _, h := range headers {
+		err := store.Append(ctx, h)
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkStore_GetByHeight(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(b)
+	store := NewTestStore(ctx, b, suite.Head())
+	const amount = 1024
+	err := store.Append(ctx, suite.GenDummyHeaders(amount)...)
+	require.NoError(b, err)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := store.GetByHeight(ctx, uint64(i%amount)+1)
+		require.NoError(b, err)
+	}
+}
+
+func TestStore_VerifyIntegrity(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	store, err := NewStoreWithHead(ctx, sync.MutexWrap(datastore.NewMapDatastore()), suite.Head(), WithWriteBatchSize(1))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+
+	in := suite.GenDummyHeaders(10)
+	require.NoError(t, store.Append(ctx, in...))
+	require.Eventually(t, func() bool {
+		return store.pending.Len() == 0
+	}, time.Second, time.Millisecond*10)
+	require.NoError(t, store.VerifyIntegrity(ctx, 1, 12))
+
+	// the height index points to the wrong header and the header is garbled
+	require.NoError(t, store.ds.Put(ctx, heightKey(5), in[4].Hash()))
+	require.NoError(t, store.ds.Put(ctx, headerKey(in[6]), []byte("garbage")))
+
+	err = store.VerifyIntegrity(ctx, 1, 12)
+	var integrityErr *IntegrityError
+	require.ErrorAs(t, err, &integrityErr)
+	require.Len(t, integrityErr.Failures, 2)
+	assert.EqualValues(t, 5, integrityErr.Failures[0].Height)
+	assert.EqualValues(t, 8, integrityErr.Failures[1].Height)
+	assert.Error(t, store.VerifyIntegrity(ctx, 8, 9))
+	assert.NoError(t, store.VerifyIntegrity(ctx, 9, 12))
+
+	// the hash index is missing or points to the wrong height
+	require.NoError(t, store.ds.Delete(ctx, hashKey(in[7].Hash())))
+	require.NoError(t, store.ds.Put(ctx, hashKey(in[8].Hash()), encodeHeight(3)))
+
+	err = store.VerifyIntegrity(ctx, 9, 12)
+	require.ErrorAs(t, err, &integrityErr)
+	require.Len(t, integrityErr.Failures, 2)
+	assert.EqualValues(t, 9, integrityErr.Failures[0].Height)
+	assert.EqualValues(t, 10, integrityErr.Failures[1].Height)
+	assert.NoError(t, store.VerifyIntegrity(ctx, 11, 12))
+}
+
+func TestStore_StrictIndexMismatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	store, err := NewStoreWithHead(ctx, ds, suite.Head())
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	in := suite.GenDummyHeaders(5)
+	require.NoError(t, store.Append(ctx, in...))
+	require.NoError(t, store.Stop(ctx))
+
+	// corrupt the index, so height 3 points to the header at height 4
+	err = ds.Put(ctx, storePrefix.Child(heightKey(3)), in[2].Hash())
+	require.NoError(t, err)
+
+	store, err = NewStore[*headertest.DummyHeader](ds)
+	require.NoError(t, err)
+	_, err = store.Head(ctx)
+	require.NoError(t, err)
+	_, err = store.GetByHeight(ctx, 3)
+	require.Error(t, err)
+
+	store, err = NewStore[*headertest.DummyHeader](ds, WithStrict(true))
+	require.NoError(t, err)
+	_, err = store.Head(ctx)
+	require.NoError(t, err)
+	require.Panics(t, func() {
+		_, _ = store.GetByHeight(ctx, 3)
+	})
+}
+
+func TestStore_Forks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	store, err := NewStoreWithHead(ctx, sync.MutexWrap(datastore.NewMapDatastore()), suite.Head(), WithWriteBatchSize(4))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+
+	in := suite.GenDummyHeaders(10)
+	fork := suite.Fork()
+	canonical := append(in, suite.GenDummyHeaders(5)...)
+	require.NoError(t, store.Append(ctx, canonical...))
+	require.Eventually(t, func() bool {
+		return store.Height() == 16
+	}, time.Second, time.Millisecond*10)
+
+	// the fork is retrievable by hashes only
+	branch := fork.GenDummyHeaders(8)
+	require.NoError(t, store.AppendFork(ctx, branch...))
+	h, err := store.Get(ctx, branch[2].Hash())
+	require.NoError(t, err)
+	assert.Equal(t, branch[2].Hash(), h.Hash())
+	h, err = store.GetByHeight(ctx, 13)
+	require.NoError(t, err)
+	assert.Equal(t, canonical[11].Hash(), h.Hash())
+	// range reads skip the headers of the fork stored at the same heights
+	store.cache.(*lru.ARCCache).Purge()
+	out, err := store.GetRangeByHeight(ctx, 12, 17)
+	require.NoError(t, err)
+	assert.Equal(t, canonical[10:15], out)
+	// and so do snapshots
+	snap := store.Snapshot()
+	_, err = snap.Get(ctx, branch[2].Hash())
+	assert.ErrorIs(t, err, header.ErrNotFound)
+	h, err = snap.Get(ctx, canonical[12].Hash())
+	require.NoError(t, err)
+	assert.Equal(t, canonical[12].Hash(), h.Hash())
+	snap.Release()
+	// a fork must link to a stored header
+	assert.Error(t, store.AppendFork(ctx, headertest.NewTestSuite(t).GenDummyHeaders(3)...))
+
+	// the fork becomes canonical
+	var (
+		hooked   []header.Hash
+		hookedLk gosync.Mutex
+	)
+	store.OnAppend(func(_ context.Context, h *headertest.DummyHeader) {
+		hookedLk.Lock()
+		defer hookedLk.Unlock()
+		hooked = append(hooked, h.Hash())
+	})
+	require.NoError(t, store.SetCanonical(ctx, branch[7].Hash()))
+	hookedLk.Lock()
+	require.Len(t, hooked, len(branch))
+	for i, h := range branch {
+		assert.Equal(t, h.Hash(), hooked[i])
+	}
+	hookedLk.Unlock()
+	assert.EqualValues(t, 19, store.Height())
+	head, err := store.Head(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, branch[7].Hash(), head.Hash())
+	h, err = store.GetByHeight(ctx, 13)
+	require.NoError(t, err)
+	assert.Equal(t, branch[1].Hash(), h.Hash())
+	h, err = store.GetByTime(ctx, canonical[13].Time())
+	require.NoError(t, err)
+	assert.NotEqual(t, canonical[13].Hash(), h.Hash())
+	// the previous chain is kept as a fork
+	h, err = store.Get(ctx, canonical[14].Hash())
+	require.NoError(t, err)
+	assert.Equal(t, canonical[14].Hash(), h.Hash())
+	require.NoError(t, store.Append(ctx, fork.GenDummyHeaders(2)...))
+	require.Eventually(t, func() bool {
+		return store.Height() == 21
+	}, time.Second, time.Millisecond*10)
+
+	// and the reorg is reverted
+	require.NoError(t, store.SetCanonical(ctx, canonical[14].Hash()))
+	assert.EqualValues(t, 16, store.Height())
+	h, err = store.GetByHeight(ctx, 13)
+	require.NoError(t, err)
+	assert.Equal(t, canonical[11].Hash(), h.Hash())
+	has, err := store.ds.Has(ctx, heightKey(17))
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	// the forks are removed with the canonical headers at the same heights
+	require.NoError(t, store.DeleteRange(ctx, 12, 30))
+	_, err = store.Get(ctx, branch[1].Hash())
+	assert.ErrorIs(t, err, header.ErrNotFound)
+}
+
+func TestStore_SharedCache(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	cache, err := lru.NewARC(64)
+	require.NoError(t, err)
+	stores := make([]*Store[*headertest.DummyHeader], 2)
+	chains := make([][]*headertest.DummyHeader, 2)
+	for i := range stores {
+		suite := headertest.NewTestSuite(t)
+		stores[i], err = NewStoreWithHead(ctx, sync.MutexWrap(datastore.NewMapDatastore()), suite.Head(),
+			WithWriteBatchSize(1), WithCache(cache), WithRecentHeadsCacheSize(0))
+		require.NoError(t, err)
+		require.NoError(t, stores[i].Start(ctx))
+		chains[i] = suite.GenDummyHeaders(5)
+		require.NoError(t, stores[i].Append(ctx, chains[i]...))
+	}
+	for i, store := range stores {
+		require.Eventually(t, func() bool {
+			return store.pending.Len() == 0
+		}, time.Second, time.Millisecond*10)
+		for _, h := range chains[i] {
+			_, err = store.Get(ctx, h.Hash())
+			require.NoError(t, err)
+		}
+	}
+	cached := cache.Len()
+	assert.GreaterOrEqual(t, cached, 10)
+
+	// the headers cached by one store are not served by the others
+	_, err = stores[0].Get(ctx, chains[1][0].Hash())
+	assert.ErrorIs(t, err, header.ErrNotFound)
+	has, err := stores[0].Has(ctx, chains[1][0].Hash())
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	// stopping one of the stores leaves the shared cache to the others
+	require.NoError(t, stores[0].Stop(ctx))
+	assert.Equal(t, cached, cache.Len())
+	require.NoError(t, stores[1].Stop(ctx))
+}
+
+func TestStore_Snapshot(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	store, err := NewStoreWithHead(ctx, sync.MutexWrap(datastore.NewMapDatastore()), suite.Head(), WithWriteBatchSize(4))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+
+	in := suite.GenDummyHeaders(10)
+	require.NoError(t, store.Append(ctx, in...))
+	require.Eventually(t, func() bool {
+		return store.Height() == 11
+	}, time.Second, time.Millisecond*10)
+
+	snap := store.Snapshot()
+	// appends are not visible within the snapshot
+	require.NoError(t, store.Append(ctx, suite.GenDummyHeaders(5)...))
+	require.Eventually(t, func() bool {
+		return store.Height() == 16
+	}, time.Second, time.Millisecond*10)
+	head, err := snap.Head(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, in[9].Hash(), head.Hash())
+	assert.False(t, snap.HasAt(ctx, 12))
+	_, err = snap.GetByHeight(ctx, 12)
+	assert.ErrorIs(t, err, header.ErrNotFound)
+
+	// pruned headers are retained until the snapshot is released
+	require.NoError(t, store.DeleteTo(ctx, 6))
+	time.Sleep(time.Millisecond * 50)
+	out, err := snap.GetRangeByHeight(ctx, 2, 12)
+	require.NoError(t, err)
+	assert.Equal(t, in, out)
+	tail, err := snap.Tail(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, tail.Height())
+	_, err = store.GetByHeight(ctx, 3)
+	assert.ErrorIs(t, err, header.ErrNotFound)
+
+	snap.Release()
+	require.Eventually(t, func() bool {
+		has, err := store.ds.Has(ctx, heightKey(3))
+		return err == nil && !has
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestStore_Prefetch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	store, err := NewStoreWithHead(ctx, ds, suite.Head(), WithWriteBatchSize(4))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	in := suite.GenDummyHeaders(20)
+	require.NoError(t, store.Append(ctx, in...))
+	require.NoError(t, store.Stop(ctx))
+
+	// the reopened store has nothing cached
+	store, err = NewStore[*headertest.DummyHeader](ds, WithPrefetchWindow(8))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+
+	h, err := store.GetByHeight(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, in[3].Hash(), h.Hash())
+	for _, h := range in[4:12] {
+		_, ok := store.cached(h.Hash())
+		assert.True(t, ok)
+	}
+	_, ok := store.cached(in[12].Hash())
+	assert.False(t, ok)
+
+	// the window is bound by the head
+	h, err = store.GetByHeight(ctx, 18)
+	require.NoError(t, err)
+	assert.Equal(t, in[16].Hash(), h.Hash())
+	_, ok = store.cached(in[19].Hash())
+	assert.True(t, ok)
+}
+
+func TestStore_RecentHeads(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	store, err := NewStoreWithHead(ctx, sync.MutexWrap(datastore.NewMapDatastore()), suite.Head(),
+		WithWriteBatchSize(4), WithRecentHeadsCacheSize(4))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+
+	in := suite.GenDummyHeaders(12)
+	require.NoError(t, store.Append(ctx, in...))
+	require.Eventually(t, func() bool {
+		return store.pending.Len() == 0
+	}, time.Second, time.Millisecond*10)
+
+	// the recent heads are served regardless of the cache and the datastore
+	store.cache.(*lru.ARCCache).Purge()
+	for _, h := range in {
+		require.NoError(t, store.ds.Delete(ctx, headerKey(h)))
+	}
+	for _, h := range in[8:] {
+		got, err := store.GetByHeight(ctx, uint64(h.Height()))
+		require.NoError(t, err)
+		assert.Equal(t, h.Hash(), got.Hash())
+		got, err = store.Get(ctx, h.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, h.Hash(), got.Hash())
+	}
+	_, err = store.Get(ctx, in[7].Hash())
+	assert.ErrorIs(t, err, header.ErrNotFound)
+
+	// removed heads are not served
+	require.NoError(t, store.DeleteRange(ctx, 12, 14))
+	_, err = store.Get(ctx, in[11].Hash())
+	assert.ErrorIs(t, err, header.ErrNotFound)
+	assert.EqualValues(t, 11, store.Height())
+}
+
+func TestStore_Stats(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	store, err := NewStore[*headertest.DummyHeader](sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	_, err = store.Stats(ctx)
+	assert.ErrorIs(t, err, header.ErrNoHead)
+
+	suite := headertest.NewTestSuite(t)
+	store, err = NewStoreWithHead(ctx, sync.MutexWrap(datastore.NewMapDatastore()), suite.Head(), WithWriteBatchSize(4))
+	require.NoError(t, err)
+	require.NoError(t, store.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, store.Stop(ctx))
+	})
+
+	in := suite.GenDummyHeaders(20)
+	require.NoError(t, store.Append(ctx, in...))
+	require.Eventually(t, func() bool {
+		return store.Height() == 21
+	}, time.Second, time.Millisecond*10)
+	require.NoError(t, store.DeleteRange(ctx, 5, 8))
+
+	stats, err := store.Stats(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 21, stats.HeadHeight)
+	assert.EqualValues(t, 8, stats.TailHeight)
+	assert.EqualValues(t, 18, stats.Stored)
+	assert.Equal(t, store.pending.Len(), stats.Pending)
+	assert.Equal(t, []Range{{From: 5, To: 8}}, stats.Gaps)
+}
@@ -0,0 +1,33 @@
+package store
+
+import (
+	"context"
+)
+
+// GetRangeStream streams the headers in the range [from:to) in ascending order of heights,
+// so the readers, e.g. the exchange server or exporters, pipeline reads with network writes
+// instead of allocating the whole range. The headers are read in chunks, ahead of the reader
+// by a single chunk at most.
+//
+// Both channels are closed once the range is streamed, or the reading fails or the context
+// is canceled, in which case the error is sent first. The headers channel must be drained or
+// the context canceled, so the reading routine does not leak.
+func (s *Store[H]) GetRangeStream(ctx context.Context, from, to uint64) (<-chan H, <-chan error) {
+	headers, errs := make(chan H), make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(headers)
+		err := s.Iterate(ctx, from, to, true, func(h H) (bool, error) {
+			select {
+			case headers <- h:
+				return false, nil
+			case <-ctx.Done():
+				return true, ctx.Err()
+			}
+		})
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return headers, errs
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// invariant is called on violations of the internal invariants of the Store.
+// In the strict mode, it panics with the dump of the Store state, so bugs surface early
+// in integration environments. Otherwise, the violation is only logged.
+func (s *Store[H]) invariant(msg string, keyvals ...any) {
+	if !s.Params.Strict {
+		log.Errorw("PLEASE FILE A BUG REPORT: "+msg, keyvals...)
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "header/store: invariant violated: %s", msg)
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", keyvals[i], keyvals[i+1])
+	}
+	b.WriteString("\n")
+	b.WriteString(s.dumpState())
+	panic(b.String())
+}
+
+// dumpState describes the current state of the Store for debugging purposes.
+func (s *Store[H]) dumpState() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "read head: %d\n", s.heightSub.Height())
+	if wh := s.writeHead.Load(); wh != nil {
+		fmt.Fprintf(&b, "write head: %d (%s)\n", (*wh).Height(), (*wh).Hash())
+	}
+	pending := s.pending.GetAll()
+	fmt.Fprintf(&b, "pending: %d headers", len(pending))
+	if len(pending) > 0 {
+		fmt.Fprintf(&b, " [%d:%d]", pending[0].Height(), pending[len(pending)-1].Height())
+	}
+	fmt.Fprintf(&b, "\nparams: %+v\n", s.Params)
+	return b.String()
+}
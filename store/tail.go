@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// Tail returns the lowest header retained by the Store, below which the history is pruned
+// or was never synced. Together with the head, it bounds the contiguous range of stored headers.
+func (s *Store[H]) Tail(ctx context.Context) (H, error) {
+	var zero H
+	if _, err := s.Head(ctx); err != nil {
+		return zero, err
+	}
+
+	for {
+		tail := s.tailHeight.Load()
+		if tail == 0 {
+			var err error
+			tail, err = s.findTail(ctx)
+			if err != nil {
+				return zero, err
+			}
+		}
+
+		h, err := s.GetByHeight(ctx, tail)
+		if errors.Is(err, header.ErrNotFound) && s.tailHeight.Load() != tail {
+			// pruned concurrently
+			continue
+		}
+		return h, err
+	}
+}
+
+// findTail searches for the lowest stored height of stores initialized before
+// the tail was tracked and persists it.
+// Heights are stored contiguously up to the head, so the height index is binary searched.
+func (s *Store[H]) findTail(ctx context.Context) (uint64, error) {
+	s.pruneLk.Lock()
+	defer s.pruneLk.Unlock()
+	if tail := s.tailHeight.Load(); tail != 0 {
+		return tail, nil
+	}
+
+	// pending headers are not indexed yet, so the search is bound by the head on disk
+	head, err := s.readHead(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var searchErr error
+	offset := sort.Search(int(head.Height()), func(i int) bool {
+		has, err := s.ds.Has(ctx, heightKey(uint64(i)+1))
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return has
+	})
+	if searchErr != nil {
+		return 0, searchErr
+	}
+
+	tail := uint64(offset) + 1
+	if err = s.ds.Put(ctx, tailKey, encodeHeight(tail)); err != nil {
+		return 0, err
+	}
+	s.tailHeight.Store(tail)
+	log.Infow("found tail", "height", tail)
+	return tail, nil
+}
@@ -0,0 +1,188 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// timePrefix is the prefix of the index mapping header timestamps to heights.
+var timePrefix = datastore.NewKey("time")
+
+// timeBucketSpan is the span of time sharing a prefix of the time index,
+// which bounds the amount of keys scanned by GetByTime.
+const timeBucketSpan = uint64(time.Hour)
+
+// timestamp returns the timestamp of the given time indexed by the time index.
+func timestamp(t time.Time) uint64 {
+	nanos := t.UnixNano()
+	if nanos < 0 {
+		return 0
+	}
+	return uint64(nanos)
+}
+
+// timeBucketKey returns the prefix of the bucket of the time index the given timestamp is in.
+// Buckets are named by their lowest timestamp.
+func timeBucketKey(ts uint64) datastore.Key {
+	return timePrefix.ChildString(fmt.Sprintf("%020d", ts-ts%timeBucketSpan))
+}
+
+// timeKey returns the key indexing the header of the given time and height.
+// Timestamps and heights are zero-padded, so the keys are ordered by time and then by height,
+// and headers of the same time do not share a key.
+func timeKey(t time.Time, height uint64) datastore.Key {
+	ts := timestamp(t)
+	return timeBucketKey(ts).ChildString(fmt.Sprintf("%020d", ts)).ChildString(fmt.Sprintf("%020d", height))
+}
+
+// parseTimeKey returns the timestamp and the height of the given key of the time index.
+func parseTimeKey(key string) (ts, height uint64, err error) {
+	namespaces := datastore.NewKey(key).Namespaces()
+	if len(namespaces) != 4 {
+		return 0, 0, fmt.Errorf("header/store: malformed time index key %s", key)
+	}
+	if ts, err = strconv.ParseUint(namespaces[2], 10, 64); err != nil {
+		return 0, 0, err
+	}
+	height, err = strconv.ParseUint(namespaces[3], 10, 64)
+	return ts, height, err
+}
+
+// indexTime saves mapping between header time and Height to the given batch.
+func indexTime[H header.Header](ctx context.Context, batch datastore.Batch, headers ...H) error {
+	for _, h := range headers {
+		err := batch.Put(ctx, timeKey(h.Time(), uint64(h.Height())), []byte{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetByTime returns the latest header with the time at or before the given one.
+// Headers are indexed by time on write, so headers written by older versions of the Store
+// are not found by their time.
+func (s *Store[H]) GetByTime(ctx context.Context, t time.Time) (H, error) {
+	var zero H
+	// headers pending to be written are not indexed yet
+	pending := s.pending.GetAll()
+	for i := len(pending) - 1; i >= 0; i-- {
+		if !pending[i].Time().After(t) {
+			return pending[i], nil
+		}
+	}
+
+	height, err := s.seekTime(ctx, timestamp(t))
+	if err != nil {
+		return zero, err
+	}
+	if s.pruned(height) {
+		return zero, header.ErrNotFound
+	}
+	// the indexed header is on disk, so there is no need to wait for its height
+	hash, err := s.heightIndex.HashByHeight(ctx, height)
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return zero, header.ErrNotFound
+		}
+		return zero, err
+	}
+	return s.Get(ctx, hash)
+}
+
+// seekTime returns the height of the latest header indexed at or before the given timestamp.
+// Datastores do not expose seeking, so it is done with reverse prefix scans, which leveldb and
+// badger serve natively: the bounds of the index are checked first, then the bucket of the
+// timestamp is scanned down to it, and then the preceding buckets are looked up for their last
+// key, until one is found. No scan goes beyond a single bucket.
+func (s *Store[H]) seekTime(ctx context.Context, ts uint64) (uint64, error) {
+	last, height, ok, err := s.edgeTimeKey(ctx, timePrefix, true)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, header.ErrNotFound
+	}
+	if last <= ts {
+		return height, nil
+	}
+	first, _, _, err := s.edgeTimeKey(ctx, timePrefix, false)
+	if err != nil {
+		return 0, err
+	}
+	if ts < first {
+		return 0, header.ErrNotFound
+	}
+
+	res, err := s.ds.Query(ctx, query.Query{
+		Prefix:   timeBucketKey(ts).String(),
+		KeysOnly: true,
+		Orders:   []query.Order{query.OrderByKeyDescending{}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	for entry := range res.Next() {
+		if entry.Error != nil {
+			res.Close()
+			return 0, entry.Error
+		}
+		indexed, height, err := parseTimeKey(entry.Key)
+		if err != nil {
+			res.Close()
+			return 0, err
+		}
+		if indexed <= ts {
+			res.Close()
+			return height, nil
+		}
+	}
+	res.Close()
+
+	// the first key is below the timestamp, so one of the preceding buckets has a key
+	for bucket := ts - ts%timeBucketSpan; bucket >= timeBucketSpan; {
+		bucket -= timeBucketSpan
+		_, height, ok, err := s.edgeTimeKey(ctx, timeBucketKey(bucket), true)
+		if err != nil || ok {
+			return height, err
+		}
+	}
+	return 0, header.ErrNotFound
+}
+
+// edgeTimeKey returns the timestamp and the height of the last or the first key
+// of the time index under the given prefix.
+func (s *Store[H]) edgeTimeKey(ctx context.Context, prefix datastore.Key, last bool) (ts, height uint64, ok bool, err error) {
+	var order query.Order = query.OrderByKey{}
+	if last {
+		order = query.OrderByKeyDescending{}
+	}
+	res, err := s.ds.Query(ctx, query.Query{
+		Prefix:   prefix.String(),
+		KeysOnly: true,
+		Orders:   []query.Order{order},
+		Limit:    1,
+	})
+	if err != nil {
+		return 0, 0, false, err
+	}
+	defer res.Close()
+
+	entry, ok := res.NextSync()
+	if !ok {
+		return 0, 0, false, nil
+	}
+	if entry.Error != nil {
+		return 0, 0, false, entry.Error
+	}
+	ts, height, err = parseTimeKey(entry.Key)
+	return ts, height, err == nil, err
+}
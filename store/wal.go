@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// walPrefix is the prefix of the write-ahead log keeping the headers pending to be written.
+var walPrefix = datastore.NewKey("wal")
+
+func walKey(height uint64) datastore.Key {
+	return walPrefix.ChildString(strconv.FormatUint(height, 10))
+}
+
+// logWrites records the given headers in the write-ahead log, if enabled.
+func (s *Store[H]) logWrites(ctx context.Context, headers ...H) error {
+	if !s.Params.WriteAheadLog {
+		return nil
+	}
+
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	for _, h := range headers {
+		b, err := h.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		if err = batch.Put(ctx, walKey(uint64(h.Height())), b); err != nil {
+			return err
+		}
+	}
+	return batch.Commit(ctx)
+}
+
+// unlogWrites removes the given headers from the write-ahead log within the batch
+// writing them, so they are removed only once written.
+func (s *Store[H]) unlogWrites(ctx context.Context, batch datastore.Batch, headers ...H) error {
+	if !s.Params.WriteAheadLog {
+		return nil
+	}
+
+	for _, h := range headers {
+		if err := batch.Delete(ctx, walKey(uint64(h.Height()))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropLogged removes the given headers from the write-ahead log, if enabled.
+func (s *Store[H]) dropLogged(ctx context.Context, headers ...H) error {
+	if !s.Params.WriteAheadLog {
+		return nil
+	}
+
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	if err = s.unlogWrites(ctx, batch, headers...); err != nil {
+		return err
+	}
+	return batch.Commit(ctx)
+}
+
+// replayWrites writes the headers left in the write-ahead log by a crash on top of the stored head.
+// The logged headers are verified against the head, and the ones failing are discarded.
+// The log is replayed even if disabled, so no logged header is lost after turning it off.
+func (s *Store[H]) replayWrites(ctx context.Context) error {
+	res, err := s.ds.Query(ctx, query.Query{Prefix: walPrefix.String()})
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+
+	var logged []H
+	var logKeys []datastore.Key
+	for entry := range res.Next() {
+		if entry.Error != nil {
+			return entry.Error
+		}
+		h, err := header.Unmarshal[H](entry.Value)
+		if err != nil {
+			return err
+		}
+		logged = append(logged, h)
+		logKeys = append(logKeys, datastore.NewKey(entry.Key))
+	}
+	if len(logged) == 0 {
+		return nil
+	}
+	sort.Slice(logged, func(i, j int) bool {
+		return logged[i].Height() < logged[j].Height()
+	})
+
+	// headers are logged before they are queued, so the log can overlap with the stored ones
+	head, err := s.readHead(ctx)
+	switch {
+	case errors.Is(err, datastore.ErrNotFound), errors.Is(err, header.ErrNotFound):
+	case err != nil:
+		return err
+	default:
+		replay := make([]H, 0, len(logged))
+		for _, h := range logged {
+			if h.Height() != head.Height()+1 {
+				continue
+			}
+			// the log is not trusted more than the headers appended, e.g. it may keep the headers
+			// of an Append which has not returned successfully
+			if err = verifyLink(head, h); err != nil {
+				log.Warnw("discarding invalid header of write-ahead log", "height", h.Height(), "err", err)
+				continue
+			}
+			replay, head = append(replay, h), h
+		}
+		if len(replay) > 0 {
+			if err = s.flush(ctx, replay...); err != nil {
+				return err
+			}
+			log.Infow("replayed write-ahead log",
+				"from", replay[0].Height(), "to", replay[len(replay)-1].Height())
+		}
+	}
+
+	// the log is cleaned up only after the replay, so a crash in between does not lose anything
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	for _, key := range logKeys {
+		if err = batch.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return batch.Commit(ctx)
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/celestiaorg/go-header"
+)
+
+// WriteBatch accumulates ranges of headers and writes them to the Store at once.
+// Unlike Store.Append, the ranges can be added in any order, e.g. as parallel range requests
+// complete, and are written atomically in a single datastore batch together with their indexes,
+// so a crash in the middle never leaves a partially indexed range behind.
+// WriteBatch is not safe for concurrent use.
+type WriteBatch[H header.Header] struct {
+	store  *Store[H]
+	ranges [][]H
+}
+
+// Batch creates a new empty WriteBatch for the Store.
+func (s *Store[H]) Batch() *WriteBatch[H] {
+	return &WriteBatch[H]{store: s}
+}
+
+// Append adds the given range of adjacent headers to the batch.
+// The headers of the range are verified against each other right away, while
+// the ranges are verified against each other and the head of the Store on Commit.
+func (b *WriteBatch[H]) Append(headers ...H) error {
+	if len(headers) == 0 {
+		return nil
+	}
+	if err := verifyRange(headers); err != nil {
+		return err
+	}
+	b.ranges = append(b.ranges, headers)
+	return nil
+}
+
+// verifyRange verifies the given headers are adjacent and valid against each other.
+func verifyRange[H header.Header](headers []H) error {
+	for i := 1; i < len(headers); i++ {
+		if headers[i].Height() != headers[i-1].Height()+1 {
+			return &header.ErrNonAdjacent{
+				Head:      headers[i-1].Height(),
+				Attempted: headers[i].Height(),
+			}
+		}
+		if err := header.Verify(headers[i-1], headers[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len returns the amount of headers in the batch.
+func (b *WriteBatch[H]) Len() int {
+	var ln int
+	for _, rng := range b.ranges {
+		ln += len(rng)
+	}
+	return ln
+}
+
+// Commit verifies the ranges of the batch form a contiguous chain on top of the head
+// and writes them to the Store, returning once they are written on disk.
+// Nothing is written if any of the ranges fails verification or the write fails, and the headers
+// are published to the readers only once written. It must not race with Append.
+// The batch is empty after a successful Commit and can be reused.
+func (b *WriteBatch[H]) Commit(ctx context.Context) error {
+	if len(b.ranges) == 0 {
+		return nil
+	}
+
+	var head H
+	if headPtr := b.store.writeHead.Load(); headPtr != nil {
+		head = *headPtr
+	} else {
+		var err error
+		head, err = b.store.Head(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(b.ranges, func(i, j int) bool {
+		return b.ranges[i][0].Height() < b.ranges[j][0].Height()
+	})
+	headers := make([]H, 0, b.Len())
+	for _, rng := range b.ranges {
+		if rng[0].Height() != head.Height()+1 {
+			return &header.ErrNonAdjacent{
+				Head:      head.Height(),
+				Attempted: rng[0].Height(),
+			}
+		}
+		if err := header.Verify(head, rng[0]); err != nil {
+			return fmt.Errorf("header/store: verifying range [%d:%d]: %w",
+				rng[0].Height(), rng[len(rng)-1].Height(), err)
+		}
+		headers, head = append(headers, rng...), rng[len(rng)-1]
+	}
+
+	// log headers, so they are not lost if the Store crashes before writing them
+	if err := b.store.logWrites(ctx, headers...); err != nil {
+		return fmt.Errorf("header/store: logging headers: %w", err)
+	}
+	flushed := make(chan error, 1)
+	select {
+	case b.store.writes <- write[H]{headers: headers, flushed: flushed, commit: true}:
+	case <-b.store.writesDn:
+		return errStoppedStore
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-flushed:
+		if err != nil {
+			return fmt.Errorf("header/store: writing batch: %w", err)
+		}
+		b.ranges = nil
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// commitBatch writes the headers of a WriteBatch after the pending ones and publishes them
+// once written. It is called from the writing routine, so no write interleaves.
+func (s *Store[H]) commitBatch(ctx context.Context, headers []H) error {
+	if err := s.flushPending(ctx); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err := s.flush(ctx, headers...)
+	s.metrics.flushed(ctx, len(headers), time.Since(start), err)
+	if err != nil {
+		// the headers are not written, so they must not be replayed either
+		if unlogErr := s.dropLogged(ctx, headers...); unlogErr != nil {
+			log.Errorw("dropping logged headers of failed batch", "err", unlogErr)
+		}
+		return err
+	}
+
+	head := headers[len(headers)-1]
+	s.writeHead.Store(&head)
+	s.recent.add(headers...)
+	s.heightSub.Pub(headers...)
+	s.callHooks(ctx, headers...)
+	log.Infow("new head", "height", head.Height(), "hash", head.Hash())
+	return nil
+}
@@ -14,6 +14,9 @@ import (
 
 var log = logging.Logger("header/sync")
 
+// partialRangeTimeout bounds storing the verified prefix of an interrupted range request.
+const partialRangeTimeout = time.Second
+
 // Syncer implements efficient synchronization for headers.
 //
 // Subjective Head - the latest known local valid header and a sync target.
@@ -298,6 +301,11 @@ func (s *Syncer[H]) requestHeaders(
 
 		headers, err := s.getter.GetVerifiedRange(ctx, fromHead, size)
 		if err != nil {
+			// the verified prefix of the range received before the failure is kept,
+			// so the next attempt continues from it
+			if len(headers) > 0 {
+				s.storePartialRange(headers...)
+			}
 			return err
 		}
 
@@ -311,6 +319,18 @@ func (s *Syncer[H]) requestHeaders(
 	return nil
 }
 
+// storePartialRange stores the verified prefix of the range, which request was interrupted.
+// The request is usually interrupted by its context, so the prefix is stored with a context
+// detached from it, bounded by partialRangeTimeout.
+func (s *Syncer[H]) storePartialRange(headers ...H) {
+	ctx, cancel := context.WithTimeout(context.Background(), partialRangeTimeout)
+	defer cancel()
+	if err := s.storeHeaders(ctx, headers...); err != nil {
+		log.Errorw("storing partial range", "from", headers[0].Height(),
+			"to", headers[len(headers)-1].Height(), "err", err)
+	}
+}
+
 // storeHeaders updates store with new headers and updates current syncStore's Head.
 func (s *Syncer[H]) storeHeaders(ctx context.Context, headers ...H) error {
 	// we don't expect any issues in storing right now, as all headers are now verified.
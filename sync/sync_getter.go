@@ -16,7 +16,11 @@ type syncGetter[H header.Header] struct {
 	head    H
 }
 
-func (se *syncGetter[H]) Head(ctx context.Context) (H, error) {
+func (se *syncGetter[H]) Head(ctx context.Context, opts ...header.CallOption) (H, error) {
+	if len(opts) > 0 {
+		// calls configured with CallOptions may get different heads, so they are not shared
+		return se.Getter.Head(ctx, opts...)
+	}
 	// the lock construction here ensures only one routine calling Head at a time
 	// while others wait via Rlock
 	if !se.headLk.TryLock() {
@@ -43,7 +43,7 @@ type fakeGetter[H header.Header] struct {
 	hits atomic.Uint32
 }
 
-func (f *fakeGetter[H]) Head(ctx context.Context) (h H, err error) {
+func (f *fakeGetter[H]) Head(ctx context.Context, _ ...header.CallOption) (h H, err error) {
 	f.hits.Add(1)
 	select {
 	case <-time.After(time.Millisecond * 100):
@@ -55,18 +55,18 @@ func (f *fakeGetter[H]) Head(ctx context.Context) (h H, err error) {
 	return
 }
 
-func (f *fakeGetter[H]) Get(ctx context.Context, hash header.Hash) (H, error) {
+func (f *fakeGetter[H]) Get(ctx context.Context, hash header.Hash, _ ...header.CallOption) (H, error) {
 	panic("implement me")
 }
 
-func (f *fakeGetter[H]) GetByHeight(ctx context.Context, u uint64) (H, error) {
+func (f *fakeGetter[H]) GetByHeight(ctx context.Context, u uint64, _ ...header.CallOption) (H, error) {
 	panic("implement me")
 }
 
-func (f *fakeGetter[H]) GetRangeByHeight(ctx context.Context, from, amount uint64) ([]H, error) {
+func (f *fakeGetter[H]) GetRangeByHeight(ctx context.Context, from, amount uint64, _ ...header.CallOption) ([]H, error) {
 	panic("implement me")
 }
 
-func (f *fakeGetter[H]) GetVerifiedRange(ctx context.Context, from H, amount uint64) ([]H, error) {
+func (f *fakeGetter[H]) GetVerifiedRange(ctx context.Context, from H, amount uint64, _ ...header.CallOption) ([]H, error) {
 	panic("implement me")
 }
@@ -15,7 +15,7 @@ import (
 // Known subjective head is considered network head if it is recent enough(now-timestamp<=blocktime)
 // Otherwise, head is requested from a trusted peer and
 // set as the new subjective head, assuming that trusted peer is always fully synced.
-func (s *Syncer[H]) Head(ctx context.Context) (H, error) {
+func (s *Syncer[H]) Head(ctx context.Context, opts ...header.CallOption) (H, error) {
 	sbjHead, err := s.subjectiveHead(ctx)
 	if err != nil {
 		return sbjHead, err
@@ -31,7 +31,7 @@ func (s *Syncer[H]) Head(ctx context.Context) (H, error) {
 	//  * If now >= TNH && now <= TNH + (THP) header propagation time
 	//    * Wait for header to arrive instead of requesting it
 	//  * This way we don't request as we know the new network header arrives exactly
-	netHead, err := s.getter.Head(ctx)
+	netHead, err := s.getter.Head(ctx, opts...)
 	if err != nil {
 		return netHead, err
 	}
@@ -144,7 +144,7 @@ func (s *Syncer[H]) validateHead(ctx context.Context, new H) pubsub.ValidationRe
 		return pubsub.ValidationIgnore
 	}
 	// perform verification
-	err = sbjHead.Verify(new)
+	err = header.Verify(sbjHead, new)
 	var verErr *header.VerifyError
 	if errors.As(err, &verErr) {
 		log.Errorw("invalid network header",
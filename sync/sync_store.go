@@ -16,7 +16,7 @@ type syncStore[H header.Header] struct {
 	head atomic.Pointer[H]
 }
 
-func (s *syncStore[H]) Head(ctx context.Context) (H, error) {
+func (s *syncStore[H]) Head(ctx context.Context, _ ...header.CallOption) (H, error) {
 	if headPtr := s.head.Load(); headPtr != nil {
 		return *headPtr, nil
 	}
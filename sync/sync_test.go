@@ -285,11 +285,66 @@ func TestSyncerIncomingDuplicate(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// TestSyncer_StoresPartialRange ensures the verified prefix of a range interrupted
+// by the context deadline is stored.
+func TestSyncer_StoresPartialRange(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	suite := headertest.NewTestSuite(t)
+	head := suite.Head()
+
+	remoteStore := store.NewTestStore(ctx, t, head)
+	localStore := store.NewTestStore(ctx, t, head)
+	syncer, err := NewSyncer[*headertest.DummyHeader](
+		&partialGetter[*headertest.DummyHeader]{Getter: local.NewExchange(remoteStore)},
+		&ctxStore[*headertest.DummyHeader]{Store: localStore},
+		headertest.NewDummySubscriber(),
+	)
+	require.NoError(t, err)
+	err = remoteStore.Append(ctx, suite.GenDummyHeaders(10)...)
+	require.NoError(t, err)
+
+	reqCtx, reqCancel := context.WithCancel(ctx)
+	reqCancel()
+	err = syncer.requestHeaders(reqCtx, head, 11)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Eventually(t, func() bool {
+		return localStore.Height() == 6
+	}, time.Second, time.Millisecond*10)
+}
+
+// partialGetter returns the first half of the requested range along with the error
+// of the context, as if the request was interrupted.
+type partialGetter[H header.Header] struct {
+	header.Getter[H]
+}
+
+func (p *partialGetter[H]) GetVerifiedRange(ctx context.Context, from H, amount uint64, _ ...header.CallOption) ([]H, error) {
+	headers, err := p.Getter.GetVerifiedRange(context.Background(), from, amount/2)
+	if err != nil {
+		return nil, err
+	}
+	return headers, ctx.Err()
+}
+
+// ctxStore fails appends with done contexts, as the stores over remote datastores do.
+type ctxStore[H header.Header] struct {
+	header.Store[H]
+}
+
+func (s *ctxStore[H]) Append(ctx context.Context, headers ...H) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Store.Append(ctx, headers...)
+}
+
 type delayedGetter[H header.Header] struct {
 	header.Getter[H]
 }
 
-func (d *delayedGetter[H]) GetVerifiedRange(ctx context.Context, from H, amount uint64) ([]H, error) {
+func (d *delayedGetter[H]) GetVerifiedRange(ctx context.Context, from H, amount uint64, _ ...header.CallOption) ([]H, error) {
 	select {
 	case <-time.After(time.Millisecond * 100):
 		return d.Getter.GetVerifiedRange(ctx, from, amount)
@@ -0,0 +1,118 @@
+package header
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Version describes a format of headers used by a chain starting from the given height,
+// e.g. after a hard fork changing the header format.
+type Version[H Header] struct {
+	// Height is the first height headers of the Version are produced at.
+	Height uint64
+	// Unmarshal decodes a header of the Version.
+	Unmarshal func([]byte) (H, error)
+	// Verify verifies an untrusted header of the Version against a trusted one,
+	// which can be of the previous Version on the fork boundary.
+	// If not set, the Verify method of the trusted header is used.
+	Verify func(trusted, untrusted H) error
+}
+
+// versions maps the type of a Header to the sorted list of its registered Versions.
+var versions sync.Map
+
+// RegisterVersions registers multiple Versions of a header type, so that
+// the Store, Exchange and Syncer can handle headers of different formats in one chain.
+// Versions must have unique heights and one of them must start from height 0 or 1.
+// Registering Versions again for the same header type replaces the previous ones.
+func RegisterVersions[H Header](vs ...Version[H]) error {
+	if len(vs) == 0 {
+		return errors.New("header: no versions to register")
+	}
+
+	vs = append([]Version[H]{}, vs...)
+	sort.Slice(vs, func(i, j int) bool {
+		return vs[i].Height < vs[j].Height
+	})
+	if vs[0].Height > 1 {
+		return fmt.Errorf("header: first version starts from height %d", vs[0].Height)
+	}
+	for i, v := range vs {
+		if v.Unmarshal == nil {
+			return fmt.Errorf("header: version at height %d has no Unmarshal", v.Height)
+		}
+		if i > 0 && vs[i-1].Height == v.Height {
+			return fmt.Errorf("header: duplicate versions at height %d", v.Height)
+		}
+	}
+
+	versions.Store(typeOf[H](), vs)
+	return nil
+}
+
+// Unmarshal decodes a header with the Version matching its height.
+// If no Versions are registered for the header type, UnmarshalBinary is used.
+func Unmarshal[H Header](data []byte) (H, error) {
+	vs := registered[H]()
+	if len(vs) == 0 {
+		var empty H
+		h := empty.New()
+		if err := h.UnmarshalBinary(data); err != nil {
+			var zero H
+			return zero, err
+		}
+		return h.(H), nil
+	}
+
+	// the height is only known after decoding, so try the newest Versions first
+	// and pick the one whose range the decoded header falls into.
+	var err error
+	for i := len(vs) - 1; i >= 0; i-- {
+		var h H
+		h, err = vs[i].Unmarshal(data)
+		if err != nil {
+			continue
+		}
+		height := uint64(h.Height())
+		if height < vs[i].Height || (i+1 < len(vs) && height >= vs[i+1].Height) {
+			err = fmt.Errorf("header: height %d is out of version range", height)
+			continue
+		}
+		return h, nil
+	}
+
+	var zero H
+	return zero, err
+}
+
+// Verify verifies the untrusted header against the trusted one with the Version
+// matching the height of the untrusted header.
+// If no Versions are registered for the header type, the Verify method of the trusted header is used.
+func Verify[H Header](trusted, untrusted H) error {
+	vs := registered[H]()
+	for i := len(vs) - 1; i >= 0; i-- {
+		if uint64(untrusted.Height()) < vs[i].Height {
+			continue
+		}
+		if vs[i].Verify != nil {
+			return vs[i].Verify(trusted, untrusted)
+		}
+		break
+	}
+	return trusted.Verify(untrusted)
+}
+
+func registered[H Header]() []Version[H] {
+	vs, ok := versions.Load(typeOf[H]())
+	if !ok {
+		return nil
+	}
+	return vs.([]Version[H])
+}
+
+func typeOf[H Header]() reflect.Type {
+	return reflect.TypeOf((*H)(nil)).Elem()
+}
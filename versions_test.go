@@ -0,0 +1,73 @@
+package header_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/go-header"
+	"github.com/celestiaorg/go-header/headertest"
+)
+
+func TestVersions(t *testing.T) {
+	errForkVerify := errors.New("post-fork verify")
+	err := header.RegisterVersions(
+		header.Version[*versionedHeader]{
+			Height:    1,
+			Unmarshal: unmarshalVersion(1),
+		},
+		header.Version[*versionedHeader]{
+			Height:    5,
+			Unmarshal: unmarshalVersion(2),
+			Verify: func(trusted, untrusted *versionedHeader) error {
+				return errForkVerify
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	suite := headertest.NewTestSuite(t)
+	headers := suite.GenDummyHeaders(6)
+	for _, h := range headers {
+		data, err := h.MarshalBinary()
+		require.NoError(t, err)
+
+		decoded, err := header.Unmarshal[*versionedHeader](data)
+		require.NoError(t, err)
+		assert.Equal(t, h.Height(), decoded.Height())
+		if h.Height() < 5 {
+			assert.Equal(t, 1, decoded.version)
+		} else {
+			assert.Equal(t, 2, decoded.version)
+		}
+	}
+
+	pre := &versionedHeader{DummyHeader: headers[1]}
+	err = header.Verify(pre, &versionedHeader{DummyHeader: headers[2]})
+	require.NoError(t, err)
+	err = header.Verify(pre, &versionedHeader{DummyHeader: headers[4]})
+	require.ErrorIs(t, err, errForkVerify)
+
+	err = header.RegisterVersions(
+		header.Version[*versionedHeader]{Height: 1, Unmarshal: unmarshalVersion(1)},
+		header.Version[*versionedHeader]{Height: 1, Unmarshal: unmarshalVersion(2)},
+	)
+	require.Error(t, err)
+}
+
+type versionedHeader struct {
+	*headertest.DummyHeader
+	version int
+}
+
+func unmarshalVersion(version int) func([]byte) (*versionedHeader, error) {
+	return func(data []byte) (*versionedHeader, error) {
+		h := &headertest.DummyHeader{}
+		if err := h.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		return &versionedHeader{DummyHeader: h, version: version}, nil
+	}
+}